@@ -0,0 +1,122 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/internal/control"
+
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:        "cache",
+	Short:      "Manage the cache of a running Blobfuse2 mount",
+	Long:       "Pin, warm up, unpin or evict files in file_cache or block_cache of a running Blobfuse2 mount",
+	SuggestFor: []string{"cach", "cahce"},
+	Example:    "blobfuse2 cache pin /mnt/blob/models",
+}
+
+// newCacheCmd : Sub command sending given method to the mount holding each path
+func newCacheCmd(use string, method string, short string, done string) *cobra.Command {
+	return &cobra.Command{
+		Use:     use + " <path>...",
+		Short:   short,
+		Long:    short + ". A directory applies to all files under it.",
+		Example: fmt.Sprintf("blobfuse2 cache %s /mnt/blob/data", use),
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mounts, err := common.ListMountPoints()
+			if err != nil {
+				return fmt.Errorf("failed to list mount points [%s]", err.Error())
+			}
+
+			for _, arg := range args {
+				mntPath, name, err := resolveMountPath(arg, mounts)
+				if err != nil {
+					return err
+				}
+
+				var result control.CacheResult
+				err = control.Call(control.SocketPath(mntPath), method, control.CacheParams{Path: name}, &result)
+				if err != nil {
+					return fmt.Errorf("failed to %s %s [%s]", use, arg, err.Error())
+				}
+
+				fmt.Printf("%s %d file(s) under %s\n", done, result.Files, arg)
+			}
+
+			return nil
+		},
+	}
+}
+
+// resolveMountPath : Mount point holding the given path and the path relative to it
+func resolveMountPath(path string, mounts []string) (string, string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Mounts may be nested, so the deepest one holding the path is the one serving it
+	mntPath := ""
+	for _, mnt := range mounts {
+		mnt = filepath.Clean(mnt)
+		if (absPath == mnt || strings.HasPrefix(absPath, mnt+"/")) && len(mnt) > len(mntPath) {
+			mntPath = mnt
+		}
+	}
+
+	if mntPath == "" {
+		return "", "", fmt.Errorf("%s is not on a blobfuse2 mount", path)
+	}
+
+	return mntPath, strings.TrimPrefix(strings.TrimPrefix(absPath, mntPath), "/"), nil
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(newCacheCmd("pin", control.MethodCachePin,
+		"Download files in cache and keep them there irrespective of timeout and cache usage", "Pinned"))
+	cacheCmd.AddCommand(newCacheCmd("warm", control.MethodCacheWarm,
+		"Download files in cache ahead of use", "Warmed"))
+	cacheCmd.AddCommand(newCacheCmd("unpin", control.MethodCacheUnpin,
+		"Let pinned files be evicted from cache again", "Unpinned"))
+	cacheCmd.AddCommand(newCacheCmd("evict", control.MethodCacheEvict,
+		"Unpin files and remove them from cache", "Evicted"))
+}
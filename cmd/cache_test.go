@@ -0,0 +1,93 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type cacheCmdTestSuite struct {
+	suite.Suite
+	assert *assert.Assertions
+}
+
+func (suite *cacheCmdTestSuite) SetupTest() {
+	suite.assert = assert.New(suite.T())
+	err := log.SetDefaultLogger("silent", common.LogConfig{Level: common.ELogLevel.LOG_DEBUG()})
+	if err != nil {
+		panic("Unable to set silent logger as default.")
+	}
+}
+
+func (suite *cacheCmdTestSuite) TestResolveMountPath() {
+	mounts := []string{"/mnt/blob", "/mnt/blob/nested", "/mnt/blobfuse"}
+
+	mnt, name, err := resolveMountPath("/mnt/blob/data/file", mounts)
+	suite.assert.NoError(err)
+	suite.assert.Equal("/mnt/blob", mnt)
+	suite.assert.Equal("data/file", name)
+
+	// Deepest mount holding the path serves it
+	mnt, name, err = resolveMountPath("/mnt/blob/nested/dir/", mounts)
+	suite.assert.NoError(err)
+	suite.assert.Equal("/mnt/blob/nested", mnt)
+	suite.assert.Equal("dir", name)
+
+	mnt, name, err = resolveMountPath("/mnt/blobfuse", mounts)
+	suite.assert.NoError(err)
+	suite.assert.Equal("/mnt/blobfuse", mnt)
+	suite.assert.Empty(name)
+
+	_, _, err = resolveMountPath("/mnt/blobs/file", mounts)
+	suite.assert.Error(err)
+	suite.assert.Contains(err.Error(), "is not on a blobfuse2 mount")
+}
+
+func (suite *cacheCmdTestSuite) TestCacheCmdNotMounted() {
+	_, err := executeCommandC(rootCmd, "cache", "pin", "/nonexistent/blobfuse2/file")
+	suite.assert.Error(err)
+	suite.assert.Contains(err.Error(), "is not on a blobfuse2 mount")
+
+	_, err = executeCommandC(rootCmd, "cache", "warm")
+	suite.assert.Error(err)
+}
+
+func TestCacheCommand(t *testing.T) {
+	suite.Run(t, new(cacheCmdTestSuite))
+}
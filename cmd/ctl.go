@@ -0,0 +1,304 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/internal/control"
+	"github.com/Azure/azure-storage-fuse/v2/internal/reqlog"
+
+	"github.com/spf13/cobra"
+)
+
+var ctlCmd = &cobra.Command{
+	Use:        "ctl",
+	Short:      "Inspect and control a running Blobfuse2 mount",
+	Long:       "List open handles, drop caches, change log level, flush dirty files, dump the pipeline, list recent storage requests or drain a running Blobfuse2 mount",
+	SuggestFor: []string{"ctrl", "control"},
+	Example:    "blobfuse2 ctl handles /mnt/blob",
+}
+
+var ctlHandlesCmd = &cobra.Command{
+	Use:     "handles <mount path>",
+	Short:   "List the handles open on the mount",
+	Example: "blobfuse2 ctl handles /mnt/blob",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var handles []control.HandleInfo
+		err := callMount(args[0], control.MethodHandlesList, nil, &handles)
+		if err != nil {
+			return err
+		}
+
+		printHandles(cmd.OutOrStdout(), handles)
+		return nil
+	},
+}
+
+var ctlDropCacheCmd = &cobra.Command{
+	Use:     "drop-cache <mount path> [component]...",
+	Short:   "Drop cached attributes, listings and file contents",
+	Long:    "Drop cached attributes, listings and file contents of the given components, or all of them. Pinned files and files in use are retained.",
+	Example: "blobfuse2 ctl drop-cache /mnt/blob attr_cache",
+	Args:    cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var result control.DropResult
+		err := callMount(args[0], control.MethodCacheDrop, control.DropParams{Components: args[1:]}, &result)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Dropped cache of %s\n", strings.Join(result.Components, ", "))
+		return nil
+	},
+}
+
+var ctlLogLevelCmd = &cobra.Command{
+	Use:     "log-level <mount path> <level>",
+	Short:   "Change log level of the mount",
+	Long:    "Change log level of the mount, until it is unmounted. Valid levels are LOG_OFF, LOG_CRIT, LOG_ERR, LOG_WARNING, LOG_INFO, LOG_TRACE and LOG_DEBUG.",
+	Example: "blobfuse2 ctl log-level /mnt/blob log_debug",
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var result control.LogLevelResult
+		err := callMount(args[0], control.MethodLogLevel, control.LogLevelParams{Level: args[1]}, &result)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Log level changed from %s to %s\n", result.Previous, result.Current)
+		return nil
+	},
+}
+
+var ctlFlushCmd = &cobra.Command{
+	Use:     "flush <path>",
+	Short:   "Upload dirty files open on the mount",
+	Long:    "Upload the file at given path if it is open and dirty, or all such files when the path is the mount point itself",
+	Example: "blobfuse2 ctl flush /mnt/blob",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mntPath, name, err := ctlResolve(args[0])
+		if err != nil {
+			return err
+		}
+
+		var result control.FlushResult
+		err = control.Call(control.SocketPath(mntPath), control.MethodFilesFlush, control.FlushParams{Path: name}, &result)
+		if err != nil {
+			return fmt.Errorf("failed to flush %s [%s]", args[0], err.Error())
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "Flushed %d file(s)\n", len(result.Flushed))
+		if len(result.Failed) == 0 {
+			return nil
+		}
+
+		failed := make([]string, 0, len(result.Failed))
+		for path := range result.Failed {
+			failed = append(failed, path)
+		}
+		sort.Strings(failed)
+		for _, path := range failed {
+			fmt.Fprintf(out, "Failed to flush %s [%s]\n", path, result.Failed[path])
+		}
+		return fmt.Errorf("failed to flush %d file(s)", len(failed))
+	},
+}
+
+var ctlDumpCmd = &cobra.Command{
+	Use:     "dump <mount path>",
+	Short:   "Dump components of the pipeline and their state as JSON",
+	Example: "blobfuse2 ctl dump /mnt/blob",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var dump control.PipelineDump
+		err := callMount(args[0], control.MethodPipelineDump, nil, &dump)
+		if err != nil {
+			return err
+		}
+
+		data, err := json.MarshalIndent(dump, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		return nil
+	},
+}
+
+var ctlDrainCmd = &cobra.Command{
+	Use:   "drain <mount path>",
+	Short: "Stop accepting new opens and flush dirty files ahead of an upgrade",
+	Long: "Hold new opens on the mount, upload dirty files and persist the handles still open next to the control socket. " +
+		"Handles already open keep being served. A few opens are held at a time, for up to 30 seconds, others fail with EAGAIN. " +
+		"Run 'blobfuse2 ctl resume' to let held opens through, or restart the mount and it warms the files in the persisted map. " +
+		"Restarting still unmounts, the mount is not handed over to the new process.",
+	Example: "blobfuse2 ctl drain /mnt/blob",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var result control.DrainResult
+		err := callMount(args[0], control.MethodMountDrain, nil, &result)
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "Mount drained, flushed %d file(s), %d handle(s) still open\n", len(result.Flushed), result.Handles)
+		fmt.Fprintf(out, "Handle map persisted to %s\n", result.HandleMap)
+		if len(result.Failed) == 0 {
+			return nil
+		}
+
+		failed := make([]string, 0, len(result.Failed))
+		for path := range result.Failed {
+			failed = append(failed, path)
+		}
+		sort.Strings(failed)
+		for _, path := range failed {
+			fmt.Fprintf(out, "Failed to flush %s [%s]\n", path, result.Failed[path])
+		}
+		return fmt.Errorf("failed to flush %d file(s)", len(failed))
+	},
+}
+
+var ctlResumeCmd = &cobra.Command{
+	Use:     "resume <mount path>",
+	Short:   "Accept new opens on a drained mount",
+	Example: "blobfuse2 ctl resume /mnt/blob",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := callMount(args[0], control.MethodMountResume, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), "Mount resumed")
+		return nil
+	},
+}
+
+var ctlRequestsFailed bool
+
+var ctlRequestsCmd = &cobra.Command{
+	Use:   "requests <mount path>",
+	Short: "List recent requests made to the storage service",
+	Long: "List the most recent requests made by the mount to the storage service, oldest first, with their status, retries, latency and request ID. " +
+		"Values of query parameters which may carry secrets, like the signature of a SAS, are redacted.",
+	Example: "blobfuse2 ctl requests /mnt/blob --failed",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var requests []reqlog.Entry
+		err := callMount(args[0], control.MethodRequestsList, nil, &requests)
+		if err != nil {
+			return err
+		}
+
+		if ctlRequestsFailed {
+			requests = slices.DeleteFunc(requests, func(e reqlog.Entry) bool { return !e.Failed() })
+		}
+		return reqlog.Write(cmd.OutOrStdout(), requests)
+	},
+}
+
+// ctlResolve : Mount point holding the given path and the path relative to it
+func ctlResolve(path string) (string, string, error) {
+	mounts, err := common.ListMountPoints()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to list mount points [%s]", err.Error())
+	}
+
+	return resolveMountPath(path, mounts)
+}
+
+// callMount : Send the method to the mount holding given path
+func callMount(path string, method string, params any, result any) error {
+	mntPath, _, err := ctlResolve(path)
+	if err != nil {
+		return err
+	}
+
+	err = control.Call(control.SocketPath(mntPath), method, params, result)
+	if err != nil {
+		return fmt.Errorf("%s failed on %s [%s]", method, mntPath, err.Error())
+	}
+	return nil
+}
+
+// printHandles : Tabulate open handles
+func printHandles(out io.Writer, handles []control.HandleInfo) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSIZE\tFLAGS\tOPS\tPATH")
+	for _, h := range handles {
+		flags := make([]string, 0, 3)
+		if h.Dirty {
+			flags = append(flags, "dirty")
+		}
+		if h.Fsynced {
+			flags = append(flags, "fsynced")
+		}
+		if h.Cached {
+			flags = append(flags, "cached")
+		}
+		if len(flags) == 0 {
+			flags = append(flags, "-")
+		}
+		fmt.Fprintf(w, "%d\t%d\t%s\t%d\t%s\n", h.ID, h.Size, strings.Join(flags, ","), h.Ops, h.Path)
+	}
+	_ = w.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(ctlCmd)
+	ctlCmd.AddCommand(ctlHandlesCmd)
+	ctlCmd.AddCommand(ctlDropCacheCmd)
+	ctlCmd.AddCommand(ctlLogLevelCmd)
+	ctlCmd.AddCommand(ctlFlushCmd)
+	ctlCmd.AddCommand(ctlDumpCmd)
+	ctlCmd.AddCommand(ctlDrainCmd)
+	ctlCmd.AddCommand(ctlResumeCmd)
+	ctlCmd.AddCommand(ctlRequestsCmd)
+
+	ctlRequestsCmd.Flags().BoolVar(&ctlRequestsFailed, "failed", false, "List only requests which failed")
+}
@@ -0,0 +1,97 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/Azure/azure-storage-fuse/v2/internal/control"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ctlCmdTestSuite struct {
+	suite.Suite
+	assert *assert.Assertions
+}
+
+func (suite *ctlCmdTestSuite) SetupTest() {
+	suite.assert = assert.New(suite.T())
+	err := log.SetDefaultLogger("silent", common.LogConfig{Level: common.ELogLevel.LOG_DEBUG()})
+	if err != nil {
+		panic("Unable to set silent logger as default.")
+	}
+}
+
+func (suite *ctlCmdTestSuite) TestPrintHandles() {
+	var out bytes.Buffer
+	printHandles(&out, []control.HandleInfo{
+		{ID: 1, Path: "dir/clean", Size: 10, Ops: 4},
+		{ID: 12, Path: "dir/dirty", Size: 2048, Dirty: true, Cached: true},
+	})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	suite.assert.Len(lines, 3)
+	suite.assert.Equal([]string{"ID", "SIZE", "FLAGS", "OPS", "PATH"}, strings.Fields(lines[0]))
+	suite.assert.Equal([]string{"1", "10", "-", "4", "dir/clean"}, strings.Fields(lines[1]))
+	suite.assert.Equal([]string{"12", "2048", "dirty,cached", "0", "dir/dirty"}, strings.Fields(lines[2]))
+}
+
+func (suite *ctlCmdTestSuite) TestCtlCmdNotMounted() {
+	for _, args := range [][]string{
+		{"ctl", "handles", "/nonexistent/blobfuse2"},
+		{"ctl", "drop-cache", "/nonexistent/blobfuse2", "attr_cache"},
+		{"ctl", "log-level", "/nonexistent/blobfuse2", "log_debug"},
+		{"ctl", "flush", "/nonexistent/blobfuse2/file"},
+		{"ctl", "dump", "/nonexistent/blobfuse2"},
+		{"ctl", "drain", "/nonexistent/blobfuse2"},
+		{"ctl", "resume", "/nonexistent/blobfuse2"},
+		{"ctl", "requests", "/nonexistent/blobfuse2"},
+	} {
+		_, err := executeCommandC(rootCmd, args...)
+		suite.assert.Error(err)
+		suite.assert.Contains(err.Error(), "is not on a blobfuse2 mount")
+	}
+
+	_, err := executeCommandC(rootCmd, "ctl", "log-level", "/nonexistent/blobfuse2")
+	suite.assert.Error(err)
+}
+
+func TestCtlCommand(t *testing.T) {
+	suite.Run(t, new(ctlCmdTestSuite))
+}
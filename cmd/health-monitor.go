@@ -34,6 +34,7 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os/exec"
 	"strings"
@@ -47,11 +48,14 @@ import (
 )
 
 type monitorOptions struct {
-	EnableMon       bool     `config:"enable-monitoring"`
-	DisableList     []string `config:"monitor-disable-list"`
-	BfsPollInterval int      `config:"stats-poll-interval-sec"`
-	ProcMonInterval int      `config:"process-monitor-interval-sec"`
-	OutputPath      string   `config:"output-path"`
+	EnableMon          bool                 `config:"enable-monitoring"`
+	DisableList        []string             `config:"monitor-disable-list"`
+	BfsPollInterval    int                  `config:"stats-poll-interval-sec"`
+	ProcMonInterval    int                  `config:"process-monitor-interval-sec"`
+	OutputPath         string               `config:"output-path"`
+	Exporters          []string             `config:"exporters"`
+	OpenMetricsAddress string               `config:"openmetrics-address"`
+	Alerts             []hmcommon.AlertRule `config:"alerts"`
 }
 
 var pid string
@@ -152,6 +156,26 @@ func buildCliParamForMonitor() []string {
 		cliParams = append(cliParams, fmt.Sprintf("--output-path=%v", options.MonitorOpt.OutputPath))
 	}
 
+	if len(options.MonitorOpt.Exporters) > 0 {
+		cliParams = append(cliParams, "--exporters="+strings.Join(options.MonitorOpt.Exporters, ","))
+	}
+	if options.MonitorOpt.OpenMetricsAddress != "" {
+		cliParams = append(cliParams, "--openmetrics-address="+options.MonitorOpt.OpenMetricsAddress)
+	}
+
+	if len(options.MonitorOpt.Alerts) > 0 {
+		rules, err := json.Marshal(options.MonitorOpt.Alerts)
+		if err != nil {
+			log.Err("health-monitor::buildCliParamForMonitor: Unable to marshal alert rules [%v]", err)
+		} else {
+			cliParams = append(cliParams, "--alert-rules="+string(rules))
+		}
+	}
+
+	if options.MountPath != "" {
+		cliParams = append(cliParams, "--mount-path="+options.MountPath)
+	}
+
 	cliParams = append(cliParams, "--cache-path="+common.ExpandPath(cacheMonitorOptions.TmpPath))
 	cliParams = append(cliParams, fmt.Sprintf("--max-size-mb=%v", cacheMonitorOptions.MaxSizeMB))
 
@@ -167,6 +191,12 @@ func buildCliParamForMonitor() []string {
 			cliParams = append(cliParams, "--no-network-profiler")
 		case hmcommon.FileCacheMon:
 			cliParams = append(cliParams, "--no-file-cache-monitor")
+		case hmcommon.DiskIOMonitor:
+			cliParams = append(cliParams, "--no-disk-io-monitor")
+		case hmcommon.FuseQueueMonitor:
+			cliParams = append(cliParams, "--no-fuse-queue-monitor")
+		case hmcommon.FdMonitor:
+			cliParams = append(cliParams, "--no-fd-monitor")
 		default:
 			log.Debug("health-monitor::buildCliParamForMonitor: Invalid health monitor option %v", v)
 		}
@@ -38,10 +38,12 @@ import (
 	"math/rand"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/config"
 	"github.com/Azure/azure-storage-fuse/v2/common/log"
 	"github.com/Azure/azure-storage-fuse/v2/component/file_cache"
 	hmcommon "github.com/Azure/azure-storage-fuse/v2/tools/health-monitor/common"
@@ -135,6 +137,74 @@ func (suite *hmonTestSuite) TestBuildHmonCliParams() {
 	suite.assert.Len(cliParams, 11)
 }
 
+func (suite *hmonTestSuite) TestBuildHmonCliParamsExporters() {
+	defer suite.cleanupTest()
+
+	options = mountOptions{}
+	options.MonitorOpt = monitorOptions{
+		EnableMon:          true,
+		Exporters:          []string{hmcommon.JSONExporter, hmcommon.OpenMetricsExporter},
+		OpenMetricsAddress: "127.0.0.1:9500",
+	}
+
+	cliParams := buildCliParamForMonitor()
+	suite.assert.Contains(cliParams, "--exporters=json,openmetrics")
+	suite.assert.Contains(cliParams, "--openmetrics-address=127.0.0.1:9500")
+}
+
+func (suite *hmonTestSuite) TestBuildHmonCliParamsSystemMonitors() {
+	defer suite.cleanupTest()
+
+	options = mountOptions{MountPath: "/mnt/blobfuse"}
+	options.MonitorOpt = monitorOptions{
+		EnableMon:   true,
+		DisableList: []string{hmcommon.DiskIOMonitor, hmcommon.FuseQueueMonitor, hmcommon.FdMonitor},
+	}
+
+	cliParams := buildCliParamForMonitor()
+	suite.assert.Contains(cliParams, "--mount-path=/mnt/blobfuse")
+	suite.assert.Contains(cliParams, "--no-disk-io-monitor")
+	suite.assert.Contains(cliParams, "--no-fuse-queue-monitor")
+	suite.assert.Contains(cliParams, "--no-fd-monitor")
+}
+
+func (suite *hmonTestSuite) TestBuildHmonCliParamsAlerts() {
+	defer suite.cleanupTest()
+	defer config.ResetConfig()
+
+	cfg := `health_monitor:
+  alerts:
+    - name: cache-full
+      metric: cache_usage_percent
+      op: ">"
+      threshold: 95
+      for-sec: 60
+      actions:
+        - type: log
+        - type: webhook
+          url: http://localhost:8080/alert
+`
+	options = mountOptions{}
+	suite.Require().NoError(config.ReadConfigFromReader(strings.NewReader(cfg)))
+	suite.Require().NoError(config.UnmarshalKey("health_monitor", &options.MonitorOpt))
+
+	cliParams := buildCliParamForMonitor()
+
+	var rules string
+	for _, p := range cliParams {
+		if r, ok := strings.CutPrefix(p, "--alert-rules="); ok {
+			rules = r
+		}
+	}
+	suite.Require().NoError(hmcommon.ParseAlertRules(rules))
+	suite.Require().Len(hmcommon.AlertRules, 1)
+	suite.assert.Equal("cache-full", hmcommon.AlertRules[0].Name)
+	suite.assert.Equal(">", hmcommon.AlertRules[0].Op)
+	suite.assert.InDelta(95, hmcommon.AlertRules[0].Threshold, 0.001)
+	suite.assert.Equal(60, hmcommon.AlertRules[0].ForSec)
+	suite.assert.Equal("http://localhost:8080/alert", hmcommon.AlertRules[0].Actions[1].URL)
+}
+
 func (suite *hmonTestSuite) TestHmonInvalidOptions() {
 	defer suite.cleanupTest()
 
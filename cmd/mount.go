@@ -54,8 +54,14 @@ import (
 
 	"github.com/Azure/azure-storage-fuse/v2/common"
 	"github.com/Azure/azure-storage-fuse/v2/common/config"
+	"github.com/Azure/azure-storage-fuse/v2/common/exectime"
 	"github.com/Azure/azure-storage-fuse/v2/common/log"
 	"github.com/Azure/azure-storage-fuse/v2/internal"
+	"github.com/Azure/azure-storage-fuse/v2/internal/audit"
+	"github.com/Azure/azure-storage-fuse/v2/internal/control"
+	"github.com/Azure/azure-storage-fuse/v2/internal/metrics"
+	"github.com/Azure/azure-storage-fuse/v2/internal/reqlog"
+	"github.com/Azure/azure-storage-fuse/v2/internal/tracing"
 
 	"github.com/sevlyar/go-daemon"
 	"github.com/spf13/cobra"
@@ -89,6 +95,10 @@ type mountOptions struct {
 	DynamicProfiler   bool           `config:"dynamic-profile"`
 	ProfilerPort      int            `config:"profiler-port"`
 	ProfilerIP        string         `config:"profiler-ip"`
+	Metrics           bool           `config:"metrics"`
+	MetricsAddress    string         `config:"metrics-address"`
+	Tracing           tracing.Config `config:"tracing"`
+	Audit             audit.Config   `config:"audit"`
 	MonitorOpt        monitorOptions `config:"health_monitor"`
 	WaitForMount      time.Duration  `config:"wait-for-mount"`
 	LazyWrite         bool           `config:"lazy-write"`
@@ -711,7 +721,50 @@ func runPipeline(pipeline *internal.Pipeline, ctx context.Context) error {
 
 	go startMonitor(os.Getpid())
 
-	err := pipeline.Start(ctx)
+	// Control socket lets commands like 'blobfuse2 cache' and 'blobfuse2 ctl' reach the components of this mount
+	ctl := control.NewServer(control.SocketPath(options.MountPath))
+	control.RegisterCacheHandlers(ctl, pipeline.Components())
+	control.RegisterAdminHandlers(ctl, pipeline.Components())
+	err := ctl.Start()
+	if err != nil {
+		log.Err("Mount::runPipeline : Failed to start control socket [%s]", err.Error())
+	}
+	defer ctl.Stop()
+
+	if options.Metrics {
+		srv, err := metrics.Start(options.MetricsAddress)
+		if err != nil {
+			log.Err("Mount::runPipeline : Failed to start metrics endpoint on [%s] [%s]", options.MetricsAddress, err.Error())
+		} else {
+			defer srv.Stop()
+		}
+	}
+
+	// Tracing has to be set up before the pipeline is formed, components are wrapped for tracing only when it is enabled
+	err = tracing.Init(options.Tracing)
+	if err != nil {
+		log.Err("Mount::runPipeline : Failed to set up tracing [%s]", err.Error())
+	}
+	defer tracing.Shutdown()
+
+	// An audit log that was asked for but cannot be written fails the mount rather than leaving access unrecorded
+	err = audit.Init(options.Audit)
+	if err != nil {
+		log.Err("Mount::runPipeline : Failed to set up audit log [%s]", err.Error())
+		return fmt.Errorf("unable to set up audit log [%s]", err.Error())
+	}
+	defer audit.Shutdown()
+
+	// Latency histograms of each component are collected along with track-time, and reported on SIGUSR1 and unmount
+	if options.Logging.TimeTracker {
+		exectime.EnableLatency()
+		defer writeLatencyReport()
+	}
+
+	// Files still open when this mount was last drained are warmed once it is back, for the jobs reopening them
+	go reloadHandleMap(ctx, pipeline.Components())
+
+	err = pipeline.Start(ctx)
 	if err != nil {
 		log.Err("mount: error unable to start pipeline [%s]", err.Error())
 		return fmt.Errorf("unable to start pipeline [%s]", err.Error())
@@ -726,6 +779,34 @@ func runPipeline(pipeline *internal.Pipeline, ctx context.Context) error {
 	return nil
 }
 
+// reloadHandleMap : Warm the files in the handle map persisted by the last drain of this mount, once the kernel mount
+// is up and hence the components below libfuse are started
+func reloadHandleMap(ctx context.Context, components []internal.Component) {
+	path := control.HandleMapPath(control.SocketPath(options.MountPath))
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+
+	for range 60 {
+		mounts, _ := common.ListMountPoints()
+		if slices.Contains(mounts, options.MountPath) {
+			_, err := control.ReloadHandleMap(path, components)
+			if err != nil {
+				log.Err("Mount::reloadHandleMap : Failed to reload handle map %s [%s]", path, err.Error())
+			}
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+
+	log.Warn("Mount::reloadHandleMap : Mount %s did not come up, handle map %s is left as is", options.MountPath, path)
+}
+
 func startMonitor(pid int) {
 	if common.EnableMonitoring {
 		log.Debug("Mount::startMonitor : pid = %v, config-file = %v", pid, options.ConfigFile)
@@ -794,12 +875,56 @@ func sigusrHandler(pipeline *internal.Pipeline, ctx context.Context) daemon.Sign
 		if sig == syscall.SIGUSR1 {
 			log.Crit("Mount::sigusrHandler : SIGUSR1 received")
 			config.OnConfigChange()
+			writeLatencyReport()
+			writeRequestLog()
 		}
 
 		return err
 	}
 }
 
+// writeLatencyReport : Write p50/p90/p99/max latency of each component and operation to a file in the work directory,
+// named after the mount path. No-op unless latency histograms are collected.
+func writeLatencyReport() {
+	if !exectime.LatencyEnabled() {
+		return
+	}
+
+	path := mountWorkFile(".latency")
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "Latency report of %s at %s\n\n", options.MountPath, time.Now().Format(time.RFC3339))
+	_ = exectime.WriteLatencyReport(buf)
+
+	err := os.WriteFile(path, buf.Bytes(), 0644)
+	if err != nil {
+		log.Err("Mount::writeLatencyReport : Failed to write latency report to %s [%s]", path, err.Error())
+		return
+	}
+	log.Crit("Mount::writeLatencyReport : Latency report written to %s", path)
+}
+
+// writeRequestLog : Write the recent requests made to the storage service to a file in the work directory, named after
+// the mount path, so that request IDs of failed calls can be attached to a support ticket
+func writeRequestLog() {
+	path := mountWorkFile(".requests")
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "Requests of %s to the storage service at %s\n\n", options.MountPath, time.Now().Format(time.RFC3339))
+	_ = reqlog.Write(buf, reqlog.Recent())
+
+	err := os.WriteFile(path, buf.Bytes(), 0600)
+	if err != nil {
+		log.Err("Mount::writeRequestLog : Failed to write request log to %s [%s]", path, err.Error())
+		return
+	}
+	log.Crit("Mount::writeRequestLog : Request log written to %s", path)
+}
+
+// mountWorkFile : Path of a file of this mount in the work directory, named after the mount path with given extension
+func mountWorkFile(ext string) string {
+	name := strings.ReplaceAll(filepath.Clean(options.MountPath), "/", "_") + ext
+	return filepath.Join(os.ExpandEnv(common.DefaultWorkDir), name)
+}
+
 func setGOConfig() {
 	// Ensure we always have more than 1 OS thread running goroutines, since there are issues with having just 1.
 	isOnlyOne := runtime.GOMAXPROCS(0) == 1
@@ -861,10 +986,10 @@ func init() {
 	mountCmd.PersistentFlags().StringVar(&options.PassPhrase, "passphrase", "",
 		"Key to decrypt config file. Can also be specified by env-variable BLOBFUSE2_SECURE_CONFIG_PASSPHRASE.\nKey length shall be 16 (AES-128), 24 (AES-192), or 32 (AES-256) bytes in length.")
 
-	mountCmd.PersistentFlags().String("log-type", "syslog", "Type of logger to be used by the system. Set to syslog by default. Allowed values are silent|syslog|base.")
+	mountCmd.PersistentFlags().String("log-type", "syslog", "Type of logger to be used by the system. Set to syslog by default. Allowed values are silent|syslog|base|json.")
 	config.BindPFlag("logging.type", mountCmd.PersistentFlags().Lookup("log-type"))
 	_ = mountCmd.RegisterFlagCompletionFunc("log-type", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		return []string{"silent", "base", "syslog"}, cobra.ShellCompDirectiveNoFileComp
+		return []string{"silent", "base", "syslog", "json"}, cobra.ShellCompDirectiveNoFileComp
 	})
 
 	// Add a generic cleanup-on-start flag that applies to all cache components
@@ -888,7 +1013,7 @@ func init() {
 	config.BindPFlag("logging.goroutine-id", mountCmd.PersistentFlags().Lookup("log-goroutine-id"))
 
 	mountCmd.PersistentFlags().Bool("log-compress",
-		false, "Enable gzip compression of rolled-over log files. Only applies to base logger.")
+		false, "Enable gzip compression of rolled-over log files. Only applies to base and json loggers.")
 	config.BindPFlag("logging.compress", mountCmd.PersistentFlags().Lookup("log-compress"))
 
 	mountCmd.PersistentFlags().Bool("foreground", false, "Mount the system in foreground mode. Default value false.")
@@ -897,6 +1022,12 @@ func init() {
 	mountCmd.PersistentFlags().Bool("read-only", false, "Mount the system in read only mode. Default value false.")
 	config.BindPFlag("read-only", mountCmd.PersistentFlags().Lookup("read-only"))
 
+	mountCmd.PersistentFlags().Bool("metrics", false, "Serve Prometheus metrics of this mount over HTTP. Default value false.")
+	config.BindPFlag("metrics", mountCmd.PersistentFlags().Lookup("metrics"))
+
+	mountCmd.PersistentFlags().String("metrics-address", metrics.DefaultAddress, "Address for the metrics endpoint to listen on.")
+	config.BindPFlag("metrics-address", mountCmd.PersistentFlags().Lookup("metrics-address"))
+
 	mountCmd.Flags().Bool("lazy-write", false, "Async write to storage container after file handle is closed.")
 	config.BindPFlag("lazy-write", mountCmd.Flags().Lookup("lazy-write"))
 	mountCmd.Flags().Lookup("lazy-write").Hidden = true
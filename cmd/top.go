@@ -0,0 +1,307 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Azure/azure-storage-fuse/v2/internal/control"
+	"github.com/Azure/azure-storage-fuse/v2/internal/stats_manager"
+
+	"github.com/spf13/cobra"
+)
+
+// Busiest paths shown on the dashboard
+const topPaths = 10
+
+type topOptions struct {
+	interval int
+	count    int
+}
+
+var topOpts topOptions
+
+var topCmd = &cobra.Command{
+	Use:   "top <mount path>",
+	Short: "Live dashboard of a running Blobfuse2 mount",
+	Long: "Show operations per second with their latency percentiles, cache hit rates, transfers to and from storage, " +
+		"block pool usage and the busiest paths of a running Blobfuse2 mount, refreshed every interval. " +
+		"The mount records metrics from the time top attaches until it is unmounted, unless its metrics endpoint is already enabled.",
+	Example: "blobfuse2 top /mnt/blob",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if topOpts.interval <= 0 {
+			return fmt.Errorf("interval shall be at least 1 second")
+		}
+
+		mntPath, _, err := ctlResolve(args[0])
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		return runTop(ctx, cmd.OutOrStdout(), mntPath, time.Duration(topOpts.interval)*time.Second, topOpts.count)
+	},
+}
+
+// runTop : Render the difference of consecutive snapshots of the mount until interrupted or count frames are shown
+func runTop(ctx context.Context, out io.Writer, mntPath string, interval time.Duration, count int) error {
+	snapshot := func(snap *stats_manager.Snapshot) error {
+		err := control.Call(control.SocketPath(mntPath), control.MethodStatsSnapshot, nil, snap)
+		if err != nil {
+			return fmt.Errorf("failed to get stats of %s [%s]", mntPath, err.Error())
+		}
+		return nil
+	}
+
+	var prev stats_manager.Snapshot
+	err := snapshot(&prev)
+	if err != nil {
+		return err
+	}
+
+	terminal := isTerminal(out)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for frame := 0; count == 0 || frame < count; frame++ {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		var cur stats_manager.Snapshot
+		err = snapshot(&cur)
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		if terminal {
+			// Move to the top left and clear the screen, so that the dashboard is redrawn in place
+			buf.WriteString("\033[H\033[2J")
+		} else if frame > 0 {
+			buf.WriteString("\n")
+		}
+		renderTop(&buf, mntPath, &prev, &cur)
+		_, err = out.Write(buf.Bytes())
+		if err != nil {
+			return err
+		}
+
+		prev = cur
+	}
+
+	return nil
+}
+
+// renderTop : Rates over the time between two snapshots, and the state of the mount in the latest one
+func renderTop(out io.Writer, mntPath string, prev, cur *stats_manager.Snapshot) {
+	secs := cur.Time.Sub(prev.Time).Seconds()
+	if secs <= 0 {
+		secs = 1
+	}
+
+	fmt.Fprintf(out, "blobfuse2 top - %s - %s\n", mntPath, cur.Time.Format(time.TimeOnly))
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	type opRate struct {
+		stats_manager.OperationStat
+		ops float64
+	}
+	ops := make([]opRate, 0, len(cur.Operations))
+	for _, op := range cur.Operations {
+		idx := slices.IndexFunc(prev.Operations, func(p stats_manager.OperationStat) bool {
+			return p.Component == op.Component && p.Operation == op.Operation
+		})
+		if idx != -1 && prev.Operations[idx].Count <= op.Count {
+			p := prev.Operations[idx]
+			op.Count -= p.Count
+			op.Errors -= min(p.Errors, op.Errors)
+			for i := range min(len(op.Buckets), len(p.Buckets)) {
+				op.Buckets[i] -= min(p.Buckets[i], op.Buckets[i])
+			}
+		}
+		if op.Count > 0 {
+			ops = append(ops, opRate{OperationStat: op, ops: float64(op.Count) / secs})
+		}
+	}
+	slices.SortFunc(ops, func(a, b opRate) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Component, b.Component), cmp.Compare(a.Operation, b.Operation))
+	})
+
+	fmt.Fprintln(w, "\nCOMPONENT\tOPERATION\tOPS/S\tERRORS/S\tP50\tP90\tP99")
+	if len(ops) == 0 {
+		fmt.Fprintln(w, "-\tidle\t\t\t\t\t")
+	}
+	maxLatency := 0.0
+	if n := len(cur.LatencyBounds); n > 0 {
+		maxLatency = cur.LatencyBounds[n-1]
+	}
+	for _, op := range ops {
+		fmt.Fprintf(w, "%s\t%s\t%.1f\t%.1f\t%s\t%s\t%s\n", op.Component, op.Operation, op.ops, float64(op.Errors)/secs,
+			formatLatency(percentile(cur.LatencyBounds, op.Buckets, 0.5), maxLatency),
+			formatLatency(percentile(cur.LatencyBounds, op.Buckets, 0.9), maxLatency),
+			formatLatency(percentile(cur.LatencyBounds, op.Buckets, 0.99), maxLatency))
+	}
+
+	if len(cur.Caches) > 0 {
+		fmt.Fprintln(w, "\nCACHE\tLOOKUPS/S\tHIT %\tHIT % SINCE ATTACH")
+		for _, c := range cur.Caches {
+			hits, misses := c.Hits, c.Misses
+			idx := slices.IndexFunc(prev.Caches, func(p stats_manager.CacheStat) bool { return p.Component == c.Component })
+			if idx != -1 && prev.Caches[idx].Hits <= hits && prev.Caches[idx].Misses <= misses {
+				hits -= prev.Caches[idx].Hits
+				misses -= prev.Caches[idx].Misses
+			}
+			fmt.Fprintf(w, "%s\t%.1f\t%s\t%s\n", c.Component, float64(hits+misses)/secs,
+				formatRatio(hits, hits+misses), formatRatio(c.Hits, c.Hits+c.Misses))
+		}
+	}
+
+	if len(cur.Transfers) > 0 {
+		fmt.Fprintln(w, "\nTRANSFER\tDIRECTION\tMB/S\tIN FLIGHT")
+		for _, t := range cur.Transfers {
+			transferred := t.Bytes
+			idx := slices.IndexFunc(prev.Transfers, func(p stats_manager.TransferStat) bool {
+				return p.Component == t.Component && p.Direction == t.Direction
+			})
+			if idx != -1 && prev.Transfers[idx].Bytes <= transferred {
+				transferred -= prev.Transfers[idx].Bytes
+			}
+			fmt.Fprintf(w, "%s\t%s\t%.2f\t%d\n", t.Component, t.Direction, float64(transferred)/secs/(1<<20), t.InFlight)
+		}
+	}
+
+	if len(cur.BlockPools) > 0 {
+		fmt.Fprintln(w, "\nBLOCK POOL\tBLOCKS\tUSAGE %")
+		for _, p := range cur.BlockPools {
+			fmt.Fprintf(w, "%s\t%d\t%d\n", p.Component, p.Blocks, p.UsagePercent)
+		}
+	}
+	_ = w.Flush()
+
+	if len(cur.BusyPaths) > 0 {
+		w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "\nOPS (LAST 10-20s)\tPATH")
+		for _, p := range cur.BusyPaths[:min(topPaths, len(cur.BusyPaths))] {
+			fmt.Fprintf(w, "%d\t%s\n", p.Ops, p.Path)
+		}
+		_ = w.Flush()
+	}
+}
+
+// percentile : Latency below which the given quantile of operations fall, interpolated linearly within the bucket
+// holding it. Operations slower than the last bound are reported as +Inf.
+func percentile(bounds []float64, buckets []uint64, q float64) float64 {
+	var total uint64
+	for _, c := range buckets {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := q * float64(total)
+	cumulative := 0.0
+	for i, c := range buckets {
+		if c == 0 || cumulative+float64(c) < rank {
+			cumulative += float64(c)
+			continue
+		}
+		if i >= len(bounds) {
+			return math.Inf(1)
+		}
+
+		lower := 0.0
+		if i > 0 {
+			lower = bounds[i-1]
+		}
+		return lower + (bounds[i]-lower)*(rank-cumulative)/float64(c)
+	}
+
+	return math.Inf(1)
+}
+
+func formatLatency(secs float64, maxLatency float64) string {
+	switch {
+	case math.IsInf(secs, 1):
+		return ">" + formatLatency(maxLatency, maxLatency)
+	case secs == 0:
+		return "-"
+	case secs < 0.001:
+		return fmt.Sprintf("%.0fus", secs*1e6)
+	case secs < 1:
+		return fmt.Sprintf("%.1fms", secs*1e3)
+	}
+	return fmt.Sprintf("%.2fs", secs)
+}
+
+func formatRatio(part, total uint64) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", float64(part)*100/float64(total))
+}
+
+// isTerminal : Whether the output is a terminal the dashboard can be redrawn on
+func isTerminal(out io.Writer) bool {
+	f, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func init() {
+	rootCmd.AddCommand(topCmd)
+	topCmd.Flags().IntVar(&topOpts.interval, "interval", 1, "Seconds between refreshes")
+	topCmd.Flags().IntVar(&topOpts.count, "count", 0, "Number of refreshes before exiting, 0 to run until interrupted")
+}
@@ -0,0 +1,189 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/Azure/azure-storage-fuse/v2/internal/control"
+	"github.com/Azure/azure-storage-fuse/v2/internal/stats_manager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type topCmdTestSuite struct {
+	suite.Suite
+	assert *assert.Assertions
+}
+
+func (suite *topCmdTestSuite) SetupTest() {
+	suite.assert = assert.New(suite.T())
+	err := log.SetDefaultLogger("silent", common.LogConfig{Level: common.ELogLevel.LOG_DEBUG()})
+	if err != nil {
+		panic("Unable to set silent logger as default.")
+	}
+}
+
+func (suite *topCmdTestSuite) TestPercentile() {
+	bounds := []float64{0.001, 0.01, 0.1}
+
+	suite.assert.Zero(percentile(bounds, []uint64{0, 0, 0, 0}, 0.5))
+	suite.assert.InDelta(0.0005, percentile(bounds, []uint64{10, 0, 0, 0}, 0.5), 1e-9)
+	suite.assert.InDelta(0.0055, percentile(bounds, []uint64{0, 10, 0, 0}, 0.5), 1e-9)
+	suite.assert.InDelta(0.001, percentile(bounds, []uint64{50, 50, 0, 0}, 0.5), 1e-9)
+	suite.assert.InDelta(0.091, percentile(bounds, []uint64{90, 0, 10, 0}, 0.99), 1e-9)
+	suite.assert.True(math.IsInf(percentile(bounds, []uint64{1, 0, 0, 99}, 0.9), 1))
+
+	suite.assert.Equal("-", formatLatency(0, 0.1))
+	suite.assert.Equal("500us", formatLatency(0.0005, 0.1))
+	suite.assert.Equal("5.5ms", formatLatency(0.0055, 0.1))
+	suite.assert.Equal("2.50s", formatLatency(2.5, 30))
+	suite.assert.Equal(">30.00s", formatLatency(math.Inf(1), 30))
+}
+
+func (suite *topCmdTestSuite) TestRenderTop() {
+	now := time.Now()
+	bounds := []float64{0.001, 0.01}
+	prev := &stats_manager.Snapshot{
+		Time:          now,
+		LatencyBounds: bounds,
+		Operations: []stats_manager.OperationStat{
+			{Component: "libfuse", Operation: "read", Count: 100, Errors: 1, Buckets: []uint64{100, 0, 0}},
+			{Component: "libfuse", Operation: "getattr", Count: 7, Buckets: []uint64{7, 0, 0}},
+		},
+		Caches:    []stats_manager.CacheStat{{Component: "block_cache", Hits: 10, Misses: 10}},
+		Transfers: []stats_manager.TransferStat{{Component: "azstorage", Direction: "download", Bytes: 1 << 20}},
+	}
+	cur := &stats_manager.Snapshot{
+		Time:          now.Add(2 * time.Second),
+		LatencyBounds: bounds,
+		Operations: []stats_manager.OperationStat{
+			{Component: "libfuse", Operation: "read", Count: 300, Errors: 5, Buckets: []uint64{100, 200, 0}},
+			{Component: "libfuse", Operation: "getattr", Count: 7, Buckets: []uint64{7, 0, 0}},
+			{Component: "libfuse", Operation: "write", Count: 2, Buckets: []uint64{0, 0, 2}},
+		},
+		Caches:     []stats_manager.CacheStat{{Component: "block_cache", Hits: 40, Misses: 20}},
+		Transfers:  []stats_manager.TransferStat{{Component: "azstorage", Direction: "download", Bytes: 5 << 20, InFlight: 3}},
+		BlockPools: []stats_manager.BlockPoolStat{{Component: "block_cache", Blocks: 64, UsagePercent: 50}},
+		BusyPaths:  []stats_manager.PathStat{{Path: "dir/a.bin", Ops: 250}, {Path: "b.txt", Ops: 3}},
+	}
+
+	var out bytes.Buffer
+	renderTop(&out, "/mnt/blob", prev, cur)
+	text := out.String()
+
+	// Fields of the first line starting with the given fields
+	fields := func(prefix ...string) []string {
+		for line := range strings.SplitSeq(text, "\n") {
+			if f := strings.Fields(line); len(f) >= len(prefix) && slices.Equal(f[:len(prefix)], prefix) {
+				return f
+			}
+		}
+		return nil
+	}
+
+	suite.assert.Contains(text, "blobfuse2 top - /mnt/blob")
+	suite.assert.Equal([]string{"libfuse", "read", "100.0", "2.0", "5.5ms", "9.1ms", "9.9ms"}, fields("libfuse", "read"))
+	suite.assert.Equal([]string{"libfuse", "write", "1.0", "0.0", ">10.0ms", ">10.0ms", ">10.0ms"}, fields("libfuse", "write"))
+	// Operations without activity in the interval are not shown
+	suite.assert.NotContains(text, "getattr")
+	suite.assert.Equal([]string{"block_cache", "20.0", "75.0", "66.7"}, fields("block_cache", "20.0"))
+	suite.assert.Equal([]string{"azstorage", "download", "2.00", "3"}, fields("azstorage"))
+	suite.assert.Equal([]string{"block_cache", "64", "50"}, fields("block_cache", "64"))
+	suite.assert.Equal([]string{"250", "dir/a.bin"}, fields("250"))
+
+	// Read is busier than write
+	suite.assert.Less(strings.Index(text, "read"), strings.Index(text, "write"))
+
+	out.Reset()
+	renderTop(&out, "/mnt/blob", cur, cur)
+	suite.assert.Contains(out.String(), "idle")
+}
+
+func (suite *topCmdTestSuite) TestRunTop() {
+	mntPath := filepath.Join(suite.T().TempDir(), "mnt")
+	server := control.NewServer(control.SocketPath(mntPath))
+	calls := 0
+	server.Handle(control.MethodStatsSnapshot, func(_ json.RawMessage) (any, error) {
+		calls++
+		return stats_manager.Snapshot{
+			Time:       time.Now(),
+			Operations: []stats_manager.OperationStat{{Component: "libfuse", Operation: "open", Count: uint64(calls * 10), Buckets: []uint64{uint64(calls * 10)}}},
+		}, nil
+	})
+	suite.Require().NoError(server.Start())
+	defer server.Stop()
+
+	var out bytes.Buffer
+	err := runTop(context.Background(), &out, mntPath, 10*time.Millisecond, 2)
+	suite.assert.NoError(err)
+	suite.assert.Equal(3, calls)
+	suite.assert.Equal(2, strings.Count(out.String(), "blobfuse2 top - "+mntPath))
+	suite.assert.NotContains(out.String(), "\033[")
+
+	// Interrupted before the first refresh
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	suite.assert.NoError(runTop(ctx, &out, mntPath, time.Hour, 0))
+
+	server.Stop()
+	err = runTop(context.Background(), &out, mntPath, 10*time.Millisecond, 1)
+	suite.assert.Error(err)
+	suite.assert.Contains(err.Error(), "failed to get stats of")
+}
+
+func (suite *topCmdTestSuite) TestTopCmdNotMounted() {
+	_, err := executeCommandC(rootCmd, "top", "/nonexistent/blobfuse2")
+	suite.assert.Error(err)
+	suite.assert.Contains(err.Error(), "is not on a blobfuse2 mount")
+
+	_, err = executeCommandC(rootCmd, "top", "--interval=0", "/nonexistent/blobfuse2")
+	suite.assert.Error(err)
+	suite.assert.Contains(err.Error(), "interval shall be at least 1 second")
+	topOpts = topOptions{interval: 1}
+}
+
+func TestTopCommand(t *testing.T) {
+	suite.Run(t, new(topCmdTestSuite))
+}
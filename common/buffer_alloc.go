@@ -0,0 +1,328 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package common
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Huge page modes for block buffers
+const (
+	HugePagesNone    = "none"
+	HugePagesTHP     = "thp"
+	HugePagesHugeTLB = "hugetlb"
+)
+
+// NUMA policy keeping a pool of block buffers on each node with memory, the buffers of each pool being filled and
+// drained by workers pinned to the CPUs of that node
+const NumaLocal = "local"
+
+var numaNodesPath = "/sys/devices/system/node/has_memory"
+
+// BufferOptions : How memory of block buffers is to be mapped
+type BufferOptions struct {
+	HugePages  string // none, thp or hugetlb
+	NumaPolicy string // empty, a node number or local
+
+	// Called once per kind of fallback with the reason, as this package can not log
+	OnFallback func(reason string)
+}
+
+// BufferAllocator : Allocates block buffers as per the options, falling back to regular pages
+// on the local node when huge pages or the requested NUMA nodes are not available.
+// Buffers are bound to a NUMA node. With several nodes the pools are expected to be split per node with ForNode and
+// served by workers pinned with LockToNode, buffers allocated without that are interleaved across the nodes.
+// A nil allocator maps regular pages.
+type BufferAllocator struct {
+	opts         BufferOptions
+	nodes        []int // NUMA nodes buffers are bound to, empty for default placement
+	hugePageSize uint64
+
+	hugeTLBFailed sync.Once
+	thpFailed     sync.Once
+	numaFailed    sync.Once
+}
+
+// NewBufferAllocator : Validate the options and create an allocator for them
+func NewBufferAllocator(opts BufferOptions) (*BufferAllocator, error) {
+	if opts.HugePages == "" {
+		opts.HugePages = HugePagesNone
+	}
+
+	switch opts.HugePages {
+	case HugePagesNone, HugePagesTHP, HugePagesHugeTLB:
+	default:
+		return nil, fmt.Errorf("invalid huge-pages %s, supported values are none, thp and hugetlb", opts.HugePages)
+	}
+
+	a := &BufferAllocator{
+		opts:         opts,
+		hugePageSize: getHugePageSize(),
+	}
+
+	if opts.NumaPolicy == "" {
+		return a, nil
+	}
+
+	online := getNumaNodes()
+	if opts.NumaPolicy == NumaLocal {
+		a.nodes = online
+	} else {
+		node, err := strconv.Atoi(opts.NumaPolicy)
+		if err != nil || node < 0 {
+			return nil, fmt.Errorf("invalid numa-policy %s, supported values are a node number and local", opts.NumaPolicy)
+		}
+
+		for _, n := range online {
+			if n == node {
+				a.nodes = []int{node}
+			}
+		}
+	}
+
+	if len(a.nodes) == 0 {
+		a.fallback(fmt.Sprintf("NUMA nodes for policy %s not available, using default placement", opts.NumaPolicy))
+	}
+
+	return a, nil
+}
+
+// Allocate : Map a new buffer of given size
+func (a *BufferAllocator) Allocate(size uint64) ([]byte, error) {
+	if size == 0 {
+		return nil, fmt.Errorf("invalid size")
+	}
+
+	prot, flags := syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE
+	if a == nil {
+		return syscall.Mmap(-1, 0, int(size), prot, flags)
+	}
+
+	var buf []byte
+	var err error
+	thp := a.opts.HugePages == HugePagesTHP
+
+	if a.opts.HugePages == HugePagesHugeTLB {
+		// Huge pages can only back a mapping which is a multiple of their size
+		if a.hugePageSize != 0 && size%a.hugePageSize == 0 {
+			buf, err = syscall.Mmap(-1, 0, int(size), prot, flags|unix.MAP_HUGETLB)
+		} else {
+			err = fmt.Errorf("size %v is not a multiple of huge page size %v", size, a.hugePageSize)
+		}
+
+		if err != nil {
+			a.hugeTLBFailed.Do(func() {
+				a.fallback(fmt.Sprintf("failed to map huge pages, falling back to transparent huge pages [%s]", err.Error()))
+			})
+			buf = nil
+			thp = true
+		}
+	}
+
+	if buf == nil {
+		buf, err = syscall.Mmap(-1, 0, int(size), prot, flags)
+		if err != nil {
+			return nil, err
+		}
+
+		if thp {
+			if err := unix.Madvise(buf, unix.MADV_HUGEPAGE); err != nil {
+				a.thpFailed.Do(func() {
+					a.fallback(fmt.Sprintf("transparent huge pages not available, using regular pages [%s]", err.Error()))
+				})
+			}
+		}
+	}
+
+	// Placement applies to the pages as they are touched first, so it has to be set right after the mapping
+	if len(a.nodes) > 0 {
+		mode := unix.MPOL_BIND
+		if len(a.nodes) > 1 {
+			mode = unix.MPOL_INTERLEAVE
+		}
+
+		if err := mbind(buf, mode, a.nodes); err != nil {
+			a.numaFailed.Do(func() {
+				a.fallback(fmt.Sprintf("failed to place buffer on NUMA nodes %v, using default placement [%s]", a.nodes, err.Error()))
+			})
+		}
+	}
+
+	return buf, nil
+}
+
+func (a *BufferAllocator) fallback(reason string) {
+	if a.opts.OnFallback != nil {
+		a.opts.OnFallback(reason)
+	}
+}
+
+// Nodes : NUMA nodes the buffers are placed on, empty when the default placement is used
+func (a *BufferAllocator) Nodes() []int {
+	if a == nil {
+		return nil
+	}
+	return a.nodes
+}
+
+// ForNode : Allocator binding buffers to the node at the given index of Nodes, for the pool of that node
+func (a *BufferAllocator) ForNode(index int) *BufferAllocator {
+	return &BufferAllocator{
+		opts:         a.opts,
+		nodes:        []int{a.nodes[index]},
+		hugePageSize: a.hugePageSize,
+	}
+}
+
+// LockToNode : Lock the calling goroutine to its thread and restrict the thread to the CPUs of the node at the given
+// index of Nodes, so that the buffers of that node are local to it. The goroutine shall not unlock the thread, which
+// then exits with the goroutine instead of running others on the CPUs of the node.
+func (a *BufferAllocator) LockToNode(index int) error {
+	runtime.LockOSThread()
+	return pinToNode(a.nodes[index])
+}
+
+// pinToNode : Restrict the calling thread to the CPUs of the NUMA node
+func pinToNode(node int) error {
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(numaNodesPath), fmt.Sprintf("node%d", node), "cpulist"))
+	if err != nil {
+		return err
+	}
+
+	cpus, err := parseNodeList(strings.TrimSpace(string(data)))
+	if err != nil {
+		return err
+	}
+
+	if len(cpus) == 0 {
+		return fmt.Errorf("NUMA node %v has no CPUs", node)
+	}
+
+	var set unix.CPUSet
+	for _, cpu := range cpus {
+		set.Set(cpu)
+	}
+	return unix.SchedSetaffinity(0, &set)
+}
+
+// mbind : Set the memory policy of the buffer to the given nodes
+func mbind(buf []byte, mode int, nodes []int) error {
+	mask := make([]uint64, slices.Max(nodes)/64+1)
+	for _, node := range nodes {
+		mask[node/64] |= 1 << (node % 64)
+	}
+
+	// Kernel takes number of bits in the mask plus one, as libnuma does
+	_, _, errno := unix.Syscall6(unix.SYS_MBIND, uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)), uintptr(mode),
+		uintptr(unsafe.Pointer(&mask[0])), uintptr(len(mask)*64+1), 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// getHugePageSize : Default huge page size of the system, 0 if not known
+func getHugePageSize() uint64 {
+	data, err := os.ReadFile("/proc/meminfo")
+	if err != nil {
+		return 0
+	}
+
+	for line := range strings.SplitSeq(string(data), "\n") {
+		if value, found := strings.CutPrefix(line, "Hugepagesize:"); found {
+			size, err := strconv.ParseUint(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "kB")), 10, 64)
+			if err == nil {
+				return size * 1024
+			}
+		}
+	}
+
+	return 0
+}
+
+// getNumaNodes : NUMA nodes having memory, empty if not known
+func getNumaNodes() []int {
+	data, err := os.ReadFile(numaNodesPath)
+	if err != nil {
+		return nil
+	}
+
+	nodes, err := parseNodeList(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil
+	}
+
+	return nodes
+}
+
+// parseNodeList : Parse a node or CPU list like "0-2,4" into the numbers it holds
+func parseNodeList(list string) ([]int, error) {
+	nodes := make([]int, 0)
+	if list == "" {
+		return nodes, nil
+	}
+
+	for part := range strings.SplitSeq(list, ",") {
+		first, last, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, err
+		}
+
+		end := start
+		if isRange {
+			end, err = strconv.Atoi(last)
+			if err != nil || end < start {
+				return nil, fmt.Errorf("invalid node range %s", part)
+			}
+		}
+
+		for n := start; n <= end; n++ {
+			nodes = append(nodes, n)
+		}
+	}
+
+	return nodes, nil
+}
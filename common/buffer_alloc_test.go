@@ -0,0 +1,307 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type bufferAllocTestSuite struct {
+	suite.Suite
+	assert    *assert.Assertions
+	nodesPath string
+	fallbacks []string
+}
+
+func (suite *bufferAllocTestSuite) SetupTest() {
+	suite.assert = assert.New(suite.T())
+	suite.nodesPath = numaNodesPath
+	suite.fallbacks = nil
+}
+
+func (suite *bufferAllocTestSuite) TearDownTest() {
+	numaNodesPath = suite.nodesPath
+}
+
+func (suite *bufferAllocTestSuite) setNodes(list string) {
+	numaNodesPath = filepath.Join(suite.T().TempDir(), "has_memory")
+	suite.assert.NoError(os.WriteFile(numaNodesPath, []byte(list+"\n"), 0644))
+}
+
+func (suite *bufferAllocTestSuite) options(hugePages string, numaPolicy string) BufferOptions {
+	return BufferOptions{
+		HugePages:  hugePages,
+		NumaPolicy: numaPolicy,
+		OnFallback: func(reason string) { suite.fallbacks = append(suite.fallbacks, reason) },
+	}
+}
+
+func (suite *bufferAllocTestSuite) allocate(a *BufferAllocator, size uint64) {
+	buf, err := a.Allocate(size)
+	suite.assert.NoError(err)
+	suite.assert.Len(buf, int(size))
+
+	buf[0], buf[size-1] = 1, 2
+	suite.assert.NoError(syscall.Munmap(buf))
+}
+
+func TestBufferAlloc(t *testing.T) {
+	suite.Run(t, new(bufferAllocTestSuite))
+}
+
+func (suite *bufferAllocTestSuite) TestParseNodeList() {
+	nodes, err := parseNodeList("0-2,4,6-7")
+	suite.assert.NoError(err)
+	suite.assert.Equal([]int{0, 1, 2, 4, 6, 7}, nodes)
+
+	nodes, err = parseNodeList("")
+	suite.assert.NoError(err)
+	suite.assert.Empty(nodes)
+
+	_, err = parseNodeList("3-1")
+	suite.assert.Error(err)
+
+	_, err = parseNodeList("a")
+	suite.assert.Error(err)
+}
+
+func (suite *bufferAllocTestSuite) TestInvalidOptions() {
+	_, err := NewBufferAllocator(BufferOptions{HugePages: "huge"})
+	suite.assert.ErrorContains(err, "invalid huge-pages")
+
+	_, err = NewBufferAllocator(BufferOptions{NumaPolicy: "interleave"})
+	suite.assert.ErrorContains(err, "invalid numa-policy")
+
+	_, err = NewBufferAllocator(BufferOptions{NumaPolicy: "-1"})
+	suite.assert.ErrorContains(err, "invalid numa-policy")
+}
+
+func (suite *bufferAllocTestSuite) TestNilAllocator() {
+	var a *BufferAllocator
+	suite.allocate(a, 4096)
+	suite.assert.Empty(a.Nodes())
+
+	_, err := a.Allocate(0)
+	suite.assert.Error(err)
+}
+
+func (suite *bufferAllocTestSuite) TestHugeTLBFallback() {
+	a, err := NewBufferAllocator(suite.options(HugePagesHugeTLB, ""))
+	suite.assert.NoError(err)
+
+	// Huge pages can never back a mapping smaller than a huge page, so this falls back every time but reports once
+	suite.allocate(a, 4096)
+	suite.allocate(a, 4096)
+	suite.assert.NotEmpty(suite.fallbacks)
+	suite.assert.Contains(suite.fallbacks[0], "failed to map huge pages")
+	suite.assert.Equal(1, countPrefix(suite.fallbacks, "failed to map huge pages"))
+}
+
+func (suite *bufferAllocTestSuite) TestTHP() {
+	a, err := NewBufferAllocator(suite.options(HugePagesTHP, ""))
+	suite.assert.NoError(err)
+
+	suite.allocate(a, 4*1024*1024)
+	suite.allocate(a, 4*1024*1024)
+	suite.assert.LessOrEqual(len(suite.fallbacks), 1)
+}
+
+func (suite *bufferAllocTestSuite) TestNumaNode() {
+	suite.setNodes("0")
+	a, err := NewBufferAllocator(suite.options(HugePagesNone, "0"))
+	suite.assert.NoError(err)
+	suite.assert.Equal([]int{0}, a.Nodes())
+	suite.allocate(a, 4096)
+
+	// Node without memory falls back to the default placement
+	a, err = NewBufferAllocator(suite.options(HugePagesNone, "5"))
+	suite.assert.NoError(err)
+	suite.assert.Empty(a.Nodes())
+	suite.assert.Contains(suite.fallbacks[len(suite.fallbacks)-1], "NUMA nodes for policy 5 not available")
+	suite.allocate(a, 4096)
+}
+
+func (suite *bufferAllocTestSuite) TestNumaLocal() {
+	suite.setNodes("0-1,3")
+	a, err := NewBufferAllocator(suite.options(HugePagesNone, NumaLocal))
+	suite.assert.NoError(err)
+	suite.assert.Equal([]int{0, 1, 3}, a.Nodes())
+
+	// Pool of each node binds its buffers to that node only, buffers allocated for all nodes are interleaved
+	node := a.ForNode(2)
+	suite.assert.Equal([]int{3}, node.Nodes())
+
+	// Nodes the host does not have make mbind fail, which is reported once per allocator and the buffer is still usable
+	for range 2 {
+		suite.allocate(a, 4096)
+		suite.allocate(node, 4096)
+	}
+	suite.assert.LessOrEqual(countPrefix(suite.fallbacks, "failed to place buffer"), 2)
+}
+
+func (suite *bufferAllocTestSuite) TestLockToNode() {
+	allowed := unix.CPUSet{}
+	suite.Require().NoError(unix.SchedGetaffinity(0, &allowed))
+	cpu := 0
+	for !allowed.IsSet(cpu) {
+		cpu++
+	}
+
+	// Node 1 runs on a single CPU the test may use
+	suite.setNodes("0-1")
+	dir := filepath.Join(filepath.Dir(numaNodesPath), "node1")
+	suite.Require().NoError(os.MkdirAll(dir, 0755))
+	suite.Require().NoError(os.WriteFile(filepath.Join(dir, "cpulist"), []byte(strconv.Itoa(cpu)+"\n"), 0644))
+
+	a, err := NewBufferAllocator(suite.options(HugePagesNone, NumaLocal))
+	suite.Require().NoError(err)
+
+	// Thread is left locked and pinned, so it goes away with the goroutine
+	done := make(chan unix.CPUSet)
+	go func() {
+		set := unix.CPUSet{}
+		suite.assert.NoError(a.LockToNode(1))
+		suite.assert.NoError(unix.SchedGetaffinity(0, &set))
+		done <- set
+	}()
+
+	set := <-done
+	suite.assert.Equal(1, set.Count())
+	suite.assert.True(set.IsSet(cpu))
+
+	// Node without a CPU list can not be pinned to
+	go func() {
+		err := a.LockToNode(0)
+		suite.assert.Error(err)
+		close(done)
+	}()
+	<-done
+}
+
+func countPrefix(list []string, prefix string) int {
+	count := 0
+	for _, s := range list {
+		if strings.HasPrefix(s, prefix) {
+			count++
+		}
+	}
+	return count
+}
+
+// BenchmarkBufferAllocator : Copy a block into a block of the pool, for each allocation mode. Blocks are allocated
+// once, as the pools do. NUMA modes pin the benchmark to the first node and bind the pool block to the same node
+// (local) or to the second node (remote), so that the gap between them is the cost of a pool on the wrong node.
+// Run it on the target host, as the gain from huge pages and NUMA placement depends on its hardware and settings.
+func BenchmarkBufferAllocator(b *testing.B) {
+	const blockSize = 16 * 1024 * 1024
+
+	type mode struct {
+		name      string
+		hugePages string
+		node      int // index of the NUMA node the pool block is bound to, -1 for default placement
+	}
+
+	modes := []mode{
+		{"none", HugePagesNone, -1},
+		{"thp", HugePagesTHP, -1},
+		{"hugetlb", HugePagesHugeTLB, -1},
+		{"none-local", HugePagesNone, 0},
+		{"none-remote", HugePagesNone, 1},
+		{"thp-local", HugePagesTHP, 0},
+		{"thp-remote", HugePagesTHP, 1},
+	}
+
+	nodes := getNumaNodes()
+	for _, m := range modes {
+		b.Run(m.name, func(b *testing.B) {
+			src := BufferOptions{HugePages: m.hugePages}
+			dst := src
+			if m.node >= 0 {
+				if len(nodes) < 2 {
+					b.Skip("needs two NUMA nodes with memory")
+				}
+
+				runtime.LockOSThread()
+				defer runtime.UnlockOSThread()
+				if err := pinToNode(nodes[0]); err != nil {
+					b.Skipf("failed to pin to NUMA node %v [%s]", nodes[0], err.Error())
+				}
+
+				src.NumaPolicy = strconv.Itoa(nodes[0])
+				dst.NumaPolicy = strconv.Itoa(nodes[m.node])
+			}
+
+			srcBuf := benchmarkBuffer(b, src, blockSize)
+			defer func() { _ = syscall.Munmap(srcBuf) }()
+			dstBuf := benchmarkBuffer(b, dst, blockSize)
+			defer func() { _ = syscall.Munmap(dstBuf) }()
+
+			b.SetBytes(blockSize)
+			b.ResetTimer()
+			for b.Loop() {
+				copy(dstBuf, srcBuf)
+			}
+		})
+	}
+}
+
+// benchmarkBuffer : Allocate a buffer as per the options and fault its pages in
+func benchmarkBuffer(b *testing.B, opts BufferOptions, size uint64) []byte {
+	opts.OnFallback = func(reason string) { b.Logf("fallback: %s", reason) }
+	a, err := NewBufferAllocator(opts)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	buf, err := a.Allocate(size)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for i := range buf {
+		buf[i] = byte(i)
+	}
+	return buf
+}
@@ -0,0 +1,246 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package common
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Config key of the cache rules, a top level section shared by all caching components
+const CacheRulesKey = "cache-rules"
+
+// CacheRule : Caching behaviour overridden for the paths matching a glob.
+// Fields not set in the rule keep the setting of the component.
+type CacheRule struct {
+	// Glob relative to the mount root. '*', '?' and '[...]' match within a path segment
+	// as in path.Match, while '**' matches any number of segments including none.
+	Path string `config:"path" yaml:"path"`
+
+	TimeoutSec *uint32 `config:"timeout-sec" yaml:"timeout-sec,omitempty"` // attr_cache, entry_cache, file_cache and block_cache disk timeout
+	Prefetch   *uint32 `config:"prefetch" yaml:"prefetch,omitempty"`       // block_cache prefetch depth, 0 disables prefetch
+	NeverCache bool    `config:"never-cache" yaml:"never-cache,omitempty"` // Do not keep anything of these paths once not in use
+	Pin        bool    `config:"pin" yaml:"pin,omitempty"`                 // Exempt these paths from eviction
+}
+
+// CacheRules : Ordered list of cache rules, the first rule matching a path applies to it.
+// A nil CacheRules matches nothing.
+type CacheRules struct {
+	rules    []CacheRule
+	patterns []PathGlob
+}
+
+// CachePolicy : Overrides applying to a path, the zero value overrides nothing
+type CachePolicy struct {
+	rule *CacheRule
+}
+
+// NewCacheRules : Validate the rules and compile their globs, nil is returned when there are no rules
+func NewCacheRules(rules []CacheRule) (*CacheRules, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	cr := &CacheRules{
+		rules:    rules,
+		patterns: make([]PathGlob, len(rules)),
+	}
+
+	for i, rule := range rules {
+		if strings.Trim(rule.Path, "/") == "" {
+			return nil, fmt.Errorf("cache rule %d has no path", i)
+		}
+
+		if rule.NeverCache && rule.Pin {
+			return nil, fmt.Errorf("cache rule for %s can not set both never-cache and pin", rule.Path)
+		}
+
+		glob, err := NewPathGlob(rule.Path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path %s in cache rule [%s]", rule.Path, err.Error())
+		}
+		cr.patterns[i] = glob
+	}
+
+	return cr, nil
+}
+
+// PathGlob : Compiled glob over a path relative to the mount root, with the syntax of the path of a cache rule
+type PathGlob struct {
+	segments []string
+}
+
+// NewPathGlob : Validate and compile the glob
+func NewPathGlob(pattern string) (PathGlob, error) {
+	segments := strings.Split(strings.Trim(pattern, "/"), "/")
+	for _, segment := range segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return PathGlob{}, err
+		}
+	}
+
+	return PathGlob{segments: segments}, nil
+}
+
+// Match : Whether the path matches the glob
+func (g PathGlob) Match(name string) bool {
+	return matchSegments(g.segments, strings.Split(strings.Trim(name, "/"), "/"))
+}
+
+// Len : Number of rules
+func (cr *CacheRules) Len() int {
+	if cr == nil {
+		return 0
+	}
+	return len(cr.rules)
+}
+
+// Match : Policy of the first rule matching the path
+func (cr *CacheRules) Match(name string) CachePolicy {
+	if cr == nil {
+		return CachePolicy{}
+	}
+
+	segments := strings.Split(strings.Trim(name, "/"), "/")
+	for i := range cr.rules {
+		if matchSegments(cr.patterns[i].segments, segments) {
+			return CachePolicy{rule: &cr.rules[i]}
+		}
+	}
+
+	return CachePolicy{}
+}
+
+// MinTimeout : Smallest timeout set by any rule, false if no rule sets one
+func (cr *CacheRules) MinTimeout() (uint32, bool) {
+	found := false
+	timeout := uint32(0)
+
+	for i := range cr.Len() {
+		if t := cr.rules[i].TimeoutSec; t != nil && (!found || *t < timeout) {
+			timeout, found = *t, true
+		}
+	}
+
+	return timeout, found
+}
+
+// MaxTimeout : Largest timeout set by any rule, false if no rule sets one
+func (cr *CacheRules) MaxTimeout() (uint32, bool) {
+	found := false
+	timeout := uint32(0)
+
+	for i := range cr.Len() {
+		if t := cr.rules[i].TimeoutSec; t != nil && (!found || *t > timeout) {
+			timeout, found = *t, true
+		}
+	}
+
+	return timeout, found
+}
+
+// matchSegments : Match path segments against glob segments, '**' taking any number of path segments
+func matchSegments(pattern []string, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for skip := 0; skip <= len(segments); skip++ {
+				if matchSegments(pattern[1:], segments[skip:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(segments) == 0 {
+			return false
+		}
+
+		if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+			return false
+		}
+
+		pattern, segments = pattern[1:], segments[1:]
+	}
+
+	return len(segments) == 0
+}
+
+// Matched : Whether a rule applies to the path
+func (p CachePolicy) Matched() bool {
+	return p.rule != nil
+}
+
+// Path : Glob of the rule applying to the path, empty if none
+func (p CachePolicy) Path() string {
+	if p.rule == nil {
+		return ""
+	}
+	return p.rule.Path
+}
+
+// Timeout : Timeout in seconds for the path, def when the rule does not set one. Never cached paths have no timeout.
+func (p CachePolicy) Timeout(def uint32) uint32 {
+	if p.rule == nil {
+		return def
+	}
+
+	if p.rule.NeverCache {
+		return 0
+	}
+
+	if p.rule.TimeoutSec != nil {
+		return *p.rule.TimeoutSec
+	}
+
+	return def
+}
+
+// Prefetch : Number of blocks to prefetch for the path, def when the rule does not set one
+func (p CachePolicy) Prefetch(def uint32) uint32 {
+	if p.rule == nil || p.rule.Prefetch == nil {
+		return def
+	}
+	return *p.rule.Prefetch
+}
+
+// NeverCache : Whether nothing of the path is to be kept once it is not in use
+func (p CachePolicy) NeverCache() bool {
+	return p.rule != nil && p.rule.NeverCache
+}
+
+// Pin : Whether the path is exempt from eviction
+func (p CachePolicy) Pin() bool {
+	return p.rule != nil && p.rule.Pin
+}
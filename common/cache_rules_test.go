@@ -0,0 +1,166 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type cacheRulesTestSuite struct {
+	suite.Suite
+	assert *assert.Assertions
+}
+
+func (suite *cacheRulesTestSuite) SetupTest() {
+	suite.assert = assert.New(suite.T())
+}
+
+func TestCacheRules(t *testing.T) {
+	suite.Run(t, new(cacheRulesTestSuite))
+}
+
+func uint32Ptr(v uint32) *uint32 {
+	return &v
+}
+
+func (suite *cacheRulesTestSuite) TestNoRules() {
+	cr, err := NewCacheRules(nil)
+	suite.assert.NoError(err)
+	suite.assert.Nil(cr)
+	suite.assert.Equal(0, cr.Len())
+
+	policy := cr.Match("a/b")
+	suite.assert.False(policy.Matched())
+	suite.assert.EqualValues(120, policy.Timeout(120))
+	suite.assert.EqualValues(10, policy.Prefetch(10))
+	suite.assert.False(policy.NeverCache())
+	suite.assert.False(policy.Pin())
+
+	_, found := cr.MinTimeout()
+	suite.assert.False(found)
+	_, found = cr.MaxTimeout()
+	suite.assert.False(found)
+}
+
+func (suite *cacheRulesTestSuite) TestInvalidRules() {
+	_, err := NewCacheRules([]CacheRule{{Path: "/"}})
+	suite.assert.ErrorContains(err, "has no path")
+
+	_, err = NewCacheRules([]CacheRule{{Path: "data/[a-"}})
+	suite.assert.ErrorContains(err, "invalid path")
+
+	_, err = NewCacheRules([]CacheRule{{Path: "data/**", NeverCache: true, Pin: true}})
+	suite.assert.ErrorContains(err, "both never-cache and pin")
+}
+
+func (suite *cacheRulesTestSuite) TestGlobs() {
+	cr, err := NewCacheRules([]CacheRule{
+		{Path: "/checkpoints/**"},
+		{Path: "datasets/*.parquet"},
+		{Path: "**/tmp/file?.log"},
+		{Path: "models/[ab]*/weights"},
+	})
+	suite.assert.NoError(err)
+	suite.assert.Equal(4, cr.Len())
+
+	matches := map[string]string{
+		"checkpoints":                   "/checkpoints/**",
+		"checkpoints/run1/step10.ckpt":  "/checkpoints/**",
+		"/checkpoints/step10.ckpt":      "/checkpoints/**",
+		"datasets/train.parquet":        "datasets/*.parquet",
+		"datasets/2024/train.parquet":   "",
+		"tmp/file1.log":                 "**/tmp/file?.log",
+		"a/b/tmp/file2.log":             "**/tmp/file?.log",
+		"a/b/tmp/file10.log":            "",
+		"models/alpha/weights":          "models/[ab]*/weights",
+		"models/gamma/weights":          "",
+		"checkpointsX/step10.ckpt":      "",
+		"datasets/train.parquet/nested": "",
+	}
+
+	for name, glob := range matches {
+		policy := cr.Match(name)
+		suite.assert.Equal(glob != "", policy.Matched(), name)
+		suite.assert.Equal(glob, policy.Path(), name)
+	}
+}
+
+func (suite *cacheRulesTestSuite) TestFirstMatchApplies() {
+	cr, err := NewCacheRules([]CacheRule{
+		{Path: "datasets/hot/**", TimeoutSec: uint32Ptr(3600), Prefetch: uint32Ptr(64), Pin: true},
+		{Path: "datasets/**", Prefetch: uint32Ptr(0)},
+		{Path: "checkpoints/**", TimeoutSec: uint32Ptr(600), NeverCache: true},
+	})
+	suite.assert.NoError(err)
+
+	hot := cr.Match("datasets/hot/a.bin")
+	suite.assert.EqualValues(3600, hot.Timeout(120))
+	suite.assert.EqualValues(64, hot.Prefetch(10))
+	suite.assert.True(hot.Pin())
+	suite.assert.False(hot.NeverCache())
+
+	cold := cr.Match("datasets/cold/a.bin")
+	suite.assert.EqualValues(120, cold.Timeout(120))
+	suite.assert.EqualValues(0, cold.Prefetch(10))
+	suite.assert.False(cold.Pin())
+
+	// Never cached paths have no timeout whatever the rule says
+	ckpt := cr.Match("checkpoints/step1")
+	suite.assert.True(ckpt.NeverCache())
+	suite.assert.EqualValues(0, ckpt.Timeout(120))
+	suite.assert.EqualValues(10, ckpt.Prefetch(10))
+
+	timeout, found := cr.MinTimeout()
+	suite.assert.True(found)
+	suite.assert.EqualValues(600, timeout)
+
+	timeout, found = cr.MaxTimeout()
+	suite.assert.True(found)
+	suite.assert.EqualValues(3600, timeout)
+}
+
+func (suite *cacheRulesTestSuite) TestPathGlob() {
+	glob, err := NewPathGlob("/logs/**/*.log")
+	suite.assert.NoError(err)
+	suite.assert.True(glob.Match("logs/a.log"))
+	suite.assert.True(glob.Match("/logs/2026/10/a.log"))
+	suite.assert.False(glob.Match("logs/a.txt"))
+	suite.assert.False(glob.Match("data/logs/a.log"))
+
+	_, err = NewPathGlob("logs/[a")
+	suite.assert.Error(err)
+}
@@ -0,0 +1,141 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package exectime
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// Each power of two is split in 2^subBucketBits linear buckets, which keeps the relative error of a recorded value
+// under 1/64, i.e. about 1.6%, across the whole range
+const (
+	subBucketBits = 6
+	subBuckets    = 1 << subBucketBits
+
+	// Latencies longer than this, a little over a minute, are counted in the last bucket. Max is still exact.
+	maxTrackable = time.Duration(1<<36 - 1)
+)
+
+var numBuckets = bucketIndex(uint64(maxTrackable)) + 1
+
+// Histogram : HDR style histogram of latencies with log-linear buckets. Recording is lock free and safe to call
+// from multiple goroutines, a report taken while values are recorded may be off by the values in flight.
+type Histogram struct {
+	counts []atomic.Uint64
+	count  atomic.Uint64
+	sum    atomic.Int64
+	max    atomic.Int64
+}
+
+// NewHistogram : Create an empty histogram
+func NewHistogram() *Histogram {
+	return &Histogram{
+		counts: make([]atomic.Uint64, numBuckets),
+	}
+}
+
+// bucketIndex : Values below 2*subBuckets have a bucket each, above that every power of two has subBuckets buckets
+func bucketIndex(v uint64) int {
+	if v < 2*subBuckets {
+		return int(v)
+	}
+	shift := bits.Len64(v) - subBucketBits - 1
+	return shift*subBuckets + int(v>>shift)
+}
+
+// bucketUpperBound : Highest value that falls in the bucket
+func bucketUpperBound(idx int) uint64 {
+	if idx < 2*subBuckets {
+		return uint64(idx)
+	}
+	shift := idx/subBuckets - 1
+	m := uint64(idx - shift*subBuckets)
+	return (m+1)<<shift - 1
+}
+
+// Record : Add a latency to the histogram
+func (h *Histogram) Record(d time.Duration) {
+	d = max(d, 0)
+	h.counts[bucketIndex(uint64(min(d, maxTrackable)))].Add(1)
+	h.count.Add(1)
+	h.sum.Add(int64(d))
+
+	for {
+		cur := h.max.Load()
+		if int64(d) <= cur || h.max.CompareAndSwap(cur, int64(d)) {
+			break
+		}
+	}
+}
+
+// Count : Number of latencies recorded
+func (h *Histogram) Count() uint64 {
+	return h.count.Load()
+}
+
+// Mean : Average of the latencies recorded
+func (h *Histogram) Mean() time.Duration {
+	count := h.count.Load()
+	if count == 0 {
+		return 0
+	}
+	return time.Duration(uint64(h.sum.Load()) / count)
+}
+
+// Max : Longest latency recorded
+func (h *Histogram) Max() time.Duration {
+	return time.Duration(h.max.Load())
+}
+
+// Percentile : Latency at or below which q (0 to 1) of the recorded latencies fall, rounded up to its bucket.
+// Latencies beyond the trackable range are reported as the max.
+func (h *Histogram) Percentile(q float64) time.Duration {
+	count := h.count.Load()
+	if count == 0 {
+		return 0
+	}
+
+	rank := max(1, uint64(math.Ceil(q*float64(count))))
+	var seen uint64
+	for i := range h.counts {
+		seen += h.counts[i].Load()
+		if seen >= rank && i < len(h.counts)-1 {
+			return min(time.Duration(bucketUpperBound(i)), h.Max())
+		}
+	}
+	return h.Max()
+}
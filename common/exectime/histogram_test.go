@@ -0,0 +1,165 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package exectime
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type histogramTestSuite struct {
+	suite.Suite
+	assert *assert.Assertions
+}
+
+func (s *histogramTestSuite) SetupTest() {
+	s.assert = assert.New(s.T())
+}
+
+func (s *histogramTestSuite) TearDownTest() {
+	DisableLatency()
+}
+
+func (s *histogramTestSuite) TestBuckets() {
+	// Buckets are contiguous and each value falls within the bounds of its bucket
+	for v := uint64(1); v < 1<<20; v++ {
+		idx := bucketIndex(v)
+		if v > bucketUpperBound(idx) || v <= bucketUpperBound(idx-1) {
+			s.Failf("value outside its bucket", "value %d, bucket %d", v, idx)
+			break
+		}
+	}
+
+	// Relative error stays within the sub bucket resolution
+	for _, v := range []uint64{1000, 123456, 98765432, uint64(maxTrackable)} {
+		upper := bucketUpperBound(bucketIndex(v))
+		s.assert.LessOrEqual(float64(upper-v)/float64(v), 1.0/subBuckets)
+	}
+	s.assert.Equal(numBuckets-1, bucketIndex(uint64(maxTrackable)))
+}
+
+func (s *histogramTestSuite) TestPercentiles() {
+	h := NewHistogram()
+	s.assert.Zero(h.Percentile(0.5))
+	s.assert.Zero(h.Mean())
+
+	for i := 1; i <= 1000; i++ {
+		h.Record(time.Duration(i) * time.Microsecond)
+	}
+
+	s.assert.EqualValues(1000, h.Count())
+	s.assert.Equal(500500*time.Nanosecond, h.Mean())
+	s.assert.Equal(time.Millisecond, h.Max())
+
+	for q, want := range map[float64]time.Duration{0.5: 500 * time.Microsecond, 0.9: 900 * time.Microsecond, 0.99: 990 * time.Microsecond} {
+		got := h.Percentile(q)
+		s.assert.GreaterOrEqual(got, want)
+		s.assert.InEpsilon(float64(want), float64(got), 1.0/subBuckets)
+	}
+	s.assert.Equal(time.Millisecond, h.Percentile(1))
+}
+
+func (s *histogramTestSuite) TestOutOfRange() {
+	h := NewHistogram()
+	h.Record(-time.Second)
+	h.Record(10 * time.Minute)
+
+	s.assert.EqualValues(2, h.Count())
+	s.assert.Zero(h.Percentile(0.5))
+	s.assert.Equal(10*time.Minute, h.Max())
+	s.assert.Equal(10*time.Minute, h.Percentile(1))
+}
+
+func (s *histogramTestSuite) TestConcurrentRecord() {
+	h := NewHistogram()
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Go(func() {
+			for j := range 1000 {
+				h.Record(time.Duration(i*1000+j) * time.Nanosecond)
+			}
+		})
+	}
+	wg.Wait()
+
+	s.assert.EqualValues(8000, h.Count())
+	s.assert.Equal(7999*time.Nanosecond, h.Max())
+}
+
+func (s *histogramTestSuite) TestObserveDisabled() {
+	Observe("a.Op", time.Millisecond)
+	s.assert.Empty(LatencyReport())
+}
+
+func (s *histogramTestSuite) TestReport() {
+	EnableLatency()
+	s.assert.True(LatencyEnabled())
+
+	for range 10 {
+		Observe("libfuse.read", 2*time.Millisecond)
+		Observe("azstorage.ReadInBuffer", time.Millisecond)
+	}
+	Observe("azstorage.ReadInBuffer", 100*time.Millisecond+123456*time.Nanosecond)
+
+	report := LatencyReport()
+	s.Require().Len(report, 2)
+	s.assert.Equal("azstorage.ReadInBuffer", report[0].Key)
+	s.assert.EqualValues(11, report[0].Count)
+	s.assert.Equal(100123456*time.Nanosecond, report[0].Max)
+	s.assert.Equal(100123456*time.Nanosecond, report[0].P99)
+	s.assert.InEpsilon(float64(time.Millisecond), float64(report[0].P50), 1.0/subBuckets)
+	s.assert.Equal("libfuse.read", report[1].Key)
+
+	buf := new(bytes.Buffer)
+	s.Require().NoError(WriteLatencyReport(buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	s.Require().Len(lines, 3)
+	s.assert.Equal([]string{"OPERATION", "COUNT", "MEAN", "P50", "P90", "P99", "MAX"}, strings.Fields(lines[0]))
+	s.assert.Equal("100ms", strings.Fields(lines[1])[6], "durations are rounded to three digits")
+	s.assert.Equal([]string{"libfuse.read", "10", "2ms"}, strings.Fields(lines[2])[:3])
+
+	DisableLatency()
+	s.assert.False(LatencyEnabled())
+	s.assert.Empty(LatencyReport())
+}
+
+func TestHistogramTestSuite(t *testing.T) {
+	suite.Run(t, new(histogramTestSuite))
+}
@@ -0,0 +1,135 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package exectime
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+// Latency histograms of the mount, keyed by "<component>.<operation>". Collected only once enabled, which the
+// mount does when 'track-time' is set in logging config.
+var (
+	latencyEnabled atomic.Bool
+	latencies      sync.Map // string -> *Histogram
+)
+
+// EnableLatency : Start collecting latency histograms
+func EnableLatency() {
+	latencyEnabled.Store(true)
+}
+
+// DisableLatency : Stop collecting latency histograms and drop the ones collected so far
+func DisableLatency() {
+	latencyEnabled.Store(false)
+	latencies.Clear()
+}
+
+// LatencyEnabled : Whether latency histograms are being collected
+func LatencyEnabled() bool {
+	return latencyEnabled.Load()
+}
+
+// Observe : Record the latency of a call against its key, no-op unless latency collection is enabled
+func Observe(key string, d time.Duration) {
+	if !latencyEnabled.Load() {
+		return
+	}
+
+	h, ok := latencies.Load(key)
+	if !ok {
+		h, _ = latencies.LoadOrStore(key, NewHistogram())
+	}
+	h.(*Histogram).Record(d)
+}
+
+// LatencySummary : Percentiles of the latencies recorded against a key
+type LatencySummary struct {
+	Key   string
+	Count uint64
+	Mean  time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+// LatencyReport : Summary of each latency histogram collected, sorted by key
+func LatencyReport() []LatencySummary {
+	var report []LatencySummary
+	latencies.Range(func(k, v any) bool {
+		h := v.(*Histogram)
+		report = append(report, LatencySummary{
+			Key:   k.(string),
+			Count: h.Count(),
+			Mean:  h.Mean(),
+			P50:   h.Percentile(0.50),
+			P90:   h.Percentile(0.90),
+			P99:   h.Percentile(0.99),
+			Max:   h.Max(),
+		})
+		return true
+	})
+
+	slices.SortFunc(report, func(a, b LatencySummary) int {
+		return cmp.Compare(a.Key, b.Key)
+	})
+	return report
+}
+
+// WriteLatencyReport : Write the latency report as a table, one row per component and operation
+func WriteLatencyReport(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "OPERATION\tCOUNT\tMEAN\tP50\tP90\tP99\tMAX")
+	for _, l := range LatencyReport() {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n", l.Key, l.Count,
+			round3(l.Mean), round3(l.P50), round3(l.P90), round3(l.P99), round3(l.Max))
+	}
+	return tw.Flush()
+}
+
+// round3 : Round the duration to three significant digits, percentiles are not more precise than that
+func round3(d time.Duration) time.Duration {
+	r := time.Duration(1)
+	for d >= 1000*r {
+		r *= 10
+	}
+	return d.Round(r)
+}
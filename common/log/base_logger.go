@@ -72,8 +72,14 @@ type BaseLogger struct {
 	compressWg sync.WaitGroup
 	rotateMu   sync.Mutex
 	rotationID uint64
+
+	// Renders an event as a line of the log, set by loggers which reuse the file handling of BaseLogger
+	formatEvent eventFormatter
 }
 
+// eventFormatter : Render a log event with the caller's file name and line
+type eventFormatter func(lvl string, file string, line int, format string, args []any) string
+
 func newBaseLogger(config LogFileConfig) (*BaseLogger, error) {
 	l := &BaseLogger{fileConfig: config}
 	err := l.init()
@@ -227,6 +233,11 @@ func (l *BaseLogger) Destroy() error {
 func (l *BaseLogger) logEvent(lvl string, format string, args ...any) {
 	// Only log if the log level matches the log request
 	_, fn, ln, _ := runtime.Caller(3)
+	if l.formatEvent != nil {
+		l.channel <- l.formatEvent(lvl, filepath.Base(fn), ln, format, args)
+		return
+	}
+
 	msg := fmt.Sprintf(format, args...)
 
 	base := fmt.Sprintf("%s : %s[%d] : ",
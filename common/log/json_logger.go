@@ -0,0 +1,127 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+)
+
+// JSONLogger : File logger writing one JSON object per event, for log shippers to index without parsing free-form
+// text. Rotation and compression of the log file are the same as BaseLogger.
+type JSONLogger struct {
+	*BaseLogger
+}
+
+// jsonEvent : Fields of an event in the log
+type jsonEvent struct {
+	Timestamp   string `json:"timestamp"`
+	Level       string `json:"level"`
+	Tag         string `json:"tag"`
+	PID         int    `json:"pid"`
+	MountPath   string `json:"mount_path,omitempty"`
+	GoroutineID uint64 `json:"goroutine_id"`
+	Component   string `json:"component,omitempty"`
+	Op          string `json:"op,omitempty"`
+	Path        string `json:"path,omitempty"`
+	File        string `json:"file"`
+	Line        int    `json:"line"`
+	Message     string `json:"message"`
+}
+
+// jsonTimeFormat : RFC 3339 with milliseconds, fixed width so that lines sort by time
+const jsonTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// Messages are prefixed with "<Component>::<op> : " by convention. When the message goes on with the path of the
+// operation, e.g. "%s served from cache" or "name=%s", the argument is reported as the path.
+var (
+	msgPrefix = regexp.MustCompile(`^([A-Za-z0-9_]+)::([A-Za-z0-9_]+) : `)
+	pathVerb  = regexp.MustCompile(`^(?:(?:name|path|file|src)=)?%s(?:[ ,\]]|$)`)
+)
+
+func newJSONLogger(config LogFileConfig) (*JSONLogger, error) {
+	l := &JSONLogger{BaseLogger: &BaseLogger{fileConfig: config}}
+	l.formatEvent = l.format
+
+	err := l.init()
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *JSONLogger) GetType() string {
+	return "json"
+}
+
+// format : Render the event as a JSON object on a single line
+func (l *JSONLogger) format(lvl string, file string, line int, format string, args []any) string {
+	event := jsonEvent{
+		Timestamp:   time.Now().Format(jsonTimeFormat),
+		Level:       lvl,
+		Tag:         l.fileConfig.LogTag,
+		PID:         l.procPID,
+		MountPath:   common.MountPath,
+		GoroutineID: common.GetGoroutineID(),
+		File:        file,
+		Line:        line,
+		Message:     fmt.Sprintf(format, args...),
+	}
+
+	if m := msgPrefix.FindStringSubmatch(format); m != nil {
+		event.Component, event.Op = m[1], m[2]
+		rest := format[len(m[0]):]
+
+		// A bare "%s" is usually an error rather than a path
+		if rest != "%s" && len(args) > 0 && pathVerb.MatchString(rest) {
+			if path, ok := args[0].(string); ok {
+				event.Path = path
+			}
+		}
+	}
+
+	// Encoding a struct of strings and integers can not fail, and HTML escaping only hurts readability
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(event)
+
+	// Println of the dumper terminates the line
+	return string(bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}))
+}
@@ -79,21 +79,29 @@ func NewLogger(name string, config common.LogConfig) (Logger, error) {
 		config.Tag = common.FileSystemName
 	}
 
+	fileConfig := LogFileConfig{
+		LogFile:        config.FilePath,
+		LogLevel:       config.Level,
+		LogSize:        config.MaxFileSize * 1024 * 1024,
+		LogFileCount:   int(config.FileCount),
+		LogTag:         config.Tag,
+		LogGoroutineID: config.LogGoroutineID,
+		LogCompress:    config.LogCompress,
+	}
+
 	switch name {
 	case "base":
-		baseLogger, err := newBaseLogger(LogFileConfig{
-			LogFile:        config.FilePath,
-			LogLevel:       config.Level,
-			LogSize:        config.MaxFileSize * 1024 * 1024,
-			LogFileCount:   int(config.FileCount),
-			LogTag:         config.Tag,
-			LogGoroutineID: config.LogGoroutineID,
-			LogCompress:    config.LogCompress,
-		})
+		baseLogger, err := newBaseLogger(fileConfig)
 		if err != nil {
 			return nil, err
 		}
 		return baseLogger, nil
+	case "json":
+		jsonLogger, err := newJSONLogger(fileConfig)
+		if err != nil {
+			return nil, err
+		}
+		return jsonLogger, nil
 	case "silent":
 		silentLogger := &SilentLogger{}
 		return silentLogger, nil
@@ -311,8 +319,9 @@ func invokeRotateHooks() {
 // Blobfuse2 log file in addition to stderr (which the daemon library redirects to the per-mount
 // .trace file). Supported logger modes:
 //
-//   - "base"  -> writes to logFilePath (the configured log file). Skipped when logFilePath is empty
-//     or "stdout".
+//   - "base" / "json" -> writes to logFilePath (the configured log file). Skipped when logFilePath is
+//     empty or "stdout". Crash dumps are plain text, so they break the one object per line format of
+//     the json logger, which is what a log shipper should surface anyway.
 //   - "" / "default" / "syslog" -> writes to common.SyslogFilePath (the rsyslog sink for blobfuse2
 //     messages, declared in setup/11-blobfuse2.conf).
 //
@@ -321,7 +330,7 @@ func invokeRotateHooks() {
 //
 // The crash output fd is kept attached to the live log file across rotations via two mechanisms
 // selected per logger mode:
-//  1. "base"   -> BaseLogger's in-process size-based rotation invokes the registered rotate hook.
+//  1. "base" / "json" -> BaseLogger's in-process size-based rotation invokes the registered rotate hook.
 //     No SIGHUP handler is installed because BaseLogger owns its file and does not participate in
 //     external rotation.
 //  2. syslog family -> external rotators (logrotate's postrotate, the AKS Blob CSI driver, ...)
@@ -371,7 +380,7 @@ func isSyslogFamily(loggerType string) bool {
 // dumps to a log file (silent logger, stdout base logger, unknown types, ...).
 func crashOutputTarget(loggerType, logFilePath string) string {
 	switch loggerType {
-	case "base":
+	case "base", "json":
 		// BaseLogger may be configured with "stdout" or no file at all; nothing useful to also write to.
 		if logFilePath == "" || logFilePath == "stdout" {
 			return ""
@@ -35,6 +35,7 @@ package log
 
 import (
 	"compress/gzip"
+	"encoding/json"
 	"io"
 	"os"
 	"os/signal"
@@ -254,6 +255,110 @@ func (lts *LoggerTestSuite) TestBaseLoggerRotateInvokesHook() {
 	assert.Equal(int32(2), atomic.LoadInt32(&fired))
 }
 
+func (lts *LoggerTestSuite) readJSONLog(path string) []map[string]any {
+	data, err := os.ReadFile(path)
+	lts.Require().NoError(err)
+
+	var events []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		event := map[string]any{}
+		lts.Require().NoError(json.Unmarshal([]byte(line), &event), "line is not a JSON object: %s", line)
+		events = append(events, event)
+	}
+	return events
+}
+
+func (lts *LoggerTestSuite) TestJSONLogger() {
+	assert := assert.New(lts.T())
+
+	logFile := filepath.Join(lts.T().TempDir(), "blobfuse2.json.log")
+	err := SetDefaultLogger("json", common.LogConfig{
+		FilePath: logFile,
+		Level:    common.ELogLevel.LOG_DEBUG(),
+		Tag:      "bfuse",
+	})
+	assert.NoError(err)
+	assert.Equal("json", GetType())
+
+	Debug("AttrCache::GetAttr : %s served from cache", "dir/file <1>")
+	Trace("Libfuse::libfuse_open : name=%s, flags %d", "dir/other", 2)
+	Err("Xload::Configure : %s", "invalid config")
+	Info("plain message %d", 7)
+	assert.NoError(Destroy())
+
+	events := lts.readJSONLog(logFile)
+	assert.Len(events, 4)
+
+	assert.Equal("LOG_DEBUG", events[0]["level"])
+	assert.Equal("bfuse", events[0]["tag"])
+	assert.EqualValues(os.Getpid(), events[0]["pid"])
+	assert.Equal("AttrCache", events[0]["component"])
+	assert.Equal("GetAttr", events[0]["op"])
+	assert.Equal("dir/file <1>", events[0]["path"])
+	assert.Equal("AttrCache::GetAttr : dir/file <1> served from cache", events[0]["message"])
+	assert.Equal("logger_test.go", events[0]["file"])
+	assert.NotZero(events[0]["goroutine_id"])
+	assert.NotZero(events[0]["line"])
+	_, err = time.Parse(time.RFC3339, events[0]["timestamp"].(string))
+	assert.NoError(err)
+
+	assert.Equal("LOG_TRACE", events[1]["level"])
+	assert.Equal("libfuse_open", events[1]["op"])
+	assert.Equal("dir/other", events[1]["path"])
+
+	// A bare argument is not taken for a path
+	assert.Equal("Xload", events[2]["component"])
+	assert.NotContains(events[2], "path")
+
+	assert.NotContains(events[3], "component")
+	assert.NotContains(events[3], "op")
+	assert.Equal("plain message 7", events[3]["message"])
+}
+
+func (lts *LoggerTestSuite) TestJSONLoggerRotate() {
+	assert := assert.New(lts.T())
+	resetCrashOutputState()
+
+	logFile := filepath.Join(lts.T().TempDir(), "rotate.json.log")
+	err := SetDefaultLogger("json", common.LogConfig{
+		FilePath:    logFile,
+		MaxFileSize: 1,
+		FileCount:   3,
+		Level:       common.ELogLevel.LOG_DEBUG(),
+		LogCompress: true,
+	})
+	assert.NoError(err)
+
+	var fired int32
+	registerLogRotateHook(func() { atomic.AddInt32(&fired, 1) })
+
+	Info("Test::Rotate : before rotation")
+	assert.Eventually(func() bool {
+		fi, err := os.Stat(logFile)
+		return err == nil && fi.Size() > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.NoError(LogRotate())
+	assert.Equal(int32(1), atomic.LoadInt32(&fired))
+
+	Info("Test::Rotate : after rotation")
+	assert.NoError(Destroy())
+
+	// The rotated file is compressed in background, Destroy waits for it
+	f, err := os.Open(logFile + ".1.gz")
+	assert.NoError(err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	assert.NoError(err)
+	rotated, err := io.ReadAll(gz)
+	assert.NoError(err)
+	assert.Contains(string(rotated), `"message":"Test::Rotate : before rotation"`)
+
+	events := lts.readJSONLog(logFile)
+	assert.Len(events, 1)
+	assert.Equal("Rotate", events[0]["op"])
+}
+
 func (lts *LoggerTestSuite) TestSetCrashOutput() {
 	assert := assert.New(lts.T())
 
@@ -0,0 +1,277 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package common
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Share of time (%) tasks stalled on memory in last 10 seconds, beyond which memory is considered under pressure
+	MemoryPressureStallPct = 10.0
+
+	// Usage (%) of memory limit beyond which memory is considered under pressure
+	MemoryPressureUsagePct = 90
+
+	// Usage (%) of memory limit up to which pools are allowed to grow back
+	MemoryRelaxedUsagePct = 80
+
+	// Share of time (%) tasks stalled on memory in last 10 seconds, up to which pools are allowed to grow back
+	MemoryRelaxedStallPct = 2.0
+)
+
+var (
+	cgroupFSRoot = "/sys/fs/cgroup"
+	procFSRoot   = "/proc"
+)
+
+// MemoryPressure : Memory limit, usage and stall pressure of the cgroup this process runs in.
+// Outside a cgroup v2 memory controller, limit and usage are of the whole system.
+type MemoryPressure struct {
+	Limit     uint64  // Memory available to this process in bytes
+	Usage     uint64  // Working set in bytes, memory in use less the page cache that can be reclaimed
+	SomeAvg10 float64 // % of time some tasks were stalled on memory in last 10 seconds
+	FullAvg10 float64 // % of time all tasks were stalled on memory in last 10 seconds
+}
+
+// GetMemoryPressure : Read memory.max, memory.current, memory.stat and memory.pressure of the cgroup v2 of this process,
+// falling back to /proc/meminfo and /proc/pressure/memory when there is no cgroup limit.
+// Page cache of files streamed through the mount is charged to the cgroup but is reclaimed before it runs out of
+// memory, so inactive file pages are not counted as usage, the same way the working set of a container is reported.
+func GetMemoryPressure() (*MemoryPressure, error) {
+	return readMemoryPressure(cgroupFSRoot, procFSRoot)
+}
+
+func readMemoryPressure(cgroupRoot string, procRoot string) (*MemoryPressure, error) {
+	mp := &MemoryPressure{}
+
+	dir, err := getCgroupDir(cgroupRoot, procRoot)
+	if err == nil {
+		limit, lerr := readCgroupValue(filepath.Join(dir, "memory.max"))
+		usage, uerr := readCgroupValue(filepath.Join(dir, "memory.current"))
+		if lerr == nil && uerr == nil && limit > 0 {
+			mp.Limit = limit
+			mp.Usage = usage
+			if inactive, err := readCgroupStat(filepath.Join(dir, "memory.stat"), "inactive_file"); err == nil {
+				mp.Usage -= min(inactive, usage)
+			}
+			mp.SomeAvg10, mp.FullAvg10, err = readPressureAvg10(filepath.Join(dir, "memory.pressure"))
+			if err != nil {
+				// Pressure accounting may be disabled for the cgroup, limit alone is still good to go with
+				mp.SomeAvg10, mp.FullAvg10, _ = readPressureAvg10(filepath.Join(procRoot, "pressure", "memory"))
+			}
+			return mp, nil
+		}
+	}
+
+	// No cgroup limit applies so go by the memory of the system
+	mp.Limit, mp.Usage, err = readSystemMemory(filepath.Join(procRoot, "meminfo"))
+	if err != nil {
+		return nil, err
+	}
+
+	mp.SomeAvg10, mp.FullAvg10, _ = readPressureAvg10(filepath.Join(procRoot, "pressure", "memory"))
+	return mp, nil
+}
+
+// UnderPressure : Whether the memory is close to its limit or tasks are stalling on it
+func (mp *MemoryPressure) UnderPressure() bool {
+	return mp.SomeAvg10 >= MemoryPressureStallPct || mp.Usage*100 >= mp.Limit*MemoryPressureUsagePct
+}
+
+// Headroom : Bytes that can be taken before usage reaches the relaxed mark, 0 while tasks stall on memory beyond the
+// relaxed share of time
+func (mp *MemoryPressure) Headroom() uint64 {
+	relaxed := mp.Limit * MemoryRelaxedUsagePct / 100
+	if mp.SomeAvg10 > MemoryRelaxedStallPct || mp.Usage >= relaxed {
+		return 0
+	}
+
+	return relaxed - mp.Usage
+}
+
+// getCgroupDir : Directory of the cgroup v2 this process belongs to
+func getCgroupDir(cgroupRoot string, procRoot string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(procRoot, "self", "cgroup"))
+	if err != nil {
+		return "", err
+	}
+
+	// cgroup v2 has a single hierarchy listed as "0::<path>"
+	for line := range strings.SplitSeq(string(data), "\n") {
+		if path, found := strings.CutPrefix(line, "0::"); found {
+			return filepath.Join(cgroupRoot, path), nil
+		}
+	}
+
+	return "", fmt.Errorf("cgroup v2 hierarchy not found")
+}
+
+// readCgroupValue : Read a cgroup file holding a single number, "max" is reported as 0
+func readCgroupValue(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	value := strings.TrimSpace(string(data))
+	if value == "max" {
+		return 0, nil
+	}
+
+	return strconv.ParseUint(value, 10, 64)
+}
+
+// readCgroupStat : Read a counter of a flat keyed cgroup file like memory.stat
+func readCgroupStat(path string, key string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	for line := range strings.SplitSeq(string(data), "\n") {
+		if value, found := strings.CutPrefix(line, key+" "); found {
+			return strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		}
+	}
+
+	return 0, fmt.Errorf("%s not found in %s", key, path)
+}
+
+// readPressureAvg10 : Read avg10 of "some" and "full" lines of a PSI file
+func readPressureAvg10(path string) (float64, float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	var some, full float64
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		avg, found := strings.CutPrefix(fields[1], "avg10=")
+		if !found {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(avg, 64)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		switch fields[0] {
+		case "some":
+			some = value
+		case "full":
+			full = value
+		}
+	}
+
+	return some, full, scanner.Err()
+}
+
+// readSystemMemory : Total and used memory of the system from meminfo
+func readSystemMemory(path string) (uint64, uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err == nil {
+			values[strings.TrimSuffix(fields[0], ":")] = value * 1024
+		}
+	}
+
+	total := values["MemTotal"]
+	available, found := values["MemAvailable"]
+	if !found {
+		available = values["MemFree"]
+	}
+
+	if total == 0 || available > total {
+		return 0, 0, fmt.Errorf("invalid memory info in %s", path)
+	}
+
+	return total, total - available, nil
+}
+
+// PoolAdjustment : Number of blocks a pool shall give up (negative) or can take back (positive) under the current memory state.
+// Pool of blockSize blocks holds size blocks now, and is to stay between minSize and maxSize blocks.
+// Under pressure it gives up at least a quarter of its blocks, and it grows back by at most an eighth of maxSize at a time.
+func (mp *MemoryPressure) PoolAdjustment(blockSize uint64, size, minSize, maxSize uint32) int32 {
+	if blockSize == 0 {
+		return 0
+	}
+
+	if mp.UnderPressure() {
+		if size <= minSize {
+			return 0
+		}
+
+		shrink := uint64(size / 4)
+		mark := mp.Limit * MemoryPressureUsagePct / 100
+		if mp.Usage > mark {
+			shrink = max(shrink, (mp.Usage-mark+blockSize-1)/blockSize)
+		}
+
+		return -int32(min(shrink, uint64(size-minSize)))
+	}
+
+	if size >= maxSize {
+		return 0
+	}
+
+	grow := min(mp.Headroom()/blockSize, uint64(max(maxSize/8, 1)), uint64(maxSize-size))
+	return int32(grow)
+}
@@ -0,0 +1,208 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type memoryPressureTestSuite struct {
+	suite.Suite
+	assert     *assert.Assertions
+	cgroupRoot string
+	procRoot   string
+}
+
+func (suite *memoryPressureTestSuite) SetupTest() {
+	suite.assert = assert.New(suite.T())
+	suite.cgroupRoot = suite.T().TempDir()
+	suite.procRoot = suite.T().TempDir()
+
+	suite.writeFile(filepath.Join(suite.procRoot, "self", "cgroup"), "0::/pod/blobfuse2\n")
+	suite.writeFile(filepath.Join(suite.procRoot, "meminfo"), "MemTotal:       8192 kB\nMemFree:        1024 kB\nMemAvailable:   6144 kB\n")
+	suite.writeFile(filepath.Join(suite.procRoot, "pressure", "memory"), "some avg10=1.50 avg60=0.00 avg300=0.00 total=10\nfull avg10=0.50 avg60=0.00 avg300=0.00 total=5\n")
+}
+
+func (suite *memoryPressureTestSuite) writeFile(path string, data string) {
+	suite.assert.NoError(os.MkdirAll(filepath.Dir(path), 0755))
+	suite.assert.NoError(os.WriteFile(path, []byte(data), 0644))
+}
+
+func (suite *memoryPressureTestSuite) cgroupFile(name string) string {
+	return filepath.Join(suite.cgroupRoot, "pod", "blobfuse2", name)
+}
+
+func TestMemoryPressure(t *testing.T) {
+	suite.Run(t, new(memoryPressureTestSuite))
+}
+
+func (suite *memoryPressureTestSuite) TestCgroupLimit() {
+	suite.writeFile(suite.cgroupFile("memory.max"), "1000\n")
+	suite.writeFile(suite.cgroupFile("memory.current"), "500\n")
+	suite.writeFile(suite.cgroupFile("memory.pressure"), "some avg10=12.00 avg60=3.00 avg300=1.00 total=100\nfull avg10=4.00 avg60=1.00 avg300=0.00 total=50\n")
+
+	mp, err := readMemoryPressure(suite.cgroupRoot, suite.procRoot)
+	suite.assert.NoError(err)
+	suite.assert.Equal(uint64(1000), mp.Limit)
+	suite.assert.Equal(uint64(500), mp.Usage)
+	suite.assert.InDelta(12.0, mp.SomeAvg10, 0.001)
+	suite.assert.InDelta(4.0, mp.FullAvg10, 0.001)
+
+	// Stalls alone are enough to call it pressure
+	suite.assert.True(mp.UnderPressure())
+	suite.assert.Equal(uint64(0), mp.Headroom())
+}
+
+func (suite *memoryPressureTestSuite) TestCgroupWithoutPressure() {
+	suite.writeFile(suite.cgroupFile("memory.max"), "1000\n")
+	suite.writeFile(suite.cgroupFile("memory.current"), "500\n")
+
+	// PSI of the system is used when the cgroup does not have it
+	mp, err := readMemoryPressure(suite.cgroupRoot, suite.procRoot)
+	suite.assert.NoError(err)
+	suite.assert.Equal(uint64(1000), mp.Limit)
+	suite.assert.InDelta(1.5, mp.SomeAvg10, 0.001)
+	suite.assert.False(mp.UnderPressure())
+
+	suite.writeFile(suite.cgroupFile("memory.current"), "950\n")
+	mp, err = readMemoryPressure(suite.cgroupRoot, suite.procRoot)
+	suite.assert.NoError(err)
+	suite.assert.True(mp.UnderPressure())
+}
+
+func (suite *memoryPressureTestSuite) TestHeadroom() {
+	mp := &MemoryPressure{Limit: 1000, Usage: 500}
+	suite.assert.False(mp.UnderPressure())
+	suite.assert.Equal(uint64(300), mp.Headroom())
+
+	mp.Usage = 850
+	suite.assert.False(mp.UnderPressure())
+	suite.assert.Equal(uint64(0), mp.Headroom())
+
+	// Occasional stalls do not keep pools from growing back, sustained ones do
+	mp.Usage = 500
+	mp.SomeAvg10 = 0.2
+	suite.assert.Equal(uint64(300), mp.Headroom())
+
+	mp.SomeAvg10 = 5
+	suite.assert.False(mp.UnderPressure())
+	suite.assert.Equal(uint64(0), mp.Headroom())
+}
+
+func (suite *memoryPressureTestSuite) TestCgroupWorkingSet() {
+	suite.writeFile(suite.cgroupFile("memory.max"), "1000\n")
+	suite.writeFile(suite.cgroupFile("memory.current"), "950\n")
+	suite.writeFile(suite.cgroupFile("memory.stat"), "anon 300\nfile 640\nactive_file 190\ninactive_file 450\n")
+
+	// Page cache of streamed files fills the cgroup without it being under pressure
+	mp, err := readMemoryPressure(suite.cgroupRoot, suite.procRoot)
+	suite.assert.NoError(err)
+	suite.assert.Equal(uint64(500), mp.Usage)
+	suite.assert.False(mp.UnderPressure())
+	suite.assert.Equal(uint64(300), mp.Headroom())
+
+	// Inactive file pages never take usage below zero
+	suite.writeFile(suite.cgroupFile("memory.stat"), "inactive_file 2000\n")
+	mp, err = readMemoryPressure(suite.cgroupRoot, suite.procRoot)
+	suite.assert.NoError(err)
+	suite.assert.Equal(uint64(0), mp.Usage)
+
+	// Without memory.stat all of memory.current is usage
+	suite.writeFile(suite.cgroupFile("memory.stat"), "anon 300\n")
+	mp, err = readMemoryPressure(suite.cgroupRoot, suite.procRoot)
+	suite.assert.NoError(err)
+	suite.assert.Equal(uint64(950), mp.Usage)
+}
+
+func (suite *memoryPressureTestSuite) TestNoCgroupLimit() {
+	suite.writeFile(suite.cgroupFile("memory.max"), "max\n")
+	suite.writeFile(suite.cgroupFile("memory.current"), "500\n")
+
+	// Without a limit on the cgroup, memory of the system is what matters
+	mp, err := readMemoryPressure(suite.cgroupRoot, suite.procRoot)
+	suite.assert.NoError(err)
+	suite.assert.Equal(uint64(8192*1024), mp.Limit)
+	suite.assert.Equal(uint64(2048*1024), mp.Usage)
+	suite.assert.InDelta(0.5, mp.FullAvg10, 0.001)
+}
+
+func (suite *memoryPressureTestSuite) TestCgroupV1() {
+	suite.writeFile(filepath.Join(suite.procRoot, "self", "cgroup"), "12:memory:/pod\n")
+
+	mp, err := readMemoryPressure(suite.cgroupRoot, suite.procRoot)
+	suite.assert.NoError(err)
+	suite.assert.Equal(uint64(8192*1024), mp.Limit)
+}
+
+func (suite *memoryPressureTestSuite) TestInvalidMeminfo() {
+	suite.writeFile(filepath.Join(suite.procRoot, "meminfo"), "MemTotal: 0 kB\n")
+
+	_, err := readMemoryPressure(suite.cgroupRoot, suite.procRoot)
+	suite.assert.Error(err)
+}
+
+func (suite *memoryPressureTestSuite) TestGetMemoryPressure() {
+	mp, err := GetMemoryPressure()
+	suite.assert.NoError(err)
+	suite.assert.Positive(mp.Limit)
+}
+
+func (suite *memoryPressureTestSuite) TestPoolAdjustment() {
+	// Usage beyond the pressure mark is given up even if it is more than a quarter of the pool
+	mp := &MemoryPressure{Limit: 100 * 1024, Usage: 99 * 1024}
+	suite.assert.Equal(int32(-9), mp.PoolAdjustment(1024, 20, 4, 40))
+
+	// Stalls without much usage still shrink the pool by a quarter
+	mp = &MemoryPressure{Limit: 100 * 1024, Usage: 10 * 1024, SomeAvg10: 20}
+	suite.assert.Equal(int32(-10), mp.PoolAdjustment(1024, 40, 4, 40))
+
+	// Pool never goes below its minimum
+	suite.assert.Equal(int32(-2), mp.PoolAdjustment(1024, 12, 10, 40))
+	suite.assert.Equal(int32(0), mp.PoolAdjustment(1024, 4, 4, 40))
+
+	// Growth is limited by headroom, one eighth of the pool and the pool size
+	mp = &MemoryPressure{Limit: 100 * 1024, Usage: 78 * 1024}
+	suite.assert.Equal(int32(2), mp.PoolAdjustment(1024, 20, 4, 40))
+
+	mp.Usage = 10 * 1024
+	suite.assert.Equal(int32(5), mp.PoolAdjustment(1024, 20, 4, 40))
+	suite.assert.Equal(int32(3), mp.PoolAdjustment(1024, 37, 4, 40))
+	suite.assert.Equal(int32(0), mp.PoolAdjustment(1024, 40, 4, 40))
+	suite.assert.Equal(int32(0), mp.PoolAdjustment(0, 20, 4, 40))
+}
@@ -48,6 +48,7 @@ import (
 	"github.com/Azure/azure-storage-fuse/v2/common/log"
 	"github.com/Azure/azure-storage-fuse/v2/internal"
 	"github.com/Azure/azure-storage-fuse/v2/internal/handlemap"
+	"github.com/Azure/azure-storage-fuse/v2/internal/stats_manager"
 )
 
 // defaultAttrCacheTimeout is the default TTL for cached attributes (seconds).
@@ -95,6 +96,7 @@ type AttrCache struct {
 	stopCh        chan struct{}
 	sweepWg       sync.WaitGroup
 	noCacheOnList bool
+	cacheRules    *common.CacheRules
 }
 
 // AttrCacheOptions holds the configuration for the attribute cache.
@@ -113,6 +115,11 @@ const compName = "attr_cache"
 
 // Verification to check satisfaction criteria with Component Interface
 var _ internal.Component = &AttrCache{}
+var _ internal.PathInvalidator = &AttrCache{}
+var _ internal.CacheDropper = &AttrCache{}
+var _ internal.StateReporter = &AttrCache{}
+
+var attrCacheStatsCollector *stats_manager.StatsCollector
 
 func (ac *AttrCache) Name() string {
 	return compName
@@ -134,6 +141,7 @@ func (ac *AttrCache) Priority() internal.ComponentPriority {
 func (ac *AttrCache) Start(_ context.Context) error {
 	log.Trace("AttrCache::Start : Starting component %s", ac.Name())
 	ac.lru = newAttrCacheLRU(ac.maxSizeBytes)
+	attrCacheStatsCollector = stats_manager.NewStatsCollector(ac.Name())
 	if ac.cacheTimeout > 0 {
 		ac.stopCh = make(chan struct{})
 		ac.sweepWg.Add(1)
@@ -154,6 +162,9 @@ func (ac *AttrCache) Stop() error {
 		ac.sweepWg.Wait()
 		ac.stopCh = nil
 	}
+	if attrCacheStatsCollector != nil {
+		attrCacheStatsCollector.Destroy()
+	}
 	return nil
 }
 
@@ -192,7 +203,10 @@ func (ac *AttrCache) sweepExpired() {
 	timeout := ac.cacheTimeout
 	cutoff := time.Now().Add(-timeout)
 	before := ac.lru.Size()
-	ac.lru.DeleteIf(func(_ string, item *attrCacheItem) bool {
+	ac.lru.DeleteIf(func(path string, item *attrCacheItem) bool {
+		if ac.cacheRules != nil {
+			return ac.expired(path, item)
+		}
 		return !item.cachedAt.After(cutoff)
 	})
 	maxSize := ac.lru.MaxSize()
@@ -206,6 +220,23 @@ func (ac *AttrCache) sweepExpired() {
 		ac.lru.Size()>>20, maxMB, ac.lru.Len(), (before-ac.lru.Size())>>20)
 }
 
+// timeout returns the attribute timeout of a path as per the cache rule applying to it.
+func (ac *AttrCache) timeout(policy common.CachePolicy) time.Duration {
+	if !policy.Matched() {
+		return ac.cacheTimeout
+	}
+	return time.Duration(policy.Timeout(uint32(ac.cacheTimeout/time.Second))) * time.Second
+}
+
+// expired reports whether the entry has outlived the timeout of its path. Pinned entries never expire.
+func (ac *AttrCache) expired(path string, item *attrCacheItem) bool {
+	policy := ac.cacheRules.Match(path)
+	if policy.Pin() {
+		return false
+	}
+	return time.Since(item.cachedAt) >= ac.timeout(policy)
+}
+
 // GenConfig returns a default configuration snippet for this component.
 func (ac *AttrCache) GenConfig() string {
 	log.Info("AttrCache::Configure : config generation started")
@@ -265,9 +296,22 @@ func (ac *AttrCache) Configure(_ bool) error {
 		ac.maxSizeBytes = defaultMaxSizeFloorMB * 1024 * 1024
 	}
 
+	var rules []common.CacheRule
+	err = config.UnmarshalKey(common.CacheRulesKey, &rules)
+	if err != nil {
+		log.Err("AttrCache::Configure : config error [invalid cache rules]")
+		return fmt.Errorf("config error in %s [%s]", ac.Name(), err.Error())
+	}
+
+	ac.cacheRules, err = common.NewCacheRules(rules)
+	if err != nil {
+		log.Err("AttrCache::Configure : config error [%s]", err.Error())
+		return fmt.Errorf("config error in %s [%s]", ac.Name(), err.Error())
+	}
+
 	effectiveMB := uint32(ac.maxSizeBytes / (1024 * 1024))
-	log.Crit("AttrCache::Configure : cache-timeout %v, no-symlinks %t, no-cache-on-list %t, max-size-mb %d",
-		ac.cacheTimeout, ac.noSymlinks, ac.noCacheOnList, effectiveMB)
+	log.Crit("AttrCache::Configure : cache-timeout %v, no-symlinks %t, no-cache-on-list %t, max-size-mb %d, cache-rules %d",
+		ac.cacheTimeout, ac.noSymlinks, ac.noCacheOnList, effectiveMB, ac.cacheRules.Len())
 
 	return nil
 }
@@ -460,14 +504,21 @@ func (ac *AttrCache) SyncDir(options internal.SyncDirOptions) error {
 }
 
 // GetAttr serves from cache on hit (promoting the entry to MRU), or fetches from the
-// next component and caches the result on miss.
+// next component and caches the result on miss or when the caller asks to skip the cache.
 func (ac *AttrCache) GetAttr(options internal.GetAttrOptions) (*internal.ObjAttr, error) {
 	log.Trace("AttrCache::GetAttr : %s", options.Name)
 	truncatedPath := internal.TruncateDirName(options.Name)
 
-	if item, ok := ac.lru.Get(truncatedPath); ok {
-		if time.Since(item.cachedAt) < ac.cacheTimeout {
+	policy := ac.cacheRules.Match(truncatedPath)
+	if policy.NeverCache() {
+		return ac.NextComponent().GetAttr(options)
+	}
+
+	// SkipCache callers need the current attributes; fetch them and refresh the cached entry.
+	if item, ok := ac.lru.Get(truncatedPath); ok && !options.SkipCache {
+		if policy.Pin() || time.Since(item.cachedAt) < ac.timeout(policy) {
 			log.Debug("AttrCache::GetAttr : %s served from cache", options.Name)
+			attrCacheStatsCollector.CacheLookup(true)
 			if item.isNegativeEntry() {
 				return &internal.ObjAttr{}, syscall.ENOENT
 			}
@@ -481,6 +532,7 @@ func (ac *AttrCache) GetAttr(options internal.GetAttrOptions) (*internal.ObjAttr
 	}
 
 	// Cache miss: fetch from next component.
+	attrCacheStatsCollector.CacheLookup(false)
 	pathAttr, err := ac.NextComponent().GetAttr(options)
 
 	if err == nil {
@@ -560,6 +612,36 @@ func (ac *AttrCache) CommitData(options internal.CommitDataOptions) error {
 	return err
 }
 
+// InvalidatePath drops the cached entry so the next GetAttr is served by the next component.
+func (ac *AttrCache) InvalidatePath(name string) {
+	log.Trace("AttrCache::InvalidatePath : %s", name)
+	ac.lru.invalidatePath(name)
+}
+
+// InvalidateDir drops the cached entries of the directory and all its children.
+func (ac *AttrCache) InvalidateDir(name string) {
+	log.Trace("AttrCache::InvalidateDir : %s", name)
+	ac.lru.invalidateDirectory(name)
+}
+
+// DropCache drops all the cached entries.
+func (ac *AttrCache) DropCache() {
+	log.Info("AttrCache::DropCache : Dropping %d entries", ac.lru.Len())
+	ac.lru.touch()
+	ac.lru.Purge()
+}
+
+// State reports the occupancy and settings of the cache.
+func (ac *AttrCache) State() map[string]any {
+	return map[string]any{
+		"entries":     ac.lru.Len(),
+		"size-bytes":  ac.lru.Size(),
+		"max-size":    ac.lru.MaxSize(),
+		"timeout-sec": int64(ac.cacheTimeout / time.Second),
+		"cache-rules": ac.cacheRules.Len(),
+	}
+}
+
 // ------------------------- Factory -------------------------------------------
 
 // NewAttrCacheComponent creates a new AttrCache component.
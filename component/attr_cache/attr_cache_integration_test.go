@@ -236,6 +236,24 @@ func (suite *attrCacheIntegrationTestSuite) TestGetAttrValidEntryNotExpired() {
 	suite.assert.Equal(originalCachedAt, item.cachedAt)
 }
 
+func (suite *attrCacheIntegrationTestSuite) TestGetAttrSkipCache() {
+	// Negative entry within TTL, file created behind the cache.
+	_, err := suite.attrCache.GetAttr(internal.GetAttrOptions{Name: "skip.txt"})
+	suite.assert.True(os.IsNotExist(err))
+	suite.touchFile("skip.txt")
+
+	_, err = suite.attrCache.GetAttr(internal.GetAttrOptions{Name: "skip.txt"})
+	suite.assert.True(os.IsNotExist(err), "entry within TTL should be served from cache")
+
+	// SkipCache must go to the next component and refresh the entry.
+	attr, err := suite.attrCache.GetAttr(internal.GetAttrOptions{Name: "skip.txt", SkipCache: true})
+	suite.assert.NoError(err)
+	suite.assert.Equal("skip.txt", attr.Path)
+
+	item, _ := suite.attrCache.lru.Peek("skip.txt")
+	suite.assert.True(item.exists, "negative entry should be replaced by positive one")
+}
+
 // ---- Directory tests --------------------------------------------------------
 
 func (suite *attrCacheIntegrationTestSuite) TestCreateDirInvalidatesCache() {
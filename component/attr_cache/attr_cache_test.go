@@ -990,6 +990,54 @@ func (suite *attrCacheTestSuite) TestGetAttrDoesNotExist() {
 	}
 }
 
+func (suite *attrCacheTestSuite) TestGetAttrCacheRules() {
+	defer suite.cleanupTest()
+	suite.cleanupTest() // clean up the default attr cache generated
+	config := "attr_cache:\n  timeout-sec: 60\n\ncache-rules:\n  - path: ckpt/**\n    never-cache: true\n" +
+		"  - path: pinned/*\n    pin: true\n  - path: short/*\n    timeout-sec: 1\n  - path: long/*\n    timeout-sec: 3600\n"
+	suite.setupTestHelper(config)
+	suite.assert.Equal(4, suite.attrCache.cacheRules.Len())
+
+	stale := time.Now().Add(-10 * time.Minute)
+	recent := time.Now().Add(-2 * time.Second)
+	for path, cachedAt := range map[string]time.Time{"pinned/a": stale, "long/a": stale, "short/a": recent, "other": stale} {
+		suite.attrCache.lru.Put(path, &attrCacheItem{attr: getPathAttr(path, defaultSize, fs.FileMode(defaultMode), false), exists: true, cachedAt: cachedAt})
+	}
+
+	// Pinned entries and those within the timeout of their rule are served from cache
+	for _, path := range []string{"pinned/a", "long/a"} {
+		_, err := suite.attrCache.GetAttr(internal.GetAttrOptions{Name: path})
+		suite.assert.NoError(err)
+	}
+
+	// Entries past the timeout of their rule, or the component timeout when no rule applies, are fetched again
+	for _, path := range []string{"short/a", "other"} {
+		options := internal.GetAttrOptions{Name: path}
+		suite.mock.EXPECT().GetAttr(options).Return(getPathAttr(path, defaultSize, fs.FileMode(defaultMode), false), nil)
+		_, err := suite.attrCache.GetAttr(options)
+		suite.assert.NoError(err)
+	}
+
+	// Never cached paths always go to the next component and are not added to the cache
+	options := internal.GetAttrOptions{Name: "ckpt/step1"}
+	suite.mock.EXPECT().GetAttr(options).Return(getPathAttr("ckpt/step1", defaultSize, fs.FileMode(defaultMode), false), nil).Times(2)
+	for range 2 {
+		_, err := suite.attrCache.GetAttr(options)
+		suite.assert.NoError(err)
+	}
+	suite.assert.Nil(getCacheItem(suite.attrCache, "ckpt/step1"))
+}
+
+func (suite *attrCacheTestSuite) TestInvalidCacheRules() {
+	defer suite.cleanupTest()
+	_ = config.ReadConfigFromReader(strings.NewReader("cache-rules:\n  - path: \"data/[a-\"\n"))
+	ac := NewAttrCacheComponent()
+	ac.SetNextComponent(suite.mock)
+	err := ac.Configure(true)
+	suite.assert.Error(err)
+	suite.assert.Contains(err.Error(), "invalid path")
+}
+
 func (suite *attrCacheTestSuite) TestGetAttrOtherError() {
 	defer suite.cleanupTest()
 	var paths = []string{"a", "a/"}
@@ -1304,6 +1352,50 @@ func (suite *attrCacheTestSuite) TestLRUOrderPreservesRecentlyAccessed() {
 	suite.assert.True(suite.attrCache.lru.Has(pathLast))
 }
 
+// Tests invalidation requested by other components
+func (suite *attrCacheTestSuite) TestInvalidatePath() {
+	defer suite.cleanupTest()
+
+	addPathToCache(suite.assert, suite.attrCache, "a", false)
+	addPathToCache(suite.assert, suite.attrCache, "b", false)
+
+	suite.attrCache.InvalidatePath("a")
+	assertInvalid(suite, "a")
+	assertUntouched(suite, "b")
+
+	// Path not in cache
+	suite.attrCache.InvalidatePath("c")
+	assertInvalid(suite, "c")
+}
+
+func (suite *attrCacheTestSuite) TestInvalidateDir() {
+	defer suite.cleanupTest()
+
+	a, ab, ac := addDirectoryToCache(suite.assert, suite.attrCache, "a", false)
+
+	suite.attrCache.InvalidateDir("a")
+	for p := a.Front(); p != nil; p = p.Next() {
+		assertInvalid(suite, internal.TruncateDirName(p.Value.(string)))
+	}
+	ab.PushBackList(ac)
+	for p := ab.Front(); p != nil; p = p.Next() {
+		assertUntouched(suite, internal.TruncateDirName(p.Value.(string)))
+	}
+}
+
+func (suite *attrCacheTestSuite) TestDropCache() {
+	defer suite.cleanupTest()
+
+	addPathToCache(suite.assert, suite.attrCache, "a", false)
+	addPathToCache(suite.assert, suite.attrCache, "b", false)
+	suite.assert.Equal(2, suite.attrCache.State()["entries"])
+
+	suite.attrCache.DropCache()
+	assertInvalid(suite, "a")
+	assertInvalid(suite, "b")
+	suite.assert.Equal(0, suite.attrCache.State()["entries"])
+}
+
 // In order for 'go test' to run this suite, we need to create
 // a normal test function and pass our suite to suite.Run
 func TestAttrCacheTestSuite(t *testing.T) {
@@ -1353,6 +1445,25 @@ func (s *sweeperTestSuite) TestSweepExpiredRemovesExpiredEntries() {
 	s.assert.True(ok)
 }
 
+// TestSweepExpiredHonoursCacheRules verifies that pinned entries and entries within the timeout of their rule are kept.
+func (s *sweeperTestSuite) TestSweepExpiredHonoursCacheRules() {
+	ac := newSweeperCache(100 * time.Millisecond)
+	timeout := uint32(3600)
+	rules, err := common.NewCacheRules([]common.CacheRule{{Path: "pinned/**", Pin: true}, {Path: "long/**", TimeoutSec: &timeout}})
+	s.assert.NoError(err)
+	ac.cacheRules = rules
+
+	ac.lru.Put("pinned/a", expiredItem())
+	ac.lru.Put("long/a", &attrCacheItem{cachedAt: time.Now().Add(-time.Minute), exists: true, attr: makeAttr("a")})
+	ac.lru.Put("other", expiredItem())
+
+	ac.sweepExpired()
+
+	s.assert.Equal(2, ac.lru.Len())
+	_, ok := ac.lru.Peek("other")
+	s.assert.False(ok)
+}
+
 // TestSweepExpiredKeepsFreshEntries verifies that no entries are removed when none have expired.
 func (s *sweeperTestSuite) TestSweepExpiredKeepsFreshEntries() {
 	ac := newSweeperCache(1 * time.Hour)
@@ -41,6 +41,7 @@ import (
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
 	"github.com/Azure/azure-storage-fuse/v2/common"
 	"github.com/Azure/azure-storage-fuse/v2/common/config"
 	"github.com/Azure/azure-storage-fuse/v2/common/log"
@@ -444,6 +445,7 @@ func (az *AzStorage) RenameFile(options internal.RenameFileOptions) error {
 
 func (az *AzStorage) ReadFile(options internal.ReadFileOptions) (data []byte, err error) {
 	//log.Trace("AzStorage::ReadFile : Read %s", h.Path)
+	defer azStatsCollector.StartTransfer(stats_manager.Download)()
 	return az.storage.ReadBuffer(options.Handle.Path, 0, 0)
 }
 
@@ -478,6 +480,7 @@ func (az *AzStorage) ReadInBuffer(options *internal.ReadInBufferOptions) (length
 	}
 
 	length = int(dataLen)
+	defer azStatsCollector.StartTransfer(stats_manager.Download)()
 	err = az.storage.ReadInBuffer(path, options.Offset, dataLen, options.Data, options.Etag)
 	if err != nil {
 		log.Err("AzStorage::ReadInBuffer : Failed to read %s [%s]", path, err.Error())
@@ -488,6 +491,7 @@ func (az *AzStorage) ReadInBuffer(options *internal.ReadInBufferOptions) (length
 }
 
 func (az *AzStorage) WriteFile(options *internal.WriteFileOptions) (int, error) {
+	defer azStatsCollector.StartTransfer(stats_manager.Upload)()
 	err := az.storage.Write(options)
 	return len(options.Data), err
 }
@@ -510,12 +514,14 @@ func (az *AzStorage) TruncateFile(options internal.TruncateFileOptions) error {
 
 func (az *AzStorage) CopyToFile(options internal.CopyToFileOptions) error {
 	log.Trace("AzStorage::CopyToFile : Read file %s", options.Name)
+	defer azStatsCollector.StartTransfer(stats_manager.Download)()
 	return az.storage.ReadToFile(options.Name, options.Offset, options.Count, options.File)
 }
 
 func (az *AzStorage) CopyFromFile(options internal.CopyFromFileOptions) error {
 	log.Trace("AzStorage::CopyFromFile : Upload file %s", options.Name)
-	return az.storage.WriteFromFile(options.Name, options.Metadata, options.File)
+	defer azStatsCollector.StartTransfer(stats_manager.Upload)()
+	return az.storage.WriteFromFile(options.Name, options.Metadata, options.File, options.NewETag)
 }
 
 // Symlink operations
@@ -568,6 +574,7 @@ func (az *AzStorage) Chown(options internal.ChownOptions) error {
 
 func (az *AzStorage) FlushFile(options internal.FlushFileOptions) error {
 	log.Trace("AzStorage::FlushFile : Flush file %s", options.Handle.Path)
+	defer azStatsCollector.StartTransfer(stats_manager.Upload)()
 	return az.storage.StageAndCommit(options.Handle.Path, options.Handle.CacheObj.BlockOffsetList)
 }
 
@@ -576,6 +583,7 @@ func (az *AzStorage) GetCommittedBlockList(name string) (*internal.CommittedBloc
 }
 
 func (az *AzStorage) StageData(opt internal.StageDataOptions) error {
+	defer azStatsCollector.StartTransfer(stats_manager.Upload)()
 	return az.storage.StageBlock(opt.Name, opt.Data, opt.Id)
 }
 
@@ -583,6 +591,23 @@ func (az *AzStorage) CommitData(opt internal.CommitDataOptions) error {
 	return az.storage.CommitBlocks(opt.Name, opt.List, opt.NewETag)
 }
 
+// ChangeFeedContainer returns a client to the change feed container of the storage account
+func (az *AzStorage) ChangeFeedContainer() (*container.Client, error) {
+	var svc *BlockBlob
+	switch conn := az.storage.(type) {
+	case *BlockBlob:
+		svc = conn
+	case *Datalake:
+		svc = &conn.BlockBlob
+	}
+
+	if svc == nil || svc.Service == nil {
+		return nil, fmt.Errorf("change feed is not supported for this storage connection")
+	}
+
+	return svc.Service.NewContainerClient(changeFeedContainer), nil
+}
+
 // TODO : Below methods are pending to be implemented
 // SetAttr(string, internal.ObjAttr) error
 // UnlinkFile(string) error
@@ -61,6 +61,9 @@ const (
 	target      = "Target"
 )
 
+// container where storage account publishes its change feed logs
+const changeFeedContainer = "$blobchangefeed"
+
 // headers which should be logged and not redacted
 var allowedHeaders []string = []string{
 	"x-ms-version", "x-ms-date", "x-ms-range", "x-ms-delete-snapshots", "x-ms-delete-type-permanent", "x-ms-blob-content-type",
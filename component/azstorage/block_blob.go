@@ -941,6 +941,7 @@ func (bb *BlockBlob) ReadToFile(name string, offset int64, count int64, fi *os.F
 
 		// store total bytes downloaded so far
 		azStatsCollector.UpdateStats(stats_manager.Increment, bytesDownloaded, count)
+		azStatsCollector.AddBytes(stats_manager.Download, count)
 	}
 
 	if bb.Config.validateMD5 {
@@ -1009,6 +1010,7 @@ func (bb *BlockBlob) ReadBuffer(name string, offset int64, length int64) ([]byte
 		return buff, err
 	}
 
+	azStatsCollector.AddBytes(stats_manager.Download, length)
 	return buff, nil
 }
 
@@ -1070,6 +1072,7 @@ func (bb *BlockBlob) ReadInBuffer(name string, offset int64, length int64, data
 		*etag = sanitizeEtag(downloadResponse.ETag)
 	}
 
+	azStatsCollector.AddBytes(stats_manager.Download, int64(dataRead))
 	return nil
 }
 
@@ -1124,7 +1127,7 @@ func trackUpload(name string, bytesTransferred int64, count int64, uploadPtr *in
 }
 
 // WriteFromFile : Upload local file to blob
-func (bb *BlockBlob) WriteFromFile(name string, metadata map[string]*string, fi *os.File) (err error) {
+func (bb *BlockBlob) WriteFromFile(name string, metadata map[string]*string, fi *os.File, newEtag *string) (err error) {
 	log.Trace("BlockBlob::WriteFromFile : name %s", name)
 	//defer exectime.StatTimeCurrentBlock("WriteFromFile::WriteFromFile")()
 
@@ -1180,7 +1183,7 @@ func (bb *BlockBlob) WriteFromFile(name string, metadata map[string]*string, fi
 		}
 	}
 
-	_, err = blobClient.UploadFile(context.Background(), fi, uploadOptions)
+	resp, err := blobClient.UploadFile(context.Background(), fi, uploadOptions)
 
 	if err != nil {
 		serr := storeBlobErrToErr(err)
@@ -1198,9 +1201,14 @@ func (bb *BlockBlob) WriteFromFile(name string, metadata map[string]*string, fi
 	} else {
 		log.Debug("BlockBlob::WriteFromFile : Upload complete of blob %v", name)
 
+		if newEtag != nil {
+			*newEtag = sanitizeEtag(resp.ETag)
+		}
+
 		// store total bytes uploaded so far
 		if stat.Size() > 0 {
 			azStatsCollector.UpdateStats(stats_manager.Increment, bytesUploaded, stat.Size())
+			azStatsCollector.AddBytes(stats_manager.Upload, stat.Size())
 		}
 	}
 
@@ -1230,6 +1238,7 @@ func (bb *BlockBlob) WriteFromBuffer(name string, metadata map[string]*string, d
 		return err
 	}
 
+	azStatsCollector.AddBytes(stats_manager.Upload, int64(len(data)))
 	return nil
 }
 
@@ -1701,6 +1710,7 @@ func (bb *BlockBlob) stageAndCommitModifiedBlocks(name string, data []byte, offs
 				log.Err("BlockBlob::stageAndCommitModifiedBlocks : Failed to stage to blob %s at block %v [%s]", name, blockOffset, err.Error())
 				return err
 			}
+			azStatsCollector.AddBytes(stats_manager.Upload, blk.EndIndex-blk.StartIndex)
 			blockOffset = (blk.EndIndex - blk.StartIndex) + blockOffset
 		}
 	}
@@ -1768,6 +1778,7 @@ func (bb *BlockBlob) StageAndCommit(name string, bol *common.BlockOffsetList) er
 				log.Err("BlockBlob::StageAndCommit : Failed to stage to blob %s with ID %s at block %v [%s]", name, blk.Id, blk.StartIndex, err.Error())
 				return err
 			}
+			azStatsCollector.AddBytes(stats_manager.Upload, int64(len(data)))
 			staged = true
 			blk.Flags.Clear(common.DirtyBlock)
 		}
@@ -1873,6 +1884,7 @@ func (bb *BlockBlob) StageBlock(name string, data []byte, id string) error {
 		return err
 	}
 
+	azStatsCollector.AddBytes(stats_manager.Upload, int64(len(data)))
 	return nil
 }
 
@@ -3091,7 +3091,7 @@ func (s *blockBlobTestSuite) TestMD5SetOnUpload() {
 			s.assert.Equal(blockblob.MaxUploadBlobBytes+1, n)
 			_, _ = f.Seek(0, 0)
 
-			err = s.az.storage.WriteFromFile(name, nil, f)
+			err = s.az.storage.WriteFromFile(name, nil, f, nil)
 			s.assert.NoError(err)
 
 			prop, err := s.az.storage.GetAttr(name)
@@ -3144,7 +3144,7 @@ func (s *blockBlobTestSuite) TestMD5NotSetOnUpload() {
 			s.assert.Equal(blockblob.MaxUploadBlobBytes+1, n)
 			_, _ = f.Seek(0, 0)
 
-			err = s.az.storage.WriteFromFile(name, nil, f)
+			err = s.az.storage.WriteFromFile(name, nil, f, nil)
 			s.assert.NoError(err)
 
 			prop, err := s.az.storage.GetAttr(name)
@@ -3192,7 +3192,7 @@ func (s *blockBlobTestSuite) TestMD5AutoSetOnUpload() {
 			s.assert.Equal(100, n)
 			_, _ = f.Seek(0, 0)
 
-			err = s.az.storage.WriteFromFile(name, nil, f)
+			err = s.az.storage.WriteFromFile(name, nil, f, nil)
 			s.assert.NoError(err)
 
 			prop, err := s.az.storage.GetAttr(name)
@@ -3245,7 +3245,7 @@ func (s *blockBlobTestSuite) TestInvalidateMD5PostUpload() {
 			s.assert.Equal(100, n)
 			_, _ = f.Seek(0, 0)
 
-			err = s.az.storage.WriteFromFile(name, nil, f)
+			err = s.az.storage.WriteFromFile(name, nil, f, nil)
 			s.assert.NoError(err)
 
 			blobClient := s.containerClient.NewBlobClient(name)
@@ -3301,7 +3301,7 @@ func (s *blockBlobTestSuite) TestValidateAutoMD5OnRead() {
 			s.assert.Equal(100, n)
 			_, _ = f.Seek(0, 0)
 
-			err = s.az.storage.WriteFromFile(name, nil, f)
+			err = s.az.storage.WriteFromFile(name, nil, f, nil)
 			s.assert.NoError(err)
 			_ = f.Close()
 			_ = os.Remove(name)
@@ -3357,7 +3357,7 @@ func (s *blockBlobTestSuite) TestValidateManualMD5OnRead() {
 			s.assert.Equal(blockblob.MaxUploadBlobBytes+1, n)
 			_, _ = f.Seek(0, 0)
 
-			err = s.az.storage.WriteFromFile(name, nil, f)
+			err = s.az.storage.WriteFromFile(name, nil, f, nil)
 			s.assert.NoError(err)
 			_ = f.Close()
 			_ = os.Remove(name)
@@ -3413,7 +3413,7 @@ func (s *blockBlobTestSuite) TestInvalidMD5OnRead() {
 			s.assert.Equal(100, n)
 			_, _ = f.Seek(0, 0)
 
-			err = s.az.storage.WriteFromFile(name, nil, f)
+			err = s.az.storage.WriteFromFile(name, nil, f, nil)
 			s.assert.NoError(err)
 			_ = f.Close()
 			_ = os.Remove(name)
@@ -3473,7 +3473,7 @@ func (s *blockBlobTestSuite) TestInvalidMD5OnReadNoVaildate() {
 			s.assert.Equal(100, n)
 			_, _ = f.Seek(0, 0)
 
-			err = s.az.storage.WriteFromFile(name, nil, f)
+			err = s.az.storage.WriteFromFile(name, nil, f, nil)
 			s.assert.NoError(err)
 			_ = f.Close()
 			_ = os.Remove(name)
@@ -3571,7 +3571,7 @@ func (s *blockBlobTestSuite) TestUploadBlobWithCPKEnabled() {
 	s.assert.NoError(err)
 	_, _ = f.Seek(0, 0)
 
-	err = s.az.storage.WriteFromFile(name1, nil, f)
+	err = s.az.storage.WriteFromFile(name1, nil, f, nil)
 	s.assert.NoError(err)
 
 	file := s.containerClient.NewBlobClient(name1)
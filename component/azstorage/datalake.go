@@ -503,7 +503,7 @@ func (dl *Datalake) ReadInBuffer(name string, offset int64, length int64, data [
 }
 
 // WriteFromFile : Upload local file to file
-func (dl *Datalake) WriteFromFile(name string, metadata map[string]*string, fi *os.File) (err error) {
+func (dl *Datalake) WriteFromFile(name string, metadata map[string]*string, fi *os.File, newEtag *string) (err error) {
 	// File in DataLake may have permissions and ACL set. Just uploading the file will override them.
 	// So, we need to get the existing permissions and ACL and set them back after uploading the file.
 
@@ -521,19 +521,22 @@ func (dl *Datalake) WriteFromFile(name string, metadata map[string]*string, fi *
 	}
 
 	// Upload the file, which will override the permissions and ACL
-	retCode := dl.BlockBlob.WriteFromFile(name, metadata, fi)
+	retCode := dl.BlockBlob.WriteFromFile(name, metadata, fi, newEtag)
 
 	if acl != "" {
 		// Cannot set both permissions and ACL in one call. ACL includes permission as well so just setting those back
 		// Just setting up the permissions will delete existing ACLs applied on the blob so do not convert this code to
 		// just set the permissions.
-		_, err := fileClient.SetAccessControl(context.Background(), &file.SetAccessControlOptions{
+		resp, err := fileClient.SetAccessControl(context.Background(), &file.SetAccessControlOptions{
 			ACL: &acl,
 		})
 
 		if err != nil {
 			// Earlier code was ignoring this so it might break customer cases where they do not have auth to update ACL
 			log.Err("Datalake::WriteFromFile : Failed to set ACL for %s [%s]", name, err.Error())
+		} else if newEtag != nil && retCode == nil {
+			// Setting the ACL gives the file a new ETag
+			*newEtag = sanitizeEtag(resp.ETag)
 		}
 	}
 
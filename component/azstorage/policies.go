@@ -34,16 +34,23 @@
 package azstorage
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
 	"math"
 	"net/http"
+	"net/url"
+	"slices"
 	"strings"
+	"time"
 
 	"golang.org/x/time/rate"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-storage-fuse/v2/common"
 	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/Azure/azure-storage-fuse/v2/internal/reqlog"
+	"github.com/Azure/azure-storage-fuse/v2/internal/tracing"
 )
 
 // blobfuseTelemetryPolicy is a custom pipeline policy to prepend the blobfuse user agent string to the one coming from SDK.
@@ -182,3 +189,129 @@ func (p *rateLimitingPolicy) Do(req *policy.Request) (*http.Response, error) {
 
 	return req.Next()
 }
+
+// ---------------------------------------------------------------------------------------------------------------------------------------------------
+// Policy to record each attempt of a request as a span, child of the span of the component call that issued it.
+// The query of the URL is left out of the span as it may carry a SAS token.
+type tracingPolicy struct{}
+
+func newTracingPolicy() policy.Policy {
+	return &tracingPolicy{}
+}
+
+func (p *tracingPolicy) Do(req *policy.Request) (*http.Response, error) {
+	raw := req.Raw()
+	span := tracing.Start("HTTP "+raw.Method, tracing.KindClient,
+		tracing.String("http.request.method", raw.Method),
+		tracing.String("server.address", raw.URL.Host),
+		tracing.String("url.path", raw.URL.Path))
+	if span == nil {
+		return req.Next()
+	}
+
+	resp, err := req.Next()
+
+	// Error responses are failures of the request even though the transport succeeded
+	spanErr := err
+	if resp != nil {
+		span.SetAttributes(
+			tracing.Int("http.response.status_code", int64(resp.StatusCode)),
+			tracing.String("az.service_request_id", resp.Header.Get("x-ms-request-id")))
+
+		if err == nil && resp.StatusCode >= http.StatusBadRequest {
+			spanErr = errors.New(resp.Status)
+		}
+	}
+
+	span.End(spanErr)
+	return resp, err
+}
+
+// ---------------------------------------------------------------------------------------------------------------------------------------------------
+// Policy to log each request to the storage service, after all its attempts, in the rolling request log of the mount.
+// Added to PerCallPolicies along with the telemetry policy, it hands requestAttemptPolicy a record to count the attempts
+// made by the retry policy in between and to note the request ID of the last one.
+type requestLogPolicy struct{}
+
+// requestAttempts : Attempts made for a request, shared by both policies through the operation values of the request
+type requestAttempts struct {
+	count     int
+	requestID string
+}
+
+func newRequestLogPolicy() policy.Policy {
+	return &requestLogPolicy{}
+}
+
+func (p *requestLogPolicy) Do(req *policy.Request) (*http.Response, error) {
+	attempts := &requestAttempts{}
+	req.SetOperationValue(attempts)
+
+	raw := req.Raw()
+	start := time.Now()
+	resp, err := req.Next()
+
+	entry := reqlog.Entry{
+		Time:      start,
+		Method:    raw.Method,
+		Path:      redactedPath(raw.URL),
+		Retries:   max(attempts.count-1, 0),
+		Latency:   time.Since(start),
+		RequestID: attempts.requestID,
+	}
+	if resp != nil {
+		entry.Status = resp.StatusCode
+		entry.RequestID = cmp.Or(resp.Header.Get("x-ms-request-id"), entry.RequestID)
+	}
+	if err != nil {
+		// Error of the transport carries the URL along with the SAS in its query, keep only the cause
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			entry.Error = urlErr.Err.Error()
+		} else {
+			entry.Error = err.Error()
+		}
+	}
+	reqlog.Record(entry)
+
+	return resp, err
+}
+
+// Policy run on each attempt of a request to count the attempts logged by requestLogPolicy
+type requestAttemptPolicy struct{}
+
+func newRequestAttemptPolicy() policy.Policy {
+	return &requestAttemptPolicy{}
+}
+
+func (p *requestAttemptPolicy) Do(req *policy.Request) (*http.Response, error) {
+	var attempts *requestAttempts
+	if !req.OperationValue(&attempts) {
+		return req.Next()
+	}
+
+	attempts.count++
+	resp, err := req.Next()
+	if resp != nil {
+		attempts.requestID = resp.Header.Get("x-ms-request-id")
+	}
+	return resp, err
+}
+
+// redactedPath : Path and query of the URL, with values of query parameters which are not allowed in logs, like the
+// signature of a SAS, redacted the same way the SDK logger does
+func redactedPath(u *url.URL) string {
+	query := u.Query()
+	if len(query) == 0 {
+		return u.Path
+	}
+
+	for key, values := range query {
+		if !slices.Contains(allowedQueryParams, strings.ToLower(key)) {
+			for i := range values {
+				values[i] = "REDACTED"
+			}
+		}
+	}
+	return u.Path + "?" + query.Encode()
+}
@@ -35,7 +35,13 @@ package azstorage
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -43,6 +49,8 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/Azure/azure-storage-fuse/v2/common"
 	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/Azure/azure-storage-fuse/v2/internal/reqlog"
+	"github.com/Azure/azure-storage-fuse/v2/internal/tracing"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
@@ -207,6 +215,118 @@ func (s *policiesTestSuite) TestRateLimitingPolicy_BandwidthLimit_SkipNonGet() {
 	}
 }
 
+type statusTransport struct {
+	status int
+}
+
+func (m *statusTransport) Do(req *http.Request) (*http.Response, error) {
+	header := http.Header{}
+	header.Set("x-ms-request-id", "request-1")
+	return &http.Response{StatusCode: m.status, Status: http.StatusText(m.status), Header: header}, nil
+}
+
+func (s *policiesTestSuite) TestTracingPolicy() {
+	assert := assert.New(s.T())
+
+	path := filepath.Join(s.T().TempDir(), "traces.json")
+	err := tracing.Init(tracing.Config{Enabled: true, Exporter: tracing.ExporterFile, FilePath: path})
+	assert.NoError(err)
+	defer tracing.Shutdown()
+
+	for _, status := range []int{http.StatusOK, http.StatusNotFound} {
+		pipeline := runtime.NewPipeline("test", "v1", runtime.PipelineOptions{
+			PerRetry: []policy.Policy{newTracingPolicy()},
+		}, &policy.ClientOptions{Transport: &statusTransport{status: status}})
+
+		req, err := runtime.NewRequest(context.Background(), http.MethodGet, "https://account.blob.core.windows.net/container/blob?sig=secret")
+		assert.NoError(err)
+
+		parent := tracing.Start("azstorage.ReadInBuffer", tracing.KindInternal)
+		resp, err := pipeline.Do(req)
+		assert.NoError(err)
+		assert.Equal(status, resp.StatusCode)
+		parent.End(nil)
+	}
+	tracing.Shutdown()
+
+	data, err := os.ReadFile(path)
+	assert.NoError(err)
+	traces := string(data)
+
+	assert.Equal(2, strings.Count(traces, `"name":"HTTP GET"`))
+	assert.Contains(traces, `"key":"url.path","value":{"stringValue":"/container/blob"}`)
+	assert.Contains(traces, `"key":"http.response.status_code","value":{"intValue":"404"}`)
+	assert.Contains(traces, `"key":"az.service_request_id","value":{"stringValue":"request-1"}`)
+	assert.Contains(traces, `"message":"Not Found"`)
+	assert.NotContains(traces, "secret")
+}
+
+// sequenceTransport : Responds with the given statuses in turn, a zero status fails the attempt in transport
+type sequenceTransport struct {
+	statuses []int
+	attempt  int
+}
+
+func (m *sequenceTransport) Do(req *http.Request) (*http.Response, error) {
+	status := m.statuses[min(m.attempt, len(m.statuses)-1)]
+	m.attempt++
+	if status == 0 {
+		return nil, &url.Error{Op: req.Method, URL: req.URL.String(), Err: errors.New("connection reset by peer")}
+	}
+
+	header := http.Header{}
+	header.Set("x-ms-request-id", fmt.Sprintf("request-%d", m.attempt))
+	return &http.Response{StatusCode: status, Status: http.StatusText(status), Header: header, Body: http.NoBody}, nil
+}
+
+func (s *policiesTestSuite) TestRequestLogPolicy() {
+	assert := assert.New(s.T())
+
+	send := func(statuses ...int) (reqlog.Entry, error) {
+		pipeline := runtime.NewPipeline("test", "v1", runtime.PipelineOptions{
+			PerCall:  []policy.Policy{newRequestLogPolicy()},
+			PerRetry: []policy.Policy{newRequestAttemptPolicy()},
+		}, &policy.ClientOptions{
+			Transport: &sequenceTransport{statuses: statuses},
+			Retry:     policy.RetryOptions{MaxRetries: 2, RetryDelay: time.Millisecond, MaxRetryDelay: time.Millisecond},
+		})
+
+		req, err := runtime.NewRequest(context.Background(), http.MethodPut,
+			"https://account.blob.core.windows.net/container/dir/blob?comp=block&blockid=YmxvY2s%3D&sig=secret&sv=2021-08-06")
+		assert.NoError(err)
+
+		_, err = pipeline.Do(req)
+		recent := reqlog.Recent()
+		return recent[len(recent)-1], err
+	}
+
+	entry, err := send(http.StatusServiceUnavailable, http.StatusCreated)
+	assert.NoError(err)
+	assert.Equal(http.MethodPut, entry.Method)
+	assert.Equal("/container/dir/blob?blockid=YmxvY2s%3D&comp=block&sig=REDACTED&sv=2021-08-06", entry.Path)
+	assert.Equal(http.StatusCreated, entry.Status)
+	assert.Equal("request-2", entry.RequestID)
+	assert.Equal(1, entry.Retries)
+	assert.Positive(entry.Latency)
+	assert.False(entry.Failed())
+
+	entry, err = send(http.StatusNotFound)
+	assert.NoError(err)
+	assert.Equal(http.StatusNotFound, entry.Status)
+	assert.Equal("request-1", entry.RequestID)
+	assert.Zero(entry.Retries)
+	assert.True(entry.Failed())
+
+	// Last attempt fails in transport, request ID of the attempt before it is kept
+	entry, err = send(http.StatusInternalServerError, 0)
+	assert.Error(err)
+	assert.Zero(entry.Status)
+	assert.Equal("request-1", entry.RequestID)
+	assert.Equal(2, entry.Retries)
+	assert.Equal("connection reset by peer", entry.Error)
+	assert.NotContains(fmt.Sprint(entry), "secret")
+}
+
 func TestPoliciesSuite(t *testing.T) {
 	suite.Run(t, new(policiesTestSuite))
 }
@@ -110,7 +110,8 @@ func getAzStorageClientOptions(conf *AzStorageConfig) (azcore.ClientOptions, err
 		log.Err("utils::getAzStorageClientOptions : Failed to create transport client [%s]", err.Error())
 	}
 
-	perCallPolicies := []policy.Policy{telemetryPolicy}
+	// Requests are logged once across their retries, while their attempts are counted by a per retry policy
+	perCallPolicies := []policy.Policy{telemetryPolicy, newRequestLogPolicy()}
 
 	serviceApiVersion := os.Getenv("AZURE_STORAGE_SERVICE_API_VERSION")
 	if serviceApiVersion != "" {
@@ -118,7 +119,8 @@ func getAzStorageClientOptions(conf *AzStorageConfig) (azcore.ClientOptions, err
 		perCallPolicies = append(perCallPolicies, newServiceVersionPolicy(serviceApiVersion))
 	}
 
-	perRetryPolicies := []policy.Policy{}
+	// Tracing runs ahead of rate limiting so that time spent waiting for the limiter shows up in the request span
+	perRetryPolicies := []policy.Policy{newRequestAttemptPolicy(), newTracingPolicy()}
 	if conf.capMbpsRead > 0 || conf.capIOps > 0 {
 		// Convert Mbps to Bytes/sec: 1 Mbps = (1024* 1024) / 8 = 131072 Bytes/sec
 		bytesPerSec := conf.capMbpsRead * 131072
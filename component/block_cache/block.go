@@ -49,6 +49,7 @@ const (
 	BlockFlagDirty              // Block has been written and data is not persisted yet
 	BlockFlagSynced             // Block has been written and data is persisted
 	BlockFlagFailed             // Block upload/download has failed
+	BlockFlagPrefetched         // Block was downloaded ahead of read and is not read yet
 )
 
 // Flags to denote the status of upload/download of a block
@@ -67,22 +68,48 @@ type Block struct {
 	flags  common.BitMap64 // Various states of the block
 	data   []byte          // Data read from blob
 	node   *list.Element   // node representation of this block in the list inside handle
+	shared *sharedBlock    // entry of this block in the shared table if other handles can read it
+	numa   int             // index of the NUMA node pool this block belongs to
 }
 
 type blockInfo struct {
-	id        string // blockID of the block
-	committed bool   // flag to determine if the block has been committed or not
-	size      uint64 // length of data in block
+	id        string   // blockID of the block
+	committed bool     // flag to determine if the block has been committed or not
+	size      uint64   // length of data in block
+	parts     []string // committed blockIDs making up this block when blob was written with smaller blocks
+	rechunk   bool     // committed data of this block does not line up with block size so it has to be staged again
+	run       *committedRun
+}
+
+// committedRun : Committed blocks which together cover a range of irregular blocks exactly.
+// While none of the blocks in the range is modified these committed blocks are reused as is.
+type committedRun struct {
+	first uint64   // index of the first block in the range
+	last  uint64   // index of the last block in the range
+	end   uint64   // offset where the committed data of the range ends
+	ids   []string // committed blockIDs covering the range
+}
+
+// blockIDs : BlockIDs to be put in the block list for this block
+func (bi *blockInfo) blockIDs() []string {
+	if len(bi.parts) > 0 {
+		return bi.parts
+	}
+	return []string{bi.id}
 }
 
 // AllocateBlock creates a new memory mapped buffer for the given size
 func AllocateBlock(size uint64) (*Block, error) {
+	return allocateBlock(size, nil)
+}
+
+// allocateBlock creates a new buffer for the given size, mapped as per the allocator
+func allocateBlock(size uint64, allocator *common.BufferAllocator) (*Block, error) {
 	if size == 0 {
 		return nil, fmt.Errorf("invalid size")
 	}
 
-	prot, flags := syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE
-	addr, err := syscall.Mmap(-1, 0, int(size), prot, flags)
+	addr, err := allocator.Allocate(size)
 
 	if err != nil {
 		return nil, fmt.Errorf("mmap error: %v", err)
@@ -39,6 +39,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/fs"
 	"math"
 	"os"
 	"path/filepath"
@@ -46,6 +47,7 @@ import (
 	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -54,6 +56,7 @@ import (
 	"github.com/Azure/azure-storage-fuse/v2/common/log"
 	"github.com/Azure/azure-storage-fuse/v2/internal"
 	"github.com/Azure/azure-storage-fuse/v2/internal/handlemap"
+	"github.com/Azure/azure-storage-fuse/v2/internal/stats_manager"
 	"github.com/vibhansa-msft/tlru"
 )
 
@@ -68,26 +71,46 @@ import (
 type BlockCache struct {
 	internal.BaseComponent
 
-	blockSize       uint64          // Size of each block to be cached
-	memSize         uint64          // Mem size to be used for caching at the startup
-	mntPath         string          // Mount path
-	tmpPath         string          // Disk path where these blocks will be cached
-	diskSize        uint64          // Size of disk space allocated for the caching
-	diskTimeout     uint32          // Timeout for which disk blocks will be cached
-	workers         uint32          // Number of threads working to fetch the blocks
-	prefetch        uint32          // Number of blocks to be prefetched
-	diskPolicy      *tlru.TLRU      // Disk cache eviction policy
-	blockPool       *BlockPool      // Pool of blocks
-	threadPool      *ThreadPool     // Pool of threads
-	fileLocks       *common.LockMap // Locks for each file_blockid to avoid multiple threads to fetch same block
-	fileNodeMap     sync.Map        // Map holding files that are there in our cache
-	maxDiskUsageHit bool            // Flag to indicate if we have hit max disk usage
-	noPrefetch      bool            // Flag to indicate if prefetch is disabled
-	prefetchOnOpen  bool            // Start prefetching on file open call instead of waiting for first read
-	consistency     bool            // Flag to indicate if strong data consistency is enabled
-	stream          *Stream
-	lazyWrite       bool           // Flag to indicate if lazy write is enabled
-	fileCloseOpt    sync.WaitGroup // Wait group to wait for all async close operations to complete
+	blockSize        uint64          // Size of each block to be cached
+	memSize          uint64          // Mem size to be used for caching at the startup
+	mntPath          string          // Mount path
+	tmpPath          string          // Disk path where these blocks will be cached
+	diskSize         uint64          // Size of disk space allocated for the caching
+	diskTimeout      uint32          // Timeout for which disk blocks will be cached
+	workers          uint32          // Number of threads working to fetch the blocks
+	prefetch         uint32          // Number of blocks to be prefetched
+	diskPolicy       *tlru.TLRU      // Disk cache eviction policy
+	blockPool        *BlockPool      // Pool of blocks
+	threadPool       *ThreadPool     // Pool of threads
+	fileLocks        *common.LockMap // Locks for each file_blockid to avoid multiple threads to fetch same block
+	fileNodeMap      sync.Map        // Map holding files that are there in our cache
+	sharedBlocks     *sharedBlockTable
+	maxDiskUsageHit  bool // Flag to indicate if we have hit max disk usage
+	noPrefetch       bool // Flag to indicate if prefetch is disabled
+	prefetchOnOpen   bool // Start prefetching on file open call instead of waiting for first read
+	consistency      bool // Flag to indicate if strong data consistency is enabled
+	stream           *Stream
+	lazyWrite        bool           // Flag to indicate if lazy write is enabled
+	fileCloseOpt     sync.WaitGroup // Wait group to wait for all async close operations to complete
+	persistIndex     bool           // Keep disk blocks across remounts using a persisted index
+	retainDiskBlocks bool           // Evicted blocks are not to be deleted from disk
+	writeBack        bool           // Journal dirty blocks on flush and commit them in background on close
+	journalSeq       atomic.Uint64  // Sequence number of the last journal recorded
+
+	replayLock    sync.Mutex               // Lock guarding replayPending
+	replayPending map[string]chan struct{} // Files whose journal is being replayed, closed once it is done
+	replayDone    sync.WaitGroup           // Wait group to wait for the replay of journals to complete
+
+	adaptiveMemory    bool           // Resize block pool as per the memory pressure
+	memoryPressure    atomic.Bool    // Memory is under pressure, so prefetch is throttled
+	memoryMonitorStop chan struct{}  // Channel to stop the memory monitor
+	memoryMonitorDone sync.WaitGroup // Wait group to wait for memory monitor to exit
+
+	bufferOptions common.BufferOptions // Huge page and NUMA placement of block buffers
+	cacheRules    *common.CacheRules   // Per path overrides for prefetch and disk caching
+	pinned        sync.Map             // Files whose blocks are to be kept on disk, pinned through the control socket
+	diskAccess    sync.Map             // Last use of blocks cached on disk, to expire them as per their cache rule
+	stopping      atomic.Bool          // Component is stopping so evicted disk blocks are not tracked again
 }
 
 // Structure defining your config parameters
@@ -102,6 +125,11 @@ type BlockCacheOptions struct {
 	PrefetchOnOpen bool    `config:"prefetch-on-open" yaml:"prefetch-on-open,omitempty"`
 	Consistency    bool    `config:"consistency" yaml:"consistency,omitempty"`
 	CleanupOnStart bool    `config:"cleanup-on-start" yaml:"cleanup-on-start,omitempty"`
+	PersistIndex   bool    `config:"persist-index" yaml:"persist-index,omitempty"`
+	WriteBack      bool    `config:"write-back" yaml:"write-back,omitempty"`
+	AdaptiveMemory bool    `config:"adaptive-memory" yaml:"adaptive-memory,omitempty"`
+	HugePages      string  `config:"huge-pages" yaml:"huge-pages,omitempty"`
+	NumaPolicy     string  `config:"numa-policy" yaml:"numa-policy,omitempty"`
 }
 
 const (
@@ -112,13 +140,16 @@ const (
 	MIN_POOL_USAGE   uint32 = 50
 	MIN_PREFETCH            = 5
 	MIN_WRITE_BLOCK         = 3
-	MIN_RANDREAD            = 10
 	MAX_FAIL_CNT            = 3
 	MAX_BLOCKS              = 50000
 )
 
 // Verification to check satisfaction criteria with Component Interface
 var _ internal.Component = &BlockCache{}
+var _ internal.PathInvalidator = &BlockCache{}
+var _ internal.CacheController = &BlockCache{}
+var _ internal.CacheDropper = &BlockCache{}
+var _ internal.StateReporter = &BlockCache{}
 
 func (bc *BlockCache) Name() string {
 	return compName
@@ -138,22 +169,36 @@ func (bc *BlockCache) SetNextComponent(nc internal.Component) {
 func (bc *BlockCache) Start(ctx context.Context) error {
 	log.Trace("BlockCache::Start : Starting component %s", bc.Name())
 
-	bc.blockPool = NewBlockPool(bc.blockSize, bc.memSize)
+	allocator, err := common.NewBufferAllocator(bc.bufferOptions)
+	if err != nil {
+		log.Err("BlockCache::Start : failed to create buffer allocator [%s]", err.Error())
+		return fmt.Errorf("config error in %s [%s]", bc.Name(), err.Error())
+	}
+
+	bc.blockPool = newBlockPool(bc.blockSize, bc.memSize, allocator)
 	if bc.blockPool == nil {
 		log.Err("BlockCache::Start : failed to init block pool")
 		return fmt.Errorf("config error in %s [failed to init block pool]", bc.Name())
 	}
 
+	blockCacheStatsCollector = stats_manager.NewStatsCollector(bc.Name())
+	blockCacheStatsCollector.ExportBlockPool(bc.blockPool)
+
 	bc.threadPool = newThreadPool(bc.workers, bc.download, bc.upload)
 	if bc.threadPool == nil {
 		log.Err("BlockCache::Start : failed to init thread pool")
 		return fmt.Errorf("config error in %s [failed to init thread pool]", bc.Name())
 	}
+	bc.threadPool.bindNodes(allocator)
 
 	// Start the thread pool and keep it ready for download
 	log.Debug("BlockCache::Start : Starting thread pool")
 	bc.threadPool.Start()
 
+	if bc.adaptiveMemory {
+		bc.startMemoryMonitor()
+	}
+
 	// If disk caching is enabled then start the disk eviction policy
 	if bc.tmpPath != "" {
 		err := bc.diskPolicy.Start()
@@ -161,6 +206,19 @@ func (bc *BlockCache) Start(ctx context.Context) error {
 			log.Err("BlockCache::Start : failed to start diskpolicy [%s]", err.Error())
 			return fmt.Errorf("failed to start  disk-policy for block-cache")
 		}
+
+		// Reuse the blocks cached on disk by last mount
+		if bc.persistIndex {
+			bc.restoreDiskIndex()
+		}
+
+		// Finish the commits left behind by last mount, blocks it cached on disk are of no use without an index
+		if bc.writeBack {
+			if !bc.persistIndex {
+				_ = bc.cleanupDiskCache()
+			}
+			bc.startReplay()
+		}
 	}
 
 	return nil
@@ -170,19 +228,37 @@ func (bc *BlockCache) Start(ctx context.Context) error {
 func (bc *BlockCache) Stop() error {
 	log.Trace("BlockCache::Stop : Stopping component %s", bc.Name())
 
-	if bc.lazyWrite {
+	// Journals left by last mount may still be in the middle of their commit
+	bc.replayDone.Wait()
+
+	if bc.lazyWrite || bc.writeBack {
 		// Wait for all async upload to complete if any
 		log.Info("BlockCache::Stop : Waiting for async close to complete")
 		bc.fileCloseOpt.Wait()
 	}
 
+	bc.stopMemoryMonitor()
+
 	// Wait for thread pool to stop
 	bc.threadPool.Stop()
 
 	// Clear the disk cache on exit
 	if bc.tmpPath != "" {
+		if bc.persistIndex {
+			// Stopping the disk policy evicts all blocks, so record them before and keep them on disk
+			bc.saveDiskIndex()
+			bc.retainDiskBlocks = true
+		}
+
+		bc.stopping.Store(true)
 		_ = bc.diskPolicy.Stop()
-		_ = common.TempCacheCleanup(bc.tmpPath)
+		if !bc.persistIndex {
+			_ = bc.cleanupDiskCache()
+		}
+	}
+
+	if blockCacheStatsCollector != nil {
+		blockCacheStatsCollector.Destroy()
 	}
 
 	return nil
@@ -287,6 +363,42 @@ func (bc *BlockCache) Configure(_ bool) error {
 	}
 
 	bc.tmpPath = common.ExpandPath(conf.TmpPath)
+	bc.persistIndex = conf.PersistIndex
+	bc.writeBack = conf.WriteBack
+	bc.adaptiveMemory = conf.AdaptiveMemory
+
+	bc.bufferOptions = common.BufferOptions{
+		HugePages:  conf.HugePages,
+		NumaPolicy: conf.NumaPolicy,
+		OnFallback: func(reason string) {
+			log.Warn("BlockCache::allocateBlock : %s", reason)
+		},
+	}
+
+	// Validate the options here, so that a bad value fails the mount instead of the first allocation
+	_, err = common.NewBufferAllocator(common.BufferOptions{HugePages: conf.HugePages, NumaPolicy: conf.NumaPolicy})
+	if err != nil {
+		log.Err("BlockCache::Configure : config error [%s]", err.Error())
+		return fmt.Errorf("config error in %s [%s]", bc.Name(), err.Error())
+	}
+
+	var rules []common.CacheRule
+	err = config.UnmarshalKey(common.CacheRulesKey, &rules)
+	if err != nil {
+		log.Err("BlockCache::Configure : config error [invalid cache rules]")
+		return fmt.Errorf("config error in %s [%s]", bc.Name(), err.Error())
+	}
+
+	bc.cacheRules, err = common.NewCacheRules(rules)
+	if err != nil {
+		log.Err("BlockCache::Configure : config error [%s]", err.Error())
+		return fmt.Errorf("config error in %s [%s]", bc.Name(), err.Error())
+	}
+
+	if bc.writeBack && bc.tmpPath == "" {
+		log.Err("BlockCache::Configure : config error [write-back requires disk path to keep the journal]")
+		return fmt.Errorf("config error in %s [write-back requires path to be set]", bc.Name())
+	}
 
 	if bc.tmpPath != "" {
 		//check mnt path is not same as temp path
@@ -312,7 +424,7 @@ func (bc *BlockCache) Configure(_ bool) error {
 			}
 		}
 
-		if !common.IsDirectoryEmpty(bc.tmpPath) {
+		if !common.IsDirectoryEmpty(bc.tmpPath) && !bc.persistIndex && !bc.hasJournal() {
 			log.Err("BlockCache: config error %s directory is not empty", bc.tmpPath)
 			return fmt.Errorf("config error in %s [%s]", bc.Name(), "temp directory not empty")
 		}
@@ -329,7 +441,13 @@ func (bc *BlockCache) Configure(_ bool) error {
 	}
 
 	if bc.tmpPath != "" {
-		bc.diskPolicy, err = tlru.New(uint32((bc.diskSize)/bc.blockSize), bc.diskTimeout, bc.diskEvict, 60, bc.checkDiskUsage)
+		// Disk policy expires blocks at the shortest timeout of any path, longer ones are kept on eviction
+		diskPolicyTimeout := bc.diskTimeout
+		if timeout, found := bc.cacheRules.MinTimeout(); found && timeout != 0 && timeout < diskPolicyTimeout {
+			diskPolicyTimeout = timeout
+		}
+
+		bc.diskPolicy, err = tlru.New(uint32((bc.diskSize)/bc.blockSize), diskPolicyTimeout, bc.diskEvict, 60, bc.checkDiskUsage)
 		if err != nil {
 			log.Err("BlockCache::Configure : fail to create LRU for memory nodes [%s]", err.Error())
 			return fmt.Errorf("config error in %s [%s]", bc.Name(), err.Error())
@@ -337,9 +455,9 @@ func (bc *BlockCache) Configure(_ bool) error {
 	}
 
 	log.Crit("BlockCache::Configure : block size %v, mem size %v, worker %v, prefetch %v, disk path %v, max size %v, "+
-		"disk timeout %v, prefetch-on-open %t, maxDiskUsageHit %v, noPrefetch %v, consistency %v, lazy-write: %v, cleanup-on-start %t",
+		"disk timeout %v, prefetch-on-open %t, maxDiskUsageHit %v, noPrefetch %v, consistency %v, lazy-write: %v, cleanup-on-start %t, persist-index %t, write-back %t, adaptive-memory %t, huge-pages %v, numa-policy %v, cache-rules %d",
 		bc.blockSize, bc.memSize, bc.workers, bc.prefetch, bc.tmpPath, bc.diskSize,
-		bc.diskTimeout, bc.prefetchOnOpen, bc.maxDiskUsageHit, bc.noPrefetch, bc.consistency, bc.lazyWrite, conf.CleanupOnStart)
+		bc.diskTimeout, bc.prefetchOnOpen, bc.maxDiskUsageHit, bc.noPrefetch, bc.consistency, bc.lazyWrite, conf.CleanupOnStart, bc.persistIndex, bc.writeBack, bc.adaptiveMemory, conf.HugePages, conf.NumaPolicy, bc.cacheRules.Len())
 
 	return nil
 }
@@ -368,6 +486,7 @@ func (bc *BlockCache) getDefaultMemSize() uint64 {
 // CreateFile: Create a new file
 func (bc *BlockCache) CreateFile(options internal.CreateFileOptions) (*handlemap.Handle, error) {
 	log.Trace("BlockCache::CreateFile : name=%s, mode=%d", options.Name, options.Mode)
+	bc.waitReplay(options.Name)
 
 	_, err := bc.NextComponent().CreateFile(options)
 	if err != nil {
@@ -391,6 +510,9 @@ func (bc *BlockCache) OpenFile(options internal.OpenFileOptions) (*handlemap.Han
 	log.Trace("BlockCache::OpenFile : name=%s, flags=%s, mode=%s",
 		options.Name, common.PrettyOpenFlags(options.Flags), options.Mode)
 
+	// Data left in the journal by last mount has to be in the file before it is read or written again
+	bc.waitReplay(options.Name)
+
 	attr, err := bc.NextComponent().GetAttr(internal.GetAttrOptions{Name: options.Name})
 	if err != nil {
 		log.Err("BlockCache::OpenFile : Failed to get attr of %s [%s]", options.Name, err.Error())
@@ -405,6 +527,9 @@ func (bc *BlockCache) OpenFile(options internal.OpenFileOptions) (*handlemap.Han
 		handle.SetValue("ETAG", attr.ETag)
 	}
 
+	// Readers of the same version of the file can reuse each other's downloads
+	setSharedKey(handle, options.Flags, attr.ETag)
+
 	log.Debug("BlockCache::OpenFile : Size of file handle.Size %v", handle.Size)
 	bc.prepareHandleForBlockCache(handle)
 
@@ -425,7 +550,7 @@ func (bc *BlockCache) OpenFile(options internal.OpenFileOptions) (*handlemap.Han
 
 		valid := bc.validateBlockList(handle, options, blockList)
 		if !valid {
-			return nil, fmt.Errorf("block size mismatch for %s", options.Name)
+			return nil, fmt.Errorf("%s is too large for block size %v", options.Name, bc.blockSize)
 		}
 	}
 
@@ -445,27 +570,105 @@ func (bc *BlockCache) OpenFile(options internal.OpenFileOptions) (*handlemap.Han
 
 // validateBlockList: Validates the blockList and populates the blocklist inside the handle for a file.
 // This method is only called when the file is opened in O_RDWR mode.
-// Each Block's size must equal to blockSize set in config and last block size <= config's blockSize
+// Committed blocks are mapped on to blocks of blockSize set in config. A block exactly covered by one or more
+// committed blocks keeps their blockIDs, rest are marked for re-chunk which is done only when the file is committed.
+// Consecutive irregular blocks are grouped in runs whose committed blocks line up at both ends, so that a run
+// nobody writes to can still be committed with its existing blockIDs.
 // returns true, if blockList is valid
 func (bc *BlockCache) validateBlockList(handle *handlemap.Handle, options internal.OpenFileOptions, blockList *internal.CommittedBlockList) bool {
 	lst, _ := handle.GetValue("blockList")
 	listMap := lst.(map[int64]*blockInfo)
-	listLen := len(*blockList)
 
-	for idx, block := range *blockList {
-		if (idx < (listLen-1) && block.Size != bc.blockSize) || (idx == (listLen-1) && block.Size > bc.blockSize) {
-			log.Err("BlockCache::validateBlockList : Block size mismatch for %s [block: %v, size: %v]", options.Name, block.Id, block.Size)
-			return false
+	listSize := uint64(0)
+	for _, block := range *blockList {
+		listSize += block.Size
+	}
+	fileSize := max(uint64(handle.Size), listSize)
+
+	if (fileSize+bc.blockSize-1)/bc.blockSize > MAX_BLOCKS {
+		log.Err("BlockCache::validateBlockList : File %s of size %v needs more than %v blocks of size %v", options.Name, fileSize, MAX_BLOCKS, bc.blockSize)
+		return false
+	}
+
+	rechunk := 0
+	blockOffset := uint64(0)
+	i := 0
+	var run *committedRun
+	for index := int64(0); uint64(index)*bc.blockSize < fileSize; index++ {
+		start := uint64(index) * bc.blockSize
+		end := min(start+bc.blockSize, fileSize)
+
+		// Collect the committed blocks which together cover exactly this block
+		ids := make([]string, 0, 1)
+		offset := start
+		for i < len(*blockList) && blockOffset == offset && offset+(*blockList)[i].Size <= end {
+			if (*blockList)[i].Size != 0 {
+				ids = append(ids, (*blockList)[i].Id)
+			}
+			offset += (*blockList)[i].Size
+			blockOffset += (*blockList)[i].Size
+			i++
 		}
-		listMap[int64(idx)] = &blockInfo{
-			id:        block.Id,
+
+		info := &blockInfo{
 			committed: true,
-			size:      block.Size,
+			size:      end - start,
+		}
+
+		if offset == end && len(ids) > 0 {
+			info.id = ids[0]
+			if len(ids) > 1 {
+				info.parts = ids
+			}
+		} else {
+			info.rechunk = true
+			rechunk++
+
+			if run == nil {
+				run = &committedRun{first: uint64(index)}
+			}
+			run.ids = append(run.ids, ids...)
+			info.run = run
+
+			// Skip the committed blocks ending in this block, one spilling into next block makes that one irregular as well
+			for i < len(*blockList) && blockOffset+(*blockList)[i].Size <= end {
+				if (*blockList)[i].Size != 0 {
+					run.ids = append(run.ids, (*blockList)[i].Id)
+				}
+				blockOffset += (*blockList)[i].Size
+				i++
+			}
+
+			if blockOffset == end {
+				// Committed data lines up with end of this block so the run is complete
+				run.last = uint64(index)
+				run.end = end
+				run = nil
+			}
+		}
+
+		listMap[index] = info
+	}
+
+	if run != nil {
+		// Committed blocks do not cover the tail of the file, these blocks can only be re-chunked
+		for index := run.first; index*bc.blockSize < fileSize; index++ {
+			listMap[int64(index)].run = nil
 		}
 	}
+
+	if rechunk > 0 {
+		log.Info("BlockCache::validateBlockList : %v blocks of %s do not match block size %v and will be re-chunked on commit", rechunk, options.Name, bc.blockSize)
+	}
 	return true
 }
 
+// handleNode : Index of the NUMA node whose blocks this handle uses. Handles are spread over the nodes, and the
+// blocks of a handle are downloaded and uploaded by the workers of its node.
+func (bc *BlockCache) handleNode(handle *handlemap.Handle) int {
+	return int(uint64(handle.ID) % uint64(bc.blockPool.Nodes()))
+}
+
 func (bc *BlockCache) prepareHandleForBlockCache(handle *handlemap.Handle) {
 	// Allocate a block pool object for this handle
 	// Actual linked list to hold the nodes
@@ -496,6 +699,19 @@ func (bc *BlockCache) FlushFile(options internal.FlushFileOptions) error {
 	options.Handle.Lock()
 	defer options.Handle.Unlock()
 
+	if bc.writeBack && !options.CloseInProgress && options.Handle.Dirty() {
+		// Data is safe on disk once journaled, commit happens in background when file is closed
+		if isJournalCurrent(options.Handle) {
+			return nil
+		}
+
+		err := bc.recordJournal(options.Handle)
+		if err == nil {
+			return nil
+		}
+		log.Err("BlockCache::FlushFile : Failed to record journal for %s, committing now [%s]", options.Handle.Path, err.Error())
+	}
+
 	// call commit blocks only if the handle is dirty
 	if options.Handle.Dirty() {
 		err := bc.commitBlocks(options.Handle)
@@ -505,13 +721,27 @@ func (bc *BlockCache) FlushFile(options internal.FlushFileOptions) error {
 		}
 	}
 
+	// Everything recorded in the journal is committed now
+	bc.removeJournal(options.Handle)
+
 	return nil
 }
 
 // ReleaseFile: File is closed by application so release all the blocks and submit back to blockPool
 func (bc *BlockCache) ReleaseFile(options internal.ReleaseFileOptions) error {
 	bc.fileCloseOpt.Add(1)
-	if !bc.lazyWrite {
+	if bc.writeBack && options.Handle.Dirty() && !isJournalCurrent(options.Handle) {
+		// Journal the data before returning so that a crash during background commit does not lose it
+		options.Handle.Lock()
+		err := bc.recordJournal(options.Handle)
+		options.Handle.Unlock()
+		if err != nil {
+			log.Err("BlockCache::ReleaseFile : Failed to record journal for %s, closing synchronously [%s]", options.Handle.Path, err.Error())
+			return bc.releaseFileInternal(options)
+		}
+	}
+
+	if !bc.lazyWrite && !bc.writeBack {
 		// Sync close is called so wait till the upload completes
 		return bc.releaseFileInternal(options)
 	}
@@ -536,6 +766,10 @@ func (bc *BlockCache) releaseFileInternal(options internal.ReleaseFileOptions) e
 		}
 	}
 
+	// Tracker goes away with the handle map so take it out before the cleanup
+	tracker := bc.getPrefetchTracker(options.Handle)
+	bc.returnSharedBlocks(options.Handle)
+
 	// Release the blocks that are in use and wipe out handle map
 	options.Handle.Cleanup()
 
@@ -549,8 +783,8 @@ func (bc *BlockCache) releaseFileInternal(options internal.ReleaseFileOptions) e
 		// Wait for download to complete and then free up this block
 		<-block.state
 		block.node = nil
-		block.ReUse()
-		bc.blockPool.Release(block)
+		tracker.dropBlock(block)
+		bc.recycleBlock(block)
 	}
 	options.Handle.Buffers.Cooking = nil
 
@@ -561,11 +795,13 @@ func (bc *BlockCache) releaseFileInternal(options internal.ReleaseFileOptions) e
 		block := blockList.Remove(node).(*Block)
 		// block.Unblock()
 		block.node = nil
-		block.ReUse()
-		bc.blockPool.Release(block)
+		tracker.dropBlock(block)
+		bc.recycleBlock(block)
 	}
 	options.Handle.Buffers.Cooked = nil
 
+	bc.pushPrefetchStats(options.Handle, tracker)
+
 	return nil
 }
 
@@ -657,7 +893,16 @@ func (bc *BlockCache) getBlock(handle *handlemap.Handle, readoffset uint64) (*Bl
 	// Check the given block index is already available or not
 	index := bc.getBlockIndex(readoffset)
 	node, found := handle.GetValue(fmt.Sprintf("%v", index))
+
+	// Feed this read to the pattern detector so that prefetch can follow it
+	tracker := bc.getPrefetchTracker(handle)
+	tracker.record(int64(index))
 	if !found {
+		// Some other handle of the same file might have this block already
+		block := bc.getSharedBlock(handle, index)
+		if block != nil {
+			return block, nil
+		}
 
 		// block is not present in the buffer list, check if it is uncommitted
 		// If yes, commit all the uncommitted blocks first and then download this block
@@ -680,7 +925,7 @@ func (bc *BlockCache) getBlock(handle *handlemap.Handle, readoffset uint64) (*Bl
 
 			if readoffset >= uint64(prop.Size) {
 				//create a null block and return
-				block, err := bc.blockPool.MustGet()
+				block, err := bc.blockPool.MustGetOn(bc.handleNode(handle))
 				if err != nil {
 					log.Err("BlockCache::getBlock : Unable to allocate block %v=>%s (index %v) %v", handle.ID, handle.Path, index, err)
 					return nil, err
@@ -708,7 +953,7 @@ func (bc *BlockCache) getBlock(handle *handlemap.Handle, readoffset uint64) (*Bl
 			// This is a case of random read so increment the random read count
 			handle.OptCnt++
 
-			log.Debug("BlockCache::getBlock : Unable to get block %v=>%s (offset %v, index %v) Random %v", handle.ID, handle.Path, readoffset, index, handle.OptCnt)
+			log.Debug("BlockCache::getBlock : Unable to get block %v=>%s (offset %v, index %v) Random %v, pattern %s", handle.ID, handle.Path, readoffset, index, handle.OptCnt, tracker.pattern)
 
 			// This block is not present even after prefetch so lets download it now
 			err := bc.startPrefetch(handle, index, false)
@@ -728,6 +973,7 @@ func (bc *BlockCache) getBlock(handle *handlemap.Handle, readoffset uint64) (*Bl
 
 	// We have the block now which we wish to read
 	block := node.(*Block)
+	tracker.consumeBlock(block)
 
 	// Wait for this block to complete the download
 	t, ok := <-block.state
@@ -742,11 +988,10 @@ func (bc *BlockCache) getBlock(handle *handlemap.Handle, readoffset uint64) (*Bl
 			block.flags.Clear(BlockFlagDownloading)
 
 			// Download complete and you are first reader of this block
-			if !bc.noPrefetch && handle.OptCnt <= MIN_RANDREAD {
-				// So far this file has been read sequentially so prefetch more
-				val, _ := handle.GetValue("#")
-				if int64(val.(uint64)*bc.blockSize) < handle.Size {
-					_ = bc.startPrefetch(handle, val.(uint64), true)
+			if !bc.noPrefetch && !tracker.random() {
+				// So far this file has been read in a predictable pattern so prefetch more in that direction
+				if tracker.next >= 0 && tracker.next*int64(bc.blockSize) < handle.Size {
+					_ = bc.startPrefetch(handle, uint64(tracker.next), true)
 				}
 			}
 
@@ -787,12 +1032,14 @@ func (bc *BlockCache) getBlockIndex(offset uint64) uint64 {
 }
 
 // startPrefetch: Start prefetchign the blocks from given offset. Same method is used to download currently required block as well
+// Number of blocks kept in flight and the direction in which they are fetched follow the access pattern detected for the handle
 func (bc *BlockCache) startPrefetch(handle *handlemap.Handle, index uint64, prefetch bool) error {
 	// Calculate how many buffers we have in free and in-process queue
 	currentCnt := handle.Buffers.Cooked.Len() + handle.Buffers.Cooking.Len()
 	cnt := uint32(0)
+	tracker := bc.getPrefetchTracker(handle)
 
-	if handle.OptCnt > MIN_RANDREAD {
+	if tracker.random() {
 		// This handle has been read randomly and we have reached the threshold to declare a random read case
 
 		if currentCnt > MIN_PREFETCH {
@@ -840,10 +1087,10 @@ func (bc *BlockCache) startPrefetch(handle *handlemap.Handle, index uint64, pref
 				// Remove entry of this block from map so that no one can find it
 				handle.RemoveValue(fmt.Sprintf("%v", block.id))
 				block.node = nil
+				tracker.dropBlock(block)
 
 				// Submit this block back to pool for reuse
-				block.ReUse()
-				bc.blockPool.Release(block)
+				bc.recycleBlock(block)
 
 				currentCnt--
 			}
@@ -851,11 +1098,29 @@ func (bc *BlockCache) startPrefetch(handle *handlemap.Handle, index uint64, pref
 		// As we were asked to download a block, for random read case download only the requested block
 		// This is where prefetching is blocked now as we download just the block which is requested
 		cnt = 1
+	} else if prefetch && currentCnt > int(bc.prefetchWindow(tracker)) {
+		// Prefetched blocks are being wasted so the window has shrunk, instead of sliding the window
+		// return one free block to the pool so that this handle holds fewer buffers
+		node := handle.Buffers.Cooked.Front()
+		if node != nil {
+			block := node.Value.(*Block)
+			if !block.IsDirty() && !block.flags.IsSet(BlockFlagUploading) {
+				_ = handle.Buffers.Cooked.Remove(node)
+				if block.id != -1 {
+					handle.RemoveValue(fmt.Sprintf("%v", block.id))
+				}
+				block.node = nil
+				tracker.dropBlock(block)
+
+				bc.recycleBlock(block)
+			}
+		}
+		return nil
 	} else {
-		// This handle is having sequential reads so far
-		// Allocate more buffers if required until we hit the prefetch count limit
-		for ; currentCnt < int(bc.prefetch) && cnt < MIN_PREFETCH; currentCnt++ {
-			block := bc.blockPool.TryGet()
+		// This handle is having sequential, strided or reverse reads so far
+		// Allocate more buffers if required until we hit the current prefetch window
+		for ; currentCnt < int(bc.prefetchWindow(tracker)) && cnt < MIN_PREFETCH; currentCnt++ {
+			block := bc.blockPool.TryGetOn(bc.handleNode(handle))
 			if block != nil {
 				block.node = handle.Buffers.Cooked.PushFront(block)
 				cnt++
@@ -869,10 +1134,22 @@ func (bc *BlockCache) startPrefetch(handle *handlemap.Handle, index uint64, pref
 		}
 	}
 
+	step := tracker.step()
+	key := getSharedKey(handle)
 	for i := uint32(0); i < cnt; i++ {
 		// Check if the block exists in the local cache or not
 		// If not, download the block from storage
 		_, found := handle.GetValue(fmt.Sprintf("%v", index))
+		if !found && prefetch && key != "" && bc.sharedBlocks.exists(key, int64(index)) {
+			// Another handle already holds this block, reader will borrow it from there
+			next := int64(index) + step
+			if next < 0 {
+				break
+			}
+			index = uint64(next)
+			continue
+		}
+
 		if !found {
 			// Check if the block is an uncommitted block or not
 			// For uncommitted block we need to commit the block first
@@ -892,7 +1169,13 @@ func (bc *BlockCache) startPrefetch(handle *handlemap.Handle, index uint64, pref
 			if err != nil {
 				return err
 			}
-			index++
+
+			next := int64(index) + step
+			if next < 0 {
+				// Reverse or strided reads reached the beginning of the file
+				break
+			}
+			index = uint64(next)
 		}
 	}
 
@@ -910,11 +1193,12 @@ func (bc *BlockCache) refreshBlock(handle *handlemap.Handle, index uint64, prefe
 		return io.EOF
 	}
 
+	tracker := bc.getPrefetchTracker(handle)
 	nodeList := handle.Buffers.Cooked
 	if nodeList.Len() == 0 && !prefetch {
 		// User needs a block now but there is no free block available right now
 		// this might happen when all blocks are under download and no first reader is hit for any of them
-		block, err := bc.blockPool.MustGet()
+		block, err := bc.blockPool.MustGetOn(bc.handleNode(handle))
 		if err != nil {
 			log.Err("BlockCache::refreshBlock : Unable to allocate block %v=>%s (index %v, prefetch %v) %v", handle.ID, handle.Path, index, prefetch, err)
 			return err
@@ -924,6 +1208,41 @@ func (bc *BlockCache) refreshBlock(handle *handlemap.Handle, index uint64, prefe
 	}
 
 	node := nodeList.Front()
+	orphaned := false
+	if node != nil && node.Value.(*Block).id != -1 {
+		// Block may be read by other handles, if so leave it to them and use a new one from the pool
+		// Once disowned the block belongs to the last reader, so do not touch it after that
+		block := node.Value.(*Block)
+		id := block.id
+		tracker.dropBlock(block)
+		if bc.sharedBlocks.disown(block) {
+			_ = nodeList.Remove(node)
+			handle.RemoveValue(fmt.Sprintf("%v", id))
+			node = nil
+			orphaned = true
+		}
+	}
+
+	if orphaned {
+		// Replace the block given away to other readers so that this handle keeps the same number of buffers
+		block := bc.blockPool.TryGetOn(bc.handleNode(handle))
+		if block == nil {
+			if prefetch {
+				// No point in waiting for memory just to prefetch
+				return nil
+			}
+
+			var err error
+			block, err = bc.blockPool.MustGetOn(bc.handleNode(handle))
+			if err != nil {
+				log.Err("BlockCache::refreshBlock : Unable to allocate block %v=>%s (index %v, prefetch %v) %v", handle.ID, handle.Path, index, prefetch, err)
+				return err
+			}
+		}
+		block.node = nodeList.PushFront(block)
+		node = block.node
+	}
+
 	if node != nil {
 		// Now there is at least one free block available in the list
 		block := node.Value.(*Block)
@@ -951,6 +1270,7 @@ func (bc *BlockCache) refreshBlock(handle *handlemap.Handle, index uint64, prefe
 		// Add this entry to handle map so that others can refer to the same block if required
 		handle.SetValue(fmt.Sprintf("%v", index), block)
 		handle.SetValue("#", (index + 1))
+		tracker.next = int64(index) + tracker.step()
 
 		bc.lineupDownload(handle, block, prefetch)
 	}
@@ -978,11 +1298,24 @@ func (bc *BlockCache) lineupDownload(handle *handlemap.Handle, block *Block, pre
 	bc.addToCooking(handle, block)
 
 	block.flags.Set(BlockFlagDownloading)
+	if prefetch {
+		block.flags.Set(BlockFlagPrefetched)
+	}
+
+	// Let other readers of this file wait on this download instead of downloading the block again
+	if key := getSharedKey(handle); key != "" {
+		bc.sharedBlocks.publish(key, block)
+	}
 
 	// Send the work item to worker pool to schedule download
 	bc.threadPool.Schedule(!prefetch, item)
 }
 
+// diskCached : Blocks of this file can be kept in the disk cache, unless a cache rule says otherwise
+func (bc *BlockCache) diskCached(path string) bool {
+	return bc.tmpPath != "" && !bc.cacheRules.Match(path).NeverCache()
+}
+
 // download : Method to download the given amount of data
 func (bc *BlockCache) download(item *workItem) {
 	fileName := fmt.Sprintf("%s::%v", item.handle.Path, item.block.id)
@@ -993,19 +1326,13 @@ func (bc *BlockCache) download(item *workItem) {
 	flock.Lock()
 	defer flock.Unlock()
 
-	var diskNode any
-	found := false
+	var diskNode *list.Element
 	localPath := ""
+	useDisk := bc.diskCached(item.handle.Path)
 
-	if bc.tmpPath != "" {
+	if useDisk {
 		// Update diskpolicy to reflect the new file
-		diskNode, found = bc.fileNodeMap.Load(fileName)
-		if !found {
-			diskNode = bc.diskPolicy.Add(fileName)
-			bc.fileNodeMap.Store(fileName, diskNode)
-		} else {
-			bc.diskPolicy.Refresh(diskNode.(*list.Element))
-		}
+		diskNode = bc.trackDiskBlock(fileName)
 
 		// Check local file exists for this offset and file combination or not
 		localPath = filepath.Join(bc.tmpPath, fileName)
@@ -1039,10 +1366,16 @@ func (bc *BlockCache) download(item *workItem) {
 					// If user has enabled consistency check then compute the md5sum and match it in xattr
 					successfulRead = checkBlockConsistency(bc, item, numberOfBytes, localPath, fileName)
 
+					// Block may have been cached for an older version of the blob, possibly by an earlier mount
+					if successfulRead && bc.persistIndex {
+						successfulRead = checkBlockETag(item, localPath, fileName)
+					}
+
 					// We have read the data from disk so there is no need to go over network
 					// Just mark the block that download is complete
 					if successfulRead {
-						item.block.Ready(BlockStatusDownloaded)
+						blockCacheStatsCollector.CacheLookup(true)
+						bc.downloadDone(item, BlockStatusDownloaded)
 						return
 					}
 				}
@@ -1050,6 +1383,11 @@ func (bc *BlockCache) download(item *workItem) {
 		}
 	}
 
+	// Retries of a failed download are not lookups of their own
+	if useDisk && item.failCnt == 0 {
+		blockCacheStatsCollector.CacheLookup(false)
+	}
+
 	var etag string
 	// If file does not exists then download the block from the container
 	n, err := bc.NextComponent().ReadInBuffer(&internal.ReadInBufferOptions{
@@ -1063,7 +1401,7 @@ func (bc *BlockCache) download(item *workItem) {
 		// If we failed to read the data 3 times then just give up
 		log.Err("BlockCache::download : 3 attempts to download a block have failed %v=>%s (index %v, offset %v)", item.handle.ID, item.handle.Path, item.block.id, item.block.offset)
 		item.block.Failed()
-		item.block.Ready(BlockStatusDownloadFailed)
+		bc.downloadDone(item, BlockStatusDownloadFailed)
 		return
 	}
 
@@ -1086,12 +1424,12 @@ func (bc *BlockCache) download(item *workItem) {
 		if item.ETag != "" && item.ETag != etag {
 			log.Err("BlockCache::download : Blob has changed for %v=>%s (index %v, offset %v)", item.handle.ID, item.handle.Path, item.block.id, item.block.offset)
 			item.block.Failed()
-			item.block.Ready(BlockStatusDownloadFailed)
+			bc.downloadDone(item, BlockStatusDownloadFailed)
 			return
 		}
 	}
 
-	if bc.tmpPath != "" {
+	if useDisk {
 		err := os.MkdirAll(filepath.Dir(localPath), 0777)
 		if err != nil {
 			log.Err("BlockCache::download : error creating directory structure for file %s [%s]", localPath, err.Error())
@@ -1108,7 +1446,14 @@ func (bc *BlockCache) download(item *workItem) {
 			}
 
 			f.Close()
-			bc.diskPolicy.Refresh(diskNode.(*list.Element))
+			bc.diskPolicy.Refresh(diskNode)
+
+			if bc.persistIndex {
+				if etag == "" {
+					etag = item.ETag
+				}
+				setBlockETag(localPath, etag)
+			}
 
 			// If user has enabled consistency check then compute the md5sum and save it in xattr
 			if bc.consistency {
@@ -1122,7 +1467,13 @@ func (bc *BlockCache) download(item *workItem) {
 	}
 
 	// Just mark the block that download is complete
-	item.block.Ready(BlockStatusDownloaded)
+	bc.downloadDone(item, BlockStatusDownloaded)
+}
+
+// downloadDone : Notify the reader of this handle and readers waiting from other handles that download is over
+func (bc *BlockCache) downloadDone(item *workItem, status int) {
+	item.block.Ready(status)
+	bc.sharedBlocks.complete(item.block, status != BlockStatusDownloaded)
 }
 
 func checkBlockConsistency(blockCache *BlockCache, item *workItem, numberOfBytes int, localPath, fileName string) bool {
@@ -1161,7 +1512,12 @@ func (bc *BlockCache) WriteFile(options *internal.WriteFileOptions) (int, error)
 	// Keep getting next blocks until you read the request amount of data
 	dataWritten := int(0)
 	for dataWritten < len(options.Data) {
-		block, err := bc.getOrCreateBlock(options.Handle, uint64(options.Offset))
+		// If this write replaces all the existing data of the block there is no need to fetch it
+		remaining := int64(len(options.Data) - dataWritten)
+		overwrite := uint64(options.Offset)%bc.blockSize == 0 &&
+			(remaining >= int64(bc.blockSize) || options.Offset+remaining >= options.Handle.Size)
+
+		block, err := bc.getOrCreateBlock(options.Handle, uint64(options.Offset), overwrite)
 		if err != nil {
 			// Failed to get block for writing
 			log.Err("BlockCache::WriteFile : Unable to allocate block for %s [%s]", options.Handle.Path, err.Error())
@@ -1177,6 +1533,9 @@ func (bc *BlockCache) WriteFile(options *internal.WriteFileOptions) (int, error)
 		// Mark this block has been updated
 		block.Dirty()
 		options.Handle.Flags.Set(handlemap.HandleFlagDirty)
+		if bc.writeBack {
+			options.Handle.RemoveValue(journalCurrentKeyName)
+		}
 
 		// Move offset forward in case we need to copy more data
 		options.Offset += int64(bytesWritten)
@@ -1190,7 +1549,8 @@ func (bc *BlockCache) WriteFile(options *internal.WriteFileOptions) (int, error)
 	return dataWritten, nil
 }
 
-func (bc *BlockCache) getOrCreateBlock(handle *handlemap.Handle, offset uint64) (*Block, error) {
+// getOrCreateBlock: Get the block to be written at given offset, existing data of the block is fetched unless overwrite is set
+func (bc *BlockCache) getOrCreateBlock(handle *handlemap.Handle, offset uint64, overwrite bool) (*Block, error) {
 	// Check the given block index is already available or not
 	index := bc.getBlockIndex(offset)
 	if index >= MAX_BLOCKS {
@@ -1211,7 +1571,7 @@ func (bc *BlockCache) getOrCreateBlock(handle *handlemap.Handle, offset uint64)
 		}
 
 		// Either the block is not fetched yet or offset goes beyond the file size
-		block, err = bc.blockPool.MustGet()
+		block, err = bc.blockPool.MustGetOn(bc.handleNode(handle))
 		if err != nil {
 			log.Err("BlockCache::getOrCreateBlock : Unable to allocate block %v=>%s (index %v) %v", handle.ID, handle.Path, index, err)
 			return nil, err
@@ -1221,7 +1581,7 @@ func (bc *BlockCache) getOrCreateBlock(handle *handlemap.Handle, offset uint64)
 		block.id = int64(index)
 		block.offset = index * bc.blockSize
 
-		if block.offset < uint64(handle.Size) {
+		if block.offset < uint64(handle.Size) && !overwrite {
 			shouldCommit, shouldDownload := shouldCommitAndDownload(block.id, handle)
 
 			// if a block has been staged and deleted from the buffer list, then we should commit the existing blocks
@@ -1317,6 +1677,83 @@ func (bc *BlockCache) getOrCreateBlock(handle *handlemap.Handle, offset uint64)
 	return block, nil
 }
 
+// rechunkBlocks : Fetch the blocks marked for re-chunk and mark them dirty so that they are staged at block size
+// Blocks of a run which is not modified since open keep their committed blocks and are left as is.
+func (bc *BlockCache) rechunkBlocks(handle *handlemap.Handle) error {
+	lst, _ := handle.GetValue("blockList")
+	listMap := lst.(map[int64]*blockInfo)
+
+	indices := make([]int64, 0)
+	intact := make(map[*committedRun]bool)
+	for index, info := range listMap {
+		if !info.rechunk {
+			continue
+		}
+
+		if info.run != nil {
+			if _, ok := intact[info.run]; !ok {
+				intact[info.run] = bc.isRunIntact(handle, listMap, info.run)
+			}
+			if intact[info.run] {
+				continue
+			}
+		}
+		indices = append(indices, index)
+	}
+
+	if len(indices) == 0 {
+		return nil
+	}
+	slices.Sort(indices)
+
+	log.Info("BlockCache::rechunkBlocks : Re-chunking %v blocks of %v=>%s", len(indices), handle.ID, handle.Path)
+	for _, index := range indices {
+		if uint64(index)*bc.blockSize >= uint64(handle.Size) {
+			// File was shrunk since open so this block is not part of it anymore
+			delete(listMap, index)
+			continue
+		}
+
+		block, err := bc.getOrCreateBlock(handle, uint64(index)*bc.blockSize, false)
+		if err != nil {
+			log.Err("BlockCache::rechunkBlocks : Failed to get block %v for %v=>%s [%s]", index, handle.ID, handle.Path, err.Error())
+			return err
+		}
+
+		block.Dirty()
+	}
+
+	return nil
+}
+
+// isRunIntact : Check whether committed blocks of this run can still be used in the block list as is
+func (bc *BlockCache) isRunIntact(handle *handlemap.Handle, listMap map[int64]*blockInfo, run *committedRun) bool {
+	if run.end > uint64(handle.Size) {
+		// File was truncated inside the run
+		return false
+	}
+
+	if run.end < uint64(handle.Size) && run.end%bc.blockSize != 0 {
+		// Last block of the run is short and no longer the last block of the file, it has to be filled up
+		return false
+	}
+
+	for index := run.first; index <= run.last; index++ {
+		info, ok := listMap[int64(index)]
+		if !ok || info.run != run {
+			// Block was staged since open
+			return false
+		}
+
+		node, found := handle.GetValue(fmt.Sprintf("%v", index))
+		if found && node.(*Block).IsDirty() {
+			return false
+		}
+	}
+
+	return true
+}
+
 // Stage the given number of blocks from this handle
 func (bc *BlockCache) stageBlocks(handle *handlemap.Handle, cnt int) error {
 	//log.Debug("BlockCache::stageBlocks : Staging blocks for %s, cnt %v", handle.Path, cnt)
@@ -1351,8 +1788,7 @@ func (bc *BlockCache) releaseDownloadFailedBlock(handle *handlemap.Handle, block
 
 	handle.RemoveValue(fmt.Sprintf("%v", block.id))
 	block.node = nil
-	block.ReUse()
-	bc.blockPool.Release(block)
+	bc.recycleBlock(block)
 }
 
 func (bc *BlockCache) printCooking(handle *handlemap.Handle) { //nolint
@@ -1508,7 +1944,7 @@ func (bc *BlockCache) upload(item *workItem) {
 		return
 	}
 
-	if bc.tmpPath != "" {
+	if bc.diskCached(item.handle.Path) {
 		localPath := filepath.Join(bc.tmpPath, fileName)
 
 		err := os.MkdirAll(filepath.Dir(localPath), 0777)
@@ -1528,12 +1964,11 @@ func (bc *BlockCache) upload(item *workItem) {
 			}
 
 			f.Close()
-			diskNode, found := bc.fileNodeMap.Load(fileName)
-			if !found {
-				diskNode = bc.diskPolicy.Add(fileName)
-				bc.fileNodeMap.Store(fileName, diskNode)
-			} else {
-				bc.diskPolicy.Refresh(diskNode.(*list.Element))
+			bc.trackDiskBlock(fileName)
+
+			// Block is not committed yet so it does not belong to any version of the blob
+			if bc.persistIndex {
+				setBlockETag(localPath, "")
 			}
 
 			// If user has enabled consistency check then compute the md5sum and save it in xattr
@@ -1558,6 +1993,13 @@ return_safe:
 func (bc *BlockCache) commitBlocks(handle *handlemap.Handle) error {
 	log.Debug("BlockCache::commitBlocks : Staging blocks for %s", handle.Path)
 
+	// Blocks whose committed data does not line up with block size can not be part of the new block list as is
+	err := bc.rechunkBlocks(handle)
+	if err != nil {
+		log.Err("BlockCache::commitBlocks : Failed to re-chunk blocks for %s [%s]", handle.Path, err.Error())
+		return err
+	}
+
 	// Make three attempts to upload all pending blocks
 	cnt := 0
 	for cnt = 0; cnt < 3; cnt++ {
@@ -1612,8 +2054,15 @@ func (bc *BlockCache) commitBlocks(handle *handlemap.Handle) error {
 	// set all the blocks as committed
 	list, _ := handle.GetValue("blockList")
 	listMap := list.(map[int64]*blockInfo)
+	committed := make([]int64, 0, len(listMap))
 	for k := range listMap {
 		listMap[k].committed = true
+		committed = append(committed, k)
+	}
+
+	// Blocks cached on disk are now part of the new version of the blob
+	if bc.tmpPath != "" && bc.persistIndex && newEtag != "" {
+		bc.stampCommittedBlocks(handle.Path, committed, newEtag)
 	}
 
 	restaged := false
@@ -1625,7 +2074,16 @@ func (bc *BlockCache) commitBlocks(handle *handlemap.Handle) error {
 				// The stage this block again with correct length
 				// Remove the next block from blockIDList
 				// Commit the block list again
-				block, err := bc.getOrCreateBlock(handle, uint64(i)*bc.blockSize)
+				// Blocks made of several committed blocks shift positions in the list, so look the index up by id
+				index := int64(i)
+				for k, info := range listMap {
+					if info.id == restageID {
+						index = k
+						break
+					}
+				}
+
+				block, err := bc.getOrCreateBlock(handle, uint64(index)*bc.blockSize, false)
 				if err != nil {
 					log.Err("BlockCache::commitBlocks : Failed to get block for %v [%v]", handle.Path, err.Error())
 					return err
@@ -1671,6 +2129,23 @@ func (bc *BlockCache) getBlockIDList(handle *handlemap.Handle) ([]string, []stri
 
 	for i < len(offsets) {
 		if index == offsets[i] {
+			if listMap[offsets[i]].rechunk {
+				run := listMap[offsets[i]].run
+				if run == nil {
+					log.Err("BlockCache::getBlockIDList : Block %v of %v=>%s is not re-chunked yet", offsets[i], handle.ID, handle.Path)
+					return nil, nil, fmt.Errorf("block %v of %s is not re-chunked", offsets[i], handle.Path)
+				}
+
+				// Untouched run goes in the list with its committed blocks, once for the whole range
+				if uint64(offsets[i]) == run.first {
+					blockIDList = append(blockIDList, run.ids...)
+					log.Debug("BlockCache::getBlockIDList : Preparing blocklist for %v=>%s (%v-%v : %v committed blocks)", handle.ID, handle.Path, run.first, run.last, len(run.ids))
+				}
+				index++
+				i++
+				continue
+			}
+
 			if i != len(offsets)-1 && listMap[offsets[i]].size != bc.blockSize {
 				// A non last block was staged earlier and it is not of the same size as block size
 				// This happens when a block which is not full is staged and at that moment it was the last block
@@ -1707,7 +2182,7 @@ func (bc *BlockCache) getBlockIDList(handle *handlemap.Handle) ([]string, []stri
 				i++
 
 			} else {
-				blockIDList = append(blockIDList, listMap[offsets[i]].id)
+				blockIDList = append(blockIDList, listMap[offsets[i]].blockIDs()...)
 				log.Debug("BlockCache::getBlockIDList : Preparing blocklist for %v=>%s (%v :  %v, size %v)", handle.ID, handle.Path, offsets[i], listMap[offsets[i]].id, listMap[offsets[i]].size)
 				index++
 				i++
@@ -1780,12 +2255,64 @@ func (bc *BlockCache) diskEvict(node *list.Element) {
 	flock.Lock()
 	defer flock.Unlock()
 
-	bc.fileNodeMap.Delete(fileName)
+	// Node of a block removed from disk, e.g. on invalidation, may be evicted after the block is cached again
+	if !bc.fileNodeMap.CompareAndDelete(fileName, node) {
+		return
+	}
 
+	if bc.retainDiskBlocks || bc.isPinned(fileName) {
+		// Block is tracked again by disk policy when it is read next
+		return
+	}
+
+	if bc.retainedByRule(fileName) {
+		bc.fileNodeMap.Store(fileName, bc.diskPolicy.Add(fileName))
+		return
+	}
+
+	bc.diskAccess.Delete(fileName)
 	localPath := filepath.Join(bc.tmpPath, fileName)
 	_ = os.Remove(localPath)
 }
 
+// trackDiskBlock : Add the block cached on disk to disk policy or refresh it there, noting its use for the cache rules
+// Lock of the block must be held by the caller
+func (bc *BlockCache) trackDiskBlock(fileName string) *list.Element {
+	if bc.cacheRules != nil {
+		bc.diskAccess.Store(fileName, time.Now())
+	}
+
+	if node, found := bc.fileNodeMap.Load(fileName); found {
+		bc.diskPolicy.Refresh(node.(*list.Element))
+		return node.(*list.Element)
+	}
+
+	node := bc.diskPolicy.Add(fileName)
+	bc.fileNodeMap.Store(fileName, node)
+	return node
+}
+
+// retainedByRule : Whether the cache rule of the file keeps this block on disk longer than disk policy did.
+// Blocks are not kept once disk usage is too high.
+func (bc *BlockCache) retainedByRule(fileName string) bool {
+	if bc.cacheRules == nil || bc.maxDiskUsageHit || bc.stopping.Load() {
+		return false
+	}
+
+	path, _, err := parseBlockName(fileName)
+	if err != nil {
+		return false
+	}
+
+	lastAccess, found := bc.diskAccess.Load(fileName)
+	if !found {
+		return false
+	}
+
+	timeout := time.Duration(bc.cacheRules.Match(path).Timeout(bc.diskTimeout)) * time.Second
+	return time.Since(lastAccess.(time.Time)) < timeout
+}
+
 // checkDiskUsage : Callback to check usage of disk and decide whether eviction is needed
 func (bc *BlockCache) checkDiskUsage() bool {
 	data, _ := common.GetUsage(bc.tmpPath)
@@ -1851,6 +2378,7 @@ func (bc *BlockCache) RenameDir(options internal.RenameDirOptions) error {
 // DeleteFile: Invalidate the file in local cache.
 func (bc *BlockCache) DeleteFile(options internal.DeleteFileOptions) error {
 	log.Trace("BlockCache::DeleteFile : name=%s", options.Name)
+	bc.waitReplay(options.Name)
 
 	flock := bc.fileLocks.Get(options.Name)
 	flock.Lock()
@@ -1878,6 +2406,8 @@ func (bc *BlockCache) DeleteFile(options internal.DeleteFileOptions) error {
 // RenameFile: Invalidate the file in local cache.
 func (bc *BlockCache) RenameFile(options internal.RenameFileOptions) error {
 	log.Trace("BlockCache::RenameFile : src=%s, dst=%s", options.Src, options.Dst)
+	bc.waitReplay(options.Src)
+	bc.waitReplay(options.Dst)
 
 	sflock := bc.fileLocks.Get(options.Src)
 	sflock.Lock()
@@ -1923,6 +2453,7 @@ func (bc *BlockCache) SyncFile(options internal.SyncFileOptions) error {
 
 func (bc *BlockCache) TruncateFile(options internal.TruncateFileOptions) error {
 	log.Trace("BlockCache::TruncateFile : path=%s, size=%d", options.Name, options.NewSize)
+	bc.waitReplay(options.Name)
 
 	// Set the block size that need to used by the next component
 	options.BlockSize = int64(bc.blockSize)
@@ -1966,12 +2497,79 @@ func (bc *BlockCache) StatFs() (*syscall.Statfs_t, bool, error) {
 	return statfs, true, nil
 }
 
+// InvalidatePath: Remove the blocks of this file cached on the local disk
+func (bc *BlockCache) InvalidatePath(name string) {
+	log.Trace("BlockCache::InvalidatePath : %s", name)
+
+	if bc.tmpPath == "" {
+		return
+	}
+
+	// Blocks are named '<path>::<block id>'. Entries of the directory are compared by prefix rather than globbed, as
+	// the name of the blob may carry characters special to glob patterns.
+	localPath := filepath.Join(bc.tmpPath, name)
+	entries, err := os.ReadDir(filepath.Dir(localPath))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Err("BlockCache::InvalidatePath : failed to list cached blocks of %s [%s]", name, err.Error())
+		}
+		return
+	}
+
+	prefix := filepath.Base(localPath) + "::"
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), prefix) {
+			bc.removeDiskBlock(filepath.Join(filepath.Dir(name), entry.Name()))
+		}
+	}
+}
+
+// InvalidateDir: Remove the blocks of all files under this directory cached on the local disk
+func (bc *BlockCache) InvalidateDir(name string) {
+	log.Trace("BlockCache::InvalidateDir : %s", name)
+
+	if bc.tmpPath == "" {
+		return
+	}
+
+	localPath := filepath.Join(bc.tmpPath, name)
+	_ = filepath.WalkDir(localPath, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d != nil && d.IsDir() && bc.isJournalPath(path) {
+			return filepath.SkipDir
+		}
+
+		if err == nil && d != nil && !d.IsDir() {
+			bc.removeDiskBlock(strings.TrimPrefix(path, bc.tmpPath+"/"))
+		}
+		return nil
+	})
+}
+
+// removeDiskBlock : Delete a block from disk cache while holding its lock, so that it is not read in parallel, and stop
+// tracking it in the disk policy
+func (bc *BlockCache) removeDiskBlock(fileName string) {
+	flock := bc.fileLocks.Get(fileName)
+	flock.Lock()
+	defer flock.Unlock()
+
+	if node, found := bc.fileNodeMap.LoadAndDelete(fileName); found {
+		bc.diskPolicy.Remove(node.(*list.Element))
+	}
+	bc.diskAccess.Delete(fileName)
+
+	err := os.Remove(filepath.Join(bc.tmpPath, fileName))
+	if err != nil && !os.IsNotExist(err) {
+		log.Err("BlockCache::removeDiskBlock : failed to delete %s [%s]", fileName, err.Error())
+	}
+}
+
 // ------------------------- Factory -------------------------------------------
 // Pipeline will call this method to create your object, initialize your variables here
 // << DO NOT DELETE ANY AUTO GENERATED CODE HERE >>
 func NewBlockCacheComponent() internal.Component {
 	comp := &BlockCache{
-		fileLocks: common.NewLockMap(),
+		fileLocks:    common.NewLockMap(),
+		sharedBlocks: newSharedBlockTable(),
 	}
 	comp.SetName(compName)
 	return comp
@@ -2007,4 +2605,19 @@ func init() {
 
 	strongConsistency := config.AddBoolFlag("block-cache-strong-consistency", false, "Enable strong data consistency for block cache.")
 	config.BindPFlag(compName+".consistency", strongConsistency)
+
+	persistIndex := config.AddBoolFlag("block-cache-persist-index", false, "Keep blocks cached on disk across remounts.")
+	config.BindPFlag(compName+".persist-index", persistIndex)
+
+	writeBack := config.AddBoolFlag("block-cache-write-back", false, "Journal written data to disk and commit it in background on close.")
+	config.BindPFlag(compName+".write-back", writeBack)
+
+	adaptiveMemory := config.AddBoolFlag("block-cache-adaptive-memory", false, "Shrink and grow block pool as per the memory pressure.")
+	config.BindPFlag(compName+".adaptive-memory", adaptiveMemory)
+
+	hugePages := config.AddStringFlag("block-cache-huge-pages", "", "Back block buffers with huge pages. Supported values: none, thp, hugetlb.")
+	config.BindPFlag(compName+".huge-pages", hugePages)
+
+	numaPolicy := config.AddStringFlag("block-cache-numa-policy", "", "Place block buffers on the given NUMA node, or keep a pool on each node, pin workers to its CPUs and spread handles over the nodes.")
+	config.BindPFlag(compName+".numa-policy", numaPolicy)
 }
@@ -35,9 +35,11 @@ package block_cache
 
 import (
 	"bytes"
+	"container/list"
 	"context"
 	"crypto/md5"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -47,6 +49,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"testing"
 	"time"
@@ -56,6 +59,7 @@ import (
 	"github.com/Azure/azure-storage-fuse/v2/common/log"
 	"github.com/Azure/azure-storage-fuse/v2/component/loopback"
 	"github.com/Azure/azure-storage-fuse/v2/internal"
+	"github.com/Azure/azure-storage-fuse/v2/internal/handlemap"
 	"github.com/pbnjay/memory"
 
 	"github.com/stretchr/testify/assert"
@@ -474,6 +478,8 @@ func (suite *blockCacheTestSuite) TestValidateBlockList() {
 	suite.assert.True(valid)
 
 	//Generate Blocklist, blocks with size equal to configured block size and last block size > config's block size
+	//Blocks which line up with block size are reused and the last one spilling over is re-chunked
+	h.SetValue("blockList", make(map[int64]*blockInfo))
 	blockLst = nil
 	startOffset = 0
 	for i := range noOfBlocks {
@@ -490,9 +496,22 @@ func (suite *blockCacheTestSuite) TestValidateBlockList() {
 		blockLst = append(blockLst, blk)
 	}
 	valid = tobj.blockCache.validateBlockList(h, options, &blockLst)
-	suite.assert.False(valid)
+	suite.assert.True(valid)
+
+	lst, _ := h.GetValue("blockList")
+	listMap := lst.(map[int64]*blockInfo)
+	suite.assert.Len(listMap, noOfBlocks+1)
+	for i := range noOfBlocks - 1 {
+		suite.assert.False(listMap[int64(i)].rechunk)
+		suite.assert.Equal(blockLst[i].Id, listMap[int64(i)].id)
+	}
+	suite.assert.True(listMap[int64(noOfBlocks-1)].rechunk)
+	suite.assert.True(listMap[int64(noOfBlocks)].rechunk)
+	suite.assert.Equal(blockLst[noOfBlocks-1].Size-tobj.blockCache.blockSize, listMap[int64(noOfBlocks)].size)
 
 	//Generate Blocklist, blocks with random size
+	//Every block is either made up of committed blocks exactly or has to be re-chunked
+	h.SetValue("blockList", make(map[int64]*blockInfo))
 	blockLst = nil
 	startOffset = 0
 	for range noOfBlocks {
@@ -506,8 +525,60 @@ func (suite *blockCacheTestSuite) TestValidateBlockList() {
 		blockLst = append(blockLst, blk)
 	}
 	valid = tobj.blockCache.validateBlockList(h, options, &blockLst)
-	suite.assert.False(valid)
+	suite.assert.True(valid)
 
+	lst, _ = h.GetValue("blockList")
+	listMap = lst.(map[int64]*blockInfo)
+	size := uint64(0)
+	for i := range int64(len(listMap)) {
+		suite.assert.Contains(listMap, i)
+		suite.assert.True(listMap[i].rechunk || listMap[i].id != "")
+		size += listMap[i].size
+	}
+	suite.assert.Equal(uint64(startOffset), size)
+
+	//Generate Blocklist, blocks of half the configured block size
+	//Two committed blocks make up one block and both the ids are reused
+	h.SetValue("blockList", make(map[int64]*blockInfo))
+	blockLst = nil
+	startOffset = 0
+	for range noOfBlocks {
+		blk := internal.CommittedBlock{
+			Id:     base64.StdEncoding.EncodeToString(common.NewUUIDWithLength(32)),
+			Offset: startOffset,
+			Size:   tobj.blockCache.blockSize / 2,
+		}
+		startOffset += int64(blk.Size)
+		blockLst = append(blockLst, blk)
+	}
+	valid = tobj.blockCache.validateBlockList(h, options, &blockLst)
+	suite.assert.True(valid)
+
+	lst, _ = h.GetValue("blockList")
+	listMap = lst.(map[int64]*blockInfo)
+	suite.assert.Len(listMap, noOfBlocks/2)
+	for i := range int64(noOfBlocks / 2) {
+		suite.assert.False(listMap[i].rechunk)
+		suite.assert.Equal([]string{blockLst[2*i].Id, blockLst[2*i+1].Id}, listMap[i].blockIDs())
+	}
+
+	// Block list to be committed carries all the reused ids in order
+	ids, restage, err := tobj.blockCache.getBlockIDList(h)
+	suite.assert.NoError(err)
+	suite.assert.Empty(restage)
+	suite.assert.Len(ids, noOfBlocks)
+	for i, blk := range blockLst {
+		suite.assert.Equal(blk.Id, ids[i])
+	}
+
+	//Generate Blocklist, file needing more blocks than supported
+	h.SetValue("blockList", make(map[int64]*blockInfo))
+	blockLst = internal.CommittedBlockList{{
+		Id:   base64.StdEncoding.EncodeToString(common.NewUUIDWithLength(32)),
+		Size: (MAX_BLOCKS + 1) * tobj.blockCache.blockSize,
+	}}
+	valid = tobj.blockCache.validateBlockList(h, options, &blockLst)
+	suite.assert.False(valid)
 }
 
 func (suite *blockCacheTestSuite) TestFileReadTotalBytes() {
@@ -779,6 +850,210 @@ func (suite *blockCacheTestSuite) TestFileReadRandomNoPrefetch() {
 	suite.assert.Nil(h.Buffers.Cooking)
 }
 
+func (suite *blockCacheTestSuite) TestFileReadReverse() {
+	tobj, err := setupPipeline("")
+	defer tobj.cleanupPipeline()
+
+	suite.assert.NoError(err)
+	suite.assert.NotNil(tobj.blockCache)
+
+	fileName := getTestFileName(suite.T().Name())
+	storagePath := filepath.Join(tobj.fake_storage_path, fileName)
+	fileSize := 30 * _1MB
+	data := make([]byte, fileSize)
+	_, _ = r.Read(data)
+	err = os.WriteFile(storagePath, data, 0777)
+	suite.assert.NoError(err)
+
+	options := internal.OpenFileOptions{Name: fileName}
+	h, err := tobj.blockCache.OpenFile(options)
+	suite.assert.NoError(err)
+	suite.assert.NotNil(h)
+
+	buf := make([]byte, 1000)
+	for i := 29; i >= 0; i-- {
+		offset := int64(i) * int64(_1MB)
+		n, err := tobj.blockCache.ReadInBuffer(&internal.ReadInBufferOptions{Handle: h, Offset: offset, Data: buf})
+		suite.assert.NoError(err)
+		suite.assert.Equal(1000, n)
+		suite.assert.Equal(data[offset:offset+1000], buf)
+	}
+
+	// Blocks ahead of the reader in reverse direction shall have been prefetched
+	tracker := tobj.blockCache.getPrefetchTracker(h)
+	suite.assert.Equal(patternReverse, tracker.pattern)
+	suite.assert.Positive(tracker.hits)
+
+	err = tobj.blockCache.ReleaseFile(internal.ReleaseFileOptions{Handle: h})
+	suite.assert.NoError(err)
+}
+
+func (suite *blockCacheTestSuite) TestFileReadStrided() {
+	tobj, err := setupPipeline("")
+	defer tobj.cleanupPipeline()
+
+	suite.assert.NoError(err)
+	suite.assert.NotNil(tobj.blockCache)
+
+	fileName := getTestFileName(suite.T().Name())
+	storagePath := filepath.Join(tobj.fake_storage_path, fileName)
+	fileSize := 60 * _1MB
+	data := make([]byte, fileSize)
+	_, _ = r.Read(data)
+	err = os.WriteFile(storagePath, data, 0777)
+	suite.assert.NoError(err)
+
+	options := internal.OpenFileOptions{Name: fileName}
+	h, err := tobj.blockCache.OpenFile(options)
+	suite.assert.NoError(err)
+	suite.assert.NotNil(h)
+
+	buf := make([]byte, 1000)
+	for i := 0; i < 60; i += 3 {
+		offset := int64(i) * int64(_1MB)
+		n, err := tobj.blockCache.ReadInBuffer(&internal.ReadInBufferOptions{Handle: h, Offset: offset, Data: buf})
+		suite.assert.NoError(err)
+		suite.assert.Equal(1000, n)
+		suite.assert.Equal(data[offset:offset+1000], buf)
+	}
+
+	tracker := tobj.blockCache.getPrefetchTracker(h)
+	suite.assert.Equal(patternStrided, tracker.pattern)
+	suite.assert.Equal(int64(3), tracker.step())
+	suite.assert.Positive(tracker.hits)
+
+	err = tobj.blockCache.ReleaseFile(internal.ReleaseFileOptions{Handle: h})
+	suite.assert.NoError(err)
+}
+
+func (suite *blockCacheTestSuite) TestSharedBlocksAcrossHandles() {
+	tobj, err := setupPipeline("")
+	defer tobj.cleanupPipeline()
+
+	suite.assert.NoError(err)
+	suite.assert.NotNil(tobj.blockCache)
+
+	fileName := getTestFileName(suite.T().Name())
+	storagePath := filepath.Join(tobj.fake_storage_path, fileName)
+	data := make([]byte, 10*_1MB)
+	_, _ = r.Read(data)
+	err = os.WriteFile(storagePath, data, 0777)
+	suite.assert.NoError(err)
+
+	usage := tobj.blockCache.blockPool.Usage()
+
+	h1, err := tobj.blockCache.OpenFile(internal.OpenFileOptions{Name: fileName, Flags: os.O_RDONLY})
+	suite.assert.NoError(err)
+	h2, err := tobj.blockCache.OpenFile(internal.OpenFileOptions{Name: fileName, Flags: os.O_RDONLY})
+	suite.assert.NoError(err)
+	suite.assert.NotEmpty(getSharedKey(h1))
+	suite.assert.Equal(getSharedKey(h1), getSharedKey(h2))
+
+	buf := make([]byte, len(data))
+	n, err := tobj.blockCache.ReadInBuffer(&internal.ReadInBufferOptions{Handle: h1, Offset: 0, Data: buf})
+	suite.assert.Equal(len(data), n)
+	suite.assert.True(err == nil || err == io.EOF)
+	suite.assert.Equal(data, buf)
+
+	// Second handle shall read the blocks downloaded by the first one
+	buf = make([]byte, len(data))
+	n, err = tobj.blockCache.ReadInBuffer(&internal.ReadInBufferOptions{Handle: h2, Offset: 0, Data: buf})
+	suite.assert.Equal(len(data), n)
+	suite.assert.True(err == nil || err == io.EOF)
+	suite.assert.Equal(data, buf)
+	suite.assert.Len(getBorrowedBlocks(h2).blocks, maxBorrowedBlocks)
+	suite.assert.Equal(0, h2.Buffers.Cooked.Len()+h2.Buffers.Cooking.Len())
+
+	// Owner goes away first, blocks stay with the reader till it is done
+	err = tobj.blockCache.ReleaseFile(internal.ReleaseFileOptions{Handle: h1})
+	suite.assert.NoError(err)
+
+	buf = make([]byte, len(data))
+	n, _ = tobj.blockCache.ReadInBuffer(&internal.ReadInBufferOptions{Handle: h2, Offset: 0, Data: buf})
+	suite.assert.Equal(len(data), n)
+	suite.assert.Equal(data, buf)
+
+	err = tobj.blockCache.ReleaseFile(internal.ReleaseFileOptions{Handle: h2})
+	suite.assert.NoError(err)
+
+	// All blocks are back in the pool
+	suite.assert.Eventually(func() bool { return usage == tobj.blockCache.blockPool.Usage() }, time.Second, 10*time.Millisecond)
+	suite.assert.Empty(tobj.blockCache.sharedBlocks.files)
+}
+
+func (suite *blockCacheTestSuite) TestSharedBlocksConcurrentReaders() {
+	tobj, err := setupPipeline("")
+	defer tobj.cleanupPipeline()
+
+	suite.assert.NoError(err)
+	suite.assert.NotNil(tobj.blockCache)
+
+	fileName := getTestFileName(suite.T().Name())
+	storagePath := filepath.Join(tobj.fake_storage_path, fileName)
+	data := make([]byte, 30*_1MB)
+	_, _ = r.Read(data)
+	err = os.WriteFile(storagePath, data, 0777)
+	suite.assert.NoError(err)
+
+	usage := tobj.blockCache.blockPool.Usage()
+
+	wg := sync.WaitGroup{}
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			h, err := tobj.blockCache.OpenFile(internal.OpenFileOptions{Name: fileName, Flags: os.O_RDONLY})
+			suite.assert.NoError(err)
+
+			buf := make([]byte, 64*1024)
+			offset := int64(0)
+			for offset < int64(len(data)) {
+				n, err := tobj.blockCache.ReadInBuffer(&internal.ReadInBufferOptions{Handle: h, Offset: offset, Data: buf})
+				if !suite.assert.Equal(data[offset:offset+int64(n)], buf[:n]) {
+					break
+				}
+				offset += int64(n)
+				if err != nil {
+					break
+				}
+			}
+			suite.assert.Equal(int64(len(data)), offset)
+
+			err = tobj.blockCache.ReleaseFile(internal.ReleaseFileOptions{Handle: h})
+			suite.assert.NoError(err)
+		}()
+	}
+	wg.Wait()
+
+	suite.assert.Eventually(func() bool { return usage == tobj.blockCache.blockPool.Usage() }, time.Second, 10*time.Millisecond)
+	suite.assert.Empty(tobj.blockCache.sharedBlocks.files)
+}
+
+func (suite *blockCacheTestSuite) TestNoSharedBlocksForWriter() {
+	tobj, err := setupPipeline("")
+	defer tobj.cleanupPipeline()
+
+	suite.assert.NoError(err)
+
+	fileName := getTestFileName(suite.T().Name())
+	storagePath := filepath.Join(tobj.fake_storage_path, fileName)
+	err = os.WriteFile(storagePath, make([]byte, 2*_1MB), 0777)
+	suite.assert.NoError(err)
+
+	h, err := tobj.blockCache.OpenFile(internal.OpenFileOptions{Name: fileName, Flags: os.O_RDWR})
+	suite.assert.NoError(err)
+	suite.assert.Empty(getSharedKey(h))
+
+	buf := make([]byte, 100)
+	_, err = tobj.blockCache.ReadInBuffer(&internal.ReadInBufferOptions{Handle: h, Offset: 0, Data: buf})
+	suite.assert.NoError(err)
+	suite.assert.Empty(tobj.blockCache.sharedBlocks.files)
+
+	err = tobj.blockCache.ReleaseFile(internal.ReleaseFileOptions{Handle: h})
+	suite.assert.NoError(err)
+}
+
 func (suite *blockCacheTestSuite) TestDiskUsageCheck() {
 	tobj, err := setupPipeline("")
 	defer tobj.cleanupPipeline()
@@ -2333,8 +2608,8 @@ func (suite *blockCacheTestSuite) TestBlockFailOverwrite() {
 		size:      _1MB,
 	}
 
-	// write at offset 0 where block 0 download will fail
-	n, err := tobj.blockCache.WriteFile(&internal.WriteFileOptions{Handle: h, Offset: 0, Data: dataBuff[:1*_1MB]})
+	// write part of block 0 so that its download is required, and it will fail
+	n, err := tobj.blockCache.WriteFile(&internal.WriteFileOptions{Handle: h, Offset: 0, Data: dataBuff[:_1MB/2]})
 	suite.assert.Error(err)
 	suite.assert.Contains(err.Error(), "failed to download block")
 	suite.assert.Equal(0, n)
@@ -2348,6 +2623,192 @@ func (suite *blockCacheTestSuite) TestBlockFailOverwrite() {
 	suite.assert.Equal(int64(0), fs.Size())
 }
 
+func (suite *blockCacheTestSuite) TestPartialOverwriteReusesCommittedBlocks() {
+	cfg := "block_cache:\n  block-size-mb: 1\n  mem-size-mb: 20\n  prefetch: 12\n  parallelism: 10"
+	tobj, err := setupPipeline(cfg)
+	defer tobj.cleanupPipeline()
+	suite.assert.NoError(err)
+
+	path := getTestFileName(suite.T().Name())
+	storagePath := filepath.Join(tobj.fake_storage_path, path)
+	data := make([]byte, 4*_1MB)
+	_, _ = r.Read(data)
+	err = os.WriteFile(storagePath, data, 0777)
+	suite.assert.NoError(err)
+
+	h, err := tobj.blockCache.OpenFile(internal.OpenFileOptions{Name: path, Flags: os.O_RDWR})
+	suite.assert.NoError(err)
+
+	offset := int64(_1MB + _1MB/2)
+	n, err := tobj.blockCache.WriteFile(&internal.WriteFileOptions{Handle: h, Offset: offset, Data: dataBuff[:100]})
+	suite.assert.NoError(err)
+	suite.assert.Equal(100, n)
+	copy(data[offset:], dataBuff[:100])
+
+	err = tobj.blockCache.FlushFile(internal.FlushFileOptions{Handle: h})
+	suite.assert.NoError(err)
+
+	// Only the modified block is staged, others keep the committed ids
+	lst, _ := h.GetValue("blockList")
+	listMap := lst.(map[int64]*blockInfo)
+	suite.assert.Equal("0", listMap[0].id)
+	suite.assert.NotEqual("1", listMap[1].id)
+	suite.assert.Equal("2", listMap[2].id)
+	suite.assert.Equal("3", listMap[3].id)
+
+	err = tobj.blockCache.ReleaseFile(internal.ReleaseFileOptions{Handle: h})
+	suite.assert.NoError(err)
+
+	storageData, err := os.ReadFile(storagePath)
+	suite.assert.NoError(err)
+	suite.assert.Equal(data, storageData)
+}
+
+func (suite *blockCacheTestSuite) TestRechunkOnCommit() {
+	cfg := "block_cache:\n  block-size-mb: 1\n  mem-size-mb: 20\n  prefetch: 12\n  parallelism: 10"
+	tobj, err := setupPipeline(cfg)
+	defer tobj.cleanupPipeline()
+	suite.assert.NoError(err)
+
+	// Committed block list does not cover the last half block of this file
+	path := getTestFileName(suite.T().Name())
+	storagePath := filepath.Join(tobj.fake_storage_path, path)
+	data := make([]byte, 2*_1MB+_1MB/2)
+	_, _ = r.Read(data)
+	err = os.WriteFile(storagePath, data, 0777)
+	suite.assert.NoError(err)
+
+	h, err := tobj.blockCache.OpenFile(internal.OpenFileOptions{Name: path, Flags: os.O_RDWR})
+	suite.assert.NoError(err)
+
+	lst, _ := h.GetValue("blockList")
+	listMap := lst.(map[int64]*blockInfo)
+	suite.assert.Len(listMap, 3)
+	suite.assert.True(listMap[2].rechunk)
+
+	n, err := tobj.blockCache.WriteFile(&internal.WriteFileOptions{Handle: h, Offset: 10, Data: dataBuff[:100]})
+	suite.assert.NoError(err)
+	suite.assert.Equal(100, n)
+	copy(data[10:], dataBuff[:100])
+
+	err = tobj.blockCache.FlushFile(internal.FlushFileOptions{Handle: h})
+	suite.assert.NoError(err)
+
+	suite.assert.NotEqual("0", listMap[0].id)
+	suite.assert.Equal("1", listMap[1].id)
+	suite.assert.False(listMap[2].rechunk)
+	suite.assert.NotEmpty(listMap[2].id)
+	suite.assert.Equal(uint64(_1MB/2), listMap[2].size)
+
+	err = tobj.blockCache.ReleaseFile(internal.ReleaseFileOptions{Handle: h})
+	suite.assert.NoError(err)
+
+	storageData, err := os.ReadFile(storagePath)
+	suite.assert.NoError(err)
+	suite.assert.Equal(data, storageData)
+}
+
+func (suite *blockCacheTestSuite) TestRechunkKeepsUntouchedRuns() {
+	cfg := "block_cache:\n  block-size-mb: 1\n  mem-size-mb: 20\n  prefetch: 12\n  parallelism: 10"
+	tobj, err := setupPipeline(cfg)
+	defer tobj.cleanupPipeline()
+	suite.assert.NoError(err)
+
+	path := getTestFileName(suite.T().Name())
+	storagePath := filepath.Join(tobj.fake_storage_path, path)
+	err = os.WriteFile(storagePath, make([]byte, 6*_1MB), 0777)
+	suite.assert.NoError(err)
+
+	h, err := tobj.blockCache.OpenFile(internal.OpenFileOptions{Name: path, Flags: os.O_RDWR})
+	suite.assert.NoError(err)
+
+	// Blob written by another tool with blocks of one and a half times the block size
+	blockLst := internal.CommittedBlockList{}
+	for i := range 4 {
+		blockLst = append(blockLst, internal.CommittedBlock{
+			Id:     fmt.Sprintf("irregular%v", i),
+			Offset: int64(i) * int64(_1MB+_1MB/2),
+			Size:   _1MB + _1MB/2,
+		})
+	}
+	h.SetValue("blockList", make(map[int64]*blockInfo))
+	valid := tobj.blockCache.validateBlockList(h, internal.OpenFileOptions{Name: path}, &blockLst)
+	suite.assert.True(valid)
+
+	lst, _ := h.GetValue("blockList")
+	listMap := lst.(map[int64]*blockInfo)
+	suite.assert.Len(listMap, 6)
+	first, second := listMap[0].run, listMap[3].run
+	for i := range int64(6) {
+		suite.assert.True(listMap[i].rechunk)
+	}
+	suite.assert.Equal([]string{"irregular0", "irregular1"}, first.ids)
+	suite.assert.Same(first, listMap[2].run)
+	suite.assert.Equal([]string{"irregular2", "irregular3"}, second.ids)
+	suite.assert.Same(second, listMap[5].run)
+
+	// Modify the second run only
+	n, err := tobj.blockCache.WriteFile(&internal.WriteFileOptions{Handle: h, Offset: int64(4*_1MB + 10), Data: dataBuff[:100]})
+	suite.assert.NoError(err)
+	suite.assert.Equal(100, n)
+
+	err = tobj.blockCache.rechunkBlocks(h)
+	suite.assert.NoError(err)
+	_ = tobj.blockCache.stageBlocks(h, MAX_BLOCKS)
+	tobj.blockCache.waitAndFreeUploadedBlocks(h, MAX_BLOCKS)
+
+	for i := range int64(3) {
+		suite.assert.True(listMap[i].rechunk)
+	}
+	for i := int64(3); i < 6; i++ {
+		suite.assert.False(listMap[i].rechunk)
+		suite.assert.NotEmpty(listMap[i].id)
+	}
+
+	ids, restage, err := tobj.blockCache.getBlockIDList(h)
+	suite.assert.NoError(err)
+	suite.assert.Empty(restage)
+	suite.assert.Equal([]string{"irregular0", "irregular1", listMap[3].id, listMap[4].id, listMap[5].id}, ids)
+
+	h.Flags.Clear(handlemap.HandleFlagDirty)
+	_ = tobj.blockCache.ReleaseFile(internal.ReleaseFileOptions{Handle: h})
+}
+
+func (suite *blockCacheTestSuite) TestFullBlockOverwriteSkipsDownload() {
+	cfg := "block_cache:\n  block-size-mb: 1\n  mem-size-mb: 20\n  prefetch: 12\n  parallelism: 10"
+	tobj, err := setupPipeline(cfg)
+	defer tobj.cleanupPipeline()
+	suite.assert.NoError(err)
+
+	path := getTestFileName(suite.T().Name())
+	storagePath := filepath.Join(tobj.fake_storage_path, path)
+	err = os.WriteFile(storagePath, make([]byte, 2*_1MB+_1MB/2), 0777)
+	suite.assert.NoError(err)
+
+	h, err := tobj.blockCache.OpenFile(internal.OpenFileOptions{Name: path, Flags: os.O_RDWR})
+	suite.assert.NoError(err)
+
+	// Any download from now on fails, so the write works only if existing data of the block is not fetched
+	err = os.Truncate(storagePath, 0)
+	suite.assert.NoError(err)
+
+	n, err := tobj.blockCache.WriteFile(&internal.WriteFileOptions{Handle: h, Offset: int64(_1MB), Data: dataBuff[:_1MB]})
+	suite.assert.NoError(err)
+	suite.assert.Equal(int(_1MB), n)
+
+	// Write till the end of file replaces the last block as well
+	n, err = tobj.blockCache.WriteFile(&internal.WriteFileOptions{Handle: h, Offset: int64(2 * _1MB), Data: dataBuff[:_1MB/2]})
+	suite.assert.NoError(err)
+	suite.assert.Equal(int(_1MB/2), n)
+
+	err = tobj.blockCache.ReleaseFile(internal.ReleaseFileOptions{Handle: h})
+	suite.assert.NoError(err)
+
+	storageData, err := os.ReadFile(storagePath)
+	suite.assert.NoError(err)
+	suite.assert.Equal(dataBuff[:_1MB], storageData[_1MB:2*_1MB])
+}
+
 func (suite *blockCacheTestSuite) TestBlockDownloadOffsetGreaterThanFileSize() {
 	cfg := "block_cache:\n  block-size-mb: 1\n  mem-size-mb: 20\n  prefetch: 12\n  parallelism: 10"
 	tobj, err := setupPipeline(cfg)
@@ -2966,6 +3427,591 @@ func (suite *blockCacheTestSuite) TestReadCommittedLastBlocksOverwrite() {
 	suite.assert.Equal(h.Size, int64((15*_1MB)+(_1MB/2)))
 }
 
+func (suite *blockCacheTestSuite) TestPersistDiskIndex() {
+	cachePath := getFakeStoragePath("block_cache_persist")
+	defer os.RemoveAll(cachePath)
+
+	cfg := fmt.Sprintf("read-only: true\n\nblock_cache:\n  block-size-mb: 1\n  mem-size-mb: 20\n  prefetch: 12\n  parallelism: 10\n  path: %s\n  disk-size-mb: 50\n  disk-timeout-sec: 20\n  persist-index: true", cachePath)
+	tobj, err := setupPipeline(cfg)
+	defer tobj.cleanupPipeline()
+
+	suite.assert.NoError(err)
+	suite.assert.True(tobj.blockCache.persistIndex)
+
+	blocks := map[string]string{
+		"valid::0":       "0x1",
+		"dir/valid::1":   "0x1",
+		"no_etag::0":     "",
+		"modified::0":    "0x1",
+		"not_tracked::0": "0x1",
+	}
+	for name, etag := range blocks {
+		localPath := filepath.Join(cachePath, name)
+		suite.assert.NoError(os.MkdirAll(filepath.Dir(localPath), 0777))
+		suite.assert.NoError(os.WriteFile(localPath, []byte("block data"), 0777))
+		setBlockETag(localPath, etag)
+		if name != "not_tracked::0" {
+			tobj.blockCache.fileNodeMap.Store(name, tobj.blockCache.diskPolicy.Add(name))
+		}
+	}
+
+	// Unmount keeps the blocks along with the index
+	err = tobj.blockCache.Stop()
+	suite.assert.NoError(err)
+	suite.assert.FileExists(filepath.Join(cachePath, diskIndexFile))
+	suite.assert.FileExists(filepath.Join(cachePath, "valid::0"))
+
+	// Block changed behind the index shall not be reused
+	suite.assert.NoError(os.WriteFile(filepath.Join(cachePath, "modified::0"), []byte("new block data"), 0777))
+
+	tobj.blockCache = NewBlockCacheComponent().(*BlockCache)
+	tobj.blockCache.SetNextComponent(tobj.loopback)
+	suite.assert.NoError(tobj.blockCache.Configure(true))
+	suite.assert.NoError(tobj.blockCache.Start(context.Background()))
+
+	for name := range blocks {
+		_, found := tobj.blockCache.fileNodeMap.Load(name)
+		if name == "valid::0" || name == "dir/valid::1" {
+			suite.assert.True(found)
+			suite.assert.FileExists(filepath.Join(cachePath, name))
+		} else {
+			suite.assert.False(found)
+			suite.assert.NoFileExists(filepath.Join(cachePath, name))
+		}
+	}
+
+	// Restored block is served only for the same version of the blob
+	localPath := filepath.Join(cachePath, "valid::0")
+	suite.assert.True(checkBlockETag(&workItem{ETag: "0x1"}, localPath, "valid::0"))
+	suite.assert.False(checkBlockETag(&workItem{ETag: "0x2"}, localPath, "valid::0"))
+	suite.assert.NoFileExists(localPath)
+
+	// Block without an ETag may belong to any version of the blob
+	localPath = filepath.Join(cachePath, "dir/valid::1")
+	setBlockETag(localPath, "")
+	suite.assert.False(checkBlockETag(&workItem{ETag: "0x1"}, localPath, "dir/valid::1"))
+	suite.assert.NoFileExists(localPath)
+}
+
+func (suite *blockCacheTestSuite) TestWriteBackWithoutPath() {
+	cfg := "block_cache:\n  block-size-mb: 1\n  mem-size-mb: 20\n  prefetch: 12\n  parallelism: 10\n  write-back: true"
+	tobj, err := setupPipeline(cfg)
+	defer tobj.cleanupPipeline()
+
+	suite.assert.Error(err)
+	suite.assert.Contains(err.Error(), "write-back requires path")
+}
+
+func (suite *blockCacheTestSuite) TestWriteBackCommitOnClose() {
+	cachePath := getFakeStoragePath("block_cache_journal")
+	defer os.RemoveAll(cachePath)
+
+	cfg := fmt.Sprintf("block_cache:\n  block-size-mb: 1\n  mem-size-mb: 20\n  prefetch: 12\n  parallelism: 10\n  path: %s\n  write-back: true", cachePath)
+	tobj, err := setupPipeline(cfg)
+	defer tobj.cleanupPipeline()
+	suite.assert.NoError(err)
+	suite.assert.True(tobj.blockCache.writeBack)
+
+	path := getTestFileName(suite.T().Name())
+	storagePath := filepath.Join(tobj.fake_storage_path, path)
+
+	h, err := tobj.blockCache.CreateFile(internal.CreateFileOptions{Name: path, Mode: 0777})
+	suite.assert.NoError(err)
+
+	n, err := tobj.blockCache.WriteFile(&internal.WriteFileOptions{Handle: h, Offset: 0, Data: dataBuff[:2*_1MB+_1MB/2]})
+	suite.assert.NoError(err)
+	suite.assert.Equal(int(2*_1MB+_1MB/2), n)
+
+	// Flush only journals the data, nothing reaches the storage yet
+	err = tobj.blockCache.FlushFile(internal.FlushFileOptions{Handle: h})
+	suite.assert.NoError(err)
+	suite.assert.Len(tobj.blockCache.listJournals(), 1)
+	suite.assert.True(isJournalCurrent(h))
+
+	info, err := os.Stat(storagePath)
+	suite.assert.NoError(err)
+	suite.assert.Equal(int64(0), info.Size())
+
+	// Close commits in background and drops the journal once done
+	err = tobj.blockCache.ReleaseFile(internal.ReleaseFileOptions{Handle: h})
+	suite.assert.NoError(err)
+
+	suite.assert.Eventually(func() bool {
+		return len(tobj.blockCache.listJournals()) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	tobj.blockCache.fileCloseOpt.Wait()
+	storageData, err := os.ReadFile(storagePath)
+	suite.assert.NoError(err)
+	suite.assert.Equal(dataBuff[:2*_1MB+_1MB/2], storageData)
+}
+
+func (suite *blockCacheTestSuite) TestWriteBackReplayAfterCrash() {
+	cachePath := getFakeStoragePath("block_cache_journal")
+	defer os.RemoveAll(cachePath)
+
+	cfg := fmt.Sprintf("block_cache:\n  block-size-mb: 1\n  mem-size-mb: 20\n  prefetch: 12\n  parallelism: 10\n  path: %s\n  write-back: true", cachePath)
+	tobj, err := setupPipeline(cfg)
+	defer tobj.cleanupPipeline()
+	suite.assert.NoError(err)
+
+	path := getTestFileName(suite.T().Name())
+	storagePath := filepath.Join(tobj.fake_storage_path, path)
+
+	h, err := tobj.blockCache.CreateFile(internal.CreateFileOptions{Name: path, Mode: 0777})
+	suite.assert.NoError(err)
+
+	// Write enough blocks that the first ones are staged and evicted from memory, leaving only their ids behind
+	expected := make([]byte, 0, 3*len(dataBuff))
+	for i := 0; i < 3; i++ {
+		_, err = tobj.blockCache.WriteFile(&internal.WriteFileOptions{Handle: h, Offset: int64(len(expected)), Data: dataBuff})
+		suite.assert.NoError(err)
+		expected = append(expected, dataBuff...)
+	}
+
+	err = tobj.blockCache.FlushFile(internal.FlushFileOptions{Handle: h})
+	suite.assert.NoError(err)
+
+	journals := tobj.blockCache.listJournals()
+	suite.assert.Len(journals, 1)
+
+	data, err := os.ReadFile(filepath.Join(cachePath, journalDirName, journals[0]+".json"))
+	suite.assert.NoError(err)
+	entry := journalEntry{}
+	suite.assert.NoError(json.Unmarshal(data, &entry))
+	suite.assert.Equal(path, entry.Path)
+	suite.assert.Equal(int64(len(expected)), entry.Size)
+
+	saved := 0
+	for _, block := range entry.Blocks {
+		if block.Data {
+			saved++
+		} else {
+			suite.assert.NotEmpty(block.ID)
+			suite.assert.False(block.Committed)
+		}
+	}
+	suite.assert.Positive(saved)
+	suite.assert.Less(saved, len(entry.Blocks))
+
+	// Process dies before the handle is closed
+	tobj.blockCache.threadPool.Stop()
+
+	info, err := os.Stat(storagePath)
+	suite.assert.NoError(err)
+	suite.assert.Equal(int64(0), info.Size())
+
+	// Next mount commits what the journal holds
+	tobj.blockCache = NewBlockCacheComponent().(*BlockCache)
+	tobj.blockCache.SetNextComponent(tobj.loopback)
+	suite.assert.NoError(tobj.blockCache.Configure(true))
+	suite.assert.NoError(tobj.blockCache.Start(context.Background()))
+
+	// Replay runs in background, open of the file waits for it
+	h, err = tobj.blockCache.OpenFile(internal.OpenFileOptions{Name: path, Flags: os.O_RDONLY})
+	suite.assert.NoError(err)
+	suite.assert.Equal(int64(len(expected)), h.Size)
+	suite.assert.NoError(tobj.blockCache.ReleaseFile(internal.ReleaseFileOptions{Handle: h}))
+
+	storageData, err := os.ReadFile(storagePath)
+	suite.assert.NoError(err)
+	suite.assert.Equal(expected, storageData)
+
+	tobj.blockCache.replayDone.Wait()
+	suite.assert.Empty(tobj.blockCache.listJournals())
+	suite.assert.NoFileExists(filepath.Join(cachePath, journalDirName, journals[0]+".data"))
+
+	// Journal recorded from now on sorts after the replayed one
+	suite.assert.Greater(tobj.blockCache.journalSeq.Load(), uint64(0))
+}
+
+func (suite *blockCacheTestSuite) TestWriteBackKeepsJournalOnBlockSizeChange() {
+	cachePath := getFakeStoragePath("block_cache_journal")
+	defer os.RemoveAll(cachePath)
+
+	cfg := fmt.Sprintf("block_cache:\n  block-size-mb: 1\n  mem-size-mb: 20\n  prefetch: 12\n  parallelism: 10\n  path: %s\n  write-back: true", cachePath)
+	tobj, err := setupPipeline(cfg)
+	defer tobj.cleanupPipeline()
+	suite.assert.NoError(err)
+
+	journalDir := filepath.Join(cachePath, journalDirName)
+	suite.assert.NoError(os.MkdirAll(journalDir, 0700))
+
+	data, err := json.Marshal(journalEntry{Version: journalVersion, Path: "file", Size: 10, BlockSize: 2 * _1MB})
+	suite.assert.NoError(err)
+	suite.assert.NoError(os.WriteFile(filepath.Join(journalDir, "00000000000000000007.json"), data, 0600))
+
+	err = tobj.blockCache.Stop()
+	suite.assert.NoError(err)
+	suite.assert.FileExists(filepath.Join(journalDir, "00000000000000000007.json"))
+
+	// Stale disk block left by a crash goes away while the journal stays
+	suite.assert.NoError(os.WriteFile(filepath.Join(cachePath, "file::0"), []byte("block data"), 0777))
+
+	tobj.blockCache = NewBlockCacheComponent().(*BlockCache)
+	tobj.blockCache.SetNextComponent(tobj.loopback)
+	suite.assert.NoError(tobj.blockCache.Configure(true))
+	suite.assert.NoError(tobj.blockCache.Start(context.Background()))
+
+	suite.assert.Equal([]string{"00000000000000000007"}, tobj.blockCache.listJournals())
+	suite.assert.Equal(uint64(7), tobj.blockCache.journalSeq.Load())
+	suite.assert.NoFileExists(filepath.Join(cachePath, "file::0"))
+}
+
+// etagComponent : Next component reporting the given ETag for all files, and failing GetAttr of the given path
+type etagComponent struct {
+	internal.Component
+	etag    string
+	errPath string
+}
+
+func (c *etagComponent) GetAttr(options internal.GetAttrOptions) (*internal.ObjAttr, error) {
+	if options.Name == c.errPath {
+		return nil, syscall.EIO
+	}
+
+	attr, err := c.Component.GetAttr(options)
+	if err == nil {
+		attr.ETag = c.etag
+	}
+	return attr, err
+}
+
+func (suite *blockCacheTestSuite) TestWriteBackReplayChecksETag() {
+	cachePath := getFakeStoragePath("block_cache_journal")
+	defer os.RemoveAll(cachePath)
+
+	cfg := fmt.Sprintf("block_cache:\n  block-size-mb: 1\n  mem-size-mb: 20\n  prefetch: 12\n  parallelism: 10\n  path: %s\n  write-back: true", cachePath)
+	tobj, err := setupPipeline(cfg)
+	defer tobj.cleanupPipeline()
+	suite.assert.NoError(err)
+	suite.assert.NoError(tobj.blockCache.Stop())
+
+	journalDir := filepath.Join(cachePath, journalDirName)
+	suite.assert.NoError(os.MkdirAll(journalDir, 0700))
+
+	// Same file version, file changed by someone else after the crash, and file whose version can not be checked
+	for i, path := range []string{"same", "changed", "unknown"} {
+		suite.assert.NoError(os.WriteFile(filepath.Join(tobj.fake_storage_path, path), []byte("old"), 0777))
+
+		name := fmt.Sprintf("%020d", i+1)
+		etag := "v1"
+		if path == "changed" {
+			etag = "v0"
+		}
+		data, err := json.Marshal(journalEntry{
+			Version:   journalVersion,
+			Path:      path,
+			Size:      4,
+			BlockSize: _1MB,
+			ETag:      etag,
+			Blocks:    []journalBlock{{Index: 0, Size: 4, Data: true}},
+		})
+		suite.assert.NoError(err)
+		suite.assert.NoError(os.WriteFile(filepath.Join(journalDir, name+".data"), []byte("abcd"), 0600))
+		suite.assert.NoError(os.WriteFile(filepath.Join(journalDir, name+".json"), data, 0600))
+	}
+
+	tobj.blockCache = NewBlockCacheComponent().(*BlockCache)
+	tobj.blockCache.SetNextComponent(&etagComponent{Component: tobj.loopback, etag: "v1", errPath: "unknown"})
+	suite.assert.NoError(tobj.blockCache.Configure(true))
+	suite.assert.NoError(tobj.blockCache.Start(context.Background()))
+	tobj.blockCache.replayDone.Wait()
+
+	for path, expected := range map[string]string{"same": "abcd", "changed": "old", "unknown": "old"} {
+		data, err := os.ReadFile(filepath.Join(tobj.fake_storage_path, path))
+		suite.assert.NoError(err)
+		suite.assert.Equal(expected, string(data), path)
+	}
+
+	// Journal of the changed file is set aside, the one which could not be checked is kept for next mount
+	suite.assert.FileExists(filepath.Join(journalDir, journalQuarantineDirName, "00000000000000000002.json"))
+	suite.assert.FileExists(filepath.Join(journalDir, journalQuarantineDirName, "00000000000000000002.data"))
+	suite.assert.Equal([]string{"00000000000000000003"}, tobj.blockCache.listJournals())
+}
+
+func (suite *blockCacheTestSuite) TestMemoryPressureResizesPool() {
+	interval := memoryCheckInterval
+	memoryCheckInterval = time.Hour
+	defer func() { memoryCheckInterval = interval }()
+
+	cfg := "read-only: true\n\nblock_cache:\n  block-size-mb: 1\n  mem-size-mb: 20\n  prefetch: 12\n  parallelism: 10\n  adaptive-memory: true"
+	tobj, err := setupPipeline(cfg)
+	defer tobj.cleanupPipeline()
+	suite.assert.NoError(err)
+	suite.assert.True(tobj.blockCache.adaptiveMemory)
+	suite.assert.NotNil(tobj.blockCache.memoryMonitorStop)
+
+	bc := tobj.blockCache
+	tracker := newPrefetchTracker(bc.prefetch)
+	tracker.window = tracker.maxWindow
+	suite.assert.EqualValues(12, bc.prefetchWindow(tracker))
+
+	// Usage beyond the limit shrinks the pool and throttles prefetch
+	bc.checkMemoryPressure(&common.MemoryPressure{Limit: 100 * _1MB, Usage: 95 * _1MB})
+	suite.assert.True(bc.memoryPressure.Load())
+	suite.assert.EqualValues(15, bc.blockPool.Size())
+	suite.assert.EqualValues(MIN_PREFETCH, bc.prefetchWindow(tracker))
+
+	// Pool does not go below its minimum however long the pressure lasts
+	for range 5 {
+		bc.checkMemoryPressure(&common.MemoryPressure{Limit: 100 * _1MB, Usage: 50 * _1MB, SomeAvg10: 30})
+	}
+	suite.assert.EqualValues(bc.blockPool.MinSize(), bc.blockPool.Size())
+
+	// Pool grows back gradually once memory is available
+	bc.checkMemoryPressure(&common.MemoryPressure{Limit: 100 * _1MB, Usage: 10 * _1MB})
+	suite.assert.False(bc.memoryPressure.Load())
+	suite.assert.EqualValues(bc.blockPool.MinSize()+2, bc.blockPool.Size())
+	suite.assert.EqualValues(12, bc.prefetchWindow(tracker))
+
+	for range 10 {
+		bc.checkMemoryPressure(&common.MemoryPressure{Limit: 100 * _1MB, Usage: 10 * _1MB})
+	}
+	suite.assert.EqualValues(20, bc.blockPool.Size())
+
+	// Reads go through with the resized pool
+	path := getTestFileName(suite.T().Name())
+	storagePath := filepath.Join(tobj.fake_storage_path, path)
+	suite.assert.NoError(os.WriteFile(storagePath, dataBuff[:3*_1MB], 0777))
+
+	h, err := bc.OpenFile(internal.OpenFileOptions{Name: path, Flags: os.O_RDONLY})
+	suite.assert.NoError(err)
+
+	data := make([]byte, _1MB)
+	n, err := bc.ReadInBuffer(&internal.ReadInBufferOptions{Handle: h, Offset: int64(_1MB), Data: data})
+	suite.assert.NoError(err)
+	suite.assert.Equal(int(_1MB), n)
+	suite.assert.Equal(dataBuff[_1MB:2*_1MB], data)
+	suite.assert.NoError(bc.ReleaseFile(internal.ReleaseFileOptions{Handle: h}))
+}
+
+func (suite *blockCacheTestSuite) TestInvalidBufferOptions() {
+	cfg := "read-only: true\n\nblock_cache:\n  block-size-mb: 1\n  mem-size-mb: 20\n  prefetch: 12\n  parallelism: 10\n  huge-pages: 1gb"
+	tobj, err := setupPipeline(cfg)
+	defer tobj.cleanupPipeline()
+	suite.assert.Error(err)
+	suite.assert.Contains(err.Error(), "invalid huge-pages")
+
+	cfg = "read-only: true\n\nblock_cache:\n  block-size-mb: 1\n  mem-size-mb: 20\n  prefetch: 12\n  parallelism: 10\n  numa-policy: interleave"
+	tobj, err = setupPipeline(cfg)
+	defer tobj.cleanupPipeline()
+	suite.assert.Error(err)
+	suite.assert.Contains(err.Error(), "invalid numa-policy")
+}
+
+func (suite *blockCacheTestSuite) TestHugePagesWithNumaLocal() {
+	cfg := "read-only: true\n\nblock_cache:\n  block-size-mb: 2\n  mem-size-mb: 40\n  prefetch: 12\n  parallelism: 10\n  huge-pages: hugetlb\n  numa-policy: local"
+	tobj, err := setupPipeline(cfg)
+	defer tobj.cleanupPipeline()
+	suite.assert.NoError(err)
+	suite.assert.Equal(common.HugePagesHugeTLB, tobj.blockCache.bufferOptions.HugePages)
+	suite.assert.NotNil(tobj.blockCache.blockPool.allocator)
+	suite.assert.EqualValues(20, tobj.blockCache.blockPool.Size())
+
+	// Blocks are usable whether huge pages are configured on this host or the pool fell back to regular pages
+	path := getTestFileName(suite.T().Name())
+	storagePath := filepath.Join(tobj.fake_storage_path, path)
+	suite.assert.NoError(os.WriteFile(storagePath, dataBuff[:3*_1MB], 0777))
+
+	h, err := tobj.blockCache.OpenFile(internal.OpenFileOptions{Name: path, Flags: os.O_RDONLY})
+	suite.assert.NoError(err)
+
+	data := make([]byte, _1MB)
+	n, err := tobj.blockCache.ReadInBuffer(&internal.ReadInBufferOptions{Handle: h, Offset: int64(_1MB), Data: data})
+	suite.assert.NoError(err)
+	suite.assert.Equal(int(_1MB), n)
+	suite.assert.Equal(dataBuff[_1MB:2*_1MB], data)
+	suite.assert.NoError(tobj.blockCache.ReleaseFile(internal.ReleaseFileOptions{Handle: h}))
+}
+
+func (suite *blockCacheTestSuite) TestCacheRules() {
+	diskPath := getFakeStoragePath("block_cache_rules")
+	defer os.RemoveAll(diskPath)
+
+	cfg := fmt.Sprintf("read-only: true\n\ncache-rules:\n  - path: nocache/**\n    never-cache: true\n  - path: \"*.idx\"\n    prefetch: 0\n\n"+
+		"block_cache:\n  block-size-mb: 1\n  mem-size-mb: 20\n  prefetch: 12\n  parallelism: 10\n  path: %s\n  disk-size-mb: 50", diskPath)
+	tobj, err := setupPipeline(cfg)
+	defer tobj.cleanupPipeline()
+	suite.assert.NoError(err)
+	suite.assert.Equal(2, tobj.blockCache.cacheRules.Len())
+
+	suite.assert.NoError(os.MkdirAll(filepath.Join(tobj.fake_storage_path, "nocache"), 0777))
+	for _, name := range []string{"nocache/data", "cached", "lookup.idx"} {
+		suite.assert.NoError(os.WriteFile(filepath.Join(tobj.fake_storage_path, name), dataBuff[:3*_1MB], 0777))
+	}
+
+	read := func(name string, offset int64) *handlemap.Handle {
+		h, err := tobj.blockCache.OpenFile(internal.OpenFileOptions{Name: name, Flags: os.O_RDONLY})
+		suite.assert.NoError(err)
+
+		data := make([]byte, 100)
+		n, err := tobj.blockCache.ReadInBuffer(&internal.ReadInBufferOptions{Handle: h, Offset: offset, Data: data})
+		suite.assert.NoError(err)
+		suite.assert.Equal(100, n)
+		suite.assert.Equal(dataBuff[offset:offset+100], data)
+		return h
+	}
+
+	// Blocks of never-cache paths are served from memory but never land on disk
+	h := read("nocache/data", 0)
+	suite.assert.NoError(tobj.blockCache.ReleaseFile(internal.ReleaseFileOptions{Handle: h}))
+	suite.assert.NoDirExists(filepath.Join(diskPath, "nocache"))
+
+	h = read("cached", 0)
+	suite.assert.NoError(tobj.blockCache.ReleaseFile(internal.ReleaseFileOptions{Handle: h}))
+	suite.assert.FileExists(filepath.Join(diskPath, "cached::0"))
+
+	// Prefetch of zero limits the handle to the block being read
+	h = read("lookup.idx", 0)
+	suite.assert.True(tobj.blockCache.getPrefetchTracker(h).random())
+	suite.assert.Equal(1, h.Buffers.Cooked.Len()+h.Buffers.Cooking.Len())
+	suite.assert.NoError(tobj.blockCache.ReleaseFile(internal.ReleaseFileOptions{Handle: h}))
+
+	cfg = "read-only: true\n\ncache-rules:\n  - path: \"[\"\n    prefetch: 2\n\nblock_cache:\n  block-size-mb: 1\n  mem-size-mb: 20\n  prefetch: 12"
+	tobj, err = setupPipeline(cfg)
+	defer tobj.cleanupPipeline()
+	suite.assert.Error(err)
+	suite.assert.Contains(err.Error(), "invalid path")
+}
+
+func (suite *blockCacheTestSuite) TestCacheRulesDiskTimeout() {
+	diskPath := getFakeStoragePath("block_cache_rules")
+	defer os.RemoveAll(diskPath)
+
+	cfg := fmt.Sprintf("read-only: true\n\ncache-rules:\n  - path: short/**\n    timeout-sec: 1\n  - path: hot/**\n    pin: true\n\n"+
+		"block_cache:\n  block-size-mb: 1\n  mem-size-mb: 20\n  prefetch: 12\n  parallelism: 10\n  path: %s\n  disk-size-mb: 50\n  disk-timeout-sec: 20", diskPath)
+	tobj, err := setupPipeline(cfg)
+	defer tobj.cleanupPipeline()
+	suite.assert.NoError(err)
+
+	// Disk policy runs at the shortest timeout
+	suite.assert.EqualValues(1, tobj.blockCache.diskPolicy.Timeout)
+
+	for _, name := range []string{"short/data", "hot/data", "cached"} {
+		suite.assert.NoError(os.MkdirAll(filepath.Dir(filepath.Join(tobj.fake_storage_path, name)), 0777))
+		suite.assert.NoError(os.WriteFile(filepath.Join(tobj.fake_storage_path, name), dataBuff[:_1MB/2], 0777))
+
+		h, err := tobj.blockCache.OpenFile(internal.OpenFileOptions{Name: name, Flags: os.O_RDONLY})
+		suite.assert.NoError(err)
+		data := make([]byte, 100)
+		_, err = tobj.blockCache.ReadInBuffer(&internal.ReadInBufferOptions{Handle: h, Offset: 0, Data: data})
+		suite.assert.NoError(err)
+		suite.assert.NoError(tobj.blockCache.ReleaseFile(internal.ReleaseFileOptions{Handle: h}))
+		suite.assert.FileExists(filepath.Join(diskPath, name+"::0"))
+	}
+
+	// Blocks with the shorter timeout go first, rest are kept as per disk-timeout-sec and pin
+	suite.assert.Eventually(func() bool {
+		_, err := os.Stat(filepath.Join(diskPath, "short/data::0"))
+		return os.IsNotExist(err)
+	}, 5*time.Second, 50*time.Millisecond)
+	suite.assert.FileExists(filepath.Join(diskPath, "cached::0"))
+	suite.assert.FileExists(filepath.Join(diskPath, "hot/data::0"))
+
+	_, tracked := tobj.blockCache.fileNodeMap.Load("cached::0")
+	suite.assert.True(tracked)
+}
+
+func (suite *blockCacheTestSuite) TestPinFile() {
+	tobj, err := setupPipeline("")
+	defer tobj.cleanupPipeline()
+	suite.assert.NoError(err)
+
+	for _, name := range []string{"pinned", "warm"} {
+		suite.assert.NoError(os.WriteFile(filepath.Join(tobj.fake_storage_path, name), dataBuff[:3*_1MB], 0777))
+	}
+
+	suite.assert.NoError(tobj.blockCache.PinFile("pinned"))
+	suite.assert.NoError(tobj.blockCache.WarmFile("warm"))
+	for i := range 3 {
+		suite.assert.FileExists(filepath.Join(tobj.disk_cache_path, fmt.Sprintf("pinned::%v", i)))
+		suite.assert.FileExists(filepath.Join(tobj.disk_cache_path, fmt.Sprintf("warm::%v", i)))
+	}
+
+	// Disk policy evicting every block only takes away the ones which are not pinned
+	tobj.blockCache.fileNodeMap.Range(func(_, node any) bool {
+		tobj.blockCache.diskPolicy.Remove(node.(*list.Element))
+		return true
+	})
+	suite.assert.Eventually(func() bool {
+		_, err := os.Stat(filepath.Join(tobj.disk_cache_path, "warm::2"))
+		return os.IsNotExist(err)
+	}, 5*time.Second, 10*time.Millisecond)
+	suite.assert.FileExists(filepath.Join(tobj.disk_cache_path, "pinned::0"))
+
+	suite.assert.NoError(tobj.blockCache.UnpinFile("pinned"))
+	suite.assert.False(tobj.blockCache.isPinned("pinned::0"))
+
+	suite.assert.NoError(tobj.blockCache.PinFile("pinned"))
+	suite.assert.NoError(tobj.blockCache.EvictFile("pinned"))
+	suite.assert.NoFileExists(filepath.Join(tobj.disk_cache_path, "pinned::0"))
+	suite.assert.False(tobj.blockCache.isPinned("pinned::0"))
+
+	// Without a disk cache there is nowhere to keep the blocks
+	tobj.blockCache.tmpPath = ""
+	suite.assert.Error(tobj.blockCache.PinFile("pinned"))
+	suite.assert.False(tobj.blockCache.isPinned("pinned::0"))
+	tobj.blockCache.tmpPath = tobj.disk_cache_path
+}
+
+func (suite *blockCacheTestSuite) TestDropCache() {
+	tobj, err := setupPipeline("")
+	defer tobj.cleanupPipeline()
+	suite.assert.NoError(err)
+
+	for _, name := range []string{"pinned", "warm"} {
+		suite.assert.NoError(os.WriteFile(filepath.Join(tobj.fake_storage_path, name), dataBuff[:2*_1MB], 0777))
+	}
+
+	suite.assert.NoError(tobj.blockCache.PinFile("pinned"))
+	suite.assert.NoError(tobj.blockCache.WarmFile("warm"))
+
+	tobj.blockCache.DropCache()
+	suite.assert.NoFileExists(filepath.Join(tobj.disk_cache_path, "warm::0"))
+	suite.assert.NoFileExists(filepath.Join(tobj.disk_cache_path, "warm::1"))
+	suite.assert.FileExists(filepath.Join(tobj.disk_cache_path, "pinned::0"))
+	suite.assert.FileExists(filepath.Join(tobj.disk_cache_path, "pinned::1"))
+
+	state := tobj.blockCache.State()
+	suite.assert.Equal(1, state["pinned"])
+	suite.assert.Equal(tobj.disk_cache_path, state["path"])
+	suite.assert.EqualValues(_1MB, state["block-size"])
+}
+
+func (suite *blockCacheTestSuite) TestInvalidatePathGlobCharacters() {
+	tobj, err := setupPipeline("")
+	defer tobj.cleanupPipeline()
+	suite.assert.NoError(err)
+
+	suite.assert.NoError(os.MkdirAll(filepath.Join(tobj.fake_storage_path, "dir"), 0777))
+	names := []string{"data[1]", "data1", "dir/a*", "dir/ab"}
+	for _, name := range names {
+		suite.assert.NoError(os.WriteFile(filepath.Join(tobj.fake_storage_path, name), dataBuff[:2*_1MB], 0777))
+		suite.assert.NoError(tobj.blockCache.WarmFile(name))
+	}
+
+	tobj.blockCache.InvalidatePath("data[1]")
+	tobj.blockCache.InvalidatePath("dir/a*")
+
+	for _, name := range names {
+		for i := range 2 {
+			block := fmt.Sprintf("%s::%v", name, i)
+			_, tracked := tobj.blockCache.fileNodeMap.Load(block)
+			if name == "data[1]" || name == "dir/a*" {
+				suite.assert.NoFileExists(filepath.Join(tobj.disk_cache_path, block))
+				suite.assert.False(tracked, "invalidated block is dropped from disk policy")
+			} else {
+				suite.assert.FileExists(filepath.Join(tobj.disk_cache_path, block))
+				suite.assert.True(tracked)
+			}
+		}
+	}
+
+	// Invalidating a file never cached is a no-op
+	tobj.blockCache.InvalidatePath("missing/file")
+}
+
 // In order for 'go test' to run this suite, we need to create
 // a normal test function and pass our suite to suite.Run
 func TestBlockCacheTestSuite(t *testing.T) {
@@ -36,8 +36,10 @@ package block_cache
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/Azure/azure-storage-fuse/v2/common"
 	"github.com/Azure/azure-storage-fuse/v2/common/log"
 )
 
@@ -65,10 +67,65 @@ type BlockPool struct {
 
 	// Number of block that this pool can handle at max
 	maxBlocks uint32
+
+	// Number of blocks currently allocated, less than maxBlocks while the pool is shrunk under memory pressure
+	allocated atomic.Int32
+
+	// Number of blocks still to be freed as they come back to the pool
+	deficit atomic.Int32
+
+	// Lock to serialize resizing of the pool
+	resizeLock sync.Mutex
+
+	// Allocator mapping the block buffers, nil for regular pages
+	allocator *common.BufferAllocator
+
+	// Index of the NUMA node this pool is bound to, among the nodes of the allocator
+	numa int
+
+	// Pools of each NUMA node when blocks are bound to several nodes, this pool then holds no blocks itself.
+	// Blocks are taken from the pool of the node the caller asks for, and from other nodes only when it has none free.
+	nodePools []*BlockPool
 }
 
 // NewBlockPool allocates a new pool of blocks
 func NewBlockPool(blockSize uint64, memSize uint64) *BlockPool {
+	return newBlockPool(blockSize, memSize, nil)
+}
+
+// newBlockPool allocates a new pool of blocks using the given allocator
+func newBlockPool(blockSize uint64, memSize uint64, allocator *common.BufferAllocator) *BlockPool {
+	nodes := len(allocator.Nodes())
+	if nodes < 2 {
+		return newNodeBlockPool(blockSize, memSize, allocator, 0)
+	}
+
+	// Memory is split evenly across the nodes
+	pool := &BlockPool{
+		blockSize: blockSize,
+		allocator: allocator,
+		nodePools: make([]*BlockPool, 0, nodes),
+	}
+
+	for i := range nodes {
+		nodePool := newNodeBlockPool(blockSize, memSize/uint64(nodes), allocator.ForNode(i), i)
+		if nodePool == nil {
+			for _, p := range pool.nodePools {
+				p.Terminate()
+			}
+			return nil
+		}
+
+		pool.nodePools = append(pool.nodePools, nodePool)
+		pool.maxBlocks += nodePool.maxBlocks
+	}
+
+	pool.zeroBlock = pool.nodePools[0].zeroBlock
+	return pool
+}
+
+// newNodeBlockPool allocates a new pool of blocks on the NUMA node at given index of the allocator
+func newNodeBlockPool(blockSize uint64, memSize uint64, allocator *common.BufferAllocator, numa int) *BlockPool {
 	// Ignore if config is invalid
 	if blockSize == 0 || memSize < blockSize {
 		log.Err("blockpool::NewBlockPool : blockSize : %v, memsize: %v", blockSize, memSize)
@@ -85,15 +142,19 @@ func NewBlockPool(blockSize uint64, memSize uint64) *BlockPool {
 		resetBlockCh: make(chan *Block, blockCount-1), // -1 because one block is used for zero data
 		maxBlocks:    uint32(blockCount),
 		blockSize:    blockSize,
+		allocator:    allocator,
+		numa:         numa,
 	}
+	pool.allocated.Store(int32(blockCount))
 
 	// Preallocate all blocks so that during runtime we do not spend CPU cycles on this
 	for i := range blockCount {
-		block, err := AllocateBlock(blockSize)
+		block, err := allocateBlock(blockSize, allocator)
 		if err != nil {
 			log.Err("BlockPool::NewBlockPool : Failed to allocate block [%v]", err.Error())
 			return nil
 		}
+		block.numa = numa
 
 		if i == blockCount-1 {
 			pool.zeroBlock = block
@@ -113,6 +174,13 @@ func NewBlockPool(blockSize uint64, memSize uint64) *BlockPool {
 
 // Terminate ends the block pool life
 func (pool *BlockPool) Terminate() {
+	if len(pool.nodePools) > 0 {
+		for _, p := range pool.nodePools {
+			p.Terminate()
+		}
+		return
+	}
+
 	// TODO: call terminate after all the threads have completed
 	close(pool.resetBlockCh)
 	pool.wg.Wait()
@@ -139,11 +207,190 @@ func releaseBlock(ch chan *Block) {
 
 // Usage provides % usage of this block pool
 func (pool *BlockPool) Usage() uint32 {
-	return ((pool.maxBlocks - (uint32)(len(pool.blocksCh)+len(pool.priorityCh)+len(pool.resetBlockCh))) * 100) / pool.maxBlocks
+	allocated, free := pool.counts()
+	if free >= allocated {
+		return 0
+	}
+	return ((allocated - free) * 100) / allocated
+}
+
+// counts provides number of blocks allocated and free in this pool
+func (pool *BlockPool) counts() (uint32, uint32) {
+	if len(pool.nodePools) > 0 {
+		allocated, free := uint32(0), uint32(0)
+		for _, p := range pool.nodePools {
+			a, f := p.counts()
+			allocated, free = allocated+a, free+f
+		}
+		return allocated, free
+	}
+
+	return uint32(pool.allocated.Load()), uint32(len(pool.blocksCh) + len(pool.priorityCh) + len(pool.resetBlockCh))
+}
+
+// Size provides number of blocks this pool holds once pending shrink completes
+func (pool *BlockPool) Size() uint32 {
+	if len(pool.nodePools) > 0 {
+		size := uint32(0)
+		for _, p := range pool.nodePools {
+			size += p.Size()
+		}
+		return size
+	}
+
+	return uint32(pool.allocated.Load() - pool.deficit.Load())
+}
+
+// MinSize provides number of blocks below which this pool is never shrunk
+func (pool *BlockPool) MinSize() uint32 {
+	if len(pool.nodePools) > 0 {
+		size := uint32(0)
+		for _, p := range pool.nodePools {
+			size += p.MinSize()
+		}
+		return size
+	}
+
+	// Priority blocks and the zero block are always kept so that writes and first reads go through
+	return max(uint32(cap(pool.priorityCh))+1, pool.maxBlocks/4)
+}
+
+// spread a resize of given number of blocks evenly over the pools of NUMA nodes, as far as each of them allows.
+// Returns the number of blocks resized.
+func (pool *BlockPool) spread(count uint32, resize func(*BlockPool, uint32) uint32) uint32 {
+	done := uint32(0)
+	share := (count + uint32(len(pool.nodePools)) - 1) / uint32(len(pool.nodePools))
+
+	// Second round hands what some nodes could not take to the others
+	for range 2 {
+		for _, p := range pool.nodePools {
+			if done == count {
+				return done
+			}
+			done += resize(p, min(share, count-done))
+		}
+		share = count - done
+	}
+
+	return done
+}
+
+// Shrink the pool by given number of blocks. Free blocks are released right away
+// and the rest are released as they come back to the pool. Returns the number of blocks the pool is shrunk by.
+func (pool *BlockPool) Shrink(count uint32) uint32 {
+	if len(pool.nodePools) > 0 {
+		return pool.spread(count, (*BlockPool).Shrink)
+	}
+
+	pool.resizeLock.Lock()
+	defer pool.resizeLock.Unlock()
+
+	count = min(count, pool.Size()-min(pool.Size(), pool.MinSize()))
+
+	released := uint32(0)
+	for released < count {
+		select {
+		case block := <-pool.blocksCh:
+			_ = block.Delete()
+			pool.allocated.Add(-1)
+			released++
+			continue
+		default:
+		}
+		break
+	}
+
+	pool.deficit.Add(int32(count - released))
+	log.Info("BlockPool::Shrink : Shrunk pool by %v blocks (%v released, %v pending), size %v", count, released, count-released, pool.Size())
+	return count
+}
+
+// Grow the pool back by given number of blocks, up to the size it was created with. Returns the number of blocks the pool is grown by.
+func (pool *BlockPool) Grow(count uint32) uint32 {
+	if len(pool.nodePools) > 0 {
+		return pool.spread(count, (*BlockPool).Grow)
+	}
+
+	pool.resizeLock.Lock()
+	defer pool.resizeLock.Unlock()
+
+	count = min(count, pool.maxBlocks-pool.Size())
+
+	// Blocks yet to be released can just be kept
+	grown := uint32(0)
+	for grown < count {
+		deficit := pool.deficit.Load()
+		if deficit <= 0 {
+			break
+		}
+
+		if pool.deficit.CompareAndSwap(deficit, deficit-1) {
+			grown++
+		}
+	}
+
+	for ; grown < count; grown++ {
+		block, err := allocateBlock(pool.blockSize, pool.allocator)
+		if err != nil {
+			log.Err("BlockPool::Grow : Failed to allocate block [%v]", err.Error())
+			break
+		}
+		block.numa = pool.numa
+
+		select {
+		case pool.priorityCh <- block:
+		case pool.blocksCh <- block:
+		default:
+			_ = block.Delete()
+			log.Info("BlockPool::Grow : Grown pool by %v blocks, no room for more, size %v", grown, pool.Size())
+			return grown
+		}
+		pool.allocated.Add(1)
+	}
+
+	log.Info("BlockPool::Grow : Grown pool by %v blocks, size %v", grown, pool.Size())
+	return grown
+}
+
+// releaseDeficit : Free this block instead of returning it to the pool, if the pool is still to be shrunk
+func (pool *BlockPool) releaseDeficit(block *Block) bool {
+	for {
+		deficit := pool.deficit.Load()
+		if deficit <= 0 {
+			return false
+		}
+
+		if pool.deficit.CompareAndSwap(deficit, deficit-1) {
+			_ = block.Delete()
+			pool.allocated.Add(-1)
+			return true
+		}
+	}
+}
+
+// Nodes provides number of NUMA nodes the blocks of this pool are split across
+func (pool *BlockPool) Nodes() int {
+	return max(len(pool.nodePools), 1)
 }
 
 // MustGet a Block from the pool, waits until defaultTimeout period before giving up the allocation of the buffer.
 func (pool *BlockPool) MustGet() (*Block, error) {
+	return pool.MustGetOn(0)
+}
+
+// MustGetOn gets a Block of the NUMA node at given index, waits until defaultTimeout period before giving up the
+// allocation of the buffer. A free block of another node is still taken over waiting for one of the given node.
+func (pool *BlockPool) MustGetOn(numa int) (*Block, error) {
+	if len(pool.nodePools) > 0 {
+		numa %= len(pool.nodePools)
+		for i := range pool.nodePools {
+			if block := pool.nodePools[(numa+i)%len(pool.nodePools)].poll(); block != nil {
+				return block, nil
+			}
+		}
+		return pool.nodePools[numa].MustGetOn(0)
+	}
+
 	var block *Block = nil
 	defaultTimeout := time.After(5 * time.Second)
 
@@ -165,8 +412,42 @@ func (pool *BlockPool) MustGet() (*Block, error) {
 	return block, nil
 }
 
+// poll a Block from the pool including the priority blocks, return back if nothing is available
+func (pool *BlockPool) poll() *Block {
+	var block *Block = nil
+
+	select {
+	case block = <-pool.priorityCh:
+		break
+	case block = <-pool.blocksCh:
+		break
+	default:
+		return nil
+	}
+
+	// Mark the buffer ready for reuse now
+	block.ReUse()
+	return block
+}
+
 // TryGet a Block from the pool, return back if nothing is available
 func (pool *BlockPool) TryGet() *Block {
+	return pool.TryGetOn(0)
+}
+
+// TryGetOn gets a Block of the NUMA node at given index, or of another node when it has none free.
+// Return back if nothing is available.
+func (pool *BlockPool) TryGetOn(numa int) *Block {
+	if len(pool.nodePools) > 0 {
+		numa %= len(pool.nodePools)
+		for i := range pool.nodePools {
+			if block := pool.nodePools[(numa+i)%len(pool.nodePools)].TryGetOn(0); block != nil {
+				return block
+			}
+		}
+		return nil
+	}
+
 	var block *Block = nil
 
 	select {
@@ -184,6 +465,12 @@ func (pool *BlockPool) TryGet() *Block {
 
 // Release back the Block to the pool
 func (pool *BlockPool) Release(b *Block) {
+	if len(pool.nodePools) > 0 {
+		// Block goes back to the pool of the node it is bound to
+		pool.nodePools[b.numa].Release(b)
+		return
+	}
+
 	select {
 	case pool.resetBlockCh <- b:
 		break
@@ -197,6 +484,10 @@ func (pool *BlockPool) resetBlock() {
 	defer pool.wg.Done()
 
 	for block := range pool.resetBlockCh {
+		if pool.releaseDeficit(block) {
+			continue
+		}
+
 		// reset the data with null entries
 		copy(block.data, pool.zeroBlock.data)
 
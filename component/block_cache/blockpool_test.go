@@ -278,6 +278,112 @@ func (suite *blockpoolTestSuite) TestBlockReset() {
 	suite.assert.Empty(bp.zeroBlock.data)
 }
 
+func (suite *blockpoolTestSuite) TestShrinkGrow() {
+	suite.assert = assert.New(suite.T())
+
+	bp := NewBlockPool(2, 40)
+	suite.assert.NotNil(bp)
+	suite.assert.EqualValues(20, bp.Size())
+	suite.assert.EqualValues(5, bp.MinSize())
+
+	blocks := getBlocks(suite, bp, 5)
+
+	// Free blocks go right away and the rest as they come back, never going below the minimum
+	suite.assert.EqualValues(15, bp.Shrink(100))
+	suite.assert.EqualValues(5, bp.Size())
+	suite.assert.EqualValues(8, bp.allocated.Load())
+	suite.assert.EqualValues(3, bp.deficit.Load())
+	suite.assert.Empty(bp.blocksCh)
+	suite.assert.Nil(bp.TryGet())
+
+	releaseBlocks(suite, bp, blocks)
+	suite.assert.Eventually(func() bool {
+		return bp.allocated.Load() == 5 && len(bp.priorityCh)+len(bp.blocksCh) == 4
+	}, 2*time.Second, 10*time.Millisecond)
+	suite.assert.EqualValues(0, bp.deficit.Load())
+	suite.assert.EqualValues(0, bp.Shrink(1))
+
+	// Growing never goes beyond the size pool was created with
+	suite.assert.EqualValues(15, bp.Grow(100))
+	suite.assert.EqualValues(20, bp.Size())
+	suite.assert.Len(bp.priorityCh, 2)
+	suite.assert.Len(bp.blocksCh, 17)
+	suite.assert.EqualValues(0, bp.Grow(1))
+
+	// Blocks pending release are just kept when the pool grows back
+	blocks = getBlocks(suite, bp, 17)
+	suite.assert.EqualValues(15, bp.Shrink(15))
+	suite.assert.EqualValues(15, bp.deficit.Load())
+	suite.assert.EqualValues(10, bp.Grow(10))
+	suite.assert.EqualValues(5, bp.deficit.Load())
+	suite.assert.EqualValues(20, bp.allocated.Load())
+
+	releaseBlocks(suite, bp, blocks)
+	suite.assert.Eventually(func() bool {
+		return bp.allocated.Load() == 15 && len(bp.priorityCh)+len(bp.blocksCh) == 14
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// Usage is against the blocks currently allocated, zero block being the only one in use
+	suite.assert.Equal(uint32(100/15), bp.Usage())
+
+	bp.Terminate()
+}
+
+func (suite *blockpoolTestSuite) TestNodePools() {
+	suite.assert = assert.New(suite.T())
+
+	// Pools of two nodes
+	bp := &BlockPool{blockSize: 2}
+	for i := range 2 {
+		p := newNodeBlockPool(2, 20, nil, i)
+		suite.assert.NotNil(p)
+		bp.nodePools = append(bp.nodePools, p)
+		bp.maxBlocks += p.maxBlocks
+	}
+	bp.zeroBlock = bp.nodePools[0].zeroBlock
+
+	suite.assert.EqualValues(20, bp.Size())
+	suite.assert.EqualValues(4, bp.MinSize())
+	suite.assert.Equal(uint32(10), bp.Usage())
+
+	// Blocks come from the node asked for first and from the other node once it has none free
+	block, err := bp.MustGetOn(3)
+	suite.assert.NoError(err)
+	suite.assert.Equal(1, block.numa)
+	releaseBlocks(suite, bp, []*Block{block})
+
+	blocks := getBlocks(suite, bp, 12)
+	for i, b := range blocks {
+		suite.assert.Equal(i/8, b.numa)
+	}
+	suite.assert.Empty(bp.nodePools[0].blocksCh)
+	suite.assert.Equal(uint32(70), bp.Usage())
+
+	// Blocks go back to the node they are bound to
+	releaseBlocks(suite, bp, blocks[8:])
+	suite.assert.Eventually(func() bool {
+		return len(bp.nodePools[1].priorityCh)+len(bp.nodePools[1].blocksCh) == 9
+	}, 2*time.Second, 10*time.Millisecond)
+	suite.assert.Empty(bp.nodePools[0].blocksCh)
+
+	block = bp.TryGet()
+	suite.assert.NotNil(block)
+	suite.assert.Equal(1, block.numa)
+	releaseBlocks(suite, bp, append(blocks[:8], block))
+
+	// Resizing is spread over the nodes
+	suite.assert.EqualValues(16, bp.Shrink(100))
+	suite.assert.EqualValues(2, bp.nodePools[0].Size())
+	suite.assert.EqualValues(2, bp.nodePools[1].Size())
+	suite.assert.EqualValues(7, bp.Grow(7))
+	suite.assert.EqualValues(6, bp.nodePools[0].Size())
+	suite.assert.EqualValues(5, bp.nodePools[1].Size())
+	suite.assert.EqualValues(9, bp.Grow(100))
+	suite.assert.EqualValues(20, bp.Size())
+
+	bp.Terminate()
+}
+
 func TestBlockPoolSuite(t *testing.T) {
 	suite.Run(t, new(blockpoolTestSuite))
 }
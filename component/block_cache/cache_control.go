@@ -0,0 +1,192 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package block_cache
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/Azure/azure-storage-fuse/v2/internal"
+)
+
+// Blocks of a pinned file are not deleted when disk policy evicts them, they stay on disk untracked until read
+// again. Pins live as long as the mount, blocks left behind are cleaned on next mount like any other stale block.
+
+// isPinned : Whether the file this disk block belongs to is pinned, through the control socket or a cache rule
+func (bc *BlockCache) isPinned(fileName string) bool {
+	path, _, err := parseBlockName(fileName)
+	if err != nil {
+		return false
+	}
+
+	if _, found := bc.pinned.Load(path); found {
+		return true
+	}
+	return bc.cacheRules.Match(path).Pin()
+}
+
+// WarmFile : Read all blocks of the file so that they land in the disk cache
+func (bc *BlockCache) WarmFile(name string) error {
+	log.Trace("BlockCache::WarmFile : %s", name)
+
+	if bc.tmpPath == "" {
+		return fmt.Errorf("%s has no disk cache, configure path to keep blocks ahead of use", bc.Name())
+	}
+
+	handle, err := bc.OpenFile(internal.OpenFileOptions{Name: name, Flags: os.O_RDONLY})
+	if err != nil {
+		log.Err("BlockCache::WarmFile : Failed to open %s [%s]", name, err.Error())
+		return err
+	}
+
+	// Reading sequentially also lets prefetch bring in the blocks ahead
+	data := make([]byte, bc.blockSize)
+	for offset := int64(0); offset < handle.Size; offset += int64(bc.blockSize) {
+		_, err = bc.ReadInBuffer(&internal.ReadInBufferOptions{Handle: handle, Offset: offset, Data: data})
+		if err != nil && err != io.EOF {
+			log.Err("BlockCache::WarmFile : Failed to read %s at offset %v [%s]", name, offset, err.Error())
+			break
+		}
+		err = nil
+	}
+
+	releaseErr := bc.ReleaseFile(internal.ReleaseFileOptions{Handle: handle})
+	if err != nil {
+		return err
+	}
+	return releaseErr
+}
+
+// PinFile : Bring all blocks of the file in disk cache and keep them there irrespective of disk timeout and usage
+func (bc *BlockCache) PinFile(name string) error {
+	log.Trace("BlockCache::PinFile : %s", name)
+
+	bc.pinned.Store(name, struct{}{})
+	err := bc.WarmFile(name)
+	if err != nil {
+		bc.pinned.Delete(name)
+	}
+	return err
+}
+
+// UnpinFile : Let disk policy evict blocks of the file again
+func (bc *BlockCache) UnpinFile(name string) error {
+	log.Trace("BlockCache::UnpinFile : %s", name)
+
+	bc.pinned.Delete(name)
+	return nil
+}
+
+// EvictFile : Unpin the file and remove its blocks from disk cache
+func (bc *BlockCache) EvictFile(name string) error {
+	log.Trace("BlockCache::EvictFile : %s", name)
+
+	bc.pinned.Delete(name)
+	bc.InvalidatePath(name)
+	return nil
+}
+
+// DropCache : Remove all blocks cached on disk except the ones of pinned files. Blocks in memory are held by open
+// handles, so they are left as they are and go back to the pool as the handles are closed.
+func (bc *BlockCache) DropCache() {
+	log.Trace("BlockCache::DropCache")
+
+	if bc.tmpPath == "" {
+		return
+	}
+
+	dropped := 0
+	_ = filepath.WalkDir(bc.tmpPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d == nil {
+			return nil
+		}
+		if d.IsDir() {
+			if bc.isJournalPath(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		fileName := strings.TrimPrefix(path, bc.tmpPath+"/")
+		if fileName == diskIndexFile || bc.isPinned(fileName) {
+			return nil
+		}
+
+		bc.removeDiskBlock(fileName)
+		dropped++
+		return nil
+	})
+
+	log.Info("BlockCache::DropCache : Dropped %d blocks from disk cache", dropped)
+}
+
+// State : Occupancy of block pool and disk cache
+func (bc *BlockCache) State() map[string]any {
+	state := map[string]any{
+		"block-size":      bc.blockSize,
+		"prefetch":        bc.prefetch,
+		"memory-pressure": bc.memoryPressure.Load(),
+		"write-back":      bc.writeBack,
+		"cache-rules":     bc.cacheRules.Len(),
+	}
+
+	if bc.blockPool != nil {
+		state["pool-blocks"] = bc.blockPool.Size()
+		state["pool-usage"] = bc.blockPool.Usage()
+	}
+
+	if bc.tmpPath != "" {
+		blocks, pinned := 0, 0
+		bc.fileNodeMap.Range(func(_, _ any) bool {
+			blocks++
+			return true
+		})
+		bc.pinned.Range(func(_, _ any) bool {
+			pinned++
+			return true
+		})
+
+		state["path"] = bc.tmpPath
+		state["disk-size-mb"] = bc.diskSize / _1MB
+		state["disk-blocks"] = blocks
+		state["pinned"] = pinned
+	}
+
+	return state
+}
@@ -0,0 +1,243 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package block_cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+)
+
+const (
+	// Blocks on disk are always named <path>::<index>, so this name never clashes with a block
+	diskIndexFile    = "blobfuse2_block_index.json"
+	diskIndexVersion = 1
+
+	// xattr on a disk block holding ETag of the blob the block belongs to
+	etagXattr = "user.etag"
+)
+
+// diskIndexEntry : Details of a block cached on disk, recorded in the persisted index
+type diskIndexEntry struct {
+	Path  string `json:"path"`
+	ETag  string `json:"etag"`
+	Index uint64 `json:"index"`
+	Size  int64  `json:"size"`
+}
+
+type diskIndex struct {
+	Version   int              `json:"version"`
+	BlockSize uint64           `json:"block_size"`
+	Entries   []diskIndexEntry `json:"entries"`
+}
+
+// getBlockETag : ETag recorded on a disk block, empty if not available
+func getBlockETag(localPath string) string {
+	buf := make([]byte, 256)
+	size, err := syscall.Getxattr(localPath, etagXattr, buf)
+	if err != nil || size <= 0 {
+		return ""
+	}
+
+	return string(buf[:size])
+}
+
+// setBlockETag : Record ETag of the blob on a disk block, an empty ETag removes the recorded one
+func setBlockETag(localPath string, etag string) {
+	var err error
+	if etag == "" {
+		err = syscall.Removexattr(localPath, etagXattr)
+		if err == syscall.ENODATA {
+			err = nil
+		}
+	} else {
+		err = syscall.Setxattr(localPath, etagXattr, []byte(etag), 0)
+	}
+
+	if err != nil {
+		log.Warn("BlockCache::setBlockETag : Failed to record etag of %s [%s]", localPath, err.Error())
+	}
+}
+
+// parseBlockName : Split a disk block name into blob path and block index
+func parseBlockName(fileName string) (string, uint64, error) {
+	i := strings.LastIndex(fileName, "::")
+	if i < 0 {
+		return "", 0, fmt.Errorf("invalid block name %s", fileName)
+	}
+
+	index, err := strconv.ParseUint(fileName[i+2:], 10, 64)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return fileName[:i], index, nil
+}
+
+// checkBlockETag : Whether a block read from disk belongs to the version of the blob opened by this handle.
+// A block without an ETag, cached before ETags were recorded or on a file system without user xattrs, can not be
+// told apart from one of an older version, so it is dropped as well.
+func checkBlockETag(item *workItem, localPath, fileName string) bool {
+	etag := getBlockETag(localPath)
+	if item.ETag == "" || etag == item.ETag {
+		return true
+	}
+
+	log.Info("BlockCache::checkBlockETag : Blob has changed since %s was cached [%s : %s]", fileName, etag, item.ETag)
+	_ = os.Remove(localPath)
+	return false
+}
+
+// stampCommittedBlocks : Record the new ETag on disk blocks of a file once its block list is committed
+func (bc *BlockCache) stampCommittedBlocks(path string, blocks []int64, etag string) {
+	for _, id := range blocks {
+		localPath := filepath.Join(bc.tmpPath, fmt.Sprintf("%s::%v", path, id))
+		if _, err := os.Stat(localPath); err == nil {
+			setBlockETag(localPath, etag)
+		}
+	}
+}
+
+// saveDiskIndex : Persist the blocks currently tracked by disk policy, so that next mount can reuse them.
+// Blocks without an ETag can not be validated later, hence they are not recorded.
+func (bc *BlockCache) saveDiskIndex() {
+	index := diskIndex{
+		Version:   diskIndexVersion,
+		BlockSize: bc.blockSize,
+		Entries:   make([]diskIndexEntry, 0),
+	}
+
+	bc.fileNodeMap.Range(func(key, _ any) bool {
+		fileName := key.(string)
+		path, id, err := parseBlockName(fileName)
+		if err != nil {
+			return true
+		}
+
+		localPath := filepath.Join(bc.tmpPath, fileName)
+		info, err := os.Stat(localPath)
+		if err != nil {
+			return true
+		}
+
+		etag := getBlockETag(localPath)
+		if etag != "" {
+			index.Entries = append(index.Entries, diskIndexEntry{
+				Path:  path,
+				ETag:  etag,
+				Index: id,
+				Size:  info.Size(),
+			})
+		}
+		return true
+	})
+
+	data, err := json.Marshal(index)
+	if err == nil {
+		indexPath := filepath.Join(bc.tmpPath, diskIndexFile)
+		err = os.WriteFile(indexPath+".tmp", data, os.FileMode(0644))
+		if err == nil {
+			err = os.Rename(indexPath+".tmp", indexPath)
+		}
+	}
+
+	if err != nil {
+		log.Err("BlockCache::saveDiskIndex : Failed to save disk index [%s]", err.Error())
+		return
+	}
+
+	log.Info("BlockCache::saveDiskIndex : Saved %d blocks to disk index", len(index.Entries))
+}
+
+// restoreDiskIndex : Add blocks recorded in the persisted index back to disk policy and delete the rest.
+// A block is restored only if its size and the ETag recorded on it still match the index.
+// Whether the blob itself has changed since, is checked against the handle when the block is read.
+func (bc *BlockCache) restoreDiskIndex() {
+	indexPath := filepath.Join(bc.tmpPath, diskIndexFile)
+
+	index := diskIndex{}
+	data, err := os.ReadFile(indexPath)
+	if err == nil {
+		err = json.Unmarshal(data, &index)
+	}
+
+	if err != nil && !os.IsNotExist(err) {
+		log.Err("BlockCache::restoreDiskIndex : Failed to load disk index [%s]", err.Error())
+	}
+
+	if index.Version != diskIndexVersion || index.BlockSize != bc.blockSize {
+		// Blocks cached with a different block size can not be reused
+		index.Entries = nil
+	}
+
+	restored := make(map[string]bool)
+	for _, entry := range index.Entries {
+		fileName := fmt.Sprintf("%s::%v", entry.Path, entry.Index)
+		localPath := filepath.Join(bc.tmpPath, fileName)
+
+		info, err := os.Stat(localPath)
+		if err != nil || info.Size() != entry.Size || getBlockETag(localPath) != entry.ETag {
+			continue
+		}
+
+		bc.fileNodeMap.Store(fileName, bc.diskPolicy.Add(fileName))
+		restored[localPath] = true
+	}
+
+	// Anything else on disk is not known to belong to any version of the blob
+	removed := 0
+	_ = filepath.WalkDir(bc.tmpPath, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() && bc.isJournalPath(path) {
+			// Journal is not part of the disk cache
+			return filepath.SkipDir
+		}
+
+		if err == nil && !d.IsDir() && !restored[path] && path != indexPath {
+			if os.Remove(path) == nil {
+				removed++
+			}
+		}
+		return nil
+	})
+	_ = os.Remove(indexPath)
+
+	log.Info("BlockCache::restoreDiskIndex : Restored %d blocks from disk index, removed %d blocks", len(restored), removed)
+}
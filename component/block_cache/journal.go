@@ -0,0 +1,530 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package block_cache
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/Azure/azure-storage-fuse/v2/internal"
+	"github.com/Azure/azure-storage-fuse/v2/internal/handlemap"
+)
+
+const (
+	// Journals live in their own directory under block-cache path so that disk cache cleanup leaves them alone
+	journalDirName = ".blobfuse2_journal"
+	journalVersion = 1
+
+	// Journals of files changed by someone else since they were recorded are moved here instead of being replayed
+	journalQuarantineDirName = "quarantine"
+
+	// Handle key holding name of the last journal recorded for this handle
+	journalKeyName = "journal"
+
+	// Handle key set while the last journal still has all the writes done on this handle
+	journalCurrentKeyName = "journalCurrent"
+)
+
+// journalBlock : One block of the file as recorded in the journal.
+// Either the data of the block is saved in the journal or it refers to an already staged or committed block.
+type journalBlock struct {
+	Index      int64    `json:"index"`
+	ID         string   `json:"id,omitempty"`
+	Parts      []string `json:"parts,omitempty"`
+	Committed  bool     `json:"committed"`
+	Size       uint64   `json:"size"`
+	Data       bool     `json:"data"`
+	DataOffset int64    `json:"data_offset"`
+}
+
+// journalEntry : Manifest of a journal, data of the saved blocks goes in a separate file next to it
+type journalEntry struct {
+	Version   int            `json:"version"`
+	Path      string         `json:"path"`
+	Size      int64          `json:"size"`
+	BlockSize uint64         `json:"block_size"`
+	ETag      string         `json:"etag,omitempty"` // Version of the file the journaled writes apply to
+	Blocks    []journalBlock `json:"blocks"`
+}
+
+// errJournalStale : File was changed by someone else after the journal was recorded
+var errJournalStale = errors.New("file changed since the journal was recorded")
+
+func (bc *BlockCache) getJournalDir() string {
+	return filepath.Join(bc.tmpPath, journalDirName)
+}
+
+// isJournalPath : Whether the given path on disk belongs to the journal and not the disk cache
+func (bc *BlockCache) isJournalPath(path string) bool {
+	dir := bc.getJournalDir()
+	return path == dir || strings.HasPrefix(path, dir+"/")
+}
+
+// hasJournal : Whether last mount has left a journal behind in block-cache path
+func (bc *BlockCache) hasJournal() bool {
+	return bc.writeBack && common.DirectoryExists(bc.getJournalDir())
+}
+
+// cleanupDiskCache : Delete the blocks cached on disk, journal is kept as it is yet to be replayed
+func (bc *BlockCache) cleanupDiskCache() error {
+	if !bc.writeBack {
+		return common.TempCacheCleanup(bc.tmpPath)
+	}
+
+	dirents, err := os.ReadDir(bc.tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to list directory contents : %s", err.Error())
+	}
+
+	for _, entry := range dirents {
+		if entry.Name() != journalDirName {
+			_ = os.RemoveAll(filepath.Join(bc.tmpPath, entry.Name()))
+		}
+	}
+
+	return nil
+}
+
+// writeJournalFile : Write the file and make sure it reaches the disk before returning
+func writeJournalFile(path string, write func(io.Writer) error) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(0600))
+	if err != nil {
+		return err
+	}
+
+	err = write(f)
+	if err == nil {
+		err = f.Sync()
+	}
+
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// recordJournal : Save the data of dirty blocks and ids of staged blocks of this handle in a new journal.
+// Journal last recorded for this handle is deleted once the new one is in place.
+// handle lock must be taken before calling this function
+func (bc *BlockCache) recordJournal(handle *handlemap.Handle) error {
+	log.Debug("BlockCache::recordJournal : Recording journal for %v=>%s", handle.ID, handle.Path)
+
+	// Replay can not download the blocks which do not line up with block size, so pull them in now
+	err := bc.rechunkBlocks(handle)
+	if err != nil {
+		return err
+	}
+
+	lst, _ := handle.GetValue("blockList")
+	listMap := lst.(map[int64]*blockInfo)
+
+	// Blocks in memory which are modified or not yet known to be staged need their data saved
+	saved := make(map[int64]*Block)
+	for _, blockList := range []*list.List{handle.Buffers.Cooking, handle.Buffers.Cooked} {
+		for node := blockList.Front(); node != nil; node = node.Next() {
+			block := node.Value.(*Block)
+			if block.id < 0 || block.offset >= uint64(handle.Size) {
+				continue
+			}
+
+			info, found := listMap[block.id]
+			if block.IsDirty() || (found && !info.committed) {
+				saved[block.id] = block
+			}
+		}
+	}
+
+	entry := journalEntry{
+		Version:   journalVersion,
+		Path:      handle.Path,
+		Size:      handle.Size,
+		BlockSize: bc.blockSize,
+		ETag:      bc.journalETag(handle),
+		Blocks:    make([]journalBlock, 0, len(listMap)+len(saved)),
+	}
+
+	for index, info := range listMap {
+		if _, found := saved[index]; !found && uint64(index)*bc.blockSize < uint64(handle.Size) {
+			entry.Blocks = append(entry.Blocks, journalBlock{
+				Index:     index,
+				ID:        info.id,
+				Parts:     info.parts,
+				Committed: info.committed,
+				Size:      info.size,
+			})
+		}
+	}
+
+	indices := make([]int64, 0, len(saved))
+	for index := range saved {
+		indices = append(indices, index)
+	}
+	slices.Sort(indices)
+
+	name := fmt.Sprintf("%020d", bc.journalSeq.Add(1))
+	manifestPath := filepath.Join(bc.getJournalDir(), name+".json")
+	dataPath := filepath.Join(bc.getJournalDir(), name+".data")
+
+	err = os.MkdirAll(bc.getJournalDir(), os.FileMode(0700))
+	if err != nil {
+		return err
+	}
+
+	// Data goes to disk first so that a manifest never refers to data which is not there
+	err = writeJournalFile(dataPath, func(w io.Writer) error {
+		offset := int64(0)
+		for _, index := range indices {
+			block := saved[index]
+			size := bc.getBlockSize(uint64(handle.Size), block)
+			if _, err := w.Write(block.data[:size]); err != nil {
+				return err
+			}
+
+			entry.Blocks = append(entry.Blocks, journalBlock{
+				Index:      index,
+				Size:       size,
+				Data:       true,
+				DataOffset: offset,
+			})
+			offset += int64(size)
+		}
+		return nil
+	})
+
+	if err == nil {
+		var data []byte
+		data, err = json.Marshal(entry)
+		if err == nil {
+			err = writeJournalFile(manifestPath+".tmp", func(w io.Writer) error {
+				_, err := w.Write(data)
+				return err
+			})
+		}
+	}
+
+	if err == nil {
+		err = os.Rename(manifestPath+".tmp", manifestPath)
+	}
+
+	if err != nil {
+		_ = os.Remove(manifestPath + ".tmp")
+		_ = os.Remove(dataPath)
+		return err
+	}
+
+	// New journal covers everything the previous one had
+	bc.removeJournal(handle)
+	handle.SetValue(journalKeyName, name)
+	handle.SetValue(journalCurrentKeyName, true)
+
+	log.Info("BlockCache::recordJournal : Recorded journal %s for %v=>%s with %d saved blocks", name, handle.ID, handle.Path, len(indices))
+	return nil
+}
+
+// deleteJournal : Delete manifest and data of the given journal
+func (bc *BlockCache) deleteJournal(name string) {
+	for _, ext := range []string{".json", ".data"} {
+		err := os.Remove(filepath.Join(bc.getJournalDir(), name+ext))
+		if err != nil && !os.IsNotExist(err) {
+			log.Err("BlockCache::deleteJournal : Failed to delete journal %s [%s]", name+ext, err.Error())
+		}
+	}
+}
+
+// removeJournal : Delete the journal recorded for this handle, once its data is committed it is not needed anymore
+func (bc *BlockCache) removeJournal(handle *handlemap.Handle) {
+	name, found := handle.GetValue(journalKeyName)
+	if !found {
+		return
+	}
+
+	bc.deleteJournal(name.(string))
+	handle.RemoveValue(journalKeyName)
+	handle.RemoveValue(journalCurrentKeyName)
+}
+
+// journalETag : Version of the file the writes on this handle apply to. Handles of created files do not know it yet,
+// so it is looked up once and kept on the handle.
+func (bc *BlockCache) journalETag(handle *handlemap.Handle) string {
+	if etag, found := handle.GetValue("ETAG"); found {
+		return etag.(string)
+	}
+
+	attr, err := bc.NextComponent().GetAttr(internal.GetAttrOptions{Name: handle.Path})
+	if err != nil || attr.ETag == "" {
+		return ""
+	}
+
+	handle.SetValue("ETAG", attr.ETag)
+	return attr.ETag
+}
+
+// quarantineJournal : Move the journal aside so that it is neither replayed nor lost
+func (bc *BlockCache) quarantineJournal(name string) {
+	dir := filepath.Join(bc.getJournalDir(), journalQuarantineDirName)
+	err := os.MkdirAll(dir, os.FileMode(0700))
+	if err != nil {
+		log.Err("BlockCache::quarantineJournal : Failed to create %s [%s]", dir, err.Error())
+		return
+	}
+
+	for _, ext := range []string{".json", ".data"} {
+		err = os.Rename(filepath.Join(bc.getJournalDir(), name+ext), filepath.Join(dir, name+ext))
+		if err != nil && !os.IsNotExist(err) {
+			log.Err("BlockCache::quarantineJournal : Failed to move journal %s [%s]", name+ext, err.Error())
+		}
+	}
+}
+
+// isJournalCurrent : Whether the last journal of this handle already has all the writes done on it
+func isJournalCurrent(handle *handlemap.Handle) bool {
+	_, found := handle.GetValue(journalCurrentKeyName)
+	return found
+}
+
+// listJournals : Names of the journals on disk in the order they were recorded
+func (bc *BlockCache) listJournals() []string {
+	dirents, err := os.ReadDir(bc.getJournalDir())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Err("BlockCache::listJournals : Failed to list journals [%s]", err.Error())
+		}
+		return nil
+	}
+
+	names := make([]string, 0)
+	for _, entry := range dirents {
+		name, found := strings.CutSuffix(entry.Name(), ".json")
+		if !found {
+			continue
+		}
+
+		if _, err := strconv.ParseUint(name, 10, 64); err == nil {
+			names = append(names, name)
+		}
+	}
+
+	// Names are zero padded so lexical order is the order of recording
+	slices.Sort(names)
+	return names
+}
+
+// readJournal : Manifest of the given journal
+func (bc *BlockCache) readJournal(name string) (journalEntry, error) {
+	entry := journalEntry{}
+	data, err := os.ReadFile(filepath.Join(bc.getJournalDir(), name+".json"))
+	if err == nil {
+		err = json.Unmarshal(data, &entry)
+	}
+	return entry, err
+}
+
+// startReplay : Commit the data left behind by the last mount in the journal, in background so that a large journal
+// does not hold up the mount. Files with a journal are not to be opened until it is replayed, see waitReplay.
+// A journal which can not be replayed is kept so that it can be retried on next mount.
+func (bc *BlockCache) startReplay() {
+	names := bc.listJournals()
+
+	// Journals recorded by this mount shall sort after the ones being replayed
+	for _, name := range names {
+		seq, _ := strconv.ParseUint(name, 10, 64)
+		if seq > bc.journalSeq.Load() {
+			bc.journalSeq.Store(seq)
+		}
+	}
+
+	if len(names) == 0 {
+		return
+	}
+
+	entries := make(map[string]journalEntry, len(names))
+	lastJournal := make(map[string]string)
+	for _, name := range names {
+		entry, err := bc.readJournal(name)
+		if err != nil {
+			log.Err("BlockCache::startReplay : Failed to read journal %s [%s]", name, err.Error())
+			continue
+		}
+		entries[name] = entry
+		lastJournal[entry.Path] = name
+	}
+
+	bc.replayLock.Lock()
+	bc.replayPending = make(map[string]chan struct{}, len(lastJournal))
+	for path := range lastJournal {
+		bc.replayPending[path] = make(chan struct{})
+	}
+	bc.replayLock.Unlock()
+
+	bc.replayDone.Go(func() {
+		replayed := 0
+		for _, name := range names {
+			entry, found := entries[name]
+			if !found {
+				continue
+			}
+
+			err := bc.replayJournal(name, entry)
+			switch {
+			case err == nil:
+				bc.deleteJournal(name)
+				replayed++
+			case errors.Is(err, errJournalStale):
+				log.Err("BlockCache::startReplay : Not replaying journal %s of %s, moved it to %s [%s]",
+					name, entry.Path, journalQuarantineDirName, err.Error())
+				bc.quarantineJournal(name)
+			default:
+				log.Err("BlockCache::startReplay : Failed to replay journal %s [%s]", name, err.Error())
+			}
+
+			if lastJournal[entry.Path] == name {
+				bc.replayLock.Lock()
+				close(bc.replayPending[entry.Path])
+				delete(bc.replayPending, entry.Path)
+				bc.replayLock.Unlock()
+			}
+		}
+
+		log.Info("BlockCache::startReplay : Replayed %d of %d journals", replayed, len(names))
+	})
+}
+
+// waitReplay : Wait for the journal left behind for this file by the last mount to be replayed
+func (bc *BlockCache) waitReplay(name string) {
+	bc.replayLock.Lock()
+	done, found := bc.replayPending[name]
+	bc.replayLock.Unlock()
+
+	if found {
+		log.Info("BlockCache::waitReplay : Waiting for journal of %s to be replayed", name)
+		<-done
+	}
+}
+
+// replayJournal : Write the saved blocks of the journal back to the file and commit it
+func (bc *BlockCache) replayJournal(name string, entry journalEntry) error {
+	if entry.Version != journalVersion || entry.BlockSize != bc.blockSize {
+		return fmt.Errorf("journal of %s was recorded with block size %v", entry.Path, entry.BlockSize)
+	}
+
+	attr, err := bc.NextComponent().GetAttr(internal.GetAttrOptions{Name: entry.Path})
+	if os.IsNotExist(err) {
+		// File was deleted after the journal was recorded so there is nothing to commit
+		log.Warn("BlockCache::replayJournal : %s does not exist anymore, dropping journal %s", entry.Path, name)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// Committing over a version written by someone else after the crash would silently lose their data
+	if entry.ETag != "" && attr.ETag != entry.ETag {
+		return fmt.Errorf("%w, etag %s is now %s", errJournalStale, entry.ETag, attr.ETag)
+	}
+
+	f, err := os.Open(filepath.Join(bc.getJournalDir(), name+".data"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	log.Info("BlockCache::replayJournal : Replaying journal %s for %s", name, entry.Path)
+
+	handle := handlemap.NewHandle(entry.Path)
+	handle.Size = entry.Size
+	bc.prepareHandleForBlockCache(handle)
+
+	lst, _ := handle.GetValue("blockList")
+	listMap := lst.(map[int64]*blockInfo)
+	for _, block := range entry.Blocks {
+		if !block.Data {
+			listMap[block.Index] = &blockInfo{
+				id:        block.ID,
+				parts:     block.Parts,
+				committed: block.Committed,
+				size:      block.Size,
+			}
+		}
+	}
+
+	// Saved blocks cover the whole block or reach the end of file so these writes never download anything
+	buf := make([]byte, bc.blockSize)
+	for _, block := range entry.Blocks {
+		if !block.Data {
+			continue
+		}
+
+		_, err = f.ReadAt(buf[:block.Size], block.DataOffset)
+		if err == nil {
+			_, err = bc.WriteFile(&internal.WriteFileOptions{
+				Handle: handle,
+				Offset: block.Index * int64(bc.blockSize),
+				Data:   buf[:block.Size],
+			})
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	// Even if nothing was saved, the staged blocks are still to be committed
+	handle.Flags.Set(handlemap.HandleFlagDirty)
+
+	if err == nil {
+		bc.fileCloseOpt.Add(1)
+		err = bc.releaseFileInternal(internal.ReleaseFileOptions{Handle: handle})
+	}
+
+	if err != nil {
+		// Nothing is to be committed now, just give the blocks back to the pool
+		handle.Flags.Clear(handlemap.HandleFlagDirty)
+		bc.fileCloseOpt.Add(1)
+		_ = bc.releaseFileInternal(internal.ReleaseFileOptions{Handle: handle})
+		return err
+	}
+
+	return nil
+}
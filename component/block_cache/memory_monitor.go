@@ -0,0 +1,133 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package block_cache
+
+import (
+	"time"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/Azure/azure-storage-fuse/v2/internal/stats_manager"
+)
+
+// Stats pushed when the block pool is resized
+const (
+	poolResizeStats = "PoolResize"
+	poolShrinks     = "PoolShrinks"
+	poolGrows       = "PoolGrows"
+	poolBlocks      = "PoolBlocks"
+)
+
+// Interval at which memory pressure is checked
+var memoryCheckInterval = 5 * time.Second
+
+// startMemoryMonitor : Keep resizing the block pool as per the memory pressure until Stop
+func (bc *BlockCache) startMemoryMonitor() {
+	bc.memoryMonitorStop = make(chan struct{})
+	bc.memoryMonitorDone.Add(1)
+
+	go func() {
+		defer bc.memoryMonitorDone.Done()
+
+		ticker := time.NewTicker(memoryCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-bc.memoryMonitorStop:
+				return
+			case <-ticker.C:
+				mp, err := common.GetMemoryPressure()
+				if err != nil {
+					log.Warn("BlockCache::startMemoryMonitor : Failed to read memory pressure [%s]", err.Error())
+					continue
+				}
+				bc.checkMemoryPressure(mp)
+			}
+		}
+	}()
+}
+
+// stopMemoryMonitor : Stop resizing the block pool
+func (bc *BlockCache) stopMemoryMonitor() {
+	if bc.memoryMonitorStop != nil {
+		close(bc.memoryMonitorStop)
+		bc.memoryMonitorDone.Wait()
+		bc.memoryMonitorStop = nil
+	}
+}
+
+// checkMemoryPressure : Shrink the block pool and throttle prefetch under memory pressure, grow the pool back once it is over
+func (bc *BlockCache) checkMemoryPressure(mp *common.MemoryPressure) {
+	pressure := mp.UnderPressure()
+	if bc.memoryPressure.Swap(pressure) != pressure {
+		log.Info("BlockCache::checkMemoryPressure : Memory pressure %t, usage %v of %v, stall %.2f%%", pressure, mp.Usage, mp.Limit, mp.SomeAvg10)
+	}
+
+	size := bc.blockPool.Size()
+	adjust := mp.PoolAdjustment(bc.blockSize, size, bc.blockPool.MinSize(), bc.blockPool.maxBlocks)
+
+	var resized uint32
+	var stat string
+	if adjust < 0 {
+		resized = bc.blockPool.Shrink(uint32(-adjust))
+		stat = poolShrinks
+	} else if adjust > 0 {
+		resized = bc.blockPool.Grow(uint32(adjust))
+		stat = poolGrows
+	}
+
+	if resized == 0 {
+		return
+	}
+
+	log.Info("BlockCache::checkMemoryPressure : Block pool resized from %v to %v blocks", size, bc.blockPool.Size())
+	blockCacheStatsCollector.PushEvents(poolResizeStats, "", map[string]any{
+		"from":     size,
+		"to":       bc.blockPool.Size(),
+		"usage":    mp.Usage,
+		"limit":    mp.Limit,
+		"pressure": mp.SomeAvg10,
+	})
+	blockCacheStatsCollector.UpdateStats(stats_manager.Increment, stat, (int64)(1))
+	blockCacheStatsCollector.UpdateStats(stats_manager.Replace, poolBlocks, (int64)(bc.blockPool.Size()))
+}
+
+// prefetchWindow : Number of blocks a handle may keep prefetched, limited while memory is under pressure
+func (bc *BlockCache) prefetchWindow(t *prefetchTracker) uint32 {
+	if bc.memoryPressure.Load() {
+		return min(t.window, MIN_PREFETCH)
+	}
+	return t.window
+}
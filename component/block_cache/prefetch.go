@@ -0,0 +1,223 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package block_cache
+
+import (
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/Azure/azure-storage-fuse/v2/internal/handlemap"
+	"github.com/Azure/azure-storage-fuse/v2/internal/stats_manager"
+)
+
+// Access pattern detected for a handle based on the block indices it reads
+type accessPattern int
+
+const (
+	patternUnknown accessPattern = iota
+	patternSequential
+	patternStrided
+	patternReverse
+	patternRandom
+)
+
+func (p accessPattern) String() string {
+	switch p {
+	case patternSequential:
+		return "sequential"
+	case patternStrided:
+		return "strided"
+	case patternReverse:
+		return "reverse"
+	case patternRandom:
+		return "random"
+	}
+	return "unknown"
+}
+
+const (
+	// Number of consecutive reads with the same stride needed to confirm a pattern
+	patternConfirmCount = 2
+
+	// Number of reads not matching any stride after which handle is declared random
+	patternRandomCount = 4
+
+	// Key under which the tracker is stored in the handle
+	prefetchTrackerKey = "prefetcher"
+)
+
+// Stats pushed for prefetch efficiency
+const (
+	prefetchStats  = "Prefetch"
+	prefetchHits   = "PrefetchHits"
+	prefetchWasted = "PrefetchWasted"
+)
+
+var blockCacheStatsCollector *stats_manager.StatsCollector
+
+// prefetchTracker : Per handle state to detect the access pattern and size the prefetch window
+type prefetchTracker struct {
+	lastIndex int64         // Last block index read by the application
+	stride    int64         // Distance between last two block indices read
+	streak    int           // Number of consecutive reads done with the same stride
+	misses    int           // Number of reads not matching the stride since last confirmed pattern
+	pattern   accessPattern // Currently detected access pattern
+	next      int64         // Next block index to be prefetched
+	window    uint32        // Number of blocks to keep prefetched for this handle
+	maxWindow uint32        // Upper limit of the window
+	hits      uint64        // Prefetched blocks which were read
+	wasted    uint64        // Prefetched blocks which were dropped without being read
+	disabled  bool          // Prefetch turned off for this path by a cache rule
+}
+
+func newPrefetchTracker(maxWindow uint32) *prefetchTracker {
+	return &prefetchTracker{
+		lastIndex: -1,
+		window:    max(min(MIN_PREFETCH, maxWindow), 1),
+		maxWindow: max(maxWindow, 1),
+	}
+}
+
+// getPrefetchTracker : Get the tracker for this handle, creating one on first use
+func (bc *BlockCache) getPrefetchTracker(handle *handlemap.Handle) *prefetchTracker {
+	val, found := handle.GetValue(prefetchTrackerKey)
+	if found {
+		return val.(*prefetchTracker)
+	}
+
+	window := bc.cacheRules.Match(handle.Path).Prefetch(bc.prefetch)
+	t := newPrefetchTracker(window)
+	t.disabled = (window == 0)
+	handle.SetValue(prefetchTrackerKey, t)
+	return t
+}
+
+// record : Update the detected pattern with the block index being read
+func (t *prefetchTracker) record(index int64) {
+	if t.lastIndex == -1 {
+		t.lastIndex = index
+		return
+	}
+
+	delta := index - t.lastIndex
+	if delta == 0 {
+		// Multiple reads within the same block
+		return
+	}
+	t.lastIndex = index
+
+	if delta == t.stride {
+		t.streak++
+	} else {
+		t.stride = delta
+		t.streak = 1
+	}
+
+	if t.streak >= patternConfirmCount {
+		t.misses = 0
+		switch delta {
+		case 1:
+			t.pattern = patternSequential
+		case -1:
+			t.pattern = patternReverse
+		default:
+			t.pattern = patternStrided
+		}
+		return
+	}
+
+	// A single jump does not break a pattern, keep the last one until enough reads disagree with it
+	t.misses++
+	if t.misses >= patternRandomCount {
+		t.pattern = patternRandom
+	}
+}
+
+// random : Prefetching is of no use for this handle
+func (t *prefetchTracker) random() bool {
+	return t.disabled || t.pattern == patternRandom
+}
+
+// step : Distance between two blocks to be prefetched
+func (t *prefetchTracker) step() int64 {
+	switch t.pattern {
+	case patternReverse:
+		return -1
+	case patternStrided:
+		return t.stride
+	}
+	return 1
+}
+
+// hit : A prefetched block was read, so grow the window
+func (t *prefetchTracker) hit() {
+	t.hits++
+	t.window = min(t.window+1, t.maxWindow)
+}
+
+// waste : A prefetched block was dropped without being read, so shrink the window
+func (t *prefetchTracker) waste() {
+	t.wasted++
+	t.window = max(t.window/2, 1)
+}
+
+// consumeBlock : Account a read on this block if it was brought in by prefetch
+func (t *prefetchTracker) consumeBlock(block *Block) {
+	if block.flags.Clear(BlockFlagPrefetched) {
+		t.hit()
+	}
+}
+
+// dropBlock : Account a block being reused or released, it is a waste if it was prefetched and never read
+func (t *prefetchTracker) dropBlock(block *Block) {
+	if block.flags.Clear(BlockFlagPrefetched) {
+		t.waste()
+	}
+}
+
+// pushPrefetchStats : Publish prefetch efficiency of this handle
+func (bc *BlockCache) pushPrefetchStats(handle *handlemap.Handle, t *prefetchTracker) {
+	if t.hits == 0 && t.wasted == 0 {
+		return
+	}
+
+	log.Debug("BlockCache::pushPrefetchStats : %v=>%s pattern %s, hits %v, wasted %v, window %v",
+		handle.ID, handle.Path, t.pattern, t.hits, t.wasted, t.window)
+
+	blockCacheStatsCollector.PushEvents(prefetchStats, handle.Path, map[string]any{
+		"pattern":      t.pattern.String(),
+		prefetchHits:   t.hits,
+		prefetchWasted: t.wasted,
+	})
+	blockCacheStatsCollector.UpdateStats(stats_manager.Increment, prefetchHits, (int64)(t.hits))
+	blockCacheStatsCollector.UpdateStats(stats_manager.Increment, prefetchWasted, (int64)(t.wasted))
+}
@@ -0,0 +1,156 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package block_cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type prefetchTestSuite struct {
+	suite.Suite
+	assert *assert.Assertions
+}
+
+func (suite *prefetchTestSuite) SetupTest() {
+	suite.assert = assert.New(suite.T())
+}
+
+func (suite *prefetchTestSuite) recordAll(t *prefetchTracker, indices ...int64) {
+	for _, i := range indices {
+		t.record(i)
+	}
+}
+
+func (suite *prefetchTestSuite) TestSequential() {
+	t := newPrefetchTracker(12)
+	suite.assert.Equal(patternUnknown, t.pattern)
+	suite.assert.Equal(int64(1), t.step())
+
+	suite.recordAll(t, 0, 0, 1, 2, 3)
+	suite.assert.Equal(patternSequential, t.pattern)
+	suite.assert.Equal(int64(1), t.step())
+	suite.assert.False(t.random())
+}
+
+func (suite *prefetchTestSuite) TestReverse() {
+	t := newPrefetchTracker(12)
+	suite.recordAll(t, 20, 19, 18, 17)
+	suite.assert.Equal(patternReverse, t.pattern)
+	suite.assert.Equal(int64(-1), t.step())
+}
+
+func (suite *prefetchTestSuite) TestStrided() {
+	t := newPrefetchTracker(12)
+	suite.recordAll(t, 0, 4, 8, 12)
+	suite.assert.Equal(patternStrided, t.pattern)
+	suite.assert.Equal(int64(4), t.step())
+
+	// Strides work in reverse direction as well
+	t = newPrefetchTracker(12)
+	suite.recordAll(t, 30, 27, 24)
+	suite.assert.Equal(patternStrided, t.pattern)
+	suite.assert.Equal(int64(-3), t.step())
+}
+
+func (suite *prefetchTestSuite) TestRandom() {
+	t := newPrefetchTracker(12)
+	suite.recordAll(t, 7, 42, 3, 91, 15)
+	suite.assert.Equal(patternRandom, t.pattern)
+	suite.assert.True(t.random())
+
+	// Handle going back to sequential reads is detected again
+	suite.recordAll(t, 16, 17)
+	suite.assert.Equal(patternSequential, t.pattern)
+}
+
+func (suite *prefetchTestSuite) TestSingleJumpKeepsPattern() {
+	t := newPrefetchTracker(12)
+	suite.recordAll(t, 0, 1, 2, 3, 50, 51)
+	suite.assert.Equal(patternSequential, t.pattern)
+}
+
+func (suite *prefetchTestSuite) TestWindow() {
+	t := newPrefetchTracker(12)
+	suite.assert.Equal(uint32(MIN_PREFETCH), t.window)
+
+	for range 20 {
+		t.hit()
+	}
+	suite.assert.Equal(uint32(12), t.window)
+	suite.assert.Equal(uint64(20), t.hits)
+
+	t.waste()
+	suite.assert.Equal(uint32(6), t.window)
+	for range 10 {
+		t.waste()
+	}
+	suite.assert.Equal(uint32(1), t.window)
+	suite.assert.Equal(uint64(11), t.wasted)
+
+	// Window never exceeds the configured prefetch count
+	t = newPrefetchTracker(2)
+	suite.assert.Equal(uint32(2), t.window)
+	t = newPrefetchTracker(0)
+	suite.assert.Equal(uint32(1), t.window)
+}
+
+func (suite *prefetchTestSuite) TestBlockAccounting() {
+	t := newPrefetchTracker(12)
+	block := &Block{}
+
+	// Block downloaded on demand is neither a hit nor a waste
+	t.consumeBlock(block)
+	t.dropBlock(block)
+	suite.assert.Equal(uint64(0), t.hits)
+	suite.assert.Equal(uint64(0), t.wasted)
+
+	block.flags.Set(BlockFlagPrefetched)
+	t.consumeBlock(block)
+	t.consumeBlock(block)
+	t.dropBlock(block)
+	suite.assert.Equal(uint64(1), t.hits)
+	suite.assert.Equal(uint64(0), t.wasted)
+
+	block.flags.Set(BlockFlagPrefetched)
+	t.dropBlock(block)
+	suite.assert.Equal(uint64(1), t.hits)
+	suite.assert.Equal(uint64(1), t.wasted)
+}
+
+func TestPrefetchSuite(t *testing.T) {
+	suite.Run(t, new(prefetchTestSuite))
+}
@@ -0,0 +1,312 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package block_cache
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/Azure/azure-storage-fuse/v2/internal/handlemap"
+	"github.com/Azure/azure-storage-fuse/v2/internal/stats_manager"
+)
+
+const (
+	// Key under which the shared table key of the file is stored in the handle
+	sharedKeyName = "sharedKey"
+
+	// Key under which the blocks borrowed from other handles are stored in the handle
+	borrowedKeyName = "borrowed"
+
+	// Number of blocks a handle holds from other handles, owner can not reuse these till they are returned
+	maxBorrowedBlocks = 2
+
+	// Stats for blocks served from downloads of other handles
+	sharedBlockHits = "SharedBlockHits"
+)
+
+// sharedBlock : A block downloaded by one handle which other handles of the same file version can read
+type sharedBlock struct {
+	key       string        // Key of the file in the shared table
+	index     int64         // Index of the block in the file
+	block     *Block        // Block holding the data, owned by the handle which lined up the download
+	done      chan struct{} // Closed once the download completes
+	completed bool          // Download has completed, done is closed
+	failed    bool          // Download has failed and data is not usable
+	refCnt    int32         // Number of other handles reading this block
+	orphan    bool          // Owner has given up the block, last reader shall return it to the pool
+}
+
+// sharedBlockTable : Blocks of files opened in read-only mode, keyed by path and version of the file
+// so that concurrent readers of the same file reuse in-flight and completed downloads
+type sharedBlockTable struct {
+	sync.Mutex
+	files map[string]map[int64]*sharedBlock
+}
+
+func newSharedBlockTable() *sharedBlockTable {
+	return &sharedBlockTable{
+		files: make(map[string]map[int64]*sharedBlock),
+	}
+}
+
+// getSharedKey : Key identifying this version of the file, empty if handle can not share blocks
+func getSharedKey(handle *handlemap.Handle) string {
+	key, found := handle.GetValue(sharedKeyName)
+	if !found {
+		return ""
+	}
+	return key.(string)
+}
+
+// setSharedKey : Allow a read-only handle to share blocks with other handles of the same file version
+func setSharedKey(handle *handlemap.Handle, flags int, etag string) {
+	if flags&(os.O_WRONLY|os.O_RDWR|os.O_APPEND|os.O_TRUNC) != 0 {
+		return
+	}
+
+	if etag != "" {
+		handle.SetValue(sharedKeyName, fmt.Sprintf("%s::%s", handle.Path, etag))
+	} else {
+		// Not every backend returns an ETag so fall back to size and modified time to identify the version
+		handle.SetValue(sharedKeyName, fmt.Sprintf("%s::%v::%v", handle.Path, handle.Size, handle.Mtime.UnixNano()))
+	}
+}
+
+// publish : Make a block being downloaded for this handle available to other handles
+func (t *sharedBlockTable) publish(key string, block *Block) {
+	t.Lock()
+	defer t.Unlock()
+
+	blocks, found := t.files[key]
+	if !found {
+		blocks = make(map[int64]*sharedBlock)
+		t.files[key] = blocks
+	}
+
+	if _, found = blocks[block.id]; found {
+		// Some other handle is already downloading this block
+		return
+	}
+
+	entry := &sharedBlock{
+		key:   key,
+		index: block.id,
+		block: block,
+		done:  make(chan struct{}),
+	}
+	blocks[block.id] = entry
+	block.shared = entry
+}
+
+// exists : Check whether some handle holds this block
+func (t *sharedBlockTable) exists(key string, index int64) bool {
+	t.Lock()
+	defer t.Unlock()
+
+	entry, found := t.files[key][index]
+	return found && !entry.failed
+}
+
+// borrow : Take a reference on the block if some handle holds it
+func (t *sharedBlockTable) borrow(key string, index int64) *sharedBlock {
+	t.Lock()
+	defer t.Unlock()
+
+	entry, found := t.files[key][index]
+	if !found || entry.failed {
+		return nil
+	}
+
+	entry.refCnt++
+	return entry
+}
+
+// unborrow : Drop the reference on the block, returns true if caller shall release the block to the pool
+func (t *sharedBlockTable) unborrow(entry *sharedBlock) bool {
+	t.Lock()
+	defer t.Unlock()
+
+	entry.refCnt--
+	return entry.refCnt == 0 && entry.orphan
+}
+
+// complete : Mark download of the block as done so that waiting readers can proceed
+func (t *sharedBlockTable) complete(block *Block, failed bool) {
+	t.Lock()
+	defer t.Unlock()
+
+	entry := block.shared
+	if entry == nil || entry.completed {
+		return
+	}
+
+	entry.failed = failed
+	entry.completed = true
+	close(entry.done)
+
+	if failed {
+		t.remove(entry)
+	}
+}
+
+// disown : Owner is giving up the block, returns true if other handles still read it and owner shall not reuse it
+func (t *sharedBlockTable) disown(block *Block) bool {
+	t.Lock()
+	defer t.Unlock()
+
+	entry := block.shared
+	if entry == nil {
+		return false
+	}
+	block.shared = nil
+	t.remove(entry)
+
+	if !entry.completed {
+		entry.failed = true
+		entry.completed = true
+		close(entry.done)
+	}
+
+	if entry.refCnt > 0 {
+		entry.orphan = true
+		return true
+	}
+	return false
+}
+
+// remove : Delete the entry from table so that no new reader can find it
+func (t *sharedBlockTable) remove(entry *sharedBlock) {
+	blocks := t.files[entry.key]
+	if blocks[entry.index] == entry {
+		delete(blocks, entry.index)
+		if len(blocks) == 0 {
+			delete(t.files, entry.key)
+		}
+	}
+}
+
+// borrowedBlocks : Blocks a handle is reading from downloads of other handles, oldest first
+type borrowedBlocks struct {
+	blocks map[uint64]*sharedBlock
+	order  []uint64
+}
+
+func getBorrowedBlocks(handle *handlemap.Handle) *borrowedBlocks {
+	val, found := handle.GetValue(borrowedKeyName)
+	if found {
+		return val.(*borrowedBlocks)
+	}
+
+	b := &borrowedBlocks{
+		blocks: make(map[uint64]*sharedBlock),
+	}
+	handle.SetValue(borrowedKeyName, b)
+	return b
+}
+
+// recycleBlock : Release a block of this handle to the pool unless other handles are still reading it
+func (bc *BlockCache) recycleBlock(block *Block) {
+	if bc.sharedBlocks.disown(block) {
+		// Last reader of this block will release it
+		return
+	}
+
+	block.ReUse()
+	bc.blockPool.Release(block)
+}
+
+// returnSharedBlock : Drop the reference this handle has on a block downloaded by some other handle
+func (bc *BlockCache) returnSharedBlock(entry *sharedBlock) {
+	if bc.sharedBlocks.unborrow(entry) {
+		entry.block.node = nil
+		entry.block.ReUse()
+		bc.blockPool.Release(entry.block)
+	}
+}
+
+// getSharedBlock : Get the block from another handle reading the same version of this file
+func (bc *BlockCache) getSharedBlock(handle *handlemap.Handle, index uint64) *Block {
+	key := getSharedKey(handle)
+	if key == "" {
+		return nil
+	}
+
+	borrowed := getBorrowedBlocks(handle)
+	if entry, found := borrowed.blocks[index]; found {
+		return entry.block
+	}
+
+	entry := bc.sharedBlocks.borrow(key, int64(index))
+	if entry == nil {
+		return nil
+	}
+
+	// Block may still be under download by the other handle
+	<-entry.done
+	if entry.failed {
+		bc.returnSharedBlock(entry)
+		return nil
+	}
+
+	log.Debug("BlockCache::getSharedBlock : Reusing block %v of %v=>%s downloaded by another handle", index, handle.ID, handle.Path)
+	blockCacheStatsCollector.UpdateStats(stats_manager.Increment, sharedBlockHits, (int64)(1))
+
+	// Reader has moved on from the older blocks so let the owners reuse them
+	for len(borrowed.order) >= maxBorrowedBlocks {
+		oldest := borrowed.order[0]
+		borrowed.order = borrowed.order[1:]
+		bc.returnSharedBlock(borrowed.blocks[oldest])
+		delete(borrowed.blocks, oldest)
+	}
+
+	borrowed.blocks[index] = entry
+	borrowed.order = append(borrowed.order, index)
+	return entry.block
+}
+
+// returnSharedBlocks : Drop all the blocks this handle is reading from other handles
+func (bc *BlockCache) returnSharedBlocks(handle *handlemap.Handle) {
+	val, found := handle.GetValue(borrowedKeyName)
+	if !found {
+		return
+	}
+
+	borrowed := val.(*borrowedBlocks)
+	for _, entry := range borrowed.blocks {
+		bc.returnSharedBlock(entry)
+	}
+	handle.RemoveValue(borrowedKeyName)
+}
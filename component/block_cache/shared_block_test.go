@@ -0,0 +1,163 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package block_cache
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-storage-fuse/v2/internal/handlemap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type sharedBlockTestSuite struct {
+	suite.Suite
+	assert *assert.Assertions
+}
+
+func (suite *sharedBlockTestSuite) SetupTest() {
+	suite.assert = assert.New(suite.T())
+}
+
+func (suite *sharedBlockTestSuite) TestSharedKey() {
+	handle := handlemap.NewHandle("a/b.parquet")
+	setSharedKey(handle, os.O_RDONLY, "0x8D")
+	suite.assert.Equal("a/b.parquet::0x8D", getSharedKey(handle))
+
+	// Without ETag size and modified time identify the version
+	handle = handlemap.NewHandle("a/b.parquet")
+	handle.Size = 10
+	handle.Mtime = time.Unix(0, 20)
+	setSharedKey(handle, os.O_RDONLY, "")
+	suite.assert.Equal("a/b.parquet::10::20", getSharedKey(handle))
+
+	// Handles which can write never share
+	for _, flags := range []int{os.O_WRONLY, os.O_RDWR, os.O_RDONLY | os.O_TRUNC, os.O_WRONLY | os.O_APPEND} {
+		handle = handlemap.NewHandle("a/b.parquet")
+		setSharedKey(handle, flags, "0x8D")
+		suite.assert.Empty(getSharedKey(handle))
+	}
+}
+
+func (suite *sharedBlockTestSuite) TestPublishBorrow() {
+	t := newSharedBlockTable()
+	block := &Block{id: 3}
+
+	suite.assert.Nil(t.borrow("f::1", 3))
+	t.publish("f::1", block)
+	suite.assert.NotNil(block.shared)
+	suite.assert.True(t.exists("f::1", 3))
+	suite.assert.False(t.exists("f::2", 3))
+
+	// Second download of the same block does not replace the first one
+	other := &Block{id: 3}
+	t.publish("f::1", other)
+	suite.assert.Nil(other.shared)
+
+	entry := t.borrow("f::1", 3)
+	suite.assert.NotNil(entry)
+	suite.assert.Equal(block, entry.block)
+
+	select {
+	case <-entry.done:
+		suite.assert.Fail("download is not complete yet")
+	default:
+	}
+
+	t.complete(block, false)
+	<-entry.done
+	suite.assert.False(entry.failed)
+
+	// Owner gives up while nobody else reads it, so owner can reuse the block
+	suite.assert.False(t.unborrow(entry))
+	suite.assert.False(t.disown(block))
+	suite.assert.Nil(block.shared)
+	suite.assert.False(t.exists("f::1", 3))
+	suite.assert.Empty(t.files)
+}
+
+func (suite *sharedBlockTestSuite) TestOrphan() {
+	t := newSharedBlockTable()
+	block := &Block{id: 0}
+	t.publish("f::1", block)
+	t.complete(block, false)
+
+	first := t.borrow("f::1", 0)
+	second := t.borrow("f::1", 0)
+
+	// Readers still hold the block so owner must leave it to them
+	suite.assert.True(t.disown(block))
+	suite.assert.Nil(t.borrow("f::1", 0))
+
+	suite.assert.False(t.unborrow(first))
+	suite.assert.True(t.unborrow(second))
+}
+
+func (suite *sharedBlockTestSuite) TestFailed() {
+	t := newSharedBlockTable()
+	block := &Block{id: 0}
+	t.publish("f::1", block)
+
+	entry := t.borrow("f::1", 0)
+	t.complete(block, true)
+	<-entry.done
+	suite.assert.True(entry.failed)
+	suite.assert.False(t.exists("f::1", 0))
+	suite.assert.Nil(t.borrow("f::1", 0))
+
+	suite.assert.True(t.disown(block))
+	suite.assert.True(t.unborrow(entry))
+}
+
+func (suite *sharedBlockTestSuite) TestDisownInFlight() {
+	t := newSharedBlockTable()
+	block := &Block{id: 0}
+	t.publish("f::1", block)
+	entry := t.borrow("f::1", 0)
+
+	// Waiting readers are released even if owner gives up before download completes
+	suite.assert.True(t.disown(block))
+	<-entry.done
+	suite.assert.True(entry.failed)
+
+	// Late completion from the worker is ignored
+	t.complete(block, false)
+	suite.assert.True(t.unborrow(entry))
+}
+
+func TestSharedBlockSuite(t *testing.T) {
+	suite.Run(t, new(sharedBlockTestSuite))
+}
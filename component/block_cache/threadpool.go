@@ -36,6 +36,8 @@ package block_cache
 import (
 	"sync"
 
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
 	"github.com/Azure/azure-storage-fuse/v2/internal/handlemap"
 )
 
@@ -50,15 +52,18 @@ type ThreadPool struct {
 	// Wait group to wait for all workers to finish
 	wg sync.WaitGroup
 
-	// Channel to hold pending requests
-	priorityCh chan *workItem
-	normalCh   chan *workItem
+	// Channels to hold pending requests, one per NUMA node the workers are split across
+	priorityCh []chan *workItem
+	normalCh   []chan *workItem
 
 	// Reader method that will actually read the data
 	reader func(*workItem)
 
 	// Writer method that will actually write the data
 	writer func(*workItem)
+
+	// Allocator of the blocks, set when workers are pinned to the NUMA nodes blocks are bound to
+	allocator *common.BufferAllocator
 }
 
 // One workitem to be scheduled
@@ -87,24 +92,50 @@ func newThreadPool(count uint32, reader func(*workItem), writer func(*workItem))
 		return nil
 	}
 
-	return &ThreadPool{
-		worker:     count,
-		reader:     reader,
-		writer:     writer,
-		close:      make(chan int, count),
-		priorityCh: make(chan *workItem, count*2),
-		normalCh:   make(chan *workItem, count*5000),
+	t := &ThreadPool{
+		worker: count,
+		reader: reader,
+		writer: writer,
+	}
+	t.makeChannels(1)
+	return t
+}
+
+func (t *ThreadPool) makeChannels(nodes int) {
+	perNode := t.worker / uint32(nodes)
+	t.close = make(chan int, t.worker)
+	t.priorityCh = make([]chan *workItem, nodes)
+	t.normalCh = make([]chan *workItem, nodes)
+	for i := range nodes {
+		t.priorityCh[i] = make(chan *workItem, perNode*2)
+		t.normalCh[i] = make(chan *workItem, perNode*5000)
+	}
+}
+
+// bindNodes splits the workers across the NUMA nodes of the allocator and pins each to the CPUs of its node, so that
+// blocks are filled and drained by workers local to their memory. To be called before Start.
+func (t *ThreadPool) bindNodes(allocator *common.BufferAllocator) {
+	nodes := len(allocator.Nodes())
+	if nodes < 2 {
+		return
 	}
+
+	// Every node needs workers of its own, as its blocks are not handed to others
+	t.allocator = allocator
+	t.worker = max(t.worker-t.worker%uint32(nodes), uint32(nodes))
+	t.makeChannels(nodes)
 }
 
 // Start all the workers and wait till they start receiving requests
 func (t *ThreadPool) Start() {
-	// 10% threads will listne only on high priority channel
-	highPriority := (t.worker * 10) / 100
+	nodes := uint32(len(t.normalCh))
+
+	// 10% threads of each node will listne only on high priority channel
+	highPriority := (t.worker / nodes * 10) / 100
 
 	for i := uint32(0); i < t.worker; i++ {
 		t.wg.Add(1)
-		go t.Do(i < highPriority)
+		go t.Do(i/nodes < highPriority, int(i%nodes))
 	}
 }
 
@@ -117,30 +148,48 @@ func (t *ThreadPool) Stop() {
 	t.wg.Wait()
 
 	close(t.close)
-	close(t.priorityCh)
-	close(t.normalCh)
+	for i := range t.normalCh {
+		close(t.priorityCh[i])
+		close(t.normalCh[i])
+	}
 }
 
 // Schedule the download of a block
 func (t *ThreadPool) Schedule(urgent bool, item *workItem) {
+	// Block is handed to the workers of the node it is bound to
+	node := 0
+	if item.block != nil && item.block.numa < len(t.normalCh) {
+		node = item.block.numa
+	}
+
 	// urgent specifies the priority of this task.
 	// true means high priority and false means low priority
 	if urgent {
-		t.priorityCh <- item
+		t.priorityCh[node] <- item
 	} else {
-		t.normalCh <- item
+		t.normalCh[node] <- item
 	}
 }
 
 // Do is the core task to be executed by each worker thread
-func (t *ThreadPool) Do(priority bool) {
+func (t *ThreadPool) Do(priority bool, node int) {
 	defer t.wg.Done()
 
+	if t.allocator != nil {
+		// Thread stays pinned to the node, so it is not unlocked and exits along with this worker
+		err := t.allocator.LockToNode(node)
+		if err != nil {
+			log.Warn("ThreadPool::Do : Failed to pin worker to NUMA node %v [%s]", t.allocator.Nodes()[node], err.Error())
+		}
+	}
+
+	priorityCh, normalCh := t.priorityCh[node], t.normalCh[node]
+
 	if priority {
 		// This thread will work only on high priority channel
 		for {
 			select {
-			case item := <-t.priorityCh:
+			case item := <-priorityCh:
 				if item.upload {
 					t.writer(item)
 				} else {
@@ -154,13 +203,13 @@ func (t *ThreadPool) Do(priority bool) {
 		// This thread will work only on both high and low priority channel
 		for {
 			select {
-			case item := <-t.priorityCh:
+			case item := <-priorityCh:
 				if item.upload {
 					t.writer(item)
 				} else {
 					t.reader(item)
 				}
-			case item := <-t.normalCh:
+			case item := <-normalCh:
 				if item.upload {
 					t.writer(item)
 				} else {
@@ -169,6 +169,35 @@ func (suite *threadPoolTestSuite) TestPriorityScheduleWithWriter() {
 	tp.Stop()
 }
 
+func (suite *threadPoolTestSuite) TestNodeSchedule() {
+	suite.assert = assert.New(suite.T())
+
+	callbackCnt := int32(0)
+	r := func(i *workItem) {
+		atomic.AddInt32(&callbackCnt, 1)
+	}
+
+	// Workers split across two nodes, not pinned as the host may not have them
+	tp := newThreadPool(4, r, nil)
+	suite.assert.NotNil(tp)
+	tp.makeChannels(2)
+
+	// Work goes to the workers of the node the block is bound to
+	for i := range 10 {
+		tp.Schedule(i < 2, &workItem{block: &Block{numa: 1}})
+	}
+	tp.Schedule(false, &workItem{})
+	suite.assert.Len(tp.priorityCh[1], 2)
+	suite.assert.Len(tp.normalCh[1], 8)
+	suite.assert.Len(tp.normalCh[0], 1)
+
+	tp.Start()
+	suite.assert.Eventually(func() bool {
+		return atomic.LoadInt32(&callbackCnt) == 11
+	}, 2*time.Second, 10*time.Millisecond)
+	tp.Stop()
+}
+
 func TestThreadPoolSuite(t *testing.T) {
 	suite.Run(t, new(threadPoolTestSuite))
 }
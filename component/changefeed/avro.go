@@ -0,0 +1,496 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package changefeed
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Change feed segments are stored in Avro object container files (OCF).
+// Only the subset of the specification needed to read them is implemented here:
+// all primitive and complex types, with 'null' and 'deflate' codecs.
+
+var avroMagic = []byte{'O', 'b', 'j', 1}
+
+const avroSyncSize = 16
+
+// errAvroShortRead is returned when the data ends in the middle of a value.
+// While tailing a segment this just means the block has not been completely written yet.
+var errAvroShortRead = errors.New("avro: unexpected end of data")
+
+// avroSchema is the parsed representation of an avro schema
+type avroSchema struct {
+	kind    string        // primitive type name or record/enum/array/map/fixed/union
+	fields  []avroField   // fields of a record
+	items   *avroSchema   // item type of an array or value type of a map
+	symbols []string      // symbols of an enum
+	size    int           // size of a fixed
+	union   []*avroSchema // branches of a union
+}
+
+type avroField struct {
+	name   string
+	schema *avroSchema
+}
+
+// avroFile holds the header of an object container file
+type avroFile struct {
+	schema *avroSchema
+	codec  string
+	sync   []byte
+}
+
+// parseAvroSchema parses the JSON representation of a schema
+func parseAvroSchema(data []byte) (*avroSchema, error) {
+	var raw any
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("avro: invalid schema [%s]", err.Error())
+	}
+
+	return parseSchemaNode(raw, "", map[string]*avroSchema{})
+}
+
+func parseSchemaNode(raw any, namespace string, named map[string]*avroSchema) (*avroSchema, error) {
+	switch v := raw.(type) {
+	case string:
+		switch v {
+		case "null", "boolean", "int", "long", "float", "double", "bytes", "string":
+			return &avroSchema{kind: v}, nil
+		}
+
+		if s, ok := named[v]; ok {
+			return s, nil
+		}
+		if s, ok := named[fullName(v, namespace)]; ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("avro: unknown type %s", v)
+
+	case []any:
+		s := &avroSchema{kind: "union"}
+		for _, b := range v {
+			branch, err := parseSchemaNode(b, namespace, named)
+			if err != nil {
+				return nil, err
+			}
+			s.union = append(s.union, branch)
+		}
+		return s, nil
+
+	case map[string]any:
+		kind, _ := v["type"].(string)
+		if ns, ok := v["namespace"].(string); ok && ns != "" {
+			namespace = ns
+		}
+
+		switch kind {
+		case "record", "error":
+			s := &avroSchema{kind: "record"}
+			registerNamedSchema(v, namespace, s, named)
+
+			fields, _ := v["fields"].([]any)
+			for _, f := range fields {
+				fm, ok := f.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("avro: invalid record field")
+				}
+				fs, err := parseSchemaNode(fm["type"], namespace, named)
+				if err != nil {
+					return nil, err
+				}
+				name, _ := fm["name"].(string)
+				s.fields = append(s.fields, avroField{name: name, schema: fs})
+			}
+			return s, nil
+
+		case "enum":
+			s := &avroSchema{kind: "enum"}
+			registerNamedSchema(v, namespace, s, named)
+			symbols, _ := v["symbols"].([]any)
+			for _, sym := range symbols {
+				name, _ := sym.(string)
+				s.symbols = append(s.symbols, name)
+			}
+			return s, nil
+
+		case "fixed":
+			s := &avroSchema{kind: "fixed"}
+			registerNamedSchema(v, namespace, s, named)
+			size, _ := v["size"].(float64)
+			s.size = int(size)
+			return s, nil
+
+		case "array":
+			items, err := parseSchemaNode(v["items"], namespace, named)
+			if err != nil {
+				return nil, err
+			}
+			return &avroSchema{kind: "array", items: items}, nil
+
+		case "map":
+			values, err := parseSchemaNode(v["values"], namespace, named)
+			if err != nil {
+				return nil, err
+			}
+			return &avroSchema{kind: "map", items: values}, nil
+
+		default:
+			// Primitive written in the object form, logical types are read as their underlying type
+			return parseSchemaNode(v["type"], namespace, named)
+		}
+	}
+
+	return nil, fmt.Errorf("avro: invalid schema node %v", raw)
+}
+
+func fullName(name string, namespace string) string {
+	if strings.Contains(name, ".") || namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+// registerNamedSchema makes a named type available for reference by both its short and full name
+func registerNamedSchema(v map[string]any, namespace string, s *avroSchema, named map[string]*avroSchema) {
+	name, _ := v["name"].(string)
+	if name == "" {
+		return
+	}
+	named[fullName(name, namespace)] = s
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		named[name[idx+1:]] = s
+	} else {
+		named[name] = s
+	}
+}
+
+// avroDecoder reads avro binary encoded values from a buffer
+type avroDecoder struct {
+	buf []byte
+	pos int
+}
+
+func (d *avroDecoder) readLong() (int64, error) {
+	var v uint64
+	var shift uint
+	for {
+		if d.pos >= len(d.buf) {
+			return 0, errAvroShortRead
+		}
+		b := d.buf[d.pos]
+		d.pos++
+		v |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+		shift += 7
+		if shift > 63 {
+			return 0, fmt.Errorf("avro: varint overflow")
+		}
+	}
+	// zig-zag decoding
+	return int64(v>>1) ^ -int64(v&1), nil
+}
+
+func (d *avroDecoder) readFixed(n int) ([]byte, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("avro: negative length %d", n)
+	}
+	if d.pos+n > len(d.buf) {
+		return nil, errAvroShortRead
+	}
+	b := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *avroDecoder) readBytes() ([]byte, error) {
+	n, err := d.readLong()
+	if err != nil {
+		return nil, err
+	}
+	return d.readFixed(int(n))
+}
+
+func (d *avroDecoder) readString() (string, error) {
+	b, err := d.readBytes()
+	return string(b), err
+}
+
+// readBlockCount reads the item count of a block of an array or map.
+// A negative count is followed by the size of the block in bytes, which is not needed here.
+func (d *avroDecoder) readBlockCount() (int64, error) {
+	n, err := d.readLong()
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		n = -n
+		if _, err = d.readLong(); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// readValue decodes a value of the given schema.
+// Records and maps are returned as map[string]any, arrays as []any and enums as their symbol.
+func (d *avroDecoder) readValue(s *avroSchema) (any, error) {
+	switch s.kind {
+	case "null":
+		return nil, nil
+
+	case "boolean":
+		b, err := d.readFixed(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0] != 0, nil
+
+	case "int":
+		v, err := d.readLong()
+		return int32(v), err
+
+	case "long":
+		return d.readLong()
+
+	case "float":
+		b, err := d.readFixed(4)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(b)), nil
+
+	case "double":
+		b, err := d.readFixed(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+
+	case "bytes":
+		return d.readBytes()
+
+	case "string":
+		return d.readString()
+
+	case "fixed":
+		return d.readFixed(s.size)
+
+	case "enum":
+		idx, err := d.readLong()
+		if err != nil {
+			return nil, err
+		}
+		if idx < 0 || int(idx) >= len(s.symbols) {
+			return nil, fmt.Errorf("avro: invalid enum index %d", idx)
+		}
+		return s.symbols[idx], nil
+
+	case "union":
+		idx, err := d.readLong()
+		if err != nil {
+			return nil, err
+		}
+		if idx < 0 || int(idx) >= len(s.union) {
+			return nil, fmt.Errorf("avro: invalid union index %d", idx)
+		}
+		return d.readValue(s.union[idx])
+
+	case "record":
+		rec := make(map[string]any, len(s.fields))
+		for _, f := range s.fields {
+			v, err := d.readValue(f.schema)
+			if err != nil {
+				return nil, err
+			}
+			rec[f.name] = v
+		}
+		return rec, nil
+
+	case "array":
+		arr := make([]any, 0)
+		for {
+			n, err := d.readBlockCount()
+			if err != nil {
+				return nil, err
+			}
+			if n == 0 {
+				return arr, nil
+			}
+			for range n {
+				v, err := d.readValue(s.items)
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, v)
+			}
+		}
+
+	case "map":
+		m := make(map[string]any)
+		for {
+			n, err := d.readBlockCount()
+			if err != nil {
+				return nil, err
+			}
+			if n == 0 {
+				return m, nil
+			}
+			for range n {
+				k, err := d.readString()
+				if err != nil {
+					return nil, err
+				}
+				v, err := d.readValue(s.items)
+				if err != nil {
+					return nil, err
+				}
+				m[k] = v
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("avro: unsupported type %s", s.kind)
+}
+
+// parseAvroHeader parses the header of an object container file and returns the offset of the first data block
+func parseAvroHeader(data []byte) (*avroFile, int, error) {
+	if len(data) < len(avroMagic) {
+		return nil, 0, errAvroShortRead
+	}
+	if !bytes.Equal(data[:len(avroMagic)], avroMagic) {
+		return nil, 0, fmt.Errorf("avro: not an object container file")
+	}
+
+	d := &avroDecoder{buf: data, pos: len(avroMagic)}
+	meta, err := d.readValue(&avroSchema{kind: "map", items: &avroSchema{kind: "bytes"}})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sync, err := d.readFixed(avroSyncSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	f := &avroFile{
+		codec: "null",
+		sync:  bytes.Clone(sync),
+	}
+
+	metaMap := meta.(map[string]any)
+	if codec, ok := metaMap["avro.codec"].([]byte); ok && len(codec) > 0 {
+		f.codec = string(codec)
+	}
+	if f.codec != "null" && f.codec != "deflate" {
+		return nil, 0, fmt.Errorf("avro: unsupported codec %s", f.codec)
+	}
+
+	schema, ok := metaMap["avro.schema"].([]byte)
+	if !ok {
+		return nil, 0, fmt.Errorf("avro: schema missing in header")
+	}
+	f.schema, err = parseAvroSchema(schema)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return f, d.pos, nil
+}
+
+// readBlocks decodes all complete data blocks present in data.
+// It returns the decoded records and the number of bytes consumed; an incomplete trailing block is left unread.
+func (f *avroFile) readBlocks(data []byte) ([]any, int, error) {
+	records := make([]any, 0)
+	consumed := 0
+
+	for consumed < len(data) {
+		d := &avroDecoder{buf: data, pos: consumed}
+
+		count, err := d.readLong()
+		if err == errAvroShortRead {
+			break
+		} else if err != nil {
+			return records, consumed, err
+		}
+
+		block, err := d.readBytes()
+		if err == errAvroShortRead {
+			break
+		} else if err != nil {
+			return records, consumed, err
+		}
+
+		sync, err := d.readFixed(avroSyncSize)
+		if err == errAvroShortRead {
+			break
+		} else if err != nil {
+			return records, consumed, err
+		}
+
+		if !bytes.Equal(sync, f.sync) {
+			return records, consumed, fmt.Errorf("avro: sync marker mismatch at offset %d", consumed)
+		}
+
+		if f.codec == "deflate" {
+			block, err = io.ReadAll(flate.NewReader(bytes.NewReader(block)))
+			if err != nil {
+				return records, consumed, fmt.Errorf("avro: failed to inflate block [%s]", err.Error())
+			}
+		}
+
+		bd := &avroDecoder{buf: block}
+		for range count {
+			rec, err := bd.readValue(f.schema)
+			if err != nil {
+				return records, consumed, fmt.Errorf("avro: corrupt block at offset %d [%s]", consumed, err.Error())
+			}
+			records = append(records, rec)
+		}
+
+		consumed = d.pos
+	}
+
+	return records, consumed, nil
+}
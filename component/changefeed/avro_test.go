@@ -0,0 +1,299 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package changefeed
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// ------------------------- Test encoder -------------------------------------------
+
+func encodeLong(buf *bytes.Buffer, v int64) {
+	u := uint64((v << 1) ^ (v >> 63))
+	for u >= 0x80 {
+		buf.WriteByte(byte(u) | 0x80)
+		u >>= 7
+	}
+	buf.WriteByte(byte(u))
+}
+
+func encodeBytes(buf *bytes.Buffer, b []byte) {
+	encodeLong(buf, int64(len(b)))
+	buf.Write(b)
+}
+
+// encodeValue writes v as per schema s. For unions nil selects the 'null' branch, anything else the first other branch.
+func encodeValue(buf *bytes.Buffer, s *avroSchema, v any) {
+	switch s.kind {
+	case "null":
+	case "boolean":
+		if v.(bool) {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	case "int":
+		encodeLong(buf, int64(v.(int32)))
+	case "long":
+		encodeLong(buf, v.(int64))
+	case "float":
+		_ = binary.Write(buf, binary.LittleEndian, math.Float32bits(v.(float32)))
+	case "double":
+		_ = binary.Write(buf, binary.LittleEndian, math.Float64bits(v.(float64)))
+	case "bytes":
+		encodeBytes(buf, v.([]byte))
+	case "string":
+		encodeBytes(buf, []byte(v.(string)))
+	case "fixed":
+		buf.Write(v.([]byte))
+	case "enum":
+		for i, sym := range s.symbols {
+			if sym == v.(string) {
+				encodeLong(buf, int64(i))
+			}
+		}
+	case "union":
+		for i, b := range s.union {
+			if (v == nil) == (b.kind == "null") {
+				encodeLong(buf, int64(i))
+				encodeValue(buf, b, v)
+				return
+			}
+		}
+	case "record":
+		rec := v.(map[string]any)
+		for _, f := range s.fields {
+			encodeValue(buf, f.schema, rec[f.name])
+		}
+	case "array":
+		arr := v.([]any)
+		if len(arr) > 0 {
+			encodeLong(buf, int64(len(arr)))
+			for _, item := range arr {
+				encodeValue(buf, s.items, item)
+			}
+		}
+		encodeLong(buf, 0)
+	case "map":
+		m := v.(map[string]any)
+		if len(m) > 0 {
+			// negative count followed by block size is a valid encoding as well
+			var block bytes.Buffer
+			for k, item := range m {
+				encodeBytes(&block, []byte(k))
+				encodeValue(&block, s.items, item)
+			}
+			encodeLong(buf, -int64(len(m)))
+			encodeLong(buf, int64(block.Len()))
+			buf.Write(block.Bytes())
+		}
+		encodeLong(buf, 0)
+	}
+}
+
+var testSync = []byte("0123456789abcdef")
+
+func encodeAvroHeader(schema string, codec string) []byte {
+	var buf bytes.Buffer
+	buf.Write(avroMagic)
+	encodeLong(&buf, 2)
+	encodeBytes(&buf, []byte("avro.schema"))
+	encodeBytes(&buf, []byte(schema))
+	encodeBytes(&buf, []byte("avro.codec"))
+	encodeBytes(&buf, []byte(codec))
+	encodeLong(&buf, 0)
+	buf.Write(testSync)
+	return buf.Bytes()
+}
+
+func encodeAvroBlock(schema string, codec string, records ...any) []byte {
+	s, err := parseAvroSchema([]byte(schema))
+	if err != nil {
+		panic(err)
+	}
+
+	var data bytes.Buffer
+	for _, rec := range records {
+		encodeValue(&data, s, rec)
+	}
+
+	block := data.Bytes()
+	if codec == "deflate" {
+		var compressed bytes.Buffer
+		w, _ := flate.NewWriter(&compressed, flate.DefaultCompression)
+		_, _ = w.Write(block)
+		_ = w.Close()
+		block = compressed.Bytes()
+	}
+
+	var buf bytes.Buffer
+	encodeLong(&buf, int64(len(records)))
+	encodeBytes(&buf, block)
+	buf.Write(testSync)
+	return buf.Bytes()
+}
+
+// ------------------------- Tests -------------------------------------------
+
+const testAvroSchema = `{
+	"type": "record", "name": "Sample", "namespace": "com.test",
+	"fields": [
+		{"name": "id", "type": "long"},
+		{"name": "count", "type": "int"},
+		{"name": "name", "type": "string"},
+		{"name": "valid", "type": "boolean"},
+		{"name": "ratio", "type": "double"},
+		{"name": "weight", "type": "float"},
+		{"name": "raw", "type": "bytes"},
+		{"name": "digest", "type": {"type": "fixed", "name": "Digest", "size": 4}},
+		{"name": "kind", "type": {"type": "enum", "name": "Kind", "symbols": ["A", "B", "C"]}},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "props", "type": {"type": "map", "values": "long"}},
+		{"name": "optional", "type": ["null", "string"]},
+		{"name": "child", "type": ["null", "com.test.Sample"]}
+	]
+}`
+
+func sampleRecord(id int64, child any) map[string]any {
+	return map[string]any{
+		"id":       id,
+		"count":    int32(-7),
+		"name":     "sample",
+		"valid":    true,
+		"ratio":    0.25,
+		"weight":   float32(1.5),
+		"raw":      []byte{1, 2, 3},
+		"digest":   []byte{9, 8, 7, 6},
+		"kind":     "B",
+		"tags":     []any{"x", "y"},
+		"props":    map[string]any{"k": int64(300)},
+		"optional": nil,
+		"child":    child,
+	}
+}
+
+type avroTestSuite struct {
+	suite.Suite
+	assert *assert.Assertions
+}
+
+func (suite *avroTestSuite) SetupTest() {
+	suite.assert = assert.New(suite.T())
+}
+
+func (suite *avroTestSuite) TestReadRecords() {
+	for _, codec := range []string{"null", "deflate"} {
+		rec := sampleRecord(1, sampleRecord(2, nil))
+
+		data := encodeAvroHeader(testAvroSchema, codec)
+		hdrLen := len(data)
+		data = append(data, encodeAvroBlock(testAvroSchema, codec, rec, sampleRecord(-3, nil))...)
+
+		f, offset, err := parseAvroHeader(data)
+		suite.assert.NoError(err)
+		suite.assert.Equal(hdrLen, offset)
+		suite.assert.Equal(codec, f.codec)
+
+		records, consumed, err := f.readBlocks(data[offset:])
+		suite.assert.NoError(err)
+		suite.assert.Equal(len(data)-offset, consumed)
+		suite.assert.Len(records, 2)
+		suite.assert.Equal(rec, records[0])
+		suite.assert.Equal(int64(-3), records[1].(map[string]any)["id"])
+	}
+}
+
+func (suite *avroTestSuite) TestPartialBlock() {
+	data := encodeAvroHeader(testAvroSchema, "null")
+	f, offset, err := parseAvroHeader(data)
+	suite.assert.NoError(err)
+
+	first := encodeAvroBlock(testAvroSchema, "null", sampleRecord(1, nil))
+	second := encodeAvroBlock(testAvroSchema, "null", sampleRecord(2, nil))
+	data = append(data, first...)
+	data = append(data, second[:len(second)-5]...)
+
+	records, consumed, err := f.readBlocks(data[offset:])
+	suite.assert.NoError(err)
+	suite.assert.Len(records, 1)
+	suite.assert.Equal(len(first), consumed)
+}
+
+func (suite *avroTestSuite) TestPartialHeader() {
+	data := encodeAvroHeader(testAvroSchema, "null")
+	_, _, err := parseAvroHeader(data[:len(data)-3])
+	suite.assert.Equal(errAvroShortRead, err)
+}
+
+func (suite *avroTestSuite) TestInvalidFile() {
+	_, _, err := parseAvroHeader([]byte("this is not avro"))
+	suite.assert.Error(err)
+
+	data := encodeAvroHeader(testAvroSchema, "snappy")
+	_, _, err = parseAvroHeader(data)
+	suite.assert.Error(err)
+}
+
+func (suite *avroTestSuite) TestSyncMismatch() {
+	data := encodeAvroHeader(testAvroSchema, "null")
+	f, offset, err := parseAvroHeader(data)
+	suite.assert.NoError(err)
+
+	block := encodeAvroBlock(testAvroSchema, "null", sampleRecord(1, nil))
+	block[len(block)-1] = 'X'
+
+	records, consumed, err := f.readBlocks(append(data[offset:], block...))
+	suite.assert.Error(err)
+	suite.assert.Empty(records)
+	suite.assert.Equal(0, consumed)
+}
+
+func (suite *avroTestSuite) TestInvalidSchema() {
+	_, err := parseAvroSchema([]byte(`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "Unknown"}]}`))
+	suite.assert.Error(err)
+
+	_, err = parseAvroSchema([]byte(`not json`))
+	suite.assert.Error(err)
+}
+
+func TestAvro(t *testing.T) {
+	suite.Run(t, new(avroTestSuite))
+}
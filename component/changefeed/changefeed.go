@@ -0,0 +1,450 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package changefeed
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/config"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/Azure/azure-storage-fuse/v2/internal"
+	"github.com/Azure/azure-storage-fuse/v2/internal/stats_manager"
+)
+
+/* NOTES:
+   - ChangeFeed tails the change feed of the storage account and invalidates the paths modified by
+     other writers in every caching component below it in the pipeline.
+   - It shall be placed right after libfuse so that all caching components are reachable from it.
+   - Setting 'segment-path' makes it read chunk files from a local directory instead of the account.
+*/
+
+// Common structure for Component
+type ChangeFeed struct {
+	internal.BaseComponent
+
+	segmentPath  string
+	pollInterval uint32
+	container    string
+	prefixPath   string
+
+	source       segmentSource
+	invalidators []internal.PathInvalidator
+	segments     map[string]*segmentState
+	lastPoll     time.Time
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// segmentState tracks how much of a chunk file has already been processed
+type segmentState struct {
+	file     *avroFile
+	offset   int64
+	lastRead time.Time
+}
+
+// Structure defining your config parameters
+type ChangeFeedOptions struct {
+	SegmentPath  string `config:"segment-path" yaml:"segment-path,omitempty"`
+	PollInterval uint32 `config:"poll-interval-sec" yaml:"poll-interval-sec,omitempty"`
+	Container    string `config:"container" yaml:"container,omitempty"`
+}
+
+// changeFeedProvider is implemented by the storage component to give access to the change feed container
+type changeFeedProvider interface {
+	ChangeFeedContainer() (*container.Client, error)
+}
+
+const compName = "changefeed"
+
+const defaultPollInterval = 30
+
+// Chunk files older than this are not expected to receive any more events
+const segmentRetention = 2 * time.Hour
+
+// stats keys
+const (
+	eventsProcessed  = "EventsProcessed"
+	pathsInvalidated = "PathsInvalidated"
+)
+
+// Verification to check satisfaction criteria with Component Interface
+var _ internal.Component = &ChangeFeed{}
+
+var changeFeedStatsCollector *stats_manager.StatsCollector
+
+func (cf *ChangeFeed) Name() string {
+	return compName
+}
+
+func (cf *ChangeFeed) SetName(name string) {
+	cf.BaseComponent.SetName(name)
+}
+
+func (cf *ChangeFeed) SetNextComponent(nc internal.Component) {
+	cf.BaseComponent.SetNextComponent(nc)
+}
+
+func (cf *ChangeFeed) Priority() internal.ComponentPriority {
+	return internal.EComponentPriority.LevelOne()
+}
+
+// Start : Pipeline calls this method to start the component functionality
+//
+//	this shall not block the call otherwise pipeline will not start
+func (cf *ChangeFeed) Start(ctx context.Context) error {
+	log.Trace("ChangeFeed::Start : Starting component %s", cf.Name())
+
+	// Components are started bottom up so everything below us is ready by now
+	var provider changeFeedProvider
+	for next := cf.NextComponent(); next != nil; next = next.NextComponent() {
+		comp := internal.Unwrap(next)
+		if inv, ok := comp.(internal.PathInvalidator); ok {
+			log.Info("ChangeFeed::Start : %s will receive invalidations", comp.Name())
+			cf.invalidators = append(cf.invalidators, inv)
+		}
+		if p, ok := comp.(changeFeedProvider); ok {
+			provider = p
+		}
+	}
+
+	if cf.segmentPath != "" {
+		cf.source = &localSource{path: cf.segmentPath}
+	} else {
+		if provider == nil {
+			log.Err("ChangeFeed::Start : No component in pipeline provides the change feed")
+			return fmt.Errorf("%s: no component in pipeline provides the change feed", cf.Name())
+		}
+
+		client, err := provider.ChangeFeedContainer()
+		if err != nil {
+			log.Err("ChangeFeed::Start : Failed to get change feed container [%s]", err.Error())
+			return fmt.Errorf("%s: failed to get change feed container [%s]", cf.Name(), err.Error())
+		}
+		cf.source = &blobSource{client: client}
+	}
+
+	changeFeedStatsCollector = stats_manager.NewStatsCollector(cf.Name())
+
+	// Whatever is already in the change feed happened before this mount, so caches can not hold stale data for it
+	cf.lastPoll = time.Now()
+	err := cf.poll(false)
+	if err != nil {
+		log.Err("ChangeFeed::Start : Failed to read change feed [%s]", err.Error())
+	}
+
+	cf.stopCh = make(chan struct{})
+	cf.wg.Add(1)
+	go cf.tail()
+
+	return nil
+}
+
+// Stop : Stop the component functionality and kill all threads started
+func (cf *ChangeFeed) Stop() error {
+	log.Trace("ChangeFeed::Stop : Stopping component %s", cf.Name())
+
+	if cf.stopCh != nil {
+		close(cf.stopCh)
+		cf.wg.Wait()
+	}
+
+	if changeFeedStatsCollector != nil {
+		changeFeedStatsCollector.Destroy()
+	}
+
+	return nil
+}
+
+// Configure : Pipeline will call this method after constructor so that you can read config and initialize yourself
+//
+//	Return failure if any config is not valid to exit the process
+func (cf *ChangeFeed) Configure(_ bool) error {
+	log.Trace("ChangeFeed::Configure : %s", cf.Name())
+
+	conf := ChangeFeedOptions{}
+	err := config.UnmarshalKey(cf.Name(), &conf)
+	if err != nil {
+		log.Err("ChangeFeed::Configure : config error [invalid config attributes]")
+		return fmt.Errorf("config error in %s [%s]", cf.Name(), err.Error())
+	}
+
+	cf.segmentPath = common.ExpandPath(conf.SegmentPath)
+
+	cf.pollInterval = defaultPollInterval
+	if config.IsSet(compName + ".poll-interval-sec") {
+		if conf.PollInterval == 0 {
+			log.Err("ChangeFeed::Configure : poll-interval-sec can not be zero")
+			return fmt.Errorf("config error in %s [poll-interval-sec can not be zero]", cf.Name())
+		}
+		cf.pollInterval = conf.PollInterval
+	}
+
+	// Events are reported for the whole account, so filter them on the mounted container and subdirectory
+	cf.container = conf.Container
+	if cf.container == "" {
+		_ = config.UnmarshalKey("azstorage.container", &cf.container)
+	}
+
+	_ = config.UnmarshalKey("azstorage.subdirectory", &cf.prefixPath)
+	cf.prefixPath = strings.Trim(cf.prefixPath, "/")
+
+	log.Crit("ChangeFeed::Configure : segment-path %s, poll-interval %d, container %s, subdirectory %s",
+		cf.segmentPath, cf.pollInterval, cf.container, cf.prefixPath)
+
+	return nil
+}
+
+// tail : Poll the change feed periodically till the component is stopped
+func (cf *ChangeFeed) tail() {
+	defer cf.wg.Done()
+
+	ticker := time.NewTicker(time.Duration(cf.pollInterval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			err := cf.poll(true)
+			if err != nil {
+				log.Err("ChangeFeed::tail : Failed to read change feed [%s]", err.Error())
+			}
+		case <-cf.stopCh:
+			return
+		}
+	}
+}
+
+// poll : Read the events appended to the change feed since last poll.
+// When notify is false the events are only consumed and nothing is invalidated.
+func (cf *ChangeFeed) poll(notify bool) error {
+	now := time.Now()
+
+	segments, err := cf.source.list(cf.lastPoll)
+	if err != nil {
+		return err
+	}
+
+	for _, seg := range segments {
+		state, found := cf.segments[seg.name]
+		if found && seg.size <= state.offset {
+			continue
+		}
+
+		err = cf.readSegment(seg.name, state, notify)
+		if err != nil {
+			// Skip this chunk for now, it will be retried on next poll
+			log.Err("ChangeFeed::poll : Failed to read %s [%s]", seg.name, err.Error())
+		}
+	}
+
+	// Forget chunk files which are not being appended anymore.
+	// Local source lists all files on every poll, so its state can not be dropped.
+	if cf.segmentPath == "" {
+		for name, state := range cf.segments {
+			if now.Sub(state.lastRead) > segmentRetention {
+				delete(cf.segments, name)
+			}
+		}
+	}
+
+	cf.lastPoll = now
+	return nil
+}
+
+// readSegment : Process the new events of one chunk file
+func (cf *ChangeFeed) readSegment(name string, state *segmentState, notify bool) error {
+	offset := int64(0)
+	if state != nil {
+		offset = state.offset
+	}
+
+	data, err := cf.source.read(name, offset)
+	if err != nil {
+		return err
+	}
+
+	if state == nil {
+		file, hdrLen, err := parseAvroHeader(data)
+		if err == errAvroShortRead {
+			// Header is not completely written yet
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		state = &segmentState{file: file, offset: int64(hdrLen)}
+		cf.segments[name] = state
+		data = data[hdrLen:]
+	}
+
+	records, consumed, err := state.file.readBlocks(data)
+	state.offset += int64(consumed)
+	state.lastRead = time.Now()
+
+	if notify {
+		for _, rec := range records {
+			cf.processEvent(rec)
+		}
+	}
+
+	return err
+}
+
+// processEvent : Invalidate the paths referred by a change feed event, renames refer to the destination as well
+func (cf *ChangeFeed) processEvent(rec any) {
+	event, ok := rec.(map[string]any)
+	if !ok {
+		return
+	}
+
+	changeFeedStatsCollector.UpdateStats(stats_manager.Increment, eventsProcessed, (int64)(1))
+
+	eventType, _ := event["eventType"].(string)
+	isDir := strings.HasPrefix(eventType, "Directory")
+
+	subject, _ := event["subject"].(string)
+	if name, ok := cf.mountPath(subject); ok {
+		cf.invalidate(eventType, name, isDir)
+	}
+
+	if data, ok := event["data"].(map[string]any); ok {
+		destination, _ := data["destinationUrl"].(string)
+		if name, ok := cf.urlPath(destination); ok {
+			cf.invalidate(eventType, name, isDir)
+		}
+	}
+}
+
+// invalidate : Drop the path from the caches, everything under it for a directory
+func (cf *ChangeFeed) invalidate(eventType string, name string, isDir bool) {
+	log.Debug("ChangeFeed::invalidate : %s on %s", eventType, name)
+
+	for _, inv := range cf.invalidators {
+		if isDir {
+			inv.InvalidateDir(name)
+		} else {
+			inv.InvalidatePath(name)
+		}
+	}
+
+	changeFeedStatsCollector.UpdateStats(stats_manager.Increment, pathsInvalidated, (int64)(1))
+}
+
+// mountPath : Convert the subject of an event to a path relative to the mount point.
+// Subject is of the form /blobServices/default/containers/<container>/blobs/<path>
+func (cf *ChangeFeed) mountPath(subject string) (string, bool) {
+	const containersKey = "/containers/"
+	const blobsKey = "/blobs/"
+
+	idx := strings.Index(subject, containersKey)
+	if idx < 0 {
+		return "", false
+	}
+
+	containerName, blobName, found := strings.Cut(subject[idx+len(containersKey):], blobsKey)
+	if !found {
+		return "", false
+	}
+
+	return cf.relativePath(containerName, blobName)
+}
+
+// urlPath : Convert the url of a blob, like the destination of a rename, to a path relative to the mount point.
+// Url is of the form https://<account>.<blob|dfs>.core.windows.net/<container>/<path>
+func (cf *ChangeFeed) urlPath(blobURL string) (string, bool) {
+	if blobURL == "" {
+		return "", false
+	}
+
+	parsed, err := url.Parse(blobURL)
+	if err != nil {
+		log.Warn("ChangeFeed::urlPath : Invalid blob url %s [%s]", blobURL, err.Error())
+		return "", false
+	}
+
+	containerName, blobName, found := strings.Cut(strings.TrimPrefix(parsed.Path, "/"), "/")
+	if !found {
+		return "", false
+	}
+
+	return cf.relativePath(containerName, strings.TrimSuffix(blobName, "/"))
+}
+
+// relativePath : Path of a blob relative to the mount point, if the mount holds it
+func (cf *ChangeFeed) relativePath(containerName string, blobName string) (string, bool) {
+	if blobName == "" {
+		return "", false
+	}
+
+	if cf.container != "" && containerName != cf.container {
+		return "", false
+	}
+
+	if cf.prefixPath != "" {
+		if !strings.HasPrefix(blobName, cf.prefixPath+"/") {
+			return "", false
+		}
+		blobName = blobName[len(cf.prefixPath)+1:]
+	}
+
+	return blobName, true
+}
+
+// ------------------------- Factory -------------------------------------------
+
+// Pipeline will call this method to create your object, initialize your variables here
+// << DO NOT DELETE ANY AUTO GENERATED CODE HERE >>
+func NewChangeFeedComponent() internal.Component {
+	comp := &ChangeFeed{
+		segments: make(map[string]*segmentState),
+	}
+	comp.SetName(compName)
+	return comp
+}
+
+// On init register this component to pipeline and supply your constructor
+func init() {
+	internal.AddComponent(compName, NewChangeFeedComponent)
+
+	pollInterval := config.AddUint32Flag("changefeed-poll-interval", defaultPollInterval, "interval in seconds to poll the change feed for modified paths")
+	config.BindPFlag(compName+".poll-interval-sec", pollInterval)
+}
@@ -0,0 +1,347 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package changefeed
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/config"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/Azure/azure-storage-fuse/v2/internal"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var home_dir, _ = os.UserHomeDir()
+
+// Trimmed down version of the schema used by storage for change feed events
+const changeFeedSchema = `{
+	"type": "record", "name": "BlobChangeEvent", "namespace": "com.microsoft.storage",
+	"fields": [
+		{"name": "schemaVersion", "type": "int"},
+		{"name": "topic", "type": "string"},
+		{"name": "subject", "type": "string"},
+		{"name": "eventType", "type": {"type": "enum", "name": "BlobChangeEventType",
+			"symbols": ["UnspecifiedEventType", "BlobCreated", "BlobDeleted", "BlobPropertiesUpdated", "DirectoryDeleted",
+				"BlobRenamed", "DirectoryRenamed"]}},
+		{"name": "eventTime", "type": "string"},
+		{"name": "id", "type": "string"},
+		{"name": "data", "type": {"type": "record", "name": "BlobChangeEventData", "fields": [
+			{"name": "etag", "type": "string"},
+			{"name": "contentLength", "type": "long"},
+			{"name": "previousInfo", "type": ["null", {"type": "map", "values": "string"}]},
+			{"name": "destinationUrl", "type": ["null", "string"]}
+		]}}
+	]
+}`
+
+// invalidatorComponent records the invalidations it receives
+type invalidatorComponent struct {
+	internal.BaseComponent
+	paths []string
+	dirs  []string
+}
+
+func (c *invalidatorComponent) InvalidatePath(name string) {
+	c.paths = append(c.paths, name)
+}
+
+func (c *invalidatorComponent) InvalidateDir(name string) {
+	c.dirs = append(c.dirs, name)
+}
+
+type changeFeedTestSuite struct {
+	suite.Suite
+	assert      *assert.Assertions
+	changeFeed  *ChangeFeed
+	invalidator *invalidatorComponent
+	segmentPath string
+}
+
+func changeEvent(eventType string, container string, name string) map[string]any {
+	return map[string]any{
+		"schemaVersion": int32(3),
+		"topic":         "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/acc",
+		"subject":       fmt.Sprintf("/blobServices/default/containers/%s/blobs/%s", container, name),
+		"eventType":     eventType,
+		"eventTime":     time.Now().UTC().Format(time.RFC3339),
+		"id":            "id",
+		"data": map[string]any{
+			"etag":           "0x8D",
+			"contentLength":  int64(10),
+			"previousInfo":   nil,
+			"destinationUrl": nil,
+		},
+	}
+}
+
+// renameEvent : Event renaming a blob or directory of the container to the destination
+func renameEvent(eventType string, container string, name string, destination string) map[string]any {
+	event := changeEvent(eventType, container, name)
+	event["data"].(map[string]any)["destinationUrl"] = fmt.Sprintf("https://acc.blob.core.windows.net/%s/%s", container, destination)
+	return event
+}
+
+func (suite *changeFeedTestSuite) SetupTest() {
+	err := log.SetDefaultLogger("silent", common.LogConfig{Level: common.ELogLevel.LOG_DEBUG()})
+	if err != nil {
+		panic("Unable to set silent logger as default.")
+	}
+
+	suite.segmentPath = filepath.Join(home_dir, "changefeed_test")
+	os.RemoveAll(suite.segmentPath)
+	_ = os.MkdirAll(suite.segmentPath, 0777)
+
+	suite.setupTestHelper(fmt.Sprintf("changefeed:\n  segment-path: %s\n  poll-interval-sec: 3600\n\nazstorage:\n  container: cont\n", suite.segmentPath))
+}
+
+func (suite *changeFeedTestSuite) setupTestHelper(configuration string) {
+	suite.assert = assert.New(suite.T())
+
+	err := config.ReadConfigFromReader(strings.NewReader(configuration))
+	suite.assert.NoError(err)
+
+	suite.invalidator = &invalidatorComponent{}
+	suite.changeFeed = NewChangeFeedComponent().(*ChangeFeed)
+	suite.changeFeed.SetNextComponent(suite.invalidator)
+
+	err = suite.changeFeed.Configure(true)
+	suite.assert.NoError(err)
+}
+
+func (suite *changeFeedTestSuite) cleanupTest() {
+	_ = suite.changeFeed.Stop()
+	os.RemoveAll(suite.segmentPath)
+}
+
+func (suite *changeFeedTestSuite) writeSegment(name string, records ...any) {
+	path := filepath.Join(suite.segmentPath, name)
+	_ = os.MkdirAll(filepath.Dir(path), 0777)
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0666)
+	suite.assert.NoError(err)
+	defer f.Close()
+
+	info, _ := f.Stat()
+	if info.Size() == 0 {
+		_, err = f.Write(encodeAvroHeader(changeFeedSchema, "deflate"))
+		suite.assert.NoError(err)
+	}
+
+	_, err = f.Write(encodeAvroBlock(changeFeedSchema, "deflate", records...))
+	suite.assert.NoError(err)
+}
+
+func (suite *changeFeedTestSuite) TestDefault() {
+	defer suite.cleanupTest()
+	suite.assert.Equal("changefeed", suite.changeFeed.Name())
+	suite.assert.Equal(internal.EComponentPriority.LevelOne(), suite.changeFeed.Priority())
+	suite.assert.Equal(suite.segmentPath, suite.changeFeed.segmentPath)
+	suite.assert.EqualValues(3600, suite.changeFeed.pollInterval)
+	suite.assert.Equal("cont", suite.changeFeed.container)
+	suite.assert.Empty(suite.changeFeed.prefixPath)
+}
+
+func (suite *changeFeedTestSuite) TestZeroPollInterval() {
+	defer suite.cleanupTest()
+
+	err := config.ReadConfigFromReader(strings.NewReader("changefeed:\n  poll-interval-sec: 0\n"))
+	suite.assert.NoError(err)
+
+	cf := NewChangeFeedComponent()
+	suite.assert.Error(cf.Configure(true))
+}
+
+func (suite *changeFeedTestSuite) TestNoProvider() {
+	defer suite.cleanupTest()
+
+	err := config.ReadConfigFromReader(strings.NewReader("changefeed:\n  poll-interval-sec: 10\n"))
+	suite.assert.NoError(err)
+
+	cf := NewChangeFeedComponent()
+	cf.SetNextComponent(&invalidatorComponent{})
+	suite.assert.NoError(cf.Configure(true))
+	suite.assert.Error(cf.Start(context.Background()))
+}
+
+func (suite *changeFeedTestSuite) TestExistingEventsIgnored() {
+	defer suite.cleanupTest()
+
+	suite.writeSegment("log/00/2024/01/01/1000/00000.avro", changeEvent("BlobCreated", "cont", "old.txt"))
+
+	err := suite.changeFeed.Start(context.Background())
+	suite.assert.NoError(err)
+	suite.assert.Empty(suite.invalidator.paths)
+
+	err = suite.changeFeed.poll(true)
+	suite.assert.NoError(err)
+	suite.assert.Empty(suite.invalidator.paths)
+}
+
+func (suite *changeFeedTestSuite) TestInvalidateNewEvents() {
+	defer suite.cleanupTest()
+
+	suite.writeSegment("log/00/2024/01/01/1000/00000.avro", changeEvent("BlobCreated", "cont", "old.txt"))
+
+	err := suite.changeFeed.Start(context.Background())
+	suite.assert.NoError(err)
+
+	// Events appended to an existing chunk and a new chunk
+	suite.writeSegment("log/00/2024/01/01/1000/00000.avro",
+		changeEvent("BlobPropertiesUpdated", "cont", "a.txt"),
+		changeEvent("BlobDeleted", "cont", "dir/b.txt"))
+	suite.writeSegment("log/00/2024/01/01/1100/00000.avro", changeEvent("DirectoryDeleted", "cont", "dir2"))
+
+	err = suite.changeFeed.poll(true)
+	suite.assert.NoError(err)
+	suite.assert.Equal([]string{"a.txt", "dir/b.txt"}, suite.invalidator.paths)
+	suite.assert.Equal([]string{"dir2"}, suite.invalidator.dirs)
+
+	// Nothing new to process
+	err = suite.changeFeed.poll(true)
+	suite.assert.NoError(err)
+	suite.assert.Len(suite.invalidator.paths, 2)
+}
+
+func (suite *changeFeedTestSuite) TestInvalidateRenameDestination() {
+	defer suite.cleanupTest()
+
+	err := suite.changeFeed.Start(context.Background())
+	suite.assert.NoError(err)
+
+	suite.writeSegment("00000.avro",
+		renameEvent("BlobRenamed", "cont", "a.txt", "dir/b%20c.txt"),
+		renameEvent("DirectoryRenamed", "cont", "src", "dst/"),
+		renameEvent("BlobRenamed", "cont", "d.txt", "other/e.txt"))
+
+	err = suite.changeFeed.poll(true)
+	suite.assert.NoError(err)
+	suite.assert.Equal([]string{"a.txt", "dir/b c.txt", "d.txt", "other/e.txt"}, suite.invalidator.paths)
+	suite.assert.Equal([]string{"src", "dst"}, suite.invalidator.dirs)
+}
+
+func (suite *changeFeedTestSuite) TestIncompleteBlock() {
+	defer suite.cleanupTest()
+
+	err := suite.changeFeed.Start(context.Background())
+	suite.assert.NoError(err)
+
+	// Simulate a block which is still being appended
+	path := filepath.Join(suite.segmentPath, "00000.avro")
+	block := encodeAvroBlock(changeFeedSchema, "null", changeEvent("BlobCreated", "cont", "a.txt"))
+	data := append(encodeAvroHeader(changeFeedSchema, "null"), block[:len(block)/2]...)
+	suite.assert.NoError(os.WriteFile(path, data, 0666))
+
+	err = suite.changeFeed.poll(true)
+	suite.assert.NoError(err)
+	suite.assert.Empty(suite.invalidator.paths)
+
+	data = append(data, block[len(block)/2:]...)
+	suite.assert.NoError(os.WriteFile(path, data, 0666))
+
+	err = suite.changeFeed.poll(true)
+	suite.assert.NoError(err)
+	suite.assert.Equal([]string{"a.txt"}, suite.invalidator.paths)
+}
+
+func (suite *changeFeedTestSuite) TestFilterContainer() {
+	defer suite.cleanupTest()
+
+	err := suite.changeFeed.Start(context.Background())
+	suite.assert.NoError(err)
+
+	suite.writeSegment("00000.avro",
+		changeEvent("BlobCreated", "other", "a.txt"),
+		changeEvent("BlobCreated", "cont", "b.txt"))
+
+	err = suite.changeFeed.poll(true)
+	suite.assert.NoError(err)
+	suite.assert.Equal([]string{"b.txt"}, suite.invalidator.paths)
+}
+
+func (suite *changeFeedTestSuite) TestFilterSubdirectory() {
+	defer suite.cleanupTest()
+
+	suite.setupTestHelper(fmt.Sprintf("changefeed:\n  segment-path: %s\n  container: cont2\n\nazstorage:\n  container: cont\n  subdirectory: /sub/\n", suite.segmentPath))
+	suite.assert.Equal("cont2", suite.changeFeed.container)
+	suite.assert.Equal("sub", suite.changeFeed.prefixPath)
+
+	err := suite.changeFeed.Start(context.Background())
+	suite.assert.NoError(err)
+
+	suite.writeSegment("00000.avro",
+		changeEvent("BlobCreated", "cont2", "a.txt"),
+		changeEvent("BlobCreated", "cont2", "sub/b.txt"),
+		changeEvent("BlobCreated", "cont2", "subdir/c.txt"))
+
+	err = suite.changeFeed.poll(true)
+	suite.assert.NoError(err)
+	suite.assert.Equal([]string{"b.txt"}, suite.invalidator.paths)
+}
+
+func (suite *changeFeedTestSuite) TestMountPath() {
+	defer suite.cleanupTest()
+
+	name, ok := suite.changeFeed.mountPath("/blobServices/default/containers/cont/blobs/a/b/c.txt")
+	suite.assert.True(ok)
+	suite.assert.Equal("a/b/c.txt", name)
+
+	_, ok = suite.changeFeed.mountPath("/blobServices/default/containers/cont")
+	suite.assert.False(ok)
+
+	_, ok = suite.changeFeed.mountPath("")
+	suite.assert.False(ok)
+
+	name, ok = suite.changeFeed.urlPath("https://acc.dfs.core.windows.net/cont/a/b%23c.txt")
+	suite.assert.True(ok)
+	suite.assert.Equal("a/b#c.txt", name)
+
+	_, ok = suite.changeFeed.urlPath("https://acc.blob.core.windows.net/other/a.txt")
+	suite.assert.False(ok)
+
+	_, ok = suite.changeFeed.urlPath("https://acc.blob.core.windows.net/cont")
+	suite.assert.False(ok)
+}
+
+func TestChangeFeed(t *testing.T) {
+	suite.Run(t, new(changeFeedTestSuite))
+}
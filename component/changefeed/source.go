@@ -0,0 +1,189 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package changefeed
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+)
+
+// segmentInfo describes one avro chunk file of the change feed
+type segmentInfo struct {
+	name string
+	size int64
+}
+
+// segmentSource provides the change feed chunk files to the component
+type segmentSource interface {
+	// list returns the chunk files which may have received events since the given time, sorted by name
+	list(since time.Time) ([]segmentInfo, error)
+
+	// read returns the content of a chunk file starting at the given offset
+	read(name string, offset int64) ([]byte, error)
+}
+
+// ------------------------- Local source -------------------------------------------
+
+// localSource reads chunk files from a local directory, mainly used for testing.
+// Any '.avro' file found under the directory is treated as a chunk file.
+type localSource struct {
+	path string
+}
+
+func (s *localSource) list(_ time.Time) ([]segmentInfo, error) {
+	segments := make([]segmentInfo, 0)
+
+	err := filepath.WalkDir(s.path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".avro") {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		rel, _ := filepath.Rel(s.path, path)
+		segments = append(segments, segmentInfo{name: rel, size: info.Size()})
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(segments, func(i, j int) bool { return segments[i].name < segments[j].name })
+	return segments, nil
+}
+
+func (s *localSource) read(name string, offset int64) ([]byte, error) {
+	f, err := os.Open(filepath.Join(s.path, name))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	_, err = f.Seek(offset, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(f)
+}
+
+// ------------------------- Storage account source -------------------------------------------
+
+// blobSource reads chunk files from the change feed container of the storage account.
+// Chunk files are laid out as log/<shard>/<yyyy>/<mm>/<dd>/<hhmm>/<chunk>.avro, so only the
+// hourly prefixes since the last poll are listed instead of the whole container. Events are
+// published with some delay, hence the hour before the last poll is listed as well.
+type blobSource struct {
+	client *container.Client
+}
+
+const changeFeedLogPrefix = "log/"
+
+func (s *blobSource) list(since time.Time) ([]segmentInfo, error) {
+	ctx := context.Background()
+
+	shards := make([]string, 0)
+	shardPager := s.client.NewListBlobsHierarchyPager("/", &container.ListBlobsHierarchyOptions{
+		Prefix: to.Ptr(changeFeedLogPrefix),
+	})
+	for shardPager.More() {
+		resp, err := shardPager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range resp.Segment.BlobPrefixes {
+			shards = append(shards, *p.Name)
+		}
+	}
+
+	segments := make([]segmentInfo, 0)
+	now := time.Now().UTC()
+	for hour := since.Add(-time.Hour).UTC().Truncate(time.Hour); !hour.After(now); hour = hour.Add(time.Hour) {
+		for _, shard := range shards {
+			pager := s.client.NewListBlobsFlatPager(&container.ListBlobsFlatOptions{
+				Prefix: to.Ptr(shard + hour.Format("2006/01/02/15")),
+			})
+			for pager.More() {
+				resp, err := pager.NextPage(ctx)
+				if err != nil {
+					return nil, err
+				}
+				for _, item := range resp.Segment.BlobItems {
+					if item.Name == nil || !strings.HasSuffix(*item.Name, ".avro") {
+						continue
+					}
+					size := int64(0)
+					if item.Properties != nil && item.Properties.ContentLength != nil {
+						size = *item.Properties.ContentLength
+					}
+					segments = append(segments, segmentInfo{name: *item.Name, size: size})
+				}
+			}
+		}
+	}
+
+	sort.Slice(segments, func(i, j int) bool { return segments[i].name < segments[j].name })
+	return segments, nil
+}
+
+func (s *blobSource) read(name string, offset int64) ([]byte, error) {
+	resp, err := s.client.NewBlobClient(name).DownloadStream(context.Background(), &blob.DownloadStreamOptions{
+		Range: blob.HTTPRange{Offset: offset},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s [%s]", name, err.Error())
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
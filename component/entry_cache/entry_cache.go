@@ -37,7 +37,10 @@ import (
 	"container/list"
 	"context"
 	"fmt"
+	"path"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/Azure/azure-storage-fuse/v2/common"
 	"github.com/Azure/azure-storage-fuse/v2/common/config"
@@ -53,11 +56,13 @@ type EntryCache struct {
 	pathLocks    *common.LockMap
 	pathLRU      *tlru.TLRU
 	pathMap      sync.Map
+	cacheRules   *common.CacheRules
 }
 
 type pathCacheItem struct {
 	children  []*internal.ObjAttr
 	nextToken string
+	cachedAt  time.Time
 }
 
 // By default entry cache is valid for 30 seconds
@@ -72,6 +77,9 @@ const compName = "entry_cache"
 
 // Verification to check satisfaction criteria with Component Interface
 var _ internal.Component = &EntryCache{}
+var _ internal.PathInvalidator = &EntryCache{}
+var _ internal.CacheDropper = &EntryCache{}
+var _ internal.StateReporter = &EntryCache{}
 
 func (c *EntryCache) Name() string {
 	return compName
@@ -143,7 +151,26 @@ func (c *EntryCache) Configure(_ bool) error {
 		c.cacheTimeout = conf.Timeout
 	}
 
-	c.pathLRU, err = tlru.New(1000, c.cacheTimeout, c.pathEvict, 0, nil)
+	var rules []common.CacheRule
+	err = config.UnmarshalKey(common.CacheRulesKey, &rules)
+	if err != nil {
+		log.Err("EntryCache::Configure : config error [invalid cache rules]")
+		return fmt.Errorf("config error in %s [%s]", c.Name(), err.Error())
+	}
+
+	c.cacheRules, err = common.NewCacheRules(rules)
+	if err != nil {
+		log.Err("EntryCache::Configure : config error [%s]", err.Error())
+		return fmt.Errorf("config error in %s [%s]", c.Name(), err.Error())
+	}
+
+	// Listings are dropped once the longest timeout of any path expires, shorter ones are checked on lookup
+	lruTimeout := c.cacheTimeout
+	if timeout, found := c.cacheRules.MaxTimeout(); found && timeout > lruTimeout {
+		lruTimeout = timeout
+	}
+
+	c.pathLRU, err = tlru.New(1000, lruTimeout, c.pathEvict, 0, nil)
 	if err != nil {
 		log.Err("EntryCache::Start : fail to create LRU for path caching [%s]", err.Error())
 		return fmt.Errorf("config error in %s [%s]", c.Name(), err.Error())
@@ -158,12 +185,22 @@ func (c *EntryCache) Configure(_ bool) error {
 func (c *EntryCache) StreamDir(options internal.StreamDirOptions) ([]*internal.ObjAttr, string, error) {
 	log.Trace("AttrCache::StreamDir : %s", options.Name)
 
+	policy := c.cacheRules.Match(options.Name)
+	if policy.NeverCache() {
+		return c.NextComponent().StreamDir(options)
+	}
+
 	pathKey := fmt.Sprintf("%s##%s", options.Name, options.Token)
 	flock := c.pathLocks.Get(pathKey)
 	flock.Lock()
 	defer flock.Unlock()
 
 	pathEntry, found := c.pathMap.Load(pathKey)
+	if found && c.expired(policy, pathEntry.(pathCacheItem)) {
+		c.pathMap.Delete(pathKey)
+		found = false
+	}
+
 	if !found {
 		log.Debug("EntryCache::StreamDir : Cache not valid, fetch new list for path: %s, token %s", options.Name, options.Token)
 		pathList, token, err := c.NextComponent().StreamDir(options)
@@ -171,9 +208,14 @@ func (c *EntryCache) StreamDir(options internal.StreamDirOptions) ([]*internal.O
 			item := pathCacheItem{
 				children:  pathList,
 				nextToken: token,
+				cachedAt:  time.Now(),
 			}
 			c.pathMap.Store(pathKey, item)
-			c.pathLRU.Add(pathKey)
+
+			// Pinned listings stay until invalidated
+			if !policy.Pin() {
+				c.pathLRU.Add(pathKey)
+			}
 		}
 		return pathList, token, err
 	} else {
@@ -183,6 +225,14 @@ func (c *EntryCache) StreamDir(options internal.StreamDirOptions) ([]*internal.O
 	}
 }
 
+// expired : Whether the listing has outlived the timeout of its directory. Pinned listings never expire.
+func (c *EntryCache) expired(policy common.CachePolicy, item pathCacheItem) bool {
+	if policy.Pin() {
+		return false
+	}
+	return time.Since(item.cachedAt) >= time.Duration(policy.Timeout(c.cacheTimeout))*time.Second
+}
+
 // pathEvict : Callback when a node from cache expires
 func (c *EntryCache) pathEvict(node *list.Element) {
 	pathKey := node.Value.(string)
@@ -195,6 +245,71 @@ func (c *EntryCache) pathEvict(node *list.Element) {
 	c.pathMap.Delete(pathKey)
 }
 
+// InvalidatePath : Drop cached listings of the parent directory of this path
+func (c *EntryCache) InvalidatePath(name string) {
+	log.Trace("EntryCache::InvalidatePath : %s", name)
+
+	parent := parentDirName(name)
+	c.invalidateListings(func(dir string) bool {
+		return dir == parent
+	})
+}
+
+// InvalidateDir : Drop cached listings of this directory, its children and its parent
+func (c *EntryCache) InvalidateDir(name string) {
+	log.Trace("EntryCache::InvalidateDir : %s", name)
+
+	name = internal.TruncateDirName(name)
+	parent := parentDirName(name)
+	c.invalidateListings(func(dir string) bool {
+		return dir == parent || dir == name || strings.HasPrefix(dir, name+"/")
+	})
+}
+
+// invalidateListings : Remove all cached listings whose directory matches the given filter
+func (c *EntryCache) invalidateListings(match func(dir string) bool) {
+	c.pathMap.Range(func(key, _ any) bool {
+		pathKey := key.(string)
+		dir, _, _ := strings.Cut(pathKey, "##")
+		if match(internal.TruncateDirName(dir)) {
+			flock := c.pathLocks.Get(pathKey)
+			flock.Lock()
+			c.pathMap.Delete(pathKey)
+			flock.Unlock()
+		}
+		return true
+	})
+}
+
+// DropCache : Drop all cached listings
+func (c *EntryCache) DropCache() {
+	log.Info("EntryCache::DropCache : Dropping all listings")
+	c.invalidateListings(func(string) bool { return true })
+}
+
+// State : Number of listings cached and their timeout
+func (c *EntryCache) State() map[string]any {
+	listings := 0
+	c.pathMap.Range(func(_, _ any) bool {
+		listings++
+		return true
+	})
+
+	return map[string]any{
+		"listings":    listings,
+		"timeout-sec": c.cacheTimeout,
+		"cache-rules": c.cacheRules.Len(),
+	}
+}
+
+func parentDirName(name string) string {
+	parent := path.Dir(internal.TruncateDirName(name))
+	if parent == "." || parent == "/" {
+		return ""
+	}
+	return parent
+}
+
 // ------------------------- Factory -------------------------------------------
 
 // Pipeline will call this method to create your object, initialize your variables here
@@ -215,6 +215,54 @@ func (suite *entryCacheTestSuite) TestCachedEntry() {
 
 }
 
+func (suite *entryCacheTestSuite) TestDropCache() {
+	defer suite.cleanupTest()
+
+	suite.assert.NoError(os.MkdirAll(filepath.Join(suite.fake_storage_path, "dir"), 0777))
+	suite.assert.NoError(os.WriteFile(filepath.Join(suite.fake_storage_path, "dir", "file"), []byte("data"), 0777))
+	for _, name := range []string{"", "dir"} {
+		_, _, err := suite.entryCache.StreamDir(internal.StreamDirOptions{Name: name, Token: ""})
+		suite.assert.NoError(err)
+	}
+	suite.assert.Equal(2, suite.entryCache.State()["listings"])
+
+	suite.entryCache.DropCache()
+	suite.assert.Equal(0, suite.entryCache.State()["listings"])
+}
+
+func (suite *entryCacheTestSuite) TestCacheRules() {
+	defer suite.cleanupTest()
+	suite.cleanupTest() // teardown the default entry cache generated
+	cfg := fmt.Sprintf("read-only: true\n\nentry_cache:\n  timeout-sec: 7\n\ncache-rules:\n  - path: nocache\n    never-cache: true\n  - path: short\n    timeout-sec: 1\n\nloopbackfs:\n  path: %s", suite.fake_storage_path)
+	suite.setupTestHelper(cfg)
+	suite.assert.Equal(2, suite.entryCache.State()["cache-rules"])
+
+	for _, dir := range []string{"nocache", "short"} {
+		suite.assert.NoError(os.MkdirAll(filepath.Join(suite.fake_storage_path, dir), 0777))
+		suite.assert.NoError(os.WriteFile(filepath.Join(suite.fake_storage_path, dir, "file1"), []byte("data"), 0777))
+		_, _, err := suite.entryCache.StreamDir(internal.StreamDirOptions{Name: dir, Token: ""})
+		suite.assert.NoError(err)
+		suite.assert.NoError(os.WriteFile(filepath.Join(suite.fake_storage_path, dir, "file2"), []byte("data"), 0777))
+	}
+
+	// Never cached listing is not kept at all
+	_, found := suite.entryCache.pathMap.Load("nocache##")
+	suite.assert.False(found)
+	objs, _, err := suite.entryCache.StreamDir(internal.StreamDirOptions{Name: "nocache", Token: ""})
+	suite.assert.NoError(err)
+	suite.assert.Len(objs, 2)
+
+	// Listing with a shorter timeout is fetched again once it expires, before the component timeout
+	objs, _, err = suite.entryCache.StreamDir(internal.StreamDirOptions{Name: "short", Token: ""})
+	suite.assert.NoError(err)
+	suite.assert.Len(objs, 1)
+
+	time.Sleep(1100 * time.Millisecond)
+	objs, _, err = suite.entryCache.StreamDir(internal.StreamDirOptions{Name: "short", Token: ""})
+	suite.assert.NoError(err)
+	suite.assert.Len(objs, 2)
+}
+
 // In order for 'go test' to run this suite, we need to create
 // a normal test function and pass our suite to suite.Run
 func TestEntryCacheTestSuite(t *testing.T) {
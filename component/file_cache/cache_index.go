@@ -0,0 +1,219 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package file_cache
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+)
+
+const (
+	// Directory inside tmp-path holding the persisted cache index.
+	// Being a directory it is never merged into the listings served from local cache.
+	cacheIndexDir = ".blobfuse2_index"
+
+	cacheIndexFile    = "index.json"
+	cacheIndexVersion = 1
+)
+
+// cacheIndexEntry : Details of a cached file recorded in the persisted index
+type cacheIndexEntry struct {
+	Path       string `json:"path"`
+	ETag       string `json:"etag,omitempty"`
+	Size       int64  `json:"size"`
+	LastAccess int64  `json:"last_access"`
+}
+
+type cacheIndex struct {
+	Version int               `json:"version"`
+	Entries []cacheIndexEntry `json:"entries"`
+}
+
+// getCacheIndexPath : Path of the index file for a given tmp-path
+func getCacheIndexPath(tmpPath string) string {
+	return filepath.Join(tmpPath, cacheIndexDir, cacheIndexFile)
+}
+
+// isCacheIndexPath : Whether the given local path belongs to the persisted index
+func isCacheIndexPath(tmpPath string, localPath string) bool {
+	indexDir := filepath.Join(tmpPath, cacheIndexDir)
+	return localPath == indexDir || strings.HasPrefix(localPath, indexDir+"/")
+}
+
+// saveCacheIndex : Atomically replace the index file with the given entries
+func saveCacheIndex(path string, entries []cacheIndexEntry) error {
+	data, err := json.Marshal(cacheIndex{
+		Version: cacheIndexVersion,
+		Entries: entries,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), os.FileMode(0755))
+	if err != nil {
+		return err
+	}
+
+	tmpFile := path + ".tmp"
+	err = os.WriteFile(tmpFile, data, os.FileMode(0644))
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile, path)
+}
+
+// loadCacheIndex : Read the index file, entries are returned from least to most recently accessed
+func loadCacheIndex(path string) ([]cacheIndexEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	index := cacheIndex{}
+	err = json.Unmarshal(data, &index)
+	if err != nil {
+		return nil, err
+	}
+
+	if index.Version != cacheIndexVersion {
+		log.Warn("cacheIndex::loadCacheIndex : Ignoring index %s with version %d", path, index.Version)
+		return nil, nil
+	}
+
+	sort.SliceStable(index.Entries, func(i, j int) bool {
+		return index.Entries[i].LastAccess < index.Entries[j].LastAccess
+	})
+
+	return index.Entries, nil
+}
+
+// restoreFromIndex : Add local files recorded in the index back to the lru list and delete the rest.
+// A file is restored only if its size and the ETag recorded on it still match the index.
+func (p *lruPolicy) restoreFromIndex() {
+	entries, err := loadCacheIndex(p.indexPath)
+	if err != nil && !os.IsNotExist(err) {
+		log.Err("lruPolicy::restoreFromIndex : Failed to load index %s [%s]", p.indexPath, err.Error())
+	}
+
+	restored := make(map[string]bool)
+	for _, entry := range entries {
+		localPath := filepath.Join(p.tmpPath, entry.Path)
+		info, err := os.Stat(localPath)
+		if err != nil || info.IsDir() || info.Size() != entry.Size || getCachedETag(localPath) != entry.ETag {
+			log.Debug("lruPolicy::restoreFromIndex : Skipping %s as local copy does not match index", entry.Path)
+			continue
+		}
+
+		p.cacheValidate(localPath)
+		if val, found := p.nodeMap.Load(localPath); found {
+			val.(*lruNode).lastAccess = time.Unix(entry.LastAccess, 0)
+		}
+		restored[localPath] = true
+	}
+
+	// Anything else in the cache can not be trusted as we do not know when it was downloaded
+	removed := 0
+	_ = filepath.WalkDir(p.tmpPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if isCacheIndexPath(p.tmpPath, path) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !d.IsDir() && !restored[path] {
+			if deleteFile(path) == nil {
+				removed++
+			}
+		}
+		return nil
+	})
+
+	log.Info("lruPolicy::restoreFromIndex : Restored %d files from index, removed %d untracked files", len(restored), removed)
+}
+
+// saveIndex : Persist the files currently tracked by the lru list
+func (p *lruPolicy) saveIndex() {
+	entries := make([]cacheIndexEntry, 0)
+
+	p.Lock()
+	for node := p.head; node != nil; node = node.next {
+		if node == p.currMarker || node == p.lastMarker || node.deleted {
+			continue
+		}
+
+		entries = append(entries, cacheIndexEntry{
+			Path:       strings.TrimPrefix(strings.TrimPrefix(node.name, p.tmpPath), "/"),
+			LastAccess: node.lastAccess.Unix(),
+		})
+	}
+	p.Unlock()
+
+	// Stat the files outside the lock, files which have disappeared meanwhile are dropped.
+	// Entries are saved from least to most recently used so that order is retained for same last access time.
+	valid := make([]cacheIndexEntry, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		localPath := filepath.Join(p.tmpPath, entry.Path)
+		info, err := os.Stat(localPath)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		entry.Size = info.Size()
+		entry.ETag = getCachedETag(localPath)
+		valid = append(valid, entry)
+	}
+
+	err := saveCacheIndex(p.indexPath, valid)
+	if err != nil {
+		log.Err("lruPolicy::saveIndex : Failed to save index %s [%s]", p.indexPath, err.Error())
+		return
+	}
+
+	log.Debug("lruPolicy::saveIndex : Saved %d entries to %s", len(valid), p.indexPath)
+}
@@ -36,6 +36,8 @@ package file_cache
 import (
 	"fmt"
 	"os"
+	"strings"
+	"sync"
 
 	"github.com/Azure/azure-storage-fuse/v2/common"
 	"github.com/Azure/azure-storage-fuse/v2/common/log"
@@ -56,6 +58,47 @@ type cachePolicyConfig struct {
 	fileLocks *common.LockMap
 
 	policyTrace bool
+
+	// Path of the persisted cache index, empty if the index is not to be persisted
+	indexPath string
+
+	// Per path overrides of the timeout and eviction
+	cacheRules *common.CacheRules
+
+	// Files pinned on request of the user, keyed by path in container
+	pinned *sync.Map
+}
+
+// policyOf : Cache rule applying to a file in local cache
+func (c *cachePolicyConfig) policyOf(name string) common.CachePolicy {
+	return c.cacheRules.Match(strings.TrimPrefix(name, c.tmpPath))
+}
+
+// isPinned : Whether a file in local cache is exempt from eviction, by a cache rule or on request of the user
+func (c *cachePolicyConfig) isPinned(name string) bool {
+	if c.pinned != nil {
+		if _, found := c.pinned.Load(strings.TrimPrefix(strings.TrimPrefix(name, c.tmpPath), "/")); found {
+			return true
+		}
+	}
+	return c.cacheRules != nil && c.policyOf(name).Pin()
+}
+
+// timeoutOf : Timeout in seconds of a file in local cache
+func (c *cachePolicyConfig) timeoutOf(name string) uint32 {
+	if c.cacheRules == nil {
+		return c.cacheTimeout
+	}
+	return c.policyOf(name).Timeout(c.cacheTimeout)
+}
+
+// timeoutCheckInterval : Shortest timeout of the component and the cache rules, 0 if nothing ever expires
+func (c *cachePolicyConfig) timeoutCheckInterval() uint32 {
+	interval := c.cacheTimeout
+	if timeout, found := c.cacheRules.MinTimeout(); found && timeout != 0 && (interval == 0 || timeout < interval) {
+		interval = timeout
+	}
+	return interval
 }
 
 type cachePolicy interface {
@@ -73,6 +116,24 @@ type cachePolicy interface {
 	Name() string // The name of the policy
 }
 
+// newCachePolicy : Create the eviction policy configured by name, lru being the default
+func newCachePolicy(name string, cfg cachePolicyConfig) (cachePolicy, error) {
+	switch strings.ToLower(name) {
+	case "", "lru":
+		return NewLRUPolicy(cfg), nil
+	case "lfu":
+		return newEvictionPolicy(cfg, &lfuOrder{}), nil
+	case "arc":
+		return newEvictionPolicy(cfg, newARCOrder()), nil
+	case "size":
+		return newEvictionPolicy(cfg, &sizeOrder{}), nil
+	case "ttl":
+		return newEvictionPolicy(cfg, &ttlOrder{}), nil
+	}
+
+	return nil, fmt.Errorf("invalid cache policy %s, supported policies are lru, lfu, arc, size and ttl", name)
+}
+
 // getUsagePercentage:  The current cache usage as a percentage of the maxSize
 func getUsagePercentage(path string, maxSizeMB float64) float64 {
 	var curSize float64
@@ -130,3 +191,47 @@ func deleteFile(name string) error {
 
 	return nil
 }
+
+// deleteCachedFile : Delete a file from local cache unless it is under download or has open handles.
+// onDelete, if given, is invoked while the file lock is still held. Returns false if the file is in use.
+func deleteCachedFile(tmpPath string, fileLocks *common.LockMap, name string, onDelete func()) bool {
+	azPath := strings.TrimPrefix(name, tmpPath)
+	if azPath == "" {
+		log.Err("cachePolicy::deleteCachedFile : Empty file name formed name : %s, tmpPath : %s", name, tmpPath)
+		return true
+	}
+
+	if azPath[0] == '/' {
+		azPath = azPath[1:]
+	}
+
+	flock := fileLocks.Get(azPath)
+	if fileLocks.Locked(azPath) {
+		log.Warn("cachePolicy::deleteCachedFile : File in under download %s", azPath)
+		return false
+	}
+
+	flock.Lock()
+	defer flock.Unlock()
+
+	// Check if there are any open handles to this file or not
+	if flock.Count() > 0 {
+		log.Warn("cachePolicy::deleteCachedFile : File in use %s", name)
+		return false
+	}
+
+	// There are no open handles for this file so its safe to remove this
+	err := deleteFile(name)
+	if err != nil && !os.IsNotExist(err) {
+		log.Err("cachePolicy::deleteCachedFile : failed to delete local file %s [%s]", name, err.Error())
+	}
+
+	if onDelete != nil {
+		onDelete()
+	}
+
+	// File was deleted so try clearing its parent directory
+	// TODO: Delete directories up the path recursively that are "safe to delete". Ensure there is no race between this code and code that creates directories (like OpenFile)
+	// This might require something like hierarchical locking.
+	return true
+}
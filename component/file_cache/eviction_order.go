@@ -0,0 +1,248 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package file_cache
+
+import (
+	"container/list"
+	"sort"
+	"time"
+)
+
+// isIdle : Whether the node has not been used for longer than the timeout
+func isIdle(node *policyNode, now time.Time, timeout time.Duration) bool {
+	return now.Sub(node.lastAccess) > timeout
+}
+
+// ------------------------------------------------------------------------------------------------
+
+// lfuOrder : Evicts least frequently used files first, least recently used among equals.
+// Files read once, like those touched by a scan, go before the ones in regular use.
+type lfuOrder struct{}
+
+func (o *lfuOrder) name() string                          { return "lfu" }
+func (o *lfuOrder) insert(node *policyNode)               {}
+func (o *lfuOrder) access(node *policyNode)               {}
+func (o *lfuOrder) remove(node *policyNode, evicted bool) {}
+
+func (o *lfuOrder) victims(nodes []*policyNode) []*policyNode {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].hits != nodes[j].hits {
+			return nodes[i].hits < nodes[j].hits
+		}
+		return nodes[i].lastAccess.Before(nodes[j].lastAccess)
+	})
+	return nodes
+}
+
+func (o *lfuOrder) expired(node *policyNode, now time.Time, timeout time.Duration) bool {
+	return isIdle(node, now, timeout)
+}
+
+// ------------------------------------------------------------------------------------------------
+
+// sizeOrder : Evicts large and cold files first. Files are ranked by size multiplied by time since last use,
+// so a large file has to be used more often than a small one to stay in the cache.
+type sizeOrder struct{}
+
+func (o *sizeOrder) name() string                          { return "size" }
+func (o *sizeOrder) insert(node *policyNode)               {}
+func (o *sizeOrder) access(node *policyNode)               {}
+func (o *sizeOrder) remove(node *policyNode, evicted bool) {}
+
+func (o *sizeOrder) victims(nodes []*policyNode) []*policyNode {
+	now := time.Now()
+	score := func(node *policyNode) float64 {
+		// Add a second so that size still counts for files used just now
+		return float64(node.size) * (now.Sub(node.lastAccess).Seconds() + 1)
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return score(nodes[i]) > score(nodes[j])
+	})
+	return nodes
+}
+
+func (o *sizeOrder) expired(node *policyNode, now time.Time, timeout time.Duration) bool {
+	return isIdle(node, now, timeout)
+}
+
+// ------------------------------------------------------------------------------------------------
+
+// ttlOrder : Files live for the timeout since they were cached irrespective of their use.
+// When space is needed oldest files are evicted first.
+type ttlOrder struct{}
+
+func (o *ttlOrder) name() string                          { return "ttl" }
+func (o *ttlOrder) insert(node *policyNode)               {}
+func (o *ttlOrder) access(node *policyNode)               {}
+func (o *ttlOrder) remove(node *policyNode, evicted bool) {}
+
+func (o *ttlOrder) victims(nodes []*policyNode) []*policyNode {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].created.Before(nodes[j].created)
+	})
+	return nodes
+}
+
+func (o *ttlOrder) expired(node *policyNode, now time.Time, timeout time.Duration) bool {
+	return now.Sub(node.created) > timeout
+}
+
+// ------------------------------------------------------------------------------------------------
+
+// arcOrder : Adaptive replacement cache. Files used once are kept in t1 and files used again move to t2.
+// Names of files evicted from either list are remembered in ghost lists b1 and b2, a file coming back from
+// a ghost list shifts the target size of t1 (p) in its favour. This makes the order scan resistant while
+// still adapting to workloads favouring recency.
+type arcOrder struct {
+	p int // Target number of files in t1
+
+	t1 *list.List // Files used once, most recent at front
+	t2 *list.List // Files used more than once, most recent at front
+	b1 *list.List // Names of files evicted from t1
+	b2 *list.List // Names of files evicted from t2
+
+	ghosts map[string]*list.Element
+
+	// Largest number of files seen in cache, bounds the size of the ghost lists
+	capacity int
+}
+
+type arcEntry struct {
+	elem     *list.Element
+	frequent bool
+}
+
+type arcGhost struct {
+	name     string
+	frequent bool
+}
+
+func newARCOrder() *arcOrder {
+	return &arcOrder{
+		t1:     list.New(),
+		t2:     list.New(),
+		b1:     list.New(),
+		b2:     list.New(),
+		ghosts: make(map[string]*list.Element),
+	}
+}
+
+func (o *arcOrder) name() string { return "arc" }
+
+func (o *arcOrder) insert(node *policyNode) {
+	frequent := false
+
+	if elem, found := o.ghosts[node.name]; found {
+		ghost := elem.Value.(*arcGhost)
+		if ghost.frequent {
+			// Evicted from t2 too early, give more room to t2
+			o.p = max(o.p-max(o.b1.Len()/o.b2.Len(), 1), 0)
+			o.b2.Remove(elem)
+		} else {
+			// Evicted from t1 too early, give more room to t1
+			o.p = min(o.p+max(o.b2.Len()/o.b1.Len(), 1), o.capacity)
+			o.b1.Remove(elem)
+		}
+		delete(o.ghosts, node.name)
+		frequent = true
+	}
+
+	if frequent {
+		node.data = &arcEntry{elem: o.t2.PushFront(node), frequent: true}
+	} else {
+		node.data = &arcEntry{elem: o.t1.PushFront(node)}
+	}
+
+	o.capacity = max(o.capacity, o.t1.Len()+o.t2.Len())
+}
+
+func (o *arcOrder) access(node *policyNode) {
+	entry := node.data.(*arcEntry)
+	if entry.frequent {
+		o.t2.MoveToFront(entry.elem)
+		return
+	}
+
+	o.t1.Remove(entry.elem)
+	entry.elem = o.t2.PushFront(node)
+	entry.frequent = true
+}
+
+func (o *arcOrder) remove(node *policyNode, evicted bool) {
+	entry := node.data.(*arcEntry)
+	node.data = nil
+
+	ghosts := o.b1
+	if entry.frequent {
+		o.t2.Remove(entry.elem)
+		ghosts = o.b2
+	} else {
+		o.t1.Remove(entry.elem)
+	}
+
+	if !evicted {
+		// File was deleted or changed, there is nothing to learn from it
+		return
+	}
+
+	o.ghosts[node.name] = ghosts.PushFront(&arcGhost{name: node.name, frequent: entry.frequent})
+	for ghosts.Len() > o.capacity {
+		delete(o.ghosts, ghosts.Remove(ghosts.Back()).(*arcGhost).name)
+	}
+}
+
+// victims : Least recently used files of t1 while t1 is above its target size, then those of t2
+func (o *arcOrder) victims(nodes []*policyNode) []*policyNode {
+	ordered := make([]*policyNode, 0, len(nodes))
+
+	n1 := o.t1.Len()
+	e1, e2 := o.t1.Back(), o.t2.Back()
+	for e1 != nil || e2 != nil {
+		if e1 != nil && (n1 > o.p || e2 == nil) {
+			ordered = append(ordered, e1.Value.(*policyNode))
+			e1 = e1.Prev()
+			n1--
+		} else {
+			ordered = append(ordered, e2.Value.(*policyNode))
+			e2 = e2.Prev()
+		}
+	}
+
+	return ordered
+}
+
+func (o *arcOrder) expired(node *policyNode, now time.Time, timeout time.Duration) bool {
+	return isIdle(node, now, timeout)
+}
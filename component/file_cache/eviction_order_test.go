@@ -0,0 +1,178 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package file_cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type evictionOrderTestSuite struct {
+	suite.Suite
+	assert *assert.Assertions
+}
+
+func (suite *evictionOrderTestSuite) SetupTest() {
+	suite.assert = assert.New(suite.T())
+}
+
+func victimNames(nodes []*policyNode) []string {
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.name)
+	}
+	return names
+}
+
+func (suite *evictionOrderTestSuite) TestLFUOrder() {
+	now := time.Now()
+	nodes := []*policyNode{
+		{name: "hot", hits: 10, lastAccess: now.Add(-time.Hour)},
+		{name: "scan_old", hits: 1, lastAccess: now.Add(-time.Minute)},
+		{name: "scan_new", hits: 1, lastAccess: now},
+		{name: "warm", hits: 3, lastAccess: now},
+	}
+
+	hot, warm := nodes[0], nodes[3]
+
+	order := &lfuOrder{}
+	suite.assert.Equal("lfu", order.name())
+	suite.assert.Equal([]string{"scan_old", "scan_new", "warm", "hot"}, victimNames(order.victims(nodes)))
+	suite.assert.True(order.expired(hot, now, time.Minute))
+	suite.assert.False(order.expired(warm, now, time.Minute))
+}
+
+func (suite *evictionOrderTestSuite) TestSizeOrder() {
+	now := time.Now()
+	nodes := []*policyNode{
+		{name: "small_cold", size: 1024, lastAccess: now.Add(-time.Hour)},
+		{name: "large_hot", size: 100 * MB, lastAccess: now},
+		{name: "large_cold", size: 100 * MB, lastAccess: now.Add(-time.Hour)},
+		{name: "small_hot", size: 1024, lastAccess: now},
+	}
+
+	order := &sizeOrder{}
+	suite.assert.Equal("size", order.name())
+	suite.assert.Equal([]string{"large_cold", "large_hot", "small_cold", "small_hot"}, victimNames(order.victims(nodes)))
+}
+
+func (suite *evictionOrderTestSuite) TestTTLOrder() {
+	now := time.Now()
+	nodes := []*policyNode{
+		{name: "new", created: now, lastAccess: now},
+		{name: "old", created: now.Add(-time.Hour), lastAccess: now},
+	}
+
+	newNode, oldNode := nodes[0], nodes[1]
+
+	order := &ttlOrder{}
+	suite.assert.Equal("ttl", order.name())
+	suite.assert.Equal([]string{"old", "new"}, victimNames(order.victims(nodes)))
+
+	// Use does not extend the life of a file
+	suite.assert.True(order.expired(oldNode, now, time.Minute))
+	suite.assert.False(order.expired(newNode, now, time.Minute))
+}
+
+func (suite *evictionOrderTestSuite) TestARCOrder() {
+	order := newARCOrder()
+	suite.assert.Equal("arc", order.name())
+
+	nodes := map[string]*policyNode{}
+	all := func() []*policyNode {
+		list := make([]*policyNode, 0)
+		for _, node := range nodes {
+			list = append(list, node)
+		}
+		return list
+	}
+
+	for _, name := range []string{"a", "b", "c"} {
+		nodes[name] = &policyNode{name: name}
+		order.insert(nodes[name])
+	}
+
+	// Second use moves a file to t2, t1 is evicted first as its target size is 0
+	order.access(nodes["a"])
+	suite.assert.Equal(2, order.t1.Len())
+	suite.assert.Equal(1, order.t2.Len())
+	suite.assert.Equal([]string{"b", "c", "a"}, victimNames(order.victims(all())))
+
+	// Evicted file is remembered in ghost list
+	order.remove(nodes["b"], true)
+	delete(nodes, "b")
+	suite.assert.Equal(1, order.b1.Len())
+
+	// Coming back from b1 grows target size of t1 and the file goes to t2
+	nodes["b"] = &policyNode{name: "b"}
+	order.insert(nodes["b"])
+	suite.assert.Equal(1, order.p)
+	suite.assert.Equal(0, order.b1.Len())
+	suite.assert.Equal(2, order.t2.Len())
+	suite.assert.Equal([]string{"a", "b", "c"}, victimNames(order.victims(all())))
+
+	// Invalidated file is not remembered
+	order.remove(nodes["c"], false)
+	suite.assert.Equal(0, order.t1.Len())
+	suite.assert.Equal(0, order.b1.Len())
+
+	// Coming back from b2 shrinks target size of t1
+	order.remove(nodes["a"], true)
+	suite.assert.Equal(1, order.b2.Len())
+	order.insert(&policyNode{name: "a"})
+	suite.assert.Equal(0, order.p)
+	suite.assert.Equal(0, order.b2.Len())
+}
+
+func (suite *evictionOrderTestSuite) TestARCGhostLimit() {
+	order := newARCOrder()
+
+	for i := 0; i < 10; i++ {
+		node := &policyNode{name: string(rune('a' + i))}
+		order.insert(node)
+		order.remove(node, true)
+	}
+
+	// Only a single file was ever cached at a time, so only one ghost is retained
+	suite.assert.Equal(1, order.capacity)
+	suite.assert.Equal(1, order.b1.Len())
+	suite.assert.Len(order.ghosts, 1)
+}
+
+func TestEvictionOrderTestSuite(t *testing.T) {
+	suite.Run(t, new(evictionOrderTestSuite))
+}
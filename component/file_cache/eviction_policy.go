@@ -0,0 +1,383 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package file_cache
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+)
+
+// policyNode : A file tracked by evictionPolicy
+type policyNode struct {
+	name       string
+	size       int64
+	hits       uint64
+	created    time.Time
+	lastAccess time.Time
+
+	// Book keeping owned by the eviction order
+	data any
+}
+
+// evictionOrder : Decides which of the cached files are evicted first.
+// All methods are called with the policy lock held.
+type evictionOrder interface {
+	name() string
+
+	insert(node *policyNode) // File was added to the cache
+	access(node *policyNode) // File already in cache was used again
+
+	// File was removed from the cache, evicted is false when the file was invalidated or deleted by the user
+	remove(node *policyNode, evicted bool)
+
+	// All the nodes tracked by the policy, sorted in the order in which they shall be evicted
+	victims(nodes []*policyNode) []*policyNode
+
+	// Whether the node has outlived the cache timeout
+	expired(node *policyNode, now time.Time, timeout time.Duration) bool
+}
+
+// evictionPolicy : cachePolicy which delegates the choice of files to evict to an evictionOrder.
+// Files are evicted when they outlive the cache timeout or when the cache crosses high threshold,
+// in which case files are evicted in order until usage is expected to drop below low threshold.
+type evictionPolicy struct {
+	sync.Mutex
+
+	// wait group for stopping the go-routines gracefully.
+	wg sync.WaitGroup
+
+	cachePolicyConfig
+
+	order evictionOrder
+	nodes map[string]*policyNode
+
+	// Size of a cached file, refreshed before evicting for space
+	sizeOf func(name string) int64
+
+	// Channel to close main channel select loop
+	closeSignal chan int
+
+	// Channel to contain files that needs to be deleted immediately
+	deleteEvent chan string
+
+	// Channel to check disk usage is within the limits configured or not
+	diskUsageMonitor <-chan time.Time
+
+	// Channel to check for file eviction based on file-cache timeout
+	cacheTimeoutMonitor <-chan time.Time
+
+	// DU utility was found on the path or not
+	duPresent bool
+}
+
+var _ cachePolicy = &evictionPolicy{}
+
+func newEvictionPolicy(cfg cachePolicyConfig, order evictionOrder) *evictionPolicy {
+	return &evictionPolicy{
+		cachePolicyConfig: cfg,
+		order:             order,
+		nodes:             make(map[string]*policyNode),
+		sizeOf:            localFileSize,
+	}
+}
+
+// localFileSize : Size of the file in local cache, 0 if it does not exist
+func localFileSize(name string) int64 {
+	info, err := os.Stat(name)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+func (p *evictionPolicy) StartPolicy() error {
+	log.Trace("evictionPolicy::StartPolicy : %s", p.order.name())
+
+	p.closeSignal = make(chan int)
+	p.deleteEvent = make(chan string, 1000)
+
+	_, err := common.GetUsage(p.tmpPath)
+	if err == nil {
+		p.duPresent = true
+		p.diskUsageMonitor = time.Tick(time.Duration(DiskUsageCheckInterval * time.Minute))
+	} else {
+		log.Err("evictionPolicy::StartPolicy : 'du' command not found, disabling disk usage checks")
+	}
+
+	log.Info("evictionPolicy::StartPolicy : Policy %s set with %v timeout", p.order.name(), p.cacheTimeout)
+
+	// With timeout 0 files are deleted on invalidate, so there is nothing to expire unless a cache rule sets a timeout
+	if p.timeoutCheckInterval() != 0 {
+		p.cacheTimeoutMonitor = time.Tick(time.Duration(CacheTimeoutCheckInterval * time.Second))
+	}
+
+	p.wg.Add(1)
+	go p.clearCache()
+
+	return nil
+}
+
+func (p *evictionPolicy) ShutdownPolicy() error {
+	log.Trace("evictionPolicy::ShutdownPolicy")
+	p.closeSignal <- 1
+	p.wg.Wait()
+	return nil
+}
+
+func (p *evictionPolicy) UpdateConfig(c cachePolicyConfig) error {
+	log.Trace("evictionPolicy::UpdateConfig")
+	p.Lock()
+	defer p.Unlock()
+
+	p.maxSizeMB = c.maxSizeMB
+	p.highThreshold = c.highThreshold
+	p.lowThreshold = c.lowThreshold
+	p.maxEviction = c.maxEviction
+	p.policyTrace = c.policyTrace
+	return nil
+}
+
+func (p *evictionPolicy) CacheValid(name string) {
+	p.Lock()
+	defer p.Unlock()
+
+	now := time.Now()
+	node, found := p.nodes[name]
+	if found {
+		node.hits++
+		node.lastAccess = now
+		p.order.access(node)
+		return
+	}
+
+	node = &policyNode{
+		name:       name,
+		hits:       1,
+		created:    now,
+		lastAccess: now,
+	}
+	p.nodes[name] = node
+	p.order.insert(node)
+}
+
+func (p *evictionPolicy) CacheInvalidate(name string) {
+	log.Trace("evictionPolicy::CacheInvalidate : %s", name)
+
+	// Same as lru, with timeout 0 or for an untracked file the local copy is deleted right away
+	p.Lock()
+	_, found := p.nodes[name]
+	p.Unlock()
+
+	if (p.timeoutOf(name) == 0 && !p.isPinned(name)) || !found {
+		p.CachePurge(name)
+	}
+}
+
+func (p *evictionPolicy) CachePurge(name string) {
+	log.Trace("evictionPolicy::CachePurge : %s", name)
+
+	p.Lock()
+	p.removeNode(name, false)
+	p.Unlock()
+
+	p.deleteEvent <- name
+}
+
+func (p *evictionPolicy) IsCached(name string) bool {
+	p.Lock()
+	defer p.Unlock()
+
+	_, found := p.nodes[name]
+	return found
+}
+
+func (p *evictionPolicy) Name() string {
+	return p.order.name()
+}
+
+// removeNode : Stop tracking the file, policy lock must be held
+func (p *evictionPolicy) removeNode(name string, evicted bool) {
+	node, found := p.nodes[name]
+	if !found {
+		return
+	}
+
+	delete(p.nodes, name)
+	p.order.remove(node, evicted)
+}
+
+// clearCache : Go routine handling deletions and the timer based evictions
+func (p *evictionPolicy) clearCache() {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case name := <-p.deleteEvent:
+			// File was purged from the policy already, so only the local copy needs to go
+			if !deleteCachedFile(p.tmpPath, p.fileLocks, name, nil) {
+				p.CacheValid(name)
+			}
+
+		case <-p.cacheTimeoutMonitor:
+			p.evict(p.expiredNodes())
+
+		case <-p.diskUsageMonitor:
+			pUsage := getUsagePercentage(p.tmpPath, p.maxSizeMB)
+			if pUsage > p.highThreshold {
+				log.Info("evictionPolicy::clearCache : High threshold reached %f > %f", pUsage, p.highThreshold)
+				p.evict(p.victimsForSpace(pUsage))
+			}
+
+		case <-p.closeSignal:
+			return
+		}
+	}
+}
+
+// expiredNodes : Names of files which have outlived the cache timeout
+func (p *evictionPolicy) expiredNodes() []string {
+	p.Lock()
+	defer p.Unlock()
+
+	now := time.Now()
+	timeout := time.Duration(p.cacheTimeout) * time.Second
+
+	names := make([]string, 0)
+	for name, node := range p.nodes {
+		if uint32(len(names)) >= p.maxEviction {
+			break
+		}
+
+		if p.isPinned(name) {
+			continue
+		}
+
+		if p.cacheRules != nil {
+			timeout = time.Duration(p.policyOf(name).Timeout(p.cacheTimeout)) * time.Second
+		}
+
+		if p.order.expired(node, now, timeout) {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// victimsForSpace : Names of files to evict to bring usage down to low threshold, in eviction order
+func (p *evictionPolicy) victimsForSpace(pUsage float64) []string {
+	p.Lock()
+	nodes := make([]*policyNode, 0, len(p.nodes))
+	for name, node := range p.nodes {
+		if !p.isPinned(name) {
+			nodes = append(nodes, node)
+		}
+	}
+	p.Unlock()
+
+	// Files grow and shrink while open, so take their current size
+	sizes := make([]int64, len(nodes))
+	for i, node := range nodes {
+		sizes[i] = p.sizeOf(node.name)
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	for i, node := range nodes {
+		node.size = sizes[i]
+	}
+
+	return selectVictims(p.order.victims(nodes), (pUsage-p.lowThreshold)*p.maxSizeMB*MB/100, p.maxEviction)
+}
+
+// selectVictims : Names of nodes, taken in order, whose sizes add up to the bytes to be freed
+func selectVictims(ordered []*policyNode, toFree float64, maxEviction uint32) []string {
+	names := make([]string, 0)
+	freed := float64(0)
+
+	for _, node := range ordered {
+		if freed >= toFree || uint32(len(names)) >= maxEviction {
+			break
+		}
+
+		names = append(names, node.name)
+		freed += float64(node.size)
+	}
+
+	return names
+}
+
+// evict : Delete the local copies of given files. Files in use are retained and stay in the cache.
+func (p *evictionPolicy) evict(names []string) {
+	if len(names) == 0 {
+		return
+	}
+
+	log.Debug("evictionPolicy::evict : Evicting %d files", len(names))
+
+	for _, name := range names {
+		deleteCachedFile(p.tmpPath, p.fileLocks, name, func() {
+			p.Lock()
+			p.removeNode(name, true)
+			p.Unlock()
+		})
+	}
+
+	p.printNodes()
+}
+
+func (p *evictionPolicy) printNodes() {
+	if !p.policyTrace {
+		return
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	nodes := make([]*policyNode, 0, len(p.nodes))
+	for _, node := range p.nodes {
+		nodes = append(nodes, node)
+	}
+
+	log.Debug("evictionPolicy::printNodes : Starts")
+	for i, node := range p.order.victims(nodes) {
+		log.Debug(" ==> (%d) %s hits %d", i, node.name, node.hits)
+	}
+	log.Debug("evictionPolicy::printNodes : Ends")
+}
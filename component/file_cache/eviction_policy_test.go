@@ -0,0 +1,238 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package file_cache
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type evictionPolicyTestSuite struct {
+	suite.Suite
+	assert *assert.Assertions
+	policy *evictionPolicy
+}
+
+func (suite *evictionPolicyTestSuite) SetupTest() {
+	err := log.SetDefaultLogger("silent", common.LogConfig{Level: common.ELogLevel.LOG_DEBUG()})
+	if err != nil {
+		panic("Unable to set silent logger as default.")
+	}
+	suite.assert = assert.New(suite.T())
+
+	err = os.Mkdir(cache_path, fs.FileMode(0777))
+	suite.assert.NoError(err)
+}
+
+func (suite *evictionPolicyTestSuite) setupTestHelper(name string, cacheTimeout uint32) {
+	config := cachePolicyConfig{
+		tmpPath:       cache_path,
+		cacheTimeout:  cacheTimeout,
+		maxEviction:   defaultMaxEviction,
+		maxSizeMB:     1,
+		highThreshold: defaultMaxThreshold,
+		lowThreshold:  50,
+		fileLocks:     &common.LockMap{},
+	}
+
+	policy, err := newCachePolicy(name, config)
+	suite.assert.NoError(err)
+	suite.policy = policy.(*evictionPolicy)
+
+	err = suite.policy.StartPolicy()
+	suite.assert.NoError(err)
+}
+
+func (suite *evictionPolicyTestSuite) cleanupTest() {
+	err := suite.policy.ShutdownPolicy()
+	suite.assert.NoError(err)
+
+	os.RemoveAll(cache_path)
+}
+
+func (suite *evictionPolicyTestSuite) createFile(name string, size int) string {
+	localPath := filepath.Join(cache_path, name)
+	err := os.WriteFile(localPath, make([]byte, size), 0777)
+	suite.assert.NoError(err)
+	return localPath
+}
+
+func (suite *evictionPolicyTestSuite) TestNewCachePolicy() {
+	for _, name := range []string{"lru", "LFU", "arc", "size", "ttl"} {
+		policy, err := newCachePolicy(name, cachePolicyConfig{})
+		suite.assert.NoError(err)
+		suite.assert.Equal(strings.ToLower(name), policy.Name())
+	}
+
+	policy, err := newCachePolicy("", cachePolicyConfig{})
+	suite.assert.NoError(err)
+	suite.assert.Equal("lru", policy.Name())
+
+	_, err = newCachePolicy("mru", cachePolicyConfig{})
+	suite.assert.Error(err)
+
+	// Directory created for the suite is not used by this test
+	os.RemoveAll(cache_path)
+}
+
+func (suite *evictionPolicyTestSuite) TestCacheValid() {
+	suite.setupTestHelper("lfu", 120)
+	defer suite.cleanupTest()
+
+	suite.policy.CacheValid("temp")
+	suite.policy.CacheValid("temp")
+
+	suite.assert.True(suite.policy.IsCached("temp"))
+	suite.assert.EqualValues(2, suite.policy.nodes["temp"].hits)
+	suite.assert.False(suite.policy.IsCached("temp2"))
+}
+
+func (suite *evictionPolicyTestSuite) TestCachePurge() {
+	suite.setupTestHelper("arc", 120)
+	defer suite.cleanupTest()
+
+	localPath := suite.createFile("purge", 10)
+	suite.policy.CacheValid(localPath)
+	suite.policy.CachePurge(localPath)
+
+	suite.assert.False(suite.policy.IsCached(localPath))
+	suite.assert.Eventually(func() bool {
+		_, err := os.Stat(localPath)
+		return os.IsNotExist(err)
+	}, time.Second, 10*time.Millisecond)
+
+	// Purged file is not remembered as evicted
+	suite.assert.Equal(0, suite.policy.order.(*arcOrder).b1.Len())
+}
+
+func (suite *evictionPolicyTestSuite) TestCacheInvalidate() {
+	suite.setupTestHelper("lfu", 0)
+	defer suite.cleanupTest()
+
+	// With timeout 0, file goes as soon as it is invalidated
+	localPath := suite.createFile("invalidate", 10)
+	suite.policy.CacheValid(localPath)
+	suite.policy.CacheInvalidate(localPath)
+
+	suite.assert.False(suite.policy.IsCached(localPath))
+}
+
+func (suite *evictionPolicyTestSuite) TestTimeout() {
+	suite.setupTestHelper("ttl", 1)
+	defer suite.cleanupTest()
+
+	localPath := suite.createFile("timeout", 10)
+	suite.policy.CacheValid(localPath)
+
+	// Wait for the expiry check to run after timeout
+	time.Sleep((CacheTimeoutCheckInterval + 1) * time.Second)
+
+	suite.assert.False(suite.policy.IsCached(localPath))
+	suite.assert.NoFileExists(localPath)
+}
+
+func (suite *evictionPolicyTestSuite) TestEvictForSpace() {
+	suite.setupTestHelper("size", 120)
+	defer suite.cleanupTest()
+
+	large := suite.createFile("large", 512*1024)
+	small := suite.createFile("small", 100*1024)
+	suite.policy.CacheValid(small)
+	suite.policy.CacheValid(large)
+
+	// Usage at 90% with low threshold at 50% of 1MB, evicting the large file is enough
+	suite.policy.evict(suite.policy.victimsForSpace(90))
+
+	suite.assert.False(suite.policy.IsCached(large))
+	suite.assert.NoFileExists(large)
+	suite.assert.True(suite.policy.IsCached(small))
+	suite.assert.FileExists(small)
+}
+
+func (suite *evictionPolicyTestSuite) TestEvictFileInUse() {
+	suite.setupTestHelper("lfu", 120)
+	defer suite.cleanupTest()
+
+	localPath := suite.createFile("in_use", 10)
+	suite.policy.CacheValid(localPath)
+
+	flock := suite.policy.fileLocks.Get("in_use")
+	flock.Inc()
+	defer flock.Dec()
+
+	suite.policy.evict([]string{localPath})
+
+	suite.assert.True(suite.policy.IsCached(localPath))
+	suite.assert.FileExists(localPath)
+}
+
+func (suite *evictionPolicyTestSuite) TestCacheRules() {
+	timeout := uint32(3600)
+	rules, err := common.NewCacheRules([]common.CacheRule{
+		{Path: "pinned*", Pin: true},
+		{Path: "long*", TimeoutSec: &timeout},
+	})
+	suite.assert.NoError(err)
+
+	suite.setupTestHelper("ttl", 1)
+	defer suite.cleanupTest()
+	suite.policy.cacheRules = rules
+
+	pinned := suite.createFile("pinned", 512*1024)
+	long := suite.createFile("long", 10)
+	other := suite.createFile("other", 10)
+	for _, name := range []string{pinned, long, other} {
+		suite.policy.CacheValid(name)
+	}
+
+	time.Sleep(2 * time.Second)
+	suite.assert.ElementsMatch([]string{other}, suite.policy.expiredNodes())
+
+	// Pinned files are not evicted for space either
+	suite.assert.NotContains(suite.policy.victimsForSpace(90), pinned)
+}
+
+func TestEvictionPolicyTestSuite(t *testing.T) {
+	suite.Run(t, new(evictionPolicyTestSuite))
+}
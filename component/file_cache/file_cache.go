@@ -81,9 +81,14 @@ type FileCache struct {
 	refreshSec        uint32
 	hardLimit         bool
 	diskHighWaterMark float64
+	validateOnOpen    bool
+	persistIndex      bool
 
 	lazyWrite    bool
 	fileCloseOpt sync.WaitGroup
+
+	cacheRules *common.CacheRules
+	pinned     *sync.Map // Files pinned through the control socket, keyed by path in container
 }
 
 // Structure defining your config parameters
@@ -112,8 +117,10 @@ type FileCacheOptions struct {
 	SyncToFlush   bool   `config:"sync-to-flush" yaml:"sync-to-flush,omitempty"`
 	SyncNoOp      bool   `config:"ignore-sync" yaml:"ignore-sync,omitempty"`
 
-	RefreshSec uint32 `config:"refresh-sec" yaml:"refresh-sec,omitempty"`
-	HardLimit  bool   `config:"hard-limit" yaml:"hard-limit,omitempty"`
+	RefreshSec     uint32 `config:"refresh-sec" yaml:"refresh-sec,omitempty"`
+	HardLimit      bool   `config:"hard-limit" yaml:"hard-limit,omitempty"`
+	ValidateOnOpen bool   `config:"validate-on-open" yaml:"validate-on-open,omitempty"`
+	PersistIndex   bool   `config:"persist-index" yaml:"persist-index,omitempty"`
 }
 
 const (
@@ -124,10 +131,17 @@ const (
 	defaultFileCacheTimeout = 120
 	defaultCacheUpdateCount = 100
 	MB                      = 1024 * 1024
+
+	// xattr on the local file holding ETag of the blob at the time of download
+	etagXattr = "user.etag"
 )
 
 // Verification to check satisfaction criteria with Component Interface
 var _ internal.Component = &FileCache{}
+var _ internal.PathInvalidator = &FileCache{}
+var _ internal.CacheController = &FileCache{}
+var _ internal.CacheDropper = &FileCache{}
+var _ internal.StateReporter = &FileCache{}
 
 var fileCacheStatsCollector *stats_manager.StatsCollector
 
@@ -179,7 +193,11 @@ func (fc *FileCache) Stop() error {
 	}
 
 	_ = fc.policy.ShutdownPolicy()
-	_ = common.TempCacheCleanup(fc.tmpPath)
+
+	// With a persisted index the cache is kept as is, so that the next mount can reuse it
+	if !fc.persistIndex {
+		_ = common.TempCacheCleanup(fc.tmpPath)
+	}
 
 	fileCacheStatsCollector.Destroy()
 
@@ -251,6 +269,8 @@ func (fc *FileCache) Configure(_ bool) error {
 	fc.syncToDelete = !conf.SyncNoOp
 	fc.refreshSec = conf.RefreshSec
 	fc.hardLimit = conf.HardLimit
+	fc.validateOnOpen = conf.ValidateOnOpen
+	fc.persistIndex = conf.PersistIndex
 
 	err = config.UnmarshalKey("lazy-write", &fc.lazyWrite)
 	if err != nil {
@@ -303,7 +323,7 @@ func (fc *FileCache) Configure(_ bool) error {
 		return fmt.Errorf("config error in %s error [max-size-mb: %f must be greater than 0]", fc.Name(), fc.maxCacheSizeMB)
 	}
 
-	if !isLocalDirEmpty(fc.tmpPath) && !fc.allowNonEmpty {
+	if !isLocalDirEmpty(fc.tmpPath) && !fc.allowNonEmpty && !fc.persistIndex {
 		log.Err("FileCache: config error %s directory is not empty", fc.tmpPath)
 		return fmt.Errorf("config error in %s [%s]", fc.Name(), "temp directory not empty")
 	}
@@ -320,12 +340,29 @@ func (fc *FileCache) Configure(_ bool) error {
 		fc.defaultPermission = common.DefaultFilePermissionBits
 	}
 
+	var rules []common.CacheRule
+	err = config.UnmarshalKey(common.CacheRulesKey, &rules)
+	if err != nil {
+		log.Err("FileCache::Configure : config error [invalid cache rules]")
+		return fmt.Errorf("config error in %s [%s]", fc.Name(), err.Error())
+	}
+
+	fc.cacheRules, err = common.NewCacheRules(rules)
+	if err != nil {
+		log.Err("FileCache::Configure : config error [%s]", err.Error())
+		return fmt.Errorf("config error in %s [%s]", fc.Name(), err.Error())
+	}
+
 	cacheConfig := fc.GetPolicyConfig(conf)
-	fc.policy = NewLRUPolicy(cacheConfig)
+	fc.policy, err = newCachePolicy(conf.Policy, cacheConfig)
+	if err != nil {
+		log.Err("FileCache::Configure : failed to create cache eviction policy [%s]", err.Error())
+		return fmt.Errorf("config error in %s [%s]", fc.Name(), err.Error())
+	}
 
-	if fc.policy == nil {
-		log.Err("FileCache::Configure : failed to create cache eviction policy")
-		return fmt.Errorf("config error in %s [%s]", fc.Name(), "failed to create cache policy")
+	if fc.persistIndex && fc.policy.Name() != "lru" {
+		log.Err("FileCache::Configure : persist-index is supported only with lru policy")
+		return fmt.Errorf("config error in %s [persist-index is supported only with lru policy]", fc.Name())
 	}
 
 	if config.IsSet(compName + ".background-download") {
@@ -349,11 +386,11 @@ func (fc *FileCache) Configure(_ bool) error {
 	log.Crit("FileCache::Configure : create-empty %t, cache-timeout %d, tmp-path %s, max-size-mb %d, high-mark %d, "+
 		"low-mark %d, refresh-sec %v, max-eviction %v, hard-limit %v, policy %s, allow-non-empty-temp %t, "+
 		"cleanup-on-start %t, policy-trace %t, offload-io %t, sync-to-flush %t, ignore-sync %t, defaultPermission %v, "+
-		"diskHighWaterMark %v, maxCacheSize %v, lazy-write %v, mountPath %v",
+		"diskHighWaterMark %v, maxCacheSize %v, lazy-write %v, mountPath %v, validate-on-open %v, persist-index %v, cache-rules %d",
 		fc.createEmptyFile, int(fc.cacheTimeout), fc.tmpPath, int(fc.maxCacheSizeMB), int(cacheConfig.highThreshold),
 		int(cacheConfig.lowThreshold), fc.refreshSec, cacheConfig.maxEviction, fc.hardLimit, conf.Policy, fc.allowNonEmpty,
 		conf.CleanupOnStart, fc.policyTrace, fc.offloadIO, fc.syncToFlush, fc.syncToDelete, fc.defaultPermission,
-		fc.diskHighWaterMark, fc.maxCacheSizeMB, fc.lazyWrite, fc.mountPath)
+		fc.diskHighWaterMark, fc.maxCacheSizeMB, fc.lazyWrite, fc.mountPath, fc.validateOnOpen, fc.persistIndex, fc.cacheRules.Len())
 
 	return nil
 }
@@ -428,11 +465,26 @@ func (fc *FileCache) GetPolicyConfig(conf FileCacheOptions) cachePolicyConfig {
 		maxSizeMB:     fc.maxCacheSizeMB,
 		fileLocks:     fc.fileLocks,
 		policyTrace:   conf.EnablePolicyTrace,
+		cacheRules:    fc.cacheRules,
+		pinned:        fc.pinned,
+	}
+
+	if fc.persistIndex {
+		cacheConfig.indexPath = getCacheIndexPath(fc.tmpPath)
 	}
 
 	return cacheConfig
 }
 
+// fileTimeout: Timeout in seconds of the local copy of a file, as per the cache rule applying to it
+func (fc *FileCache) fileTimeout(name string) float64 {
+	policy := fc.cacheRules.Match(name)
+	if !policy.Matched() {
+		return fc.cacheTimeout
+	}
+	return float64(policy.Timeout(uint32(fc.cacheTimeout)))
+}
+
 // isLocalDirEmpty: Whether or not the local directory is empty.
 func isLocalDirEmpty(path string) bool {
 	f, _ := os.Open(path)
@@ -458,6 +510,9 @@ func (fc *FileCache) invalidateDirectory(name string) {
 	// TODO : wouldn't this cause a race condition? a thread might get the lock before we purge - and the file would be non-existent
 	err = filepath.WalkDir(localPath, func(path string, d fs.DirEntry, err error) error {
 		if err == nil && d != nil {
+			if isCacheIndexPath(fc.tmpPath, path) {
+				return filepath.SkipDir
+			}
 			log.Debug("FileCache::invalidateDirectory : %s (%v) getting removed from cache", path, d.IsDir())
 			if !d.IsDir() {
 				fc.policy.CachePurge(path)
@@ -661,6 +716,10 @@ func (fc *FileCache) deleteEmptyDirs(options internal.DeleteDirOptions) (bool, e
 	}
 
 	for _, entry := range entries {
+		if isCacheIndexPath(fc.tmpPath, filepath.Join(localPath, entry.Name())) {
+			continue
+		}
+
 		if entry.IsDir() {
 			val, err := fc.deleteEmptyDirs(internal.DeleteDirOptions{
 				Name: filepath.Join(localPath, entry.Name()),
@@ -854,8 +913,9 @@ func (fc *FileCache) isDownloadRequired(localPath string, blobPath string, flock
 		// and hence last change time on local disk will then represent the download time.
 
 		lmt = finfo.ModTime()
-		if time.Since(finfo.ModTime()).Seconds() > fc.cacheTimeout &&
-			time.Since(time.Unix(int64(stat.Ctim.Sec), int64(stat.Ctim.Nsec))).Seconds() > fc.cacheTimeout {
+		cacheTimeout := fc.fileTimeout(blobPath)
+		if time.Since(finfo.ModTime()).Seconds() > cacheTimeout &&
+			time.Since(time.Unix(int64(stat.Ctim.Sec), int64(stat.Ctim.Nsec))).Seconds() > cacheTimeout {
 			log.Debug("FileCache::isDownloadRequired : %s not valid as per time checks", localPath)
 			downloadRequired = true
 		}
@@ -876,6 +936,23 @@ func (fc *FileCache) isDownloadRequired(localPath string, blobPath string, flock
 		downloadRequired = false
 	}
 
+	if fc.validateOnOpen && fileExists && flock.Count() == 0 {
+		// Instead of relying on timeout, check whether blob has changed since it was downloaded.
+		// If not, the local copy can be used even if timeout has expired or cache policy has lost track of it.
+		// Skip attribute caches below us, a cached ETag would defeat the check.
+		attr, err := fc.NextComponent().GetAttr(internal.GetAttrOptions{Name: blobPath, SkipCache: true})
+		if err != nil {
+			log.Err("FileCache::isDownloadRequired : Failed to get attr of %s [%s]", blobPath, err.Error())
+			return downloadRequired, fileExists, nil, err
+		}
+
+		downloadRequired = fc.isLocalCopyStale(localPath, attr, lmt, stat.Size)
+		if downloadRequired {
+			log.Info("FileCache::isDownloadRequired : %s is modified in container, forcing redownload", blobPath)
+		}
+		return downloadRequired, fileExists, attr, nil
+	}
+
 	err = nil // reset err variable
 	var attr *internal.ObjAttr = nil
 	if downloadRequired ||
@@ -886,6 +963,23 @@ func (fc *FileCache) isDownloadRequired(localPath string, blobPath string, flock
 		}
 	}
 
+	if fc.persistIndex && downloadRequired && fileExists && attr != nil && flock.Count() == 0 {
+		// Local copy has outlived the timeout, most likely it was restored from the index of an earlier mount.
+		// If blob has not changed since then, restart the timeout on the local copy instead of redownloading it.
+		if !fc.isLocalCopyStale(localPath, attr, lmt, stat.Size) {
+			log.Info("FileCache::isDownloadRequired : %s is not modified in container, reusing local copy", blobPath)
+
+			// chtimes resets the last change time, which is what timeout checks rely on
+			err = os.Chtimes(localPath, time.Unix(int64(stat.Atim.Sec), int64(stat.Atim.Nsec)), lmt)
+			if err != nil {
+				log.Err("FileCache::isDownloadRequired : Failed to change times of %s [%s]", localPath, err.Error())
+			} else {
+				flock.SetDownloadTime()
+				downloadRequired = false
+			}
+		}
+	}
+
 	if fc.refreshSec != 0 && !downloadRequired && attr != nil && stat != nil {
 		// We decided that based on lmt of file file-cache-timeout has not expired
 		// However, user has configured refresh time then check time has elapsed since last download time of file or not
@@ -907,6 +1001,51 @@ func (fc *FileCache) isDownloadRequired(localPath string, blobPath string, flock
 	return downloadRequired, fileExists, attr, err
 }
 
+// isLocalCopyStale: Whether the blob has changed since the local copy was downloaded.
+// ETag recorded at download time is compared with the current one, if either is not available
+// then last modified time and size are compared instead.
+func (fc *FileCache) isLocalCopyStale(localPath string, attr *internal.ObjAttr, lmt time.Time, size int64) bool {
+	etag := getCachedETag(localPath)
+	if etag != "" && attr.ETag != "" {
+		return etag != attr.ETag
+	}
+
+	return attr.Mtime.After(lmt) || attr.Size != size
+}
+
+// getCachedETag: ETag of the blob recorded on the local copy, empty if not available
+func getCachedETag(localPath string) string {
+	size, err := syscall.Getxattr(localPath, etagXattr, nil)
+	if err != nil || size <= 0 {
+		return ""
+	}
+
+	buf := make([]byte, size)
+	size, err = syscall.Getxattr(localPath, etagXattr, buf)
+	if err != nil {
+		return ""
+	}
+
+	return string(buf[:size])
+}
+
+// setCachedETag: Record ETag of the blob on the local copy, an empty ETag removes the recorded one
+func setCachedETag(localPath string, etag string) {
+	var err error
+	if etag == "" {
+		err = syscall.Removexattr(localPath, etagXattr)
+		if err == syscall.ENODATA {
+			err = nil
+		}
+	} else {
+		err = syscall.Setxattr(localPath, etagXattr, []byte(etag), 0)
+	}
+
+	if err != nil {
+		log.Warn("FileCache::setCachedETag : Failed to record etag of %s [%s]", localPath, err.Error())
+	}
+}
+
 // OpenFile: Makes the file available in the local cache for further file operations.
 func (fc *FileCache) OpenFile(options internal.OpenFileOptions) (*handlemap.Handle, error) {
 	log.Trace("FileCache::OpenFile : name=%s, flags=%s, mode=%s",
@@ -1026,12 +1165,18 @@ func (fc *FileCache) OpenFile(options internal.OpenFileOptions) (*handlemap.Hand
 			if err != nil {
 				log.Err("FileCache::OpenFile : Failed to change times of file %s [%s]", options.Name, err.Error())
 			}
+
+			if fc.validateOnOpen || fc.persistIndex {
+				setCachedETag(localPath, attr.ETag)
+			}
 		}
 
 		fileCacheStatsCollector.UpdateStats(stats_manager.Increment, dlFiles, (int64)(1))
+		fileCacheStatsCollector.CacheLookup(false)
 	} else {
 		log.Debug("FileCache::OpenFile : %s will be served from cache", options.Name)
 		fileCacheStatsCollector.UpdateStats(stats_manager.Increment, cacheServed, (int64)(1))
+		fileCacheStatsCollector.CacheLookup(true)
 	}
 
 	// Open the file and grab a shared lock to prevent deletion by the cache policy.
@@ -1352,10 +1497,13 @@ func (fc *FileCache) FlushFile(options internal.FlushFileOptions) error {
 			return err
 		}
 	}
+	// ETag of the uploaded version comes with the upload, a later lookup may return a version written by someone else
+	etag := ""
 	err = fc.NextComponent().CopyFromFile(
 		internal.CopyFromFileOptions{
-			Name: options.Handle.Path,
-			File: uploadHandle,
+			Name:    options.Handle.Path,
+			File:    uploadHandle,
+			NewETag: &etag,
 		})
 
 	uploadHandle.Close()
@@ -1374,6 +1522,12 @@ func (fc *FileCache) FlushFile(options internal.FlushFileOptions) error {
 
 	options.Handle.Flags.Clear(handlemap.HandleFlagDirty)
 
+	if fc.validateOnOpen || fc.persistIndex {
+		// Local copy now matches the blob, so record its new ETag for validation on next open. Without one, next open
+		// falls back to comparing last modified time and size.
+		setCachedETag(localPath, etag)
+	}
+
 	// If chmod was done on the file before it was uploaded to container then setting up mode would have been missed
 	// Such file names are added to this map and here post upload we try to set the mode correctly
 	_, found := fc.missedChmodList.Load(options.Handle.Path)
@@ -1507,7 +1661,7 @@ func (fc *FileCache) RenameFile(options internal.RenameFileOptions) error {
 
 	fc.policy.CachePurge(localSrcPath)
 
-	if fc.cacheTimeout == 0 {
+	if fc.fileTimeout(options.Dst) == 0 {
 		// Destination file needs to be deleted immediately
 		fc.policy.CachePurge(localDstPath)
 	} else {
@@ -1652,6 +1806,130 @@ func (fc *FileCache) FileUsed(name string) error {
 	return nil
 }
 
+// InvalidatePath: Remove the local copy of the file so that next open downloads it again.
+func (fc *FileCache) InvalidatePath(name string) {
+	log.Trace("FileCache::InvalidatePath : %s", name)
+
+	flock := fc.fileLocks.Get(name)
+	flock.Lock()
+	defer flock.Unlock()
+
+	localPath := filepath.Join(fc.tmpPath, name)
+	if !fc.policy.IsCached(localPath) {
+		return
+	}
+
+	// Local copy can not be removed while there are handles open on it
+	if flock.Count() > 0 {
+		log.Info("FileCache::InvalidatePath : %s has open handles, skipping invalidation", name)
+		return
+	}
+
+	err := deleteFile(localPath)
+	if err != nil && !os.IsNotExist(err) {
+		log.Err("FileCache::InvalidatePath : failed to delete local file %s [%s]", localPath, err.Error())
+	}
+
+	fc.policy.CachePurge(localPath)
+}
+
+// InvalidateDir: Remove the local copy of the directory and all its children.
+func (fc *FileCache) InvalidateDir(name string) {
+	log.Trace("FileCache::InvalidateDir : %s", name)
+	fc.invalidateDirectory(name)
+}
+
+// WarmFile: Download the file in local cache, if it is not already there.
+func (fc *FileCache) WarmFile(name string) error {
+	log.Trace("FileCache::WarmFile : %s", name)
+
+	// Open downloads the file and release puts it under the eviction policy, as if the user had read it
+	handle, err := fc.OpenFile(internal.OpenFileOptions{Name: name, Flags: os.O_RDONLY, Mode: fc.defaultPermission})
+	if err != nil {
+		log.Err("FileCache::WarmFile : failed to download %s [%s]", name, err.Error())
+		return err
+	}
+
+	return fc.ReleaseFile(internal.ReleaseFileOptions{Handle: handle})
+}
+
+// PinFile: Download the file in local cache and keep it there irrespective of timeout and cache usage.
+func (fc *FileCache) PinFile(name string) error {
+	log.Trace("FileCache::PinFile : %s", name)
+
+	fc.pinned.Store(name, struct{}{})
+	err := fc.WarmFile(name)
+	if err != nil {
+		fc.pinned.Delete(name)
+	}
+	return err
+}
+
+// UnpinFile: Let the eviction policy remove the file from local cache again.
+func (fc *FileCache) UnpinFile(name string) error {
+	log.Trace("FileCache::UnpinFile : %s", name)
+
+	fc.pinned.Delete(name)
+	return nil
+}
+
+// EvictFile: Unpin the file and remove its local copy, unless there are handles open on it.
+func (fc *FileCache) EvictFile(name string) error {
+	log.Trace("FileCache::EvictFile : %s", name)
+
+	fc.pinned.Delete(name)
+	fc.InvalidatePath(name)
+	return nil
+}
+
+// DropCache: Remove local copies of all files, except the pinned ones and those with open handles.
+func (fc *FileCache) DropCache() {
+	log.Trace("FileCache::DropCache")
+
+	names := make([]string, 0)
+	_ = filepath.WalkDir(fc.tmpPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d == nil {
+			return nil
+		}
+		if isCacheIndexPath(fc.tmpPath, path) {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() {
+			names = append(names, strings.TrimPrefix(path, fc.tmpPath+"/"))
+		}
+		return nil
+	})
+
+	dropped := 0
+	for _, name := range names {
+		if _, found := fc.pinned.Load(name); found || fc.cacheRules.Match(name).Pin() {
+			continue
+		}
+		fc.InvalidatePath(name)
+		dropped++
+	}
+
+	log.Info("FileCache::DropCache : Dropped %d of %d files in local cache", dropped, len(names))
+}
+
+// State: Settings of the local cache and the number of files pinned in it.
+func (fc *FileCache) State() map[string]any {
+	pinned := 0
+	fc.pinned.Range(func(_, _ any) bool {
+		pinned++
+		return true
+	})
+
+	return map[string]any{
+		"policy":      fc.policy.Name(),
+		"path":        fc.tmpPath,
+		"max-size-mb": fc.maxCacheSizeMB,
+		"timeout-sec": fc.cacheTimeout,
+		"pinned":      pinned,
+		"cache-rules": fc.cacheRules.Len(),
+	}
+}
+
 // ------------------------- Factory -------------------------------------------
 
 // Pipeline will call this method to create your object, initialize your variables here
@@ -1659,6 +1937,7 @@ func (fc *FileCache) FileUsed(name string) error {
 func NewFileCacheComponent() internal.Component {
 	comp := &FileCache{
 		fileLocks: common.NewLockMap(),
+		pinned:    &sync.Map{},
 	}
 	comp.SetName(compName)
 	config.AddConfigChangeEventListener(comp)
@@ -1708,7 +1987,7 @@ func init() {
 	config.BindPFlag(compName+".upload-modified-only", uploadModifiedOnly)
 	uploadModifiedOnly.Hidden = true
 
-	cachePolicy := config.AddStringFlag("file-cache-policy", "lru", "Cache eviction policy.")
+	cachePolicy := config.AddStringFlag("file-cache-policy", "lru", "Cache eviction policy. Supported values are lru, lfu, arc, size and ttl.")
 	config.BindPFlag(compName+".policy", cachePolicy)
 	cachePolicy.Hidden = true
 
@@ -1721,6 +2000,12 @@ func init() {
 	hardLimit := config.AddBoolFlag("hard-limit", false, "File cache limits are hard limits or not.")
 	config.BindPFlag(compName+".hard-limit", hardLimit)
 
+	validateOnOpen := config.AddBoolFlag("validate-on-open", false, "Validate cached file against the blob on every open instead of relying on timeout.")
+	config.BindPFlag(compName+".validate-on-open", validateOnOpen)
+
+	persistIndex := config.AddBoolFlag("persist-index", false, "Persist index of cached files so that the cache survives remounts.")
+	config.BindPFlag(compName+".persist-index", persistIndex)
+
 	config.RegisterFlagCompletionFunc("tmp-path", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return nil, cobra.ShellCompDirectiveDefault
 	})
@@ -54,6 +54,7 @@ import (
 	"github.com/Azure/azure-storage-fuse/v2/common"
 	"github.com/Azure/azure-storage-fuse/v2/common/config"
 	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/Azure/azure-storage-fuse/v2/component/attr_cache"
 	"github.com/Azure/azure-storage-fuse/v2/component/loopback"
 	"github.com/Azure/azure-storage-fuse/v2/internal"
 	"github.com/Azure/azure-storage-fuse/v2/internal/handlemap"
@@ -950,6 +951,112 @@ func (suite *fileCacheTestSuite) TestOpenFileNotInCache() {
 	suite.assert.True(err == nil || os.IsExist(err))
 }
 
+func (suite *fileCacheTestSuite) TestCacheRules() {
+	defer suite.cleanupTest()
+	suite.cleanupTest()
+	cfg := fmt.Sprintf("file_cache:\n  path: %s\n  offload-io: true\n  timeout-sec: 120\n\nloopbackfs:\n  path: %s\n\n"+
+		"cache-rules:\n  - path: ckpt/**\n    never-cache: true\n", suite.cache_path, suite.fake_storage_path)
+	suite.setupTestHelper(cfg)
+	suite.assert.Equal(1, suite.fileCache.cacheRules.Len())
+	suite.assert.EqualValues(0, suite.fileCache.fileTimeout("ckpt/step1"))
+	suite.assert.EqualValues(120, suite.fileCache.fileTimeout("data/file"))
+
+	for _, path := range []string{"ckpt/step1", "data/file"} {
+		suite.assert.NoError(suite.fileCache.CreateDir(internal.CreateDirOptions{Name: filepath.Dir(path), Mode: 0777}))
+		handle, err := suite.fileCache.CreateFile(internal.CreateFileOptions{Name: path, Mode: 0777})
+		suite.assert.NoError(err)
+		_, err = suite.fileCache.WriteFile(&internal.WriteFileOptions{Handle: handle, Offset: 0, Data: []byte("test data")})
+		suite.assert.NoError(err)
+		suite.assert.NoError(suite.fileCache.ReleaseFile(internal.ReleaseFileOptions{Handle: handle}))
+	}
+
+	// Never cached file is removed from local cache on close, the other one stays for the timeout
+	suite.assert.Eventually(func() bool {
+		_, err := os.Stat(filepath.Join(suite.cache_path, "ckpt/step1"))
+		return os.IsNotExist(err)
+	}, 5*time.Second, 10*time.Millisecond)
+	suite.assert.FileExists(filepath.Join(suite.cache_path, "data/file"))
+	suite.assert.FileExists(filepath.Join(suite.fake_storage_path, "ckpt/step1"))
+}
+
+func (suite *fileCacheTestSuite) TestInvalidCacheRules() {
+	defer suite.cleanupTest()
+	cfg := fmt.Sprintf("file_cache:\n  path: %s\n\ncache-rules:\n  - path: ckpt/**\n    never-cache: true\n    pin: true\n", suite.cache_path)
+	suite.assert.NoError(config.ReadConfigFromReader(strings.NewReader(cfg)))
+
+	fc := NewFileCacheComponent()
+	err := fc.Configure(true)
+	suite.assert.Error(err)
+	suite.assert.Contains(err.Error(), "both never-cache and pin")
+}
+
+func (suite *fileCacheTestSuite) TestPinFile() {
+	defer suite.cleanupTest()
+	suite.cleanupTest()
+	cfg := fmt.Sprintf("file_cache:\n  path: %s\n  offload-io: true\n  timeout-sec: 0\n\nloopbackfs:\n  path: %s", suite.cache_path, suite.fake_storage_path)
+	suite.setupTestHelper(cfg)
+
+	for _, path := range []string{"pinned", "warm"} {
+		suite.assert.NoError(os.WriteFile(filepath.Join(suite.fake_storage_path, path), []byte("test data"), 0777))
+	}
+
+	// With timeout 0, a warmed file goes as soon as it is downloaded while a pinned one stays
+	suite.assert.NoError(suite.fileCache.PinFile("pinned"))
+	suite.assert.NoError(suite.fileCache.WarmFile("warm"))
+	suite.assert.Eventually(func() bool {
+		_, err := os.Stat(filepath.Join(suite.cache_path, "warm"))
+		return os.IsNotExist(err)
+	}, 5*time.Second, 10*time.Millisecond)
+	suite.assert.FileExists(filepath.Join(suite.cache_path, "pinned"))
+
+	// Pinned file is not evicted when usage crosses the threshold either
+	policy := suite.fileCache.policy.(*lruPolicy)
+	policy.updateMarker()
+	policy.updateMarker()
+	policy.deleteExpiredNodes(false)
+	suite.assert.FileExists(filepath.Join(suite.cache_path, "pinned"))
+
+	suite.assert.NoError(suite.fileCache.UnpinFile("pinned"))
+	suite.assert.False(policy.isPinned(filepath.Join(suite.cache_path, "pinned")))
+
+	suite.assert.NoError(suite.fileCache.PinFile("pinned"))
+	suite.assert.NoError(suite.fileCache.EvictFile("pinned"))
+	suite.assert.NoFileExists(filepath.Join(suite.cache_path, "pinned"))
+	suite.assert.False(policy.isPinned(filepath.Join(suite.cache_path, "pinned")))
+
+	_, err := os.Stat(filepath.Join(suite.fake_storage_path, "pinned"))
+	suite.assert.NoError(err)
+}
+
+func (suite *fileCacheTestSuite) TestDropCache() {
+	defer suite.cleanupTest()
+	suite.cleanupTest()
+	cfg := fmt.Sprintf("file_cache:\n  path: %s\n  offload-io: true\n  timeout-sec: 3600\n\nloopbackfs:\n  path: %s", suite.cache_path, suite.fake_storage_path)
+	suite.setupTestHelper(cfg)
+
+	for _, path := range []string{"pinned", "open", "dir/cached"} {
+		suite.assert.NoError(os.MkdirAll(filepath.Dir(filepath.Join(suite.fake_storage_path, path)), 0777))
+		suite.assert.NoError(os.WriteFile(filepath.Join(suite.fake_storage_path, path), []byte("test data"), 0777))
+	}
+
+	suite.assert.NoError(suite.fileCache.PinFile("pinned"))
+	suite.assert.NoError(suite.fileCache.WarmFile("dir/cached"))
+	handle, err := suite.fileCache.OpenFile(internal.OpenFileOptions{Name: "open", Flags: os.O_RDONLY, Mode: 0777})
+	suite.assert.NoError(err)
+
+	// Pinned file and the one with an open handle are retained
+	suite.fileCache.DropCache()
+	suite.assert.NoFileExists(filepath.Join(suite.cache_path, "dir/cached"))
+	suite.assert.FileExists(filepath.Join(suite.cache_path, "pinned"))
+	suite.assert.FileExists(filepath.Join(suite.cache_path, "open"))
+
+	suite.assert.NoError(suite.fileCache.ReleaseFile(internal.ReleaseFileOptions{Handle: handle}))
+
+	state := suite.fileCache.State()
+	suite.assert.Equal(1, state["pinned"])
+	suite.assert.Equal(suite.cache_path, state["path"])
+}
+
 func (suite *fileCacheTestSuite) TestOpenFileInCache() {
 	defer suite.cleanupTest()
 	path := "file8"
@@ -1460,6 +1567,53 @@ func (suite *fileCacheTestSuite) TestFlushFileConcurrentWithRelease() {
 		"expected max 1 concurrent CopyFromFile, got %d — uploads were not serialized", maxInFlight.Load())
 }
 
+// TestFlushFileRecordsUploadETag verifies that the ETag recorded on the local copy is the one of the upload itself
+// and not looked up afterwards, when the blob may already have been written by someone else.
+func (suite *fileCacheTestSuite) TestFlushFileRecordsUploadETag() {
+	defer suite.cleanupTest()
+	mockCtrl := gomock.NewController(suite.T())
+	defer mockCtrl.Finish()
+
+	fc, mockComponent, cachePath, cleanup := suite.setupMockFileCacheForFlush(mockCtrl)
+	defer cleanup()
+	fc.validateOnOpen = true
+
+	path := "flush_etag.txt"
+	localPath := filepath.Join(cachePath, path)
+	err := os.MkdirAll(filepath.Dir(localPath), 0755)
+	suite.assert.NoError(err)
+	f, err := os.Create(localPath)
+	suite.assert.NoError(err)
+	defer f.Close()
+	_, err = f.WriteString("flush etag test data")
+	suite.assert.NoError(err)
+
+	handle := handlemap.NewHandle(path)
+	handle.UnixFD = uint64(f.Fd())
+	handle.SetFileObject(f)
+	handle.Flags.Set(handlemap.HandleFlagDirty)
+
+	// No GetAttr is expected after the upload
+	mockComponent.EXPECT().
+		CopyFromFile(gomock.Any()).
+		DoAndReturn(func(opts internal.CopyFromFileOptions) error {
+			*opts.NewETag = "0x2"
+			return nil
+		})
+
+	err = fc.FlushFile(internal.FlushFileOptions{Handle: handle})
+	suite.assert.NoError(err)
+	suite.assert.Equal("0x2", getCachedETag(localPath))
+
+	// Upload which does not report an ETag clears the recorded one
+	handle.Flags.Set(handlemap.HandleFlagDirty)
+	mockComponent.EXPECT().CopyFromFile(gomock.Any()).Return(nil)
+
+	err = fc.FlushFile(internal.FlushFileOptions{Handle: handle})
+	suite.assert.NoError(err)
+	suite.assert.Empty(getCachedETag(localPath))
+}
+
 // TestFlushFileSyncFileConcurrent verifies that SyncFile (which calls FlushFile internally
 // when syncToFlush is enabled) and a direct FlushFile call are serialized so that
 // CopyFromFile never runs concurrently.
@@ -2524,6 +2678,232 @@ func (suite *fileCacheTestSuite) TestOpenFileDownloadFailure() {
 	suite.assert.NoError(err)
 }
 
+func (suite *fileCacheTestSuite) TestInvalidatePath() {
+	defer suite.cleanupTest()
+	suite.cleanupTest() // teardown the default file cache generated
+	config := fmt.Sprintf("file_cache:\n  path: %s\n  offload-io: true\n  timeout-sec: 1000\n\nloopbackfs:\n  path: %s",
+		suite.cache_path, suite.fake_storage_path)
+	suite.setupTestHelper(config)
+
+	path := "file_invalidate"
+	handle, err := suite.fileCache.CreateFile(internal.CreateFileOptions{Name: path, Mode: 0777})
+	suite.assert.NoError(err)
+
+	// File is open so local copy shall not be removed
+	suite.fileCache.InvalidatePath(path)
+	_, err = os.Stat(filepath.Join(suite.cache_path, path))
+	suite.assert.NoError(err)
+
+	err = suite.fileCache.ReleaseFile(internal.ReleaseFileOptions{Handle: handle})
+	suite.assert.NoError(err)
+	_, err = os.Stat(filepath.Join(suite.cache_path, path))
+	suite.assert.NoError(err)
+
+	suite.fileCache.InvalidatePath(path)
+	_, err = os.Stat(filepath.Join(suite.cache_path, path))
+	suite.assert.True(os.IsNotExist(err))
+	suite.assert.False(suite.fileCache.policy.IsCached(filepath.Join(suite.cache_path, path)))
+
+	// File should still be in storage
+	_, err = os.Stat(filepath.Join(suite.fake_storage_path, path))
+	suite.assert.NoError(err)
+}
+
+func (suite *fileCacheTestSuite) TestValidateOnOpen() {
+	defer suite.cleanupTest()
+	suite.cleanupTest() // teardown the default file cache generated
+	config := fmt.Sprintf("file_cache:\n  path: %s\n  offload-io: true\n  timeout-sec: 1000\n  validate-on-open: true\n\nloopbackfs:\n  path: %s",
+		suite.cache_path, suite.fake_storage_path)
+	suite.setupTestHelper(config)
+	suite.assert.True(suite.fileCache.validateOnOpen)
+
+	path := "file_validate"
+	err := os.MkdirAll(suite.fake_storage_path, 0777)
+	suite.assert.NoError(err)
+	err = os.WriteFile(filepath.Join(suite.fake_storage_path, path), []byte("old data"), 0777)
+	suite.assert.NoError(err)
+	past := time.Now().Add(-time.Hour)
+	_ = os.Chtimes(filepath.Join(suite.fake_storage_path, path), past, past)
+
+	handle, err := suite.fileCache.OpenFile(internal.OpenFileOptions{Name: path, Flags: os.O_RDONLY, Mode: 0777})
+	suite.assert.NoError(err)
+	err = suite.fileCache.ReleaseFile(internal.ReleaseFileOptions{Handle: handle})
+	suite.assert.NoError(err)
+
+	// Blob not modified, local copy is served. Mark the local copy to detect a redownload.
+	err = os.WriteFile(filepath.Join(suite.cache_path, path), []byte("OLD DATA"), 0777)
+	suite.assert.NoError(err)
+	_ = os.Chtimes(filepath.Join(suite.cache_path, path), past, past)
+
+	handle, err = suite.fileCache.OpenFile(internal.OpenFileOptions{Name: path, Flags: os.O_RDONLY, Mode: 0777})
+	suite.assert.NoError(err)
+	err = suite.fileCache.ReleaseFile(internal.ReleaseFileOptions{Handle: handle})
+	suite.assert.NoError(err)
+	data, err := os.ReadFile(filepath.Join(suite.cache_path, path))
+	suite.assert.NoError(err)
+	suite.assert.Equal("OLD DATA", string(data))
+
+	// Blob modified by someone else, local copy shall be refreshed even though timeout has not expired
+	err = os.WriteFile(filepath.Join(suite.fake_storage_path, path), []byte("new data from other node"), 0777)
+	suite.assert.NoError(err)
+
+	handle, err = suite.fileCache.OpenFile(internal.OpenFileOptions{Name: path, Flags: os.O_RDONLY, Mode: 0777})
+	suite.assert.NoError(err)
+	data, err = os.ReadFile(filepath.Join(suite.cache_path, path))
+	suite.assert.NoError(err)
+	suite.assert.Equal("new data from other node", string(data))
+	err = suite.fileCache.ReleaseFile(internal.ReleaseFileOptions{Handle: handle})
+	suite.assert.NoError(err)
+}
+
+func (suite *fileCacheTestSuite) TestValidateOnOpenWithAttrCache() {
+	defer suite.cleanupTest()
+	suite.cleanupTest() // teardown the default file cache generated
+	config.ResetConfig()
+	cfg := fmt.Sprintf("file_cache:\n  path: %s\n  offload-io: true\n  timeout-sec: 1000\n  validate-on-open: true\n\nattr_cache:\n  timeout-sec: 1000\n\nloopbackfs:\n  path: %s",
+		suite.cache_path, suite.fake_storage_path)
+	err := config.ReadConfigFromReader(strings.NewReader(cfg))
+	suite.assert.NoError(err)
+
+	// file_cache -> attr_cache -> loopback, as in a regular mount
+	suite.loopback = newLoopbackFS()
+	attrCache := attr_cache.NewAttrCacheComponent()
+	attrCache.SetNextComponent(suite.loopback)
+	suite.assert.NoError(attrCache.Configure(true))
+	suite.fileCache = newTestFileCache(attrCache)
+	suite.assert.NoError(suite.loopback.Start(context.Background()))
+	suite.assert.NoError(attrCache.Start(context.Background()))
+	suite.assert.NoError(suite.fileCache.Start(context.Background()))
+	defer func() { _ = attrCache.Stop() }()
+
+	path := "file_validate_attr"
+	err = os.MkdirAll(suite.fake_storage_path, 0777)
+	suite.assert.NoError(err)
+	err = os.WriteFile(filepath.Join(suite.fake_storage_path, path), []byte("old data"), 0777)
+	suite.assert.NoError(err)
+	past := time.Now().Add(-time.Hour)
+	_ = os.Chtimes(filepath.Join(suite.fake_storage_path, path), past, past)
+
+	handle, err := suite.fileCache.OpenFile(internal.OpenFileOptions{Name: path, Flags: os.O_RDONLY, Mode: 0777})
+	suite.assert.NoError(err)
+	err = suite.fileCache.ReleaseFile(internal.ReleaseFileOptions{Handle: handle})
+	suite.assert.NoError(err)
+
+	// attr_cache now holds the old attributes, the blob is modified behind its back
+	err = os.WriteFile(filepath.Join(suite.fake_storage_path, path), []byte("new data from other node"), 0777)
+	suite.assert.NoError(err)
+	attr, err := attrCache.GetAttr(internal.GetAttrOptions{Name: path})
+	suite.assert.NoError(err)
+	suite.assert.EqualValues(len("old data"), attr.Size)
+
+	handle, err = suite.fileCache.OpenFile(internal.OpenFileOptions{Name: path, Flags: os.O_RDONLY, Mode: 0777})
+	suite.assert.NoError(err)
+	data, err := os.ReadFile(filepath.Join(suite.cache_path, path))
+	suite.assert.NoError(err)
+	suite.assert.Equal("new data from other node", string(data))
+	err = suite.fileCache.ReleaseFile(internal.ReleaseFileOptions{Handle: handle})
+	suite.assert.NoError(err)
+
+	// The fresh attributes were cached on the way
+	attr, err = attrCache.GetAttr(internal.GetAttrOptions{Name: path})
+	suite.assert.NoError(err)
+	suite.assert.EqualValues(len("new data from other node"), attr.Size)
+}
+
+func (suite *fileCacheTestSuite) TestIsLocalCopyStaleETag() {
+	defer suite.cleanupTest()
+
+	localPath := filepath.Join(suite.cache_path, "file_etag")
+	err := os.WriteFile(localPath, []byte("data"), 0777)
+	suite.assert.NoError(err)
+
+	lmt := time.Now()
+	setCachedETag(localPath, "0x1")
+	suite.assert.Equal("0x1", getCachedETag(localPath))
+
+	// ETag takes precedence over time and size
+	suite.assert.False(suite.fileCache.isLocalCopyStale(localPath, &internal.ObjAttr{ETag: "0x1", Mtime: lmt.Add(time.Hour), Size: 10}, lmt, 4))
+	suite.assert.True(suite.fileCache.isLocalCopyStale(localPath, &internal.ObjAttr{ETag: "0x2", Mtime: lmt, Size: 4}, lmt, 4))
+
+	// No ETag, fall back to time and size
+	setCachedETag(localPath, "")
+	suite.assert.Empty(getCachedETag(localPath))
+	suite.assert.False(suite.fileCache.isLocalCopyStale(localPath, &internal.ObjAttr{ETag: "0x2", Mtime: lmt, Size: 4}, lmt, 4))
+	suite.assert.True(suite.fileCache.isLocalCopyStale(localPath, &internal.ObjAttr{Mtime: lmt.Add(time.Second), Size: 4}, lmt, 4))
+	suite.assert.True(suite.fileCache.isLocalCopyStale(localPath, &internal.ObjAttr{Mtime: lmt, Size: 5}, lmt, 4))
+}
+
+func (suite *fileCacheTestSuite) TestConfigEvictionPolicy() {
+	defer suite.cleanupTest()
+	suite.cleanupTest() // teardown the default file cache generated
+	cfg := fmt.Sprintf("file_cache:\n  path: %s\n  offload-io: true\n  policy: arc\n\nloopbackfs:\n  path: %s",
+		suite.cache_path, suite.fake_storage_path)
+	suite.setupTestHelper(cfg)
+	suite.assert.Equal("arc", suite.fileCache.policy.Name())
+
+	// Unknown policy and persisting index with a policy other than lru are rejected
+	for _, option := range []string{"policy: mru", "policy: lfu\n  persist-index: true"} {
+		err := config.ReadConfigFromReader(strings.NewReader(fmt.Sprintf("file_cache:\n  path: %s\n  %s", suite.cache_path, option)))
+		suite.assert.NoError(err)
+
+		fc := NewFileCacheComponent()
+		err = fc.Configure(true)
+		suite.assert.Error(err)
+	}
+}
+
+func (suite *fileCacheTestSuite) TestPersistIndex() {
+	defer suite.cleanupTest()
+	suite.cleanupTest() // teardown the default file cache generated
+	config := fmt.Sprintf("file_cache:\n  path: %s\n  offload-io: true\n  timeout-sec: 2\n  persist-index: true\n\nloopbackfs:\n  path: %s",
+		suite.cache_path, suite.fake_storage_path)
+	suite.setupTestHelper(config)
+	suite.assert.True(suite.fileCache.persistIndex)
+
+	path := "file_persist"
+	err := os.MkdirAll(suite.fake_storage_path, 0777)
+	suite.assert.NoError(err)
+	err = os.WriteFile(filepath.Join(suite.fake_storage_path, path), []byte("old data"), 0777)
+	suite.assert.NoError(err)
+
+	handle, err := suite.fileCache.OpenFile(internal.OpenFileOptions{Name: path, Flags: os.O_RDONLY, Mode: 0777})
+	suite.assert.NoError(err)
+	err = suite.fileCache.ReleaseFile(internal.ReleaseFileOptions{Handle: handle})
+	suite.assert.NoError(err)
+
+	// Mark the local copy to detect a redownload
+	err = os.WriteFile(filepath.Join(suite.cache_path, path), []byte("OLD DATA"), 0777)
+	suite.assert.NoError(err)
+
+	// Remount, the cache shall be kept along with its index
+	err = suite.fileCache.Stop()
+	suite.assert.NoError(err)
+	err = suite.loopback.Stop()
+	suite.assert.NoError(err)
+	suite.assert.FileExists(filepath.Join(suite.cache_path, path))
+	suite.assert.FileExists(getCacheIndexPath(suite.cache_path))
+
+	// Let the timeout expire so that the local copy has to be validated against the blob
+	time.Sleep(3 * time.Second)
+	suite.setupTestHelper(config)
+	suite.assert.True(suite.fileCache.policy.IsCached(filepath.Join(suite.cache_path, path)))
+
+	handle, err = suite.fileCache.OpenFile(internal.OpenFileOptions{Name: path, Flags: os.O_RDONLY, Mode: 0777})
+	suite.assert.NoError(err)
+	err = suite.fileCache.ReleaseFile(internal.ReleaseFileOptions{Handle: handle})
+	suite.assert.NoError(err)
+	data, err := os.ReadFile(filepath.Join(suite.cache_path, path))
+	suite.assert.NoError(err)
+	suite.assert.Equal("OLD DATA", string(data))
+
+	// Index is not exposed in listings
+	dir, err := suite.fileCache.ReadDir(internal.ReadDirOptions{Name: ""})
+	suite.assert.NoError(err)
+	for _, attr := range dir {
+		suite.assert.NotEqual(cacheIndexDir, attr.Name)
+	}
+}
+
 // In order for 'go test' to run this suite, we need to create
 // a normal test function and pass our suite to suite.Run
 func TestFileCacheTestSuite(t *testing.T) {
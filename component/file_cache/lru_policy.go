@@ -34,8 +34,6 @@
 package file_cache
 
 import (
-	"os"
-	"strings"
 	"sync"
 	"time"
 
@@ -49,6 +47,8 @@ type lruNode struct {
 	usage   int
 	deleted bool
 	name    string
+
+	lastAccess time.Time
 }
 
 type lruPolicy struct {
@@ -81,6 +81,9 @@ type lruPolicy struct {
 	// Channel to check for file eviction based on file-cache timeout
 	cacheTimeoutMonitor <-chan time.Time
 
+	// Channel to persist the cache index periodically
+	indexMonitor <-chan time.Time
+
 	// DU utility was found on the path or not
 	duPresent bool
 }
@@ -91,6 +94,9 @@ const (
 
 	// Check for disk usage in below number of minutes
 	DiskUsageCheckInterval = 1
+
+	// Persist the cache index in below number of minutes
+	CacheIndexSaveInterval = 1
 )
 
 var _ cachePolicy = &lruPolicy{}
@@ -142,8 +148,15 @@ func (p *lruPolicy) StartPolicy() error {
 	// If evictTime=0, we delete on invalidate so there is no need for a timeout monitor signal to be sent.
 	log.Info("lruPolicy::StartPolicy : Policy set with %v timeout", p.cacheTimeout)
 
-	if p.cacheTimeout != 0 {
-		p.cacheTimeoutMonitor = time.Tick(time.Duration(time.Duration(p.cacheTimeout) * time.Second))
+	// Cache rules may expire some files sooner than the timeout, so check at the shortest of the timeouts
+	if interval := p.timeoutCheckInterval(); interval != 0 {
+		p.cacheTimeoutMonitor = time.Tick(time.Duration(time.Duration(interval) * time.Second))
+	}
+
+	// Rebuild the lru list from the index persisted by the last mount
+	if p.indexPath != "" {
+		p.restoreFromIndex()
+		p.indexMonitor = time.Tick(time.Duration(CacheIndexSaveInterval * time.Minute))
 	}
 
 	p.wg.Add(2)
@@ -160,6 +173,10 @@ func (p *lruPolicy) ShutdownPolicy() error {
 	p.closeSignalValidate <- 1
 	// wait for all go-routines to stop.
 	p.wg.Wait()
+
+	if p.indexPath != "" {
+		p.saveIndex()
+	}
 	return nil
 }
 
@@ -192,7 +209,7 @@ func (p *lruPolicy) CacheInvalidate(name string) {
 	// since there are other open handles. When the last close comes in, the map
 	// will be clean so we we need to try deleting the file.
 	_, found := p.nodeMap.Load(name)
-	if p.cacheTimeout == 0 || !found {
+	if (p.timeoutOf(name) == 0 && !p.isPinned(name)) || !found {
 		p.CachePurge(name)
 	}
 }
@@ -258,6 +275,7 @@ func (p *lruPolicy) cacheValidate(name string) {
 	defer p.Unlock()
 
 	node.deleted = false
+	node.lastAccess = time.Now()
 
 	if node == p.head {
 		return
@@ -297,7 +315,7 @@ func (p *lruPolicy) clearCache() {
 			// File cache timeout has hit so delete all unused files for past N seconds
 			p.updateMarker()
 			p.printNodes()
-			p.deleteExpiredNodes()
+			p.deleteExpiredNodes(true)
 
 		case <-p.diskUsageMonitor:
 			// File cache timeout has not occurred so just monitor the cache usage
@@ -311,7 +329,7 @@ func (p *lruPolicy) clearCache() {
 					cleanupCount++
 					p.updateMarker()
 					p.printNodes()
-					p.deleteExpiredNodes()
+					p.deleteExpiredNodes(false)
 
 					pUsage := getUsagePercentage(p.tmpPath, p.maxSizeMB)
 					if pUsage < p.lowThreshold || cleanupCount >= 3 {
@@ -321,6 +339,9 @@ func (p *lruPolicy) clearCache() {
 				}
 			}
 
+		case <-p.indexMonitor:
+			p.saveIndex()
+
 		case <-p.closeSignal:
 			return
 		}
@@ -386,7 +407,9 @@ func (p *lruPolicy) updateMarker() {
 	p.Unlock()
 }
 
-func (p *lruPolicy) deleteExpiredNodes() {
+// deleteExpiredNodes : Delete files not used since the last marker. Pinned files are retained,
+// and so are files within the timeout of their rule when evicting on timeout.
+func (p *lruPolicy) deleteExpiredNodes(onTimeout bool) {
 	log.Debug("lruPolicy::deleteExpiredNodes : Starts")
 
 	if p.lastMarker.next == nil {
@@ -394,6 +417,7 @@ func (p *lruPolicy) deleteExpiredNodes() {
 	}
 
 	delItems := make([]*lruNode, 0)
+	keepItems := make([]*lruNode, 0)
 	count := uint32(0)
 
 	p.Lock()
@@ -405,8 +429,12 @@ func (p *lruPolicy) deleteExpiredNodes() {
 	}
 
 	for ; node != nil && count < p.maxEviction; node = node.next {
-		delItems = append(delItems, node)
-		node.deleted = true
+		if p.retained(node, onTimeout) {
+			keepItems = append(keepItems, node)
+		} else {
+			delItems = append(delItems, node)
+			node.deleted = true
+		}
 		count++
 	}
 
@@ -418,9 +446,16 @@ func (p *lruPolicy) deleteExpiredNodes() {
 	if node != nil {
 		node.prev = p.lastMarker
 	}
+
+	// Retained nodes are put back in the list, so they must not link into the part left after the marker
+	if len(keepItems) > 0 {
+		for _, item := range append(delItems, keepItems...) {
+			item.next, item.prev = nil, nil
+		}
+	}
 	p.Unlock()
 
-	log.Debug("lruPolicy::deleteExpiredNodes : List generated %d items", count)
+	log.Debug("lruPolicy::deleteExpiredNodes : List generated %d items, retained %d", count, len(keepItems))
 
 	for _, item := range delItems {
 		if item.deleted {
@@ -429,48 +464,49 @@ func (p *lruPolicy) deleteExpiredNodes() {
 		}
 	}
 
+	for _, item := range keepItems {
+		p.requeue(item)
+	}
+
 	log.Debug("lruPolicy::deleteExpiredNodes : Ends")
 }
 
-func (p *lruPolicy) deleteItem(name string) {
-	log.Trace("lruPolicy::deleteItem : Deleting %s", name)
-
-	azPath := strings.TrimPrefix(name, p.tmpPath)
-	if azPath == "" {
-		log.Err("lruPolicy::DeleteItem : Empty file name formed name : %s, tmpPath : %s", name, p.tmpPath)
-		return
+// retained : Whether the file is pinned or its cache rule keeps it from being evicted
+func (p *lruPolicy) retained(node *lruNode, onTimeout bool) bool {
+	if p.isPinned(node.name) {
+		return true
 	}
 
-	if azPath[0] == '/' {
-		azPath = azPath[1:]
+	if p.cacheRules == nil {
+		return false
 	}
 
-	flock := p.fileLocks.Get(azPath)
-	if p.fileLocks.Locked(azPath) {
-		log.Warn("lruPolicy::DeleteItem : File in under download %s", azPath)
-		p.CacheValid(name)
-		return
-	}
+	timeout := time.Duration(p.policyOf(node.name).Timeout(p.cacheTimeout)) * time.Second
+	return onTimeout && time.Since(node.lastAccess) < timeout
+}
 
-	flock.Lock()
-	defer flock.Unlock()
+// requeue : Put back a node retained on eviction at the head of the list, keeping its last access time
+func (p *lruPolicy) requeue(node *lruNode) {
+	p.Lock()
+	defer p.Unlock()
 
-	// Check if there are any open handles to this file or not
-	if flock.Count() > 0 {
-		log.Warn("lruPolicy::DeleteItem : File in use %s", name)
-		p.CacheValid(name)
+	// Node was purged or used again in the meantime
+	if node.deleted || node.prev != nil || node == p.head {
 		return
 	}
 
-	// There are no open handles for this file so its safe to remove this
-	err := deleteFile(name)
-	if err != nil && !os.IsNotExist(err) {
-		log.Err("lruPolicy::DeleteItem : failed to delete local file %s [%s]", name, err.Error())
-	}
+	node.next = p.head
+	p.head.prev = node
+	p.head = node
+}
+
+func (p *lruPolicy) deleteItem(name string) {
+	log.Trace("lruPolicy::deleteItem : Deleting %s", name)
 
-	// File was deleted so try clearing its parent directory
-	// TODO: Delete directories up the path recursively that are "safe to delete". Ensure there is no race between this code and code that creates directories (like OpenFile)
-	// This might require something like hierarchical locking.
+	if !deleteCachedFile(p.tmpPath, p.fileLocks, name, nil) {
+		// File is in use so keep it in the cache
+		p.CacheValid(name)
+	}
 }
 
 func (p *lruPolicy) printNodes() {
@@ -274,6 +274,116 @@ func (suite *lruPolicyTestSuite) TestMaxEviction() {
 	}
 }
 
+func (suite *lruPolicyTestSuite) TestCacheRules() {
+	defer suite.cleanupTest()
+	suite.cleanupTest()
+
+	timeout := uint32(3600)
+	rules, err := common.NewCacheRules([]common.CacheRule{
+		{Path: "pinned/**", Pin: true},
+		{Path: "long/**", TimeoutSec: &timeout},
+		{Path: "never/**", NeverCache: true},
+	})
+	suite.assert.NoError(err)
+
+	config := cachePolicyConfig{
+		tmpPath:       cache_path,
+		cacheTimeout:  1,
+		maxEviction:   defaultMaxEviction,
+		maxSizeMB:     0,
+		highThreshold: defaultMaxThreshold,
+		lowThreshold:  defaultMinThreshold,
+		fileLocks:     &common.LockMap{},
+		cacheRules:    rules,
+	}
+
+	suite.setupTestHelper(config)
+
+	names := []string{"pinned/a", "long/a", "other"}
+	for _, name := range names {
+		suite.policy.CacheValid(filepath.Join(cache_path, name))
+	}
+
+	// Never cached files go as soon as they are closed, even though the cache has a timeout
+	never := filepath.Join(cache_path, "never/a")
+	suite.policy.CacheValid(never)
+	suite.policy.CacheInvalidate(never)
+	suite.assert.False(suite.policy.IsCached(never))
+
+	time.Sleep(5 * time.Second) // Wait for time > cacheTimeout
+
+	suite.assert.True(suite.policy.IsCached(filepath.Join(cache_path, "pinned/a")))
+	suite.assert.True(suite.policy.IsCached(filepath.Join(cache_path, "long/a")))
+	suite.assert.False(suite.policy.IsCached(filepath.Join(cache_path, "other")))
+
+	// Retained files are still in the list with their last access time intact
+	n, ok := suite.policy.nodeMap.Load(filepath.Join(cache_path, "long/a"))
+	suite.assert.True(ok)
+	suite.assert.Greater(time.Since(n.(*lruNode).lastAccess), 4*time.Second)
+
+	count := 0
+	for node := suite.policy.head; node != nil; node = node.next {
+		if node.usage >= 0 {
+			count++
+		}
+	}
+	suite.assert.Equal(2, count)
+}
+
+func (suite *lruPolicyTestSuite) TestPersistIndex() {
+	defer suite.cleanupTest()
+	suite.cleanupTest()
+
+	err := os.Mkdir(cache_path, fs.FileMode(0777))
+	suite.assert.NoError(err)
+
+	config := cachePolicyConfig{
+		tmpPath:       cache_path,
+		cacheTimeout:  120,
+		maxEviction:   defaultMaxEviction,
+		maxSizeMB:     0,
+		highThreshold: defaultMaxThreshold,
+		lowThreshold:  defaultMinThreshold,
+		fileLocks:     &common.LockMap{},
+		indexPath:     getCacheIndexPath(cache_path),
+	}
+
+	suite.setupTestHelper(config)
+
+	modified := filepath.Join(cache_path, "modified")
+	valid := filepath.Join(cache_path, "dir", "valid")
+	untracked := filepath.Join(cache_path, "untracked")
+	suite.assert.NoError(os.MkdirAll(filepath.Dir(valid), 0777))
+	for _, name := range []string{modified, valid, untracked} {
+		suite.assert.NoError(os.WriteFile(name, []byte("data"), 0777))
+	}
+
+	suite.policy.CacheValid(modified)
+	suite.policy.CacheValid(valid)
+
+	// Shutdown persists the index with most recently used file last
+	err = suite.policy.ShutdownPolicy()
+	suite.assert.NoError(err)
+
+	entries, err := loadCacheIndex(config.indexPath)
+	suite.assert.NoError(err)
+	suite.assert.Len(entries, 2)
+	suite.assert.Equal("dir/valid", entries[1].Path)
+	suite.assert.EqualValues(4, entries[1].Size)
+
+	// Change the local copy behind the index so that it is not restored
+	suite.assert.NoError(os.WriteFile(modified, []byte("modified data"), 0777))
+
+	suite.setupTestHelper(config)
+
+	suite.assert.True(suite.policy.IsCached(valid))
+	suite.assert.False(suite.policy.IsCached(modified))
+	suite.assert.FileExists(valid)
+	suite.assert.NoFileExists(modified)
+	suite.assert.NoFileExists(untracked)
+	suite.assert.FileExists(config.indexPath)
+}
+
 func TestLRUPolicyTestSuite(t *testing.T) {
 	suite.Run(t, new(lruPolicyTestSuite))
 }
@@ -0,0 +1,287 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package file_cache
+
+import (
+	"bufio"
+	"container/list"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Hit rate of eviction policies is compared by replaying access traces against them.
+// Besides the synthetic traces below, a trace recorded from a real workload can be replayed by
+// setting FILE_CACHE_TRACE to a file having one "<path> <size in bytes>" access per line.
+const traceFileEnv = "FILE_CACHE_TRACE"
+
+type traceAccess struct {
+	name string
+	size int64
+}
+
+type accessTrace struct {
+	name     string
+	accesses []traceAccess
+	capacity int64 // Cache size to replay the trace with
+}
+
+// lruTraceOrder : Plain lru used as baseline while comparing policies.
+// lruPolicy evicts in batches based on timeout markers, which a trace replay can not model.
+type lruTraceOrder struct {
+	lru *list.List
+}
+
+func (o *lruTraceOrder) name() string { return "lru" }
+
+func (o *lruTraceOrder) insert(node *policyNode) {
+	node.data = o.lru.PushFront(node)
+}
+
+func (o *lruTraceOrder) access(node *policyNode) {
+	o.lru.MoveToFront(node.data.(*list.Element))
+}
+
+func (o *lruTraceOrder) remove(node *policyNode, evicted bool) {
+	o.lru.Remove(node.data.(*list.Element))
+}
+
+func (o *lruTraceOrder) victims(nodes []*policyNode) []*policyNode {
+	ordered := make([]*policyNode, 0, len(nodes))
+	for e := o.lru.Back(); e != nil; e = e.Prev() {
+		ordered = append(ordered, e.Value.(*policyNode))
+	}
+	return ordered
+}
+
+func (o *lruTraceOrder) expired(node *policyNode, now time.Time, timeout time.Duration) bool {
+	return isIdle(node, now, timeout)
+}
+
+func traceOrders() []evictionOrder {
+	return []evictionOrder{
+		&lruTraceOrder{lru: list.New()},
+		&lfuOrder{},
+		newARCOrder(),
+		&sizeOrder{},
+		&ttlOrder{},
+	}
+}
+
+// replayTrace : Replay the trace against the order and return the hit rate in percent.
+// Files are evicted one at a time, in the order chosen by the policy, whenever cached bytes exceed capacity.
+func replayTrace(order evictionOrder, trace *accessTrace) float64 {
+	p := newEvictionPolicy(cachePolicyConfig{maxEviction: math.MaxUint32}, order)
+
+	used := int64(0)
+	hits := 0
+	for _, access := range trace.accesses {
+		if p.IsCached(access.name) {
+			hits++
+			p.CacheValid(access.name)
+			continue
+		}
+
+		p.CacheValid(access.name)
+		p.nodes[access.name].size = access.size
+		used += access.size
+
+		for used > trace.capacity {
+			nodes := make([]*policyNode, 0, len(p.nodes))
+			for _, node := range p.nodes {
+				nodes = append(nodes, node)
+			}
+
+			victim := order.victims(nodes)[0]
+			used -= victim.size
+			p.removeNode(victim.name, true)
+		}
+	}
+
+	return float64(hits) * 100 / float64(len(trace.accesses))
+}
+
+// newTrace : Trace with cache capacity set to a fraction of the distinct bytes accessed
+func newTrace(name string, accesses []traceAccess, fraction float64) *accessTrace {
+	sizes := make(map[string]int64)
+	for _, access := range accesses {
+		sizes[access.name] = access.size
+	}
+
+	total := int64(0)
+	for _, size := range sizes {
+		total += size
+	}
+
+	return &accessTrace{
+		name:     name,
+		accesses: accesses,
+		capacity: int64(float64(total) * fraction),
+	}
+}
+
+// zipfTrace : Popularity of files follows a zipf distribution, sizes are uniform
+func zipfTrace(r *rand.Rand) *accessTrace {
+	zipf := rand.NewZipf(r, 1.1, 1, 999)
+	accesses := make([]traceAccess, 0, 20000)
+	for i := 0; i < 20000; i++ {
+		id := zipf.Uint64()
+		accesses = append(accesses, traceAccess{name: fmt.Sprintf("zipf/%d", id), size: int64(id%64+1) * 1024})
+	}
+	return newTrace("zipf", accesses, 0.1)
+}
+
+// scanTrace : Small hot set used repeatedly, interleaved with scans reading a large number of files once
+func scanTrace(r *rand.Rand) *accessTrace {
+	accesses := make([]traceAccess, 0, 20000)
+	scanned := 0
+	for i := 0; i < 200; i++ {
+		for j := 0; j < 50; j++ {
+			accesses = append(accesses, traceAccess{name: fmt.Sprintf("hot/%d", r.Intn(50)), size: 64 * 1024})
+		}
+		for j := 0; j < 50; j++ {
+			accesses = append(accesses, traceAccess{name: fmt.Sprintf("scan/%d", scanned), size: 64 * 1024})
+			scanned++
+		}
+	}
+	return newTrace("scan", accesses, 0.01)
+}
+
+// mixedSizeTrace : Mostly small files with a few large ones, all of similar popularity
+func mixedSizeTrace(r *rand.Rand) *accessTrace {
+	zipf := rand.NewZipf(r, 1.05, 1, 1999)
+	accesses := make([]traceAccess, 0, 20000)
+	for i := 0; i < 20000; i++ {
+		id := zipf.Uint64()
+		size := int64(16 * 1024)
+		if id%20 == 0 {
+			size = 64 * MB
+		}
+		accesses = append(accesses, traceAccess{name: fmt.Sprintf("mixed/%d", id), size: size})
+	}
+	return newTrace("mixed-size", accesses, 0.1)
+}
+
+// loadRecordedTrace : Trace recorded from a workload, nil if none is configured
+func loadRecordedTrace() (*accessTrace, error) {
+	path := os.Getenv(traceFileEnv)
+	if path == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	accesses := make([]traceAccess, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid size in trace line %s", scanner.Text())
+		}
+		accesses = append(accesses, traceAccess{name: fields[0], size: size})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return newTrace("recorded", accesses, 0.1), nil
+}
+
+func accessTraces(tb testing.TB) []*accessTrace {
+	r := rand.New(rand.NewSource(1))
+	traces := []*accessTrace{zipfTrace(r), scanTrace(r), mixedSizeTrace(r)}
+
+	recorded, err := loadRecordedTrace()
+	if err != nil {
+		tb.Fatalf("failed to load trace from %s [%s]", os.Getenv(traceFileEnv), err.Error())
+	}
+	if recorded != nil {
+		traces = append(traces, recorded)
+	}
+
+	return traces
+}
+
+// BenchmarkPolicyHitRate : Reports hit rate of each policy on each trace.
+// Run with: go test -run xxx -bench BenchmarkPolicyHitRate ./component/file_cache
+func BenchmarkPolicyHitRate(b *testing.B) {
+	for _, trace := range accessTraces(b) {
+		for i := range traceOrders() {
+			b.Run(fmt.Sprintf("%s/%s", trace.name, traceOrders()[i].name()), func(b *testing.B) {
+				hitRate := float64(0)
+				for n := 0; n < b.N; n++ {
+					hitRate = replayTrace(traceOrders()[i], trace)
+				}
+				b.ReportMetric(hitRate, "hit%")
+			})
+		}
+	}
+}
+
+// TestPolicyHitRate : Frequency aware policies shall not be polluted by scans,
+// and size aware policy shall keep more files when a few large files are accessed.
+func TestPolicyHitRate(t *testing.T) {
+	hitRates := make(map[string]map[string]float64)
+	for _, trace := range accessTraces(t) {
+		hitRates[trace.name] = make(map[string]float64)
+		for _, order := range traceOrders() {
+			hitRates[trace.name][order.name()] = replayTrace(order, trace)
+			t.Logf("%s / %s : %.2f%%", trace.name, order.name(), hitRates[trace.name][order.name()])
+		}
+	}
+
+	scan := hitRates["scan"]
+	assert.Greater(t, scan["arc"], scan["lru"])
+	assert.Greater(t, scan["lfu"], scan["lru"])
+
+	mixed := hitRates["mixed-size"]
+	assert.Greater(t, mixed["size"], mixed["lru"])
+}
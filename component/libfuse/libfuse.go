@@ -37,12 +37,20 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/Azure/azure-storage-fuse/v2/common"
 	"github.com/Azure/azure-storage-fuse/v2/common/config"
+	"github.com/Azure/azure-storage-fuse/v2/common/exectime"
 	"github.com/Azure/azure-storage-fuse/v2/common/log"
 	"github.com/Azure/azure-storage-fuse/v2/internal"
+	"github.com/Azure/azure-storage-fuse/v2/internal/audit"
+	"github.com/Azure/azure-storage-fuse/v2/internal/handlemap"
 	"github.com/Azure/azure-storage-fuse/v2/internal/stats_manager"
+	"github.com/Azure/azure-storage-fuse/v2/internal/tracing"
 )
 
 /* NOTES:
@@ -78,6 +86,9 @@ type Libfuse struct {
 	maxBackground           uint32 // libfuse max_background: max pending background requests
 	kernelListCacheTtlInSec uint32
 	kernelListCacheTracker  *kernelListCacheTracker
+	drainLock               sync.Mutex
+	resumed                 chan struct{} // Closed once a drained mount resumes, nil while it is not draining
+	heldOpens               atomic.Int32  // Opens and creates held by a drain
 }
 
 // To support pagination in readdir calls this structure holds a block of items for a given directory
@@ -139,6 +150,7 @@ var ignoreFiles = map[string]bool{
 
 // Verification to check satisfaction criteria with Component Interface
 var _ internal.Component = &Libfuse{}
+var _ internal.Drainer = &Libfuse{}
 
 func (lf *Libfuse) Name() string {
 	return compName
@@ -191,9 +203,157 @@ func (lf *Libfuse) Start(ctx context.Context) error {
 	return nil
 }
 
+// operation : Fuse operation in progress, timed for metrics, traced as parent of the calls it makes into the pipeline
+// and audited when the audit log wants its path
+type operation struct {
+	name  string
+	start time.Time
+	span  *tracing.Span
+	event *audit.Event
+}
+
+func startOperation(name string) *operation {
+	return &operation{
+		name:  name,
+		start: time.Now(),
+		span:  tracing.Start("fuse."+name, tracing.KindServer),
+	}
+}
+
+// endOperation : Record latency of a fuse operation, in stats and in its latency histogram, and its errno when ret
+// is the negated errno returned to libfuse
+func endOperation[T ~int32](op *operation, ret *T) {
+	errno := syscall.Errno(-min(*ret, 0))
+	elapsed := time.Since(op.start)
+	libfuseStatsCollector.ObserveOperation(op.name, elapsed, errno)
+	exectime.Observe(compName+"."+op.name, elapsed)
+	audit.Record(op.event, errno)
+
+	if errno != 0 {
+		op.span.End(errno)
+	} else {
+		op.span.End(nil)
+	}
+}
+
+// Callers of opens that were audited, by handle id, so that the release of the handle is attributed to the same caller
+var auditedHandles sync.Map
+
+// audit : Start an audit event for this operation on the given path, nil if the audit log does not want it
+func (op *operation) audit(path string) *audit.Event {
+	if !audit.Wanted(op.name, path) {
+		return nil
+	}
+
+	op.event = audit.NewEvent(op.name, path, fuseCaller())
+	return op.event
+}
+
+// auditOpen : Remember the caller of an audited open or create against the handle it returned, so that the release
+// of the handle is audited as well
+func (op *operation) auditOpen(handle *handlemap.Handle) {
+	if op.event == nil {
+		return
+	}
+
+	op.event.Handle = uint64(handle.ID)
+	if audit.Audits("release") {
+		auditedHandles.Store(handle.ID, op.event.Caller)
+	}
+}
+
+// auditRelease : Start the audit event for the release of a handle whose open was audited
+func (op *operation) auditRelease(handle *handlemap.Handle) *audit.Event {
+	val, found := auditedHandles.LoadAndDelete(handle.ID)
+	if !found {
+		return nil
+	}
+
+	op.event = audit.NewEvent(op.name, handle.Path, val.(audit.Caller))
+	op.event.Handle = uint64(handle.ID)
+	return op.event
+}
+
+// auditMode : Permission bits of a mode as the audit log reports them
+func auditMode(mode uint32) string {
+	return fmt.Sprintf("%#o", mode&0o7777)
+}
+
+// Drain : Hold new opens and creates until the mount resumes, for a bounded time, handles already open keep being
+// served. Handles
+// written natively are marked dirty so that the caller can flush them.
+func (lf *Libfuse) Drain() error {
+	lf.drainLock.Lock()
+	if lf.resumed == nil {
+		lf.resumed = make(chan struct{})
+		log.Info("Libfuse::Drain : Draining mount %s, new opens will wait until it resumes", lf.mountPath)
+	}
+	lf.drainLock.Unlock()
+
+	markDirtyHandles()
+	return nil
+}
+
+// Resume : Let the opens held by a drain through and accept new ones again
+func (lf *Libfuse) Resume() {
+	lf.drainLock.Lock()
+	defer lf.drainLock.Unlock()
+
+	if lf.resumed != nil {
+		close(lf.resumed)
+		lf.resumed = nil
+		log.Info("Libfuse::Resume : Resuming mount %s", lf.mountPath)
+	}
+}
+
+// Draining : Whether new opens are being held
+func (lf *Libfuse) Draining() bool {
+	lf.drainLock.Lock()
+	defer lf.drainLock.Unlock()
+	return lf.resumed != nil
+}
+
+// Opens and creates held by a drain each occupy a fuse worker thread, libfuse runs 10 of them by default. Only a few
+// are held, and not for long, so that the threads left keep serving reads, flushes and releases of open handles.
+var (
+	maxHeldOpens    int32 = 4
+	heldOpenTimeout       = 30 * time.Second
+)
+
+// waitResume : Hold an open or create while the mount is draining, so that the job issuing it pauses instead of
+// failing. False is returned when the open cannot be held any longer, either because too many are held already or
+// because the mount did not resume in time, and shall then fail with EAGAIN.
+func (lf *Libfuse) waitResume(method string, name string) bool {
+	lf.drainLock.Lock()
+	resumed := lf.resumed
+	lf.drainLock.Unlock()
+
+	if resumed == nil {
+		return true
+	}
+
+	if lf.heldOpens.Add(1) > maxHeldOpens {
+		lf.heldOpens.Add(-1)
+		log.Warn("Libfuse::%s : Mount is draining and %d opens are held already, failing %s", method, maxHeldOpens, name)
+		return false
+	}
+	defer lf.heldOpens.Add(-1)
+
+	log.Info("Libfuse::%s : Mount is draining, holding %s until it resumes", method, name)
+	select {
+	case <-resumed:
+		return true
+	case <-time.After(heldOpenTimeout):
+		log.Warn("Libfuse::%s : Mount did not resume within %v, failing %s", method, heldOpenTimeout, name)
+		return false
+	}
+}
+
 // Stop : Stop the component functionality and kill all threads started
 func (lf *Libfuse) Stop() error {
 	log.Trace("Libfuse::Stop : Stopping component %s", lf.Name())
+	// Opens still held by a drain are let through so that their fuse threads can finish
+	lf.Resume()
 	if lf.kernelListCacheTracker != nil {
 		lf.kernelListCacheTracker.stop()
 		lf.kernelListCacheTracker = nil
@@ -52,12 +52,14 @@ import (
 	"io"
 	"io/fs"
 	"os"
+	"sync"
 	"syscall"
 	"unsafe"
 
 	"github.com/Azure/azure-storage-fuse/v2/common"
 	"github.com/Azure/azure-storage-fuse/v2/common/log"
 	"github.com/Azure/azure-storage-fuse/v2/internal"
+	"github.com/Azure/azure-storage-fuse/v2/internal/audit"
 	"github.com/Azure/azure-storage-fuse/v2/internal/handlemap"
 	"github.com/Azure/azure-storage-fuse/v2/internal/stats_manager"
 )
@@ -93,8 +95,36 @@ func trimFusePath(path *C.char) string {
 	return str
 }
 
+// fuseCaller : Process and credentials of the caller of the fuse operation being served
+func fuseCaller() audit.Caller {
+	ctx := C.fuse_get_context()
+	if ctx == nil {
+		return audit.Caller{}
+	}
+	return audit.Caller{UID: uint32(ctx.uid), GID: uint32(ctx.gid), PID: int32(ctx.pid)}
+}
+
 var fuse_opts C.fuse_options_t // nolint
 
+// Native objects of open files by handle id. Writes served natively only mark the native object dirty, so these
+// are looked up when handles need to be flushed outside of a flush or release call from the kernel.
+var nativeHandles sync.Map
+
+// markDirtyHandles marks the handles written natively since they were last flushed as dirty
+func markDirtyHandles() {
+	nativeHandles.Range(func(key, val any) bool {
+		fileHandle := val.(*C.file_handle_t)
+		if fileHandle.dirty == 0 {
+			return true
+		}
+
+		if handle, ok := handlemap.Load(key.(handlemap.HandleID)); ok {
+			handle.Flags.Set(handlemap.HandleFlagDirty)
+		}
+		return true
+	})
+}
+
 // convertConfig converts the config options from Go to C
 func (lf *Libfuse) convertConfig() *C.fuse_options_t {
 	fuse_opts := &C.fuse_options_t{}
@@ -439,9 +469,12 @@ func (lf *Libfuse) fillStat(attr *internal.ObjAttr, stbuf *C.stat_t) {
 // libfuse_getattr gets file attributes
 //
 //export libfuse_getattr
-func libfuse_getattr(path *C.char, stbuf *C.stat_t, fi *C.fuse_file_info_t) C.int {
+func libfuse_getattr(path *C.char, stbuf *C.stat_t, fi *C.fuse_file_info_t) (ret C.int) {
+	defer endOperation(startOperation("getattr"), &ret)
+
 	name := trimFusePath(path)
 	name = common.NormalizeObjectName(name)
+	libfuseStatsCollector.ObservePath(name)
 	// log.Trace("Libfuse::libfuse_getattr : %s", name)
 
 	// Return the default configuration for the root
@@ -479,9 +512,16 @@ func libfuse_getattr(path *C.char, stbuf *C.stat_t, fi *C.fuse_file_info_t) C.in
 // libfuse_mkdir creates a directory
 //
 //export libfuse_mkdir
-func libfuse_mkdir(path *C.char, mode C.mode_t) C.int {
+func libfuse_mkdir(path *C.char, mode C.mode_t) (ret C.int) {
+	op := startOperation("mkdir")
+	defer endOperation(op, &ret)
+
 	name := trimFusePath(path)
 	name = common.NormalizeObjectName(name)
+	libfuseStatsCollector.ObservePath(name)
+	if ev := op.audit(name); ev != nil {
+		ev.Mode = auditMode(uint32(mode))
+	}
 	log.Trace("Libfuse::libfuse_mkdir : %s", name)
 
 	err := fuseFS.NextComponent().CreateDir(internal.CreateDirOptions{Name: name, Mode: fs.FileMode(uint32(mode) & 0xffffffff)})
@@ -507,9 +547,12 @@ func libfuse_mkdir(path *C.char, mode C.mode_t) C.int {
 // libfuse_opendir opens handle to given directory
 //
 //export libfuse_opendir
-func libfuse_opendir(path *C.char, fi *C.fuse_file_info_t) C.int {
+func libfuse_opendir(path *C.char, fi *C.fuse_file_info_t) (ret C.int) {
+	defer endOperation(startOperation("opendir"), &ret)
+
 	name := trimFusePath(path)
 	name = common.NormalizeObjectName(name)
+	libfuseStatsCollector.ObservePath(name)
 	if name != "" {
 		name = name + "/"
 	}
@@ -557,7 +600,9 @@ func libfuse_opendir(path *C.char, fi *C.fuse_file_info_t) C.int {
 // libfuse_releasedir opens handle to given directory
 //
 //export libfuse_releasedir
-func libfuse_releasedir(path *C.char, fi *C.fuse_file_info_t) C.int {
+func libfuse_releasedir(path *C.char, fi *C.fuse_file_info_t) (ret C.int) {
+	defer endOperation(startOperation("releasedir"), &ret)
+
 	handle := (*handlemap.Handle)(unsafe.Pointer(uintptr(fi.fh)))
 
 	log.Trace("Libfuse::libfuse_releasedir : %s, handle: %d", handle.Path, handle.ID)
@@ -570,7 +615,9 @@ func libfuse_releasedir(path *C.char, fi *C.fuse_file_info_t) C.int {
 // libfuse_readdir reads a directory
 //
 //export libfuse_readdir
-func libfuse_readdir(_ *C.char, buf unsafe.Pointer, filler C.fuse_fill_dir_t, off C.off_t, fi *C.fuse_file_info_t, flag C.fuse_readdir_flags_t) C.int {
+func libfuse_readdir(_ *C.char, buf unsafe.Pointer, filler C.fuse_fill_dir_t, off C.off_t, fi *C.fuse_file_info_t, flag C.fuse_readdir_flags_t) (ret C.int) {
+	defer endOperation(startOperation("readdir"), &ret)
+
 	handle := (*handlemap.Handle)(unsafe.Pointer(uintptr(fi.fh)))
 
 	handle.RLock()
@@ -644,9 +691,14 @@ func libfuse_readdir(_ *C.char, buf unsafe.Pointer, filler C.fuse_fill_dir_t, of
 // libfuse_rmdir deletes a directory, which must be empty.
 //
 //export libfuse_rmdir
-func libfuse_rmdir(path *C.char) C.int {
+func libfuse_rmdir(path *C.char) (ret C.int) {
+	op := startOperation("rmdir")
+	defer endOperation(op, &ret)
+
 	name := trimFusePath(path)
 	name = common.NormalizeObjectName(name)
+	libfuseStatsCollector.ObservePath(name)
+	op.audit(name)
 	log.Trace("Libfuse::libfuse_rmdir : %s", name)
 
 	empty := fuseFS.NextComponent().IsDirEmpty(internal.IsDirEmptyOptions{Name: name})
@@ -673,7 +725,9 @@ func libfuse_rmdir(path *C.char) C.int {
 // File Operations
 //
 //export libfuse_statfs
-func libfuse_statfs(path *C.char, buf *C.statvfs_t) C.int {
+func libfuse_statfs(path *C.char, buf *C.statvfs_t) (ret C.int) {
+	defer endOperation(startOperation("statfs"), &ret)
+
 	name := trimFusePath(path)
 	name = common.NormalizeObjectName(name)
 	log.Trace("Libfuse::libfuse_statfs : %s", name)
@@ -705,11 +759,24 @@ func libfuse_statfs(path *C.char, buf *C.statvfs_t) C.int {
 // libfuse_create creates a file with the specified mode and then opens it.
 //
 //export libfuse_create
-func libfuse_create(path *C.char, mode C.mode_t, fi *C.fuse_file_info_t) C.int {
+func libfuse_create(path *C.char, mode C.mode_t, fi *C.fuse_file_info_t) (ret C.int) {
+	op := startOperation("create")
+	defer endOperation(op, &ret)
+
 	name := trimFusePath(path)
 	name = common.NormalizeObjectName(name)
+	libfuseStatsCollector.ObservePath(name)
+	if ev := op.audit(name); ev != nil {
+		ev.Flags = common.PrettyOpenFlags(int(fi.flags))
+		ev.Mode = auditMode(uint32(mode))
+	}
 	log.Trace("Libfuse::libfuse_create : %s", name)
 
+	// Opens are held for a while when the mount is draining, so that jobs pause instead of failing
+	if !fuseFS.waitResume("libfuse_create", name) {
+		return -C.EAGAIN
+	}
+
 	handle, err := fuseFS.NextComponent().CreateFile(internal.CreateFileOptions{Name: name, Mode: fs.FileMode(uint32(mode) & 0xffffffff)})
 	if err != nil {
 		log.Err("Libfuse::libfuse_create : Failed to create %s [%s]", name, err.Error())
@@ -723,6 +790,7 @@ func libfuse_create(path *C.char, mode C.mode_t, fi *C.fuse_file_info_t) C.int {
 	}
 
 	handlemap.Add(handle)
+	op.auditOpen(handle)
 	ret_val := C.allocate_native_file_object(0, C.uint64_t(uintptr(unsafe.Pointer(handle))), 0)
 	if !handle.Cached() {
 		ret_val.fd = 0
@@ -730,6 +798,7 @@ func libfuse_create(path *C.char, mode C.mode_t, fi *C.fuse_file_info_t) C.int {
 
 	log.Trace("Libfuse::libfuse_create : %s, handle %d", name, handle.ID)
 	fi.fh = C.uint64_t(uintptr(unsafe.Pointer(ret_val)))
+	nativeHandles.Store(handle.ID, ret_val)
 
 	libfuseStatsCollector.PushEvents(createFile, name, map[string]any{md: fs.FileMode(uint32(mode) & 0xffffffff)})
 
@@ -742,10 +811,23 @@ func libfuse_create(path *C.char, mode C.mode_t, fi *C.fuse_file_info_t) C.int {
 // libfuse_open opens a file
 //
 //export libfuse_open
-func libfuse_open(path *C.char, fi *C.fuse_file_info_t) C.int {
+func libfuse_open(path *C.char, fi *C.fuse_file_info_t) (ret C.int) {
+	op := startOperation("open")
+	defer endOperation(op, &ret)
+
 	name := trimFusePath(path)
 	name = common.NormalizeObjectName(name)
+	libfuseStatsCollector.ObservePath(name)
+	if ev := op.audit(name); ev != nil {
+		ev.Flags = common.PrettyOpenFlags(int(fi.flags))
+	}
 	log.Trace("Libfuse::libfuse_open : %s", name)
+
+	// Opens are held for a while when the mount is draining, so that jobs pause instead of failing
+	if !fuseFS.waitResume("libfuse_open", name) {
+		return -C.EAGAIN
+	}
+
 	// TODO: Should this sit behind a user option? What if we change something to support these in the future?
 	// Mask out SYNC and DIRECT flags since write operation will fail
 	if fi.flags&C.O_SYNC != 0 || fi.flags&C.__O_DIRECT != 0 {
@@ -793,6 +875,7 @@ func libfuse_open(path *C.char, fi *C.fuse_file_info_t) C.int {
 	}
 
 	handlemap.Add(handle)
+	op.auditOpen(handle)
 	//fi.fh = C.ulong(uintptr(unsafe.Pointer(handle)))
 	ret_val := C.allocate_native_file_object(C.uint64_t(handle.UnixFD), C.uint64_t(uintptr(unsafe.Pointer(handle))), C.uint64_t(handle.Size))
 	if !handle.Cached() {
@@ -800,6 +883,7 @@ func libfuse_open(path *C.char, fi *C.fuse_file_info_t) C.int {
 	}
 	log.Trace("Libfuse::libfuse_open : %s, handle %d", name, handle.ID)
 	fi.fh = C.uint64_t(uintptr(unsafe.Pointer(ret_val)))
+	nativeHandles.Store(handle.ID, ret_val)
 
 	// increment open file handles count
 	libfuseStatsCollector.UpdateStats(stats_manager.Increment, openHandles, (int64)(1))
@@ -810,9 +894,12 @@ func libfuse_open(path *C.char, fi *C.fuse_file_info_t) C.int {
 // libfuse_read reads data from an open file
 //
 //export libfuse_read
-func libfuse_read(path *C.char, buf *C.char, size C.size_t, off C.off_t, fi *C.fuse_file_info_t) C.int {
+func libfuse_read(path *C.char, buf *C.char, size C.size_t, off C.off_t, fi *C.fuse_file_info_t) (ret C.int) {
+	defer endOperation(startOperation("read"), &ret)
+
 	fileHandle := (*C.file_handle_t)(unsafe.Pointer(uintptr(fi.fh)))
 	handle := (*handlemap.Handle)(unsafe.Pointer(uintptr(fileHandle.obj)))
+	libfuseStatsCollector.ObservePath(handle.Path)
 
 	offset := uint64(off)
 	data := (*[1 << 30]byte)(unsafe.Pointer(buf))
@@ -840,15 +927,19 @@ func libfuse_read(path *C.char, buf *C.char, size C.size_t, off C.off_t, fi *C.f
 		return -C.EIO
 	}
 
+	C.count_io(&fileHandle.rd_bytes, C.int(bytesRead))
 	return C.int(bytesRead)
 }
 
 // libfuse_write writes data to an open file
 //
 //export libfuse_write
-func libfuse_write(path *C.char, buf *C.char, size C.size_t, off C.off_t, fi *C.fuse_file_info_t) C.int {
+func libfuse_write(path *C.char, buf *C.char, size C.size_t, off C.off_t, fi *C.fuse_file_info_t) (ret C.int) {
+	defer endOperation(startOperation("write"), &ret)
+
 	fileHandle := (*C.file_handle_t)(unsafe.Pointer(uintptr(fi.fh)))
 	handle := (*handlemap.Handle)(unsafe.Pointer(uintptr(fileHandle.obj)))
+	libfuseStatsCollector.ObservePath(handle.Path)
 
 	offset := uint64(off)
 	data := (*[1 << 30]byte)(unsafe.Pointer(buf))
@@ -866,6 +957,7 @@ func libfuse_write(path *C.char, buf *C.char, size C.size_t, off C.off_t, fi *C.
 		return -C.EIO
 	}
 
+	C.count_io(&fileHandle.wr_bytes, C.int(bytesWritten))
 	return C.int(bytesWritten)
 }
 
@@ -878,9 +970,12 @@ func libfuse_write(path *C.char, buf *C.char, size C.size_t, off C.off_t, fi *C.
 // is final, so each flush should be treated equally.
 //
 //export libfuse_flush
-func libfuse_flush(path *C.char, fi *C.fuse_file_info_t) C.int {
+func libfuse_flush(path *C.char, fi *C.fuse_file_info_t) (ret C.int) {
+	defer endOperation(startOperation("flush"), &ret)
+
 	fileHandle := (*C.file_handle_t)(unsafe.Pointer(uintptr(fi.fh)))
 	handle := (*handlemap.Handle)(unsafe.Pointer(uintptr(fileHandle.obj)))
+	libfuseStatsCollector.ObservePath(handle.Path)
 	log.Trace("Libfuse::libfuse_flush : %s, handle: %d", handle.Path, handle.ID)
 
 	// If the file handle is not dirty, there is no need to flush
@@ -911,9 +1006,17 @@ func libfuse_flush(path *C.char, fi *C.fuse_file_info_t) C.int {
 // Release is called when there are no more references to an open file, all file descriptors are closed for this handle.
 //
 //export libfuse_release
-func libfuse_release(path *C.char, fi *C.fuse_file_info_t) C.int {
+func libfuse_release(path *C.char, fi *C.fuse_file_info_t) (ret C.int) {
+	op := startOperation("release")
+	defer endOperation(op, &ret)
+
 	fileHandle := (*C.file_handle_t)(unsafe.Pointer(uintptr(fi.fh)))
 	handle := (*handlemap.Handle)(unsafe.Pointer(uintptr(fileHandle.obj)))
+	libfuseStatsCollector.ObservePath(handle.Path)
+	if ev := op.auditRelease(handle); ev != nil {
+		ev.BytesRead = audit.Int64(int64(fileHandle.rd_bytes))
+		ev.BytesWritten = audit.Int64(int64(fileHandle.wr_bytes))
+	}
 
 	log.Trace("Libfuse::libfuse_release : %s, handle: %d", handle.Path, handle.ID)
 
@@ -936,6 +1039,7 @@ func libfuse_release(path *C.char, fi *C.fuse_file_info_t) C.int {
 	}
 
 	handlemap.Delete(handle.ID)
+	nativeHandles.Delete(handle.ID)
 	C.release_native_file_object(fi)
 
 	// decrement open file handles count
@@ -947,13 +1051,16 @@ func libfuse_release(path *C.char, fi *C.fuse_file_info_t) C.int {
 // libfuse_fsync synchronizes file contents
 //
 //export libfuse_fsync
-func libfuse_fsync(path *C.char, datasync C.int, fi *C.fuse_file_info_t) C.int {
+func libfuse_fsync(path *C.char, datasync C.int, fi *C.fuse_file_info_t) (ret C.int) {
+	defer endOperation(startOperation("fsync"), &ret)
+
 	if fi.fh == 0 {
 		return C.int(-C.EIO)
 	}
 
 	fileHandle := (*C.file_handle_t)(unsafe.Pointer(uintptr(fi.fh)))
 	handle := (*handlemap.Handle)(unsafe.Pointer(uintptr(fileHandle.obj)))
+	libfuseStatsCollector.ObservePath(handle.Path)
 	log.Trace("Libfuse::libfuse_fsync : %s, handle: %d", handle.Path, handle.ID)
 
 	options := internal.SyncFileOptions{Handle: handle}
@@ -981,9 +1088,16 @@ func libfuse_fsync(path *C.char, datasync C.int, fi *C.fuse_file_info_t) C.int {
 // Libfuse Doc: https://github.com/libfuse/libfuse/blob/fc95fd5076fd845e496bfbcec1ad9da16534b1c9/include/fuse_lowlevel.h#L328
 //
 //export libfuse_truncate
-func libfuse_truncate(path *C.char, off C.off_t, fi *C.fuse_file_info_t) C.int {
+func libfuse_truncate(path *C.char, off C.off_t, fi *C.fuse_file_info_t) (ret C.int) {
+	op := startOperation("truncate")
+	defer endOperation(op, &ret)
+
 	name := trimFusePath(path)
 	name = common.NormalizeObjectName(name)
+	libfuseStatsCollector.ObservePath(name)
+	if ev := op.audit(name); ev != nil {
+		ev.Size = audit.Int64(int64(off))
+	}
 
 	var handle *handlemap.Handle
 	if fi == nil {
@@ -1020,9 +1134,14 @@ func libfuse_truncate(path *C.char, off C.off_t, fi *C.fuse_file_info_t) C.int {
 // libfuse_unlink removes a file
 //
 //export libfuse_unlink
-func libfuse_unlink(path *C.char) C.int {
+func libfuse_unlink(path *C.char) (ret C.int) {
+	op := startOperation("unlink")
+	defer endOperation(op, &ret)
+
 	name := trimFusePath(path)
 	name = common.NormalizeObjectName(name)
+	libfuseStatsCollector.ObservePath(name)
+	op.audit(name)
 	log.Trace("Libfuse::libfuse_unlink : %s", name)
 
 	err := fuseFS.NextComponent().DeleteFile(internal.DeleteFileOptions{Name: name})
@@ -1048,11 +1167,18 @@ func libfuse_unlink(path *C.char) C.int {
 // TODO: handle EACCESS, EINVAL?
 //
 //export libfuse_rename
-func libfuse_rename(src *C.char, dst *C.char, flags C.uint) C.int {
+func libfuse_rename(src *C.char, dst *C.char, flags C.uint) (ret C.int) {
+	op := startOperation("rename")
+	defer endOperation(op, &ret)
+
 	srcPath := trimFusePath(src)
 	srcPath = common.NormalizeObjectName(srcPath)
+	libfuseStatsCollector.ObservePath(srcPath)
 	dstPath := trimFusePath(dst)
 	dstPath = common.NormalizeObjectName(dstPath)
+	if ev := op.audit(srcPath); ev != nil {
+		ev.Destination = dstPath
+	}
 	log.Trace("Libfuse::libfuse_rename : %s -> %s", srcPath, dstPath)
 	// Note: When running other commands from the command line, a lot of them seemed to handle some cases like ENOENT themselves.
 	// Rename did not, so we manually check here.
@@ -1141,11 +1267,18 @@ func libfuse_rename(src *C.char, dst *C.char, flags C.uint) C.int {
 // libfuse_symlink creates a symbolic link
 //
 //export libfuse_symlink
-func libfuse_symlink(target *C.char, link *C.char) C.int {
+func libfuse_symlink(target *C.char, link *C.char) (ret C.int) {
+	op := startOperation("symlink")
+	defer endOperation(op, &ret)
+
 	name := trimFusePath(link)
 	name = common.NormalizeObjectName(name)
+	libfuseStatsCollector.ObservePath(name)
 	targetPath := C.GoString(target)
 	targetPath = common.NormalizeObjectName(targetPath)
+	if ev := op.audit(name); ev != nil {
+		ev.Destination = targetPath
+	}
 	log.Trace("Libfuse::libfuse_symlink : Received for %s -> %s", name, targetPath)
 
 	err := fuseFS.NextComponent().CreateLink(internal.CreateLinkOptions{Name: name, Target: targetPath})
@@ -1163,9 +1296,12 @@ func libfuse_symlink(target *C.char, link *C.char) C.int {
 // libfuse_readlink reads the target of a symbolic link
 //
 //export libfuse_readlink
-func libfuse_readlink(path *C.char, buf *C.char, size C.size_t) C.int {
+func libfuse_readlink(path *C.char, buf *C.char, size C.size_t) (ret C.int) {
+	defer endOperation(startOperation("readlink"), &ret)
+
 	name := trimFusePath(path)
 	name = common.NormalizeObjectName(name)
+	libfuseStatsCollector.ObservePath(name)
 	//log.Trace("Libfuse::libfuse_readlink : Received for %s", name)
 
 	linkSize := int64(0)
@@ -1195,9 +1331,12 @@ func libfuse_readlink(path *C.char, buf *C.char, size C.size_t) C.int {
 // libfuse_fsyncdir synchronizes directory contents
 //
 //export libfuse_fsyncdir
-func libfuse_fsyncdir(path *C.char, datasync C.int, fi *C.fuse_file_info_t) C.int {
+func libfuse_fsyncdir(path *C.char, datasync C.int, fi *C.fuse_file_info_t) (ret C.int) {
+	defer endOperation(startOperation("fsyncdir"), &ret)
+
 	name := trimFusePath(path)
 	name = common.NormalizeObjectName(name)
+	libfuseStatsCollector.ObservePath(name)
 	log.Trace("Libfuse::libfuse_fsyncdir : %s", name)
 
 	options := internal.SyncDirOptions{Name: name}
@@ -1219,9 +1358,16 @@ func libfuse_fsyncdir(path *C.char, datasync C.int, fi *C.fuse_file_info_t) C.in
 // libfuse_chmod changes permission bits of a file
 //
 //export libfuse_chmod
-func libfuse_chmod(path *C.char, mode C.mode_t, fi *C.fuse_file_info_t) C.int {
+func libfuse_chmod(path *C.char, mode C.mode_t, fi *C.fuse_file_info_t) (ret C.int) {
+	op := startOperation("chmod")
+	defer endOperation(op, &ret)
+
 	name := trimFusePath(path)
 	name = common.NormalizeObjectName(name)
+	libfuseStatsCollector.ObservePath(name)
+	if ev := op.audit(name); ev != nil {
+		ev.Mode = auditMode(uint32(mode))
+	}
 	log.Trace("Libfuse::libfuse_chmod : %s", name)
 
 	err := fuseFS.NextComponent().Chmod(
@@ -1248,9 +1394,12 @@ func libfuse_chmod(path *C.char, mode C.mode_t, fi *C.fuse_file_info_t) C.int {
 // libfuse_chown changes the owner and group of a file
 //
 //export libfuse_chown
-func libfuse_chown(path *C.char, uid C.uid_t, gid C.gid_t, fi *C.fuse_file_info_t) C.int {
+func libfuse_chown(path *C.char, uid C.uid_t, gid C.gid_t, fi *C.fuse_file_info_t) (ret C.int) {
+	defer endOperation(startOperation("chown"), &ret)
+
 	name := trimFusePath(path)
 	name = common.NormalizeObjectName(name)
+	libfuseStatsCollector.ObservePath(name)
 	log.Trace("Libfuse::libfuse_chown : %s", name)
 	// TODO: Implement
 	return 0
@@ -1259,9 +1408,12 @@ func libfuse_chown(path *C.char, uid C.uid_t, gid C.gid_t, fi *C.fuse_file_info_
 // libfuse_utimens changes the access and modification times of a file
 //
 //export libfuse_utimens
-func libfuse_utimens(path *C.char, tv *C.timespec_t, fi *C.fuse_file_info_t) C.int {
+func libfuse_utimens(path *C.char, tv *C.timespec_t, fi *C.fuse_file_info_t) (ret C.int) {
+	defer endOperation(startOperation("utimens"), &ret)
+
 	name := trimFusePath(path)
 	name = common.NormalizeObjectName(name)
+	libfuseStatsCollector.ObservePath(name)
 	log.Trace("Libfuse::libfuse_utimens : %s", name)
 	// TODO: is the conversion from [2]timespec to *timespec ok?
 	// TODO: Implement
@@ -247,6 +247,22 @@ func (suite *libfuseTestSuite) TestOpenError() {
 	testOpenError(suite)
 }
 
+func (suite *libfuseTestSuite) TestOpenDraining() {
+	testOpenDraining(suite)
+}
+
+func (suite *libfuseTestSuite) TestOpenDrainingBounded() {
+	testOpenDrainingBounded(suite)
+}
+
+func (suite *libfuseTestSuite) TestDrainMarksDirty() {
+	testDrainMarksDirty(suite)
+}
+
+func (suite *libfuseTestSuite) TestAudit() {
+	testAudit(suite)
+}
+
 // read
 
 // write
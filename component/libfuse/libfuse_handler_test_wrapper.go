@@ -40,16 +40,21 @@ package libfuse
 // #include "libfuse_wrapper.h"
 import "C"
 import (
+	"encoding/json"
 	"errors"
 	"io/fs"
+	"os"
+	"path/filepath"
 	"strings"
 	"syscall"
+	"time"
 	"unsafe"
 
 	"github.com/Azure/azure-storage-fuse/v2/common"
 	"github.com/Azure/azure-storage-fuse/v2/common/config"
 	"github.com/Azure/azure-storage-fuse/v2/common/log"
 	"github.com/Azure/azure-storage-fuse/v2/internal"
+	"github.com/Azure/azure-storage-fuse/v2/internal/audit"
 	"github.com/Azure/azure-storage-fuse/v2/internal/handlemap"
 
 	"github.com/golang/mock/gomock"
@@ -387,6 +392,142 @@ func testOpenError(suite *libfuseTestSuite) {
 	suite.assert.Equal(C.int(-C.EIO), err)
 }
 
+func testOpenDraining(suite *libfuseTestSuite) {
+	defer suite.cleanupTest()
+	name := "path"
+	path := C.CString("/" + name)
+	defer C.free(unsafe.Pointer(path))
+	mode := fs.FileMode(fuseFS.filePermission)
+	flags := C.O_RDWR & 0xffffffff
+	info := &C.fuse_file_info_t{}
+	info.flags = C.O_RDWR
+
+	options := internal.OpenFileOptions{Name: name, Flags: flags, Mode: mode}
+	suite.mock.EXPECT().OpenFile(options).Return(&handlemap.Handle{}, nil)
+
+	// Open does not reach the pipeline while the mount is draining, it goes through once the mount resumes
+	suite.assert.NoError(suite.libfuse.Drain())
+	suite.assert.True(suite.libfuse.Draining())
+	done := make(chan C.int, 1)
+	go func() { done <- libfuse_open(path, info) }()
+	suite.assert.Never(func() bool { return len(done) > 0 }, 100*time.Millisecond, 10*time.Millisecond)
+
+	suite.libfuse.Resume()
+	suite.assert.False(suite.libfuse.Draining())
+	suite.assert.Equal(C.int(0), <-done)
+}
+
+func testOpenDrainingBounded(suite *libfuseTestSuite) {
+	defer suite.cleanupTest()
+	path := C.CString("/path")
+	defer C.free(unsafe.Pointer(path))
+	info := &C.fuse_file_info_t{}
+	info.flags = C.O_RDWR
+
+	defer func(held int32, timeout time.Duration) {
+		maxHeldOpens, heldOpenTimeout = held, timeout
+	}(maxHeldOpens, heldOpenTimeout)
+
+	suite.assert.NoError(suite.libfuse.Drain())
+	defer suite.libfuse.Resume()
+
+	// Open fails once it has been held for too long, without reaching the pipeline
+	heldOpenTimeout = 50 * time.Millisecond
+	suite.assert.Equal(C.int(-C.EAGAIN), libfuse_open(path, info))
+
+	// Open fails right away when too many are held already
+	maxHeldOpens, heldOpenTimeout = 0, time.Minute
+	start := time.Now()
+	suite.assert.Equal(C.int(-C.EAGAIN), libfuse_open(path, info))
+	suite.assert.Less(time.Since(start), heldOpenTimeout)
+	suite.assert.Zero(suite.libfuse.heldOpens.Load())
+}
+
+func testDrainMarksDirty(suite *libfuseTestSuite) {
+	defer suite.cleanupTest()
+	name := "path"
+	path := C.CString("/" + name)
+	defer C.free(unsafe.Pointer(path))
+	mode := fs.FileMode(fuseFS.filePermission)
+	flags := C.O_RDWR & 0xffffffff
+	info := &C.fuse_file_info_t{}
+	info.flags = C.O_RDWR
+	options := internal.OpenFileOptions{Name: name, Flags: flags, Mode: mode}
+	suite.mock.EXPECT().OpenFile(options).Return(&handlemap.Handle{}, nil)
+	suite.assert.Equal(C.int(0), libfuse_open(path, info))
+
+	// Native write only marks the native object dirty
+	fobj := (*C.file_handle_t)(unsafe.Pointer(uintptr(info.fh)))
+	handle := (*handlemap.Handle)(unsafe.Pointer(uintptr(fobj.obj)))
+	defer handlemap.Delete(handle.ID)
+	defer nativeHandles.Delete(handle.ID)
+	fobj.dirty = 1
+	suite.assert.False(handle.Dirty())
+
+	suite.assert.NoError(suite.libfuse.Drain())
+	suite.assert.True(handle.Dirty())
+	suite.libfuse.Resume()
+}
+
+func testAudit(suite *libfuseTestSuite) {
+	defer suite.cleanupTest()
+	auditPath := filepath.Join(suite.T().TempDir(), "audit.log")
+	suite.assert.NoError(audit.Init(audit.Config{Enabled: true, FilePath: auditPath, ExcludePaths: []string{"tmp/**"}}))
+	defer audit.Shutdown()
+
+	name := "path"
+	path := C.CString("/" + name)
+	defer C.free(unsafe.Pointer(path))
+	mode := fs.FileMode(fuseFS.filePermission)
+	flags := C.O_RDWR & 0xffffffff
+	info := &C.fuse_file_info_t{}
+	info.flags = C.O_RDWR
+	handle := handlemap.NewHandle(name)
+	options := internal.OpenFileOptions{Name: name, Flags: flags, Mode: mode}
+	suite.mock.EXPECT().OpenFile(options).Return(handle, nil)
+	suite.assert.Equal(C.int(0), libfuse_open(path, info))
+
+	// Bytes served natively are reported on release
+	fobj := (*C.file_handle_t)(unsafe.Pointer(uintptr(info.fh)))
+	fobj.rd_bytes = 10
+	fobj.wr_bytes = 20
+	suite.mock.EXPECT().ReleaseFile(internal.ReleaseFileOptions{Handle: handle}).Return(nil)
+	suite.assert.Equal(C.int(0), libfuse_release(path, info))
+
+	suite.mock.EXPECT().DeleteFile(internal.DeleteFileOptions{Name: name}).Return(syscall.ENOENT)
+	suite.assert.Equal(C.int(-C.ENOENT), libfuse_unlink(path))
+
+	excluded := C.CString("/tmp/dir")
+	defer C.free(unsafe.Pointer(excluded))
+	suite.mock.EXPECT().CreateDir(internal.CreateDirOptions{Name: "tmp/dir", Mode: fs.FileMode(0775)}).Return(nil)
+	suite.assert.Equal(C.int(0), libfuse_mkdir(excluded, 0775))
+
+	audit.Shutdown()
+	data, err := os.ReadFile(auditPath)
+	suite.assert.NoError(err)
+
+	var events []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		event := map[string]any{}
+		suite.assert.NoError(json.Unmarshal([]byte(line), &event))
+		events = append(events, event)
+	}
+	suite.Require().Len(events, 3)
+
+	suite.assert.Equal("open", events[0]["op"])
+	suite.assert.Equal(name, events[0]["path"])
+	suite.assert.Equal("ok", events[0]["result"])
+	suite.assert.EqualValues(handle.ID, events[0]["handle"])
+
+	suite.assert.Equal("release", events[1]["op"])
+	suite.assert.EqualValues(handle.ID, events[1]["handle"])
+	suite.assert.EqualValues(10, events[1]["bytes_read"])
+	suite.assert.EqualValues(20, events[1]["bytes_written"])
+
+	suite.assert.Equal("unlink", events[2]["op"])
+	suite.assert.Equal("ENOENT", events[2]["result"])
+}
+
 func testTruncate(suite *libfuseTestSuite) {
 	defer suite.cleanupTest()
 	name := "path"
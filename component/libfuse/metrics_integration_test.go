@@ -0,0 +1,111 @@
+//go:build integration && !fuse2
+
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package libfuse
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-storage-fuse/v2/component/loopback"
+	"github.com/Azure/azure-storage-fuse/v2/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// metricsIntegrationSuite scrapes the metrics endpoint of a live FUSE mount
+// backed by loopback storage.
+//
+// Run with:
+//
+//	go test -tags "integration fuse3" -v ./component/libfuse/... -timeout 120s
+type metricsIntegrationSuite struct {
+	suite.Suite
+	assert *assert.Assertions
+}
+
+func (s *metricsIntegrationSuite) SetupTest() {
+	s.assert = assert.New(s.T())
+}
+
+func (s *metricsIntegrationSuite) scrape(addr string) string {
+	resp, err := http.Get(fmt.Sprintf("http://%s/metrics", addr))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	s.Require().NoError(err)
+	return string(body)
+}
+
+// TestScrapeLoopbackMount verifies that operations on the mount show up as latency and errno series.
+func (s *metricsIntegrationSuite) TestScrapeLoopbackMount() {
+	storageDir := s.T().TempDir()
+	backend := loopback.NewLoopbackFSComponent()
+	h := newIntegrationHarness(s.T(), backend, fmt.Sprintf("loopbackfs:\n  path: %s\n", storageDir))
+	s.Require().NoError(backend.Configure(true))
+
+	srv, err := metrics.Start("127.0.0.1:0")
+	s.Require().NoError(err)
+	defer srv.Stop()
+
+	h.start(s.T())
+	defer h.stop(s.T())
+
+	path := filepath.Join(h.MountDir, "metrics.txt")
+	s.Require().NoError(os.WriteFile(path, []byte("hello metrics"), 0644))
+
+	data, err := os.ReadFile(path)
+	s.Require().NoError(err)
+	s.assert.Equal("hello metrics", string(data))
+
+	_, err = os.Stat(filepath.Join(h.MountDir, "missing.txt"))
+	s.assert.True(os.IsNotExist(err))
+
+	body := s.scrape(srv.Addr())
+	s.assert.Contains(body, "# TYPE blobfuse2_operation_duration_seconds histogram")
+	s.assert.Contains(body, `blobfuse2_operation_duration_seconds_count{component="libfuse",operation="create"} 1`)
+	s.assert.Contains(body, `blobfuse2_operation_duration_seconds_bucket{component="libfuse",operation="getattr",le="+Inf"}`)
+	s.assert.Contains(body, `blobfuse2_operation_errors_total{component="libfuse",operation="getattr",errno="ENOENT"}`)
+}
+
+func TestMetricsIntegrationSuite(t *testing.T) {
+	suite.Run(t, new(metricsIntegrationSuite))
+}
@@ -45,8 +45,17 @@ typedef struct {
     uint64_t       obj;                 // Handlemap.Handle object representing this handle
     uint16_t       cnt;                 // Number of read-write operations done on this handle
     uint8_t        dirty;               // A write operation was performed on this handle
+    uint64_t       rd_bytes;            // Bytes read through this handle, reported by the audit log on release
+    uint64_t       wr_bytes;            // Bytes written through this handle, reported by the audit log on release
 } file_handle_t;
 
+// count_io : Add the bytes transferred by a successful read or write to a handle counter
+static void count_io(uint64_t* counter, int res)
+{
+    if (res > 0)
+        __atomic_fetch_add(counter, (uint64_t)res, __ATOMIC_RELAXED);
+}
+
 
 // allocate_native_file_object : Allocate a native C-struct to hold handle map object and unix FD
 static file_handle_t* allocate_native_file_object(uint64_t fd, uint64_t obj, uint64_t file_size)
@@ -80,6 +89,7 @@ static int native_pread(char *path, char *buf, size_t size, off_t offset, file_h
     int res = pread(handle_obj->fd, buf, size, offset);
     if (res == -1)
         res = -errno;
+    count_io(&handle_obj->rd_bytes, res);
     
     #if 0
     handle_obj->cnt++;
@@ -100,6 +110,7 @@ static int native_pwrite(char *path, char *buf, size_t size, off_t offset, file_
     int res = pwrite(handle_obj->fd, buf, size, offset);
     if (res == -1)
         res = -errno;
+    count_io(&handle_obj->wr_bytes, res);
 
     // Increment the operation counter and mark a write was done on this handle
     handle_obj->dirty = 1;
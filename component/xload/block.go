@@ -36,6 +36,8 @@ package xload
 import (
 	"fmt"
 	"syscall"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
 )
 
 // Block is a memory mapped buffer with its state to hold data
@@ -45,16 +47,21 @@ type Block struct {
 	Length int64  // Length of data that this block holds
 	Id     string // ID to represent this block in the blob
 	Data   []byte // Data this block holds
+	numa   int    // Index of the NUMA node pool this block belongs to
 }
 
 // AllocateBlock creates a new memory mapped buffer for the given size
 func AllocateBlock(size uint64) (*Block, error) {
+	return allocateBlock(size, nil)
+}
+
+// allocateBlock creates a new buffer for the given size, mapped as per the allocator
+func allocateBlock(size uint64, allocator *common.BufferAllocator) (*Block, error) {
 	if size == 0 {
 		return nil, fmt.Errorf("invalid size")
 	}
 
-	prot, flags := syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE
-	addr, err := syscall.Mmap(-1, 0, int(size), prot, flags)
+	addr, err := allocator.Allocate(size)
 
 	if err != nil {
 		return nil, fmt.Errorf("mmap error: %v", err)
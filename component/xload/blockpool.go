@@ -36,8 +36,10 @@ package xload
 import (
 	"context"
 	"fmt"
+	"sync"
 	"sync/atomic"
 
+	"github.com/Azure/azure-storage-fuse/v2/common"
 	"github.com/Azure/azure-storage-fuse/v2/common/log"
 )
 
@@ -60,10 +62,69 @@ type BlockPool struct {
 
 	// Context to cancel new block allocation
 	ctx context.Context
+
+	// Number of blocks currently allocated, less than maxBlocks while the pool is shrunk under memory pressure
+	allocated atomic.Int32
+
+	// Number of blocks still to be freed as they come back to the pool
+	deficit atomic.Int32
+
+	// Lock to serialize resizing of the pool
+	resizeLock sync.Mutex
+
+	// Allocator mapping the block buffers, nil for regular pages
+	allocator *common.BufferAllocator
+
+	// Index of the NUMA node this pool is bound to, among the nodes of the allocator
+	numa int
+
+	// Pools of each NUMA node when blocks are bound to several nodes, this pool then holds no blocks itself.
+	// Blocks are taken from the pool of the node the caller asks for, and from other nodes only when it has none free.
+	nodePools []*BlockPool
 }
 
 // NewBlockPool allocates a new pool of blocks
 func NewBlockPool(blockSize uint64, blockCount uint32, ctx context.Context) *BlockPool {
+	return newBlockPool(blockSize, blockCount, ctx, nil)
+}
+
+// newBlockPool allocates a new pool of blocks using the given allocator
+func newBlockPool(blockSize uint64, blockCount uint32, ctx context.Context, allocator *common.BufferAllocator) *BlockPool {
+	nodes := uint32(len(allocator.Nodes()))
+	if nodes < 2 || blockCount < nodes {
+		return newNodeBlockPool(blockSize, blockCount, ctx, allocator, 0)
+	}
+
+	// Blocks are split evenly across the nodes
+	pool := &BlockPool{
+		blockSize: blockSize,
+		maxBlocks: blockCount,
+		ctx:       ctx,
+		allocator: allocator,
+		nodePools: make([]*BlockPool, 0, nodes),
+	}
+
+	for i := range nodes {
+		count := blockCount / nodes
+		if i < blockCount%nodes {
+			count++
+		}
+
+		nodePool := newNodeBlockPool(blockSize, count, ctx, allocator.ForNode(int(i)), int(i))
+		if nodePool == nil {
+			for _, p := range pool.nodePools {
+				p.Terminate()
+			}
+			return nil
+		}
+		pool.nodePools = append(pool.nodePools, nodePool)
+	}
+
+	return pool
+}
+
+// newNodeBlockPool allocates a new pool of blocks on the NUMA node at given index of the allocator
+func newNodeBlockPool(blockSize uint64, blockCount uint32, ctx context.Context, allocator *common.BufferAllocator, numa int) *BlockPool {
 	// Ignore if config is invalid
 	if blockSize == 0 || blockCount == 0 {
 		log.Err("BlockPool::NewBlockPool : blockSize : %v, block count : %v", blockSize, blockCount)
@@ -78,17 +139,21 @@ func NewBlockPool(blockSize uint64, blockCount uint32, ctx context.Context) *Blo
 		maxBlocks:  uint32(blockCount),
 		blockSize:  blockSize,
 		ctx:        ctx,
+		allocator:  allocator,
+		numa:       numa,
 	}
 
 	pool.waitLength.Store(0)
+	pool.allocated.Store(int32(blockCount))
 
 	// Preallocate all blocks so that during runtime we do not spend CPU cycles on this
 	for i := range blockCount {
-		block, err := AllocateBlock(blockSize)
+		block, err := allocateBlock(blockSize, allocator)
 		if err != nil {
 			log.Err("BlockPool::NewBlockPool : unable to allocate block [%s]", err.Error())
 			return nil
 		}
+		block.numa = numa
 
 		if i < highPriority {
 			pool.priorityCh <- block
@@ -102,6 +167,13 @@ func NewBlockPool(blockSize uint64, blockCount uint32, ctx context.Context) *Blo
 
 // Terminate ends the block pool life
 func (pool *BlockPool) Terminate() {
+	if len(pool.nodePools) > 0 {
+		for _, p := range pool.nodePools {
+			p.Terminate()
+		}
+		return
+	}
+
 	close(pool.blocksCh)
 	close(pool.priorityCh)
 
@@ -122,22 +194,196 @@ func releaseBlocks(ch chan *Block) {
 
 // Usage provides % usage of this block pool
 func (pool *BlockPool) Usage() uint32 {
-	return ((pool.maxBlocks - (uint32)(len(pool.blocksCh)+len(pool.priorityCh))) * 100) / pool.maxBlocks
+	allocated, priority, free := pool.counts()
+	free += priority
+	if free >= allocated {
+		return 0
+	}
+	return ((allocated - free) * 100) / allocated
+}
+
+// counts provides number of blocks allocated, free priority blocks and other free blocks in this pool
+func (pool *BlockPool) counts() (uint32, uint32, uint32) {
+	if len(pool.nodePools) > 0 {
+		allocated, priority, free := uint32(0), uint32(0), uint32(0)
+		for _, p := range pool.nodePools {
+			a, pr, f := p.counts()
+			allocated, priority, free = allocated+a, priority+pr, free+f
+		}
+		return allocated, priority, free
+	}
+
+	return uint32(pool.allocated.Load()), uint32(len(pool.priorityCh)), uint32(len(pool.blocksCh))
+}
+
+// Size provides number of blocks this pool holds once pending shrink completes
+func (pool *BlockPool) Size() uint32 {
+	if len(pool.nodePools) > 0 {
+		size := uint32(0)
+		for _, p := range pool.nodePools {
+			size += p.Size()
+		}
+		return size
+	}
+
+	return uint32(pool.allocated.Load() - pool.deficit.Load())
+}
+
+// MinSize provides number of blocks below which this pool is never shrunk
+func (pool *BlockPool) MinSize() uint32 {
+	if len(pool.nodePools) > 0 {
+		size := uint32(0)
+		for _, p := range pool.nodePools {
+			size += p.MinSize()
+		}
+		return size
+	}
+
+	// Priority blocks are always kept so that files already being processed can complete
+	return max(uint32(cap(pool.priorityCh)), pool.maxBlocks/4, 1)
+}
+
+// Shrink the pool by given number of blocks. Free blocks are released right away
+// and the rest are released as they come back to the pool. Returns the number of blocks the pool is shrunk by.
+func (pool *BlockPool) Shrink(count uint32) uint32 {
+	if len(pool.nodePools) > 0 {
+		return pool.spread(count, (*BlockPool).Shrink)
+	}
+
+	pool.resizeLock.Lock()
+	defer pool.resizeLock.Unlock()
+
+	count = min(count, pool.Size()-min(pool.Size(), pool.MinSize()))
+
+	released := uint32(0)
+	for released < count {
+		select {
+		case block := <-pool.blocksCh:
+			_ = block.Delete()
+			pool.allocated.Add(-1)
+			released++
+			continue
+		default:
+		}
+		break
+	}
+
+	pool.deficit.Add(int32(count - released))
+	log.Info("BlockPool::Shrink : Shrunk pool by %v blocks (%v released, %v pending), size %v", count, released, count-released, pool.Size())
+	return count
+}
+
+// Grow the pool back by given number of blocks, up to the size it was created with. Returns the number of blocks the pool is grown by.
+func (pool *BlockPool) Grow(count uint32) uint32 {
+	if len(pool.nodePools) > 0 {
+		return pool.spread(count, (*BlockPool).Grow)
+	}
+
+	pool.resizeLock.Lock()
+	defer pool.resizeLock.Unlock()
+
+	count = min(count, pool.maxBlocks-pool.Size())
+
+	// Blocks yet to be released can just be kept
+	grown := uint32(0)
+	for grown < count {
+		deficit := pool.deficit.Load()
+		if deficit <= 0 {
+			break
+		}
+
+		if pool.deficit.CompareAndSwap(deficit, deficit-1) {
+			grown++
+		}
+	}
+
+	for ; grown < count; grown++ {
+		block, err := allocateBlock(pool.blockSize, pool.allocator)
+		if err != nil {
+			log.Err("BlockPool::Grow : unable to allocate block [%s]", err.Error())
+			break
+		}
+		block.numa = pool.numa
+
+		select {
+		case pool.priorityCh <- block:
+		case pool.blocksCh <- block:
+		default:
+			_ = block.Delete()
+			log.Info("BlockPool::Grow : Grown pool by %v blocks, no room for more, size %v", grown, pool.Size())
+			return grown
+		}
+		pool.allocated.Add(1)
+	}
+
+	log.Info("BlockPool::Grow : Grown pool by %v blocks, size %v", grown, pool.Size())
+	return grown
+}
+
+// spread a resize of given number of blocks evenly over the pools of NUMA nodes, as far as each of them allows.
+// Returns the number of blocks resized.
+func (pool *BlockPool) spread(count uint32, resize func(*BlockPool, uint32) uint32) uint32 {
+	done := uint32(0)
+	share := (count + uint32(len(pool.nodePools)) - 1) / uint32(len(pool.nodePools))
+
+	// Second round hands what some nodes could not take to the others
+	for range 2 {
+		for _, p := range pool.nodePools {
+			if done == count {
+				return done
+			}
+			done += resize(p, min(share, count-done))
+		}
+		share = count - done
+	}
+
+	return done
+}
+
+// releaseDeficit : Free this block instead of returning it to the pool, if the pool is still to be shrunk
+func (pool *BlockPool) releaseDeficit(block *Block) bool {
+	for {
+		deficit := pool.deficit.Load()
+		if deficit <= 0 {
+			return false
+		}
+
+		if pool.deficit.CompareAndSwap(deficit, deficit-1) {
+			_ = block.Delete()
+			pool.allocated.Add(-1)
+			return true
+		}
+	}
 }
 
 func (pool *BlockPool) GetUsageDetails() (uint32, uint32, uint32, int32) {
-	return pool.maxBlocks, uint32(len(pool.priorityCh)), uint32(len(pool.blocksCh)), pool.waitLength.Load()
+	_, priority, free := pool.counts()
+	return pool.maxBlocks, priority, free, pool.waitLength.Load()
 }
 
 func (pool *BlockPool) GetBlockSize() uint64 {
 	return pool.blockSize
 }
 
+// Nodes provides number of NUMA nodes the blocks of this pool are split across
+func (pool *BlockPool) Nodes() int {
+	return max(len(pool.nodePools), 1)
+}
+
 func (pool *BlockPool) GetBlock(priority bool) *Block {
+	return pool.GetBlockOn(priority, 0)
+}
+
+// GetBlockOn gets a block of the NUMA node at given index
+func (pool *BlockPool) GetBlockOn(priority bool, numa int) *Block {
 	// increment an atomic variable to track the number of blocks in use
 	pool.waitLength.Add(1)
 	defer pool.waitLength.Add(-1)
 
+	if len(pool.nodePools) > 0 {
+		return pool.getNodeBlock(priority, numa%len(pool.nodePools))
+	}
+
 	if priority {
 		return pool.mustGet()
 	} else {
@@ -146,6 +392,47 @@ func (pool *BlockPool) GetBlock(priority bool) *Block {
 
 }
 
+// getNodeBlock gets a block from the pool of the given node. A free block of another node
+// is still taken over waiting for one of the given node.
+func (pool *BlockPool) getNodeBlock(priority bool, numa int) *Block {
+	for i := range pool.nodePools {
+		if block := pool.nodePools[(numa+i)%len(pool.nodePools)].poll(priority); block != nil {
+			return block
+		}
+	}
+
+	if priority {
+		return pool.nodePools[numa].mustGet()
+	} else {
+		return pool.nodePools[numa].tryGet()
+	}
+}
+
+// poll a block from the pool, return back if nothing is available. Priority blocks are taken only for priority callers.
+func (pool *BlockPool) poll(priority bool) *Block {
+	var block *Block = nil
+
+	select {
+	case block = <-pool.blocksCh:
+		break
+	default:
+		if !priority {
+			return nil
+		}
+
+		select {
+		case block = <-pool.priorityCh:
+			break
+		default:
+			return nil
+		}
+	}
+
+	// Mark the buffer ready for reuse now
+	block.ReUse()
+	return block
+}
+
 // TryGet a block from the pool. If the pool is empty, wait till a block is released back to the pool
 func (pool *BlockPool) tryGet() *Block {
 	var block *Block = nil
@@ -188,6 +475,16 @@ func (pool *BlockPool) mustGet() *Block {
 
 // Release back the Block to the pool
 func (pool *BlockPool) Release(block *Block) {
+	if len(pool.nodePools) > 0 {
+		// Block goes back to the pool of the node it is bound to
+		pool.nodePools[block.numa].Release(block)
+		return
+	}
+
+	if pool.releaseDeficit(block) {
+		return
+	}
+
 	select {
 	case pool.priorityCh <- block:
 		break
@@ -37,6 +37,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/Azure/azure-storage-fuse/v2/common"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
@@ -169,6 +170,130 @@ func (suite *blockpoolTestSuite) TestBlockPoolBufferExhaution() {
 	suite.assert.Empty(bp.priorityCh)
 }
 
+func (suite *blockpoolTestSuite) TestBlockPoolShrinkGrow() {
+	suite.assert = assert.New(suite.T())
+
+	bp := NewBlockPool(1, 20, context.TODO())
+	suite.assert.NotNil(bp)
+	suite.assert.EqualValues(20, bp.Size())
+	suite.assert.EqualValues(5, bp.MinSize())
+
+	var blocks []*Block
+	for range 5 {
+		blocks = append(blocks, bp.GetBlock(false))
+	}
+
+	// Free blocks go right away and the rest as they come back, never going below the minimum
+	suite.assert.EqualValues(15, bp.Shrink(100))
+	suite.assert.EqualValues(5, bp.Size())
+	suite.assert.EqualValues(2, bp.deficit.Load())
+	suite.assert.Empty(bp.blocksCh)
+	suite.assert.Len(bp.priorityCh, 2)
+
+	for _, blk := range blocks {
+		bp.Release(blk)
+	}
+	suite.assert.EqualValues(5, bp.allocated.Load())
+	suite.assert.EqualValues(0, bp.deficit.Load())
+	suite.assert.Equal(uint32(0), bp.Usage())
+
+	// Growing never goes beyond the size pool was created with
+	suite.assert.EqualValues(15, bp.Grow(100))
+	suite.assert.EqualValues(20, bp.Size())
+	suite.assert.Len(bp.priorityCh, 2)
+	suite.assert.Len(bp.blocksCh, 18)
+
+	bp.Terminate()
+	suite.assert.Empty(bp.blocksCh)
+	suite.assert.Empty(bp.priorityCh)
+}
+
+func (suite *blockpoolTestSuite) TestBlockPoolWithAllocator() {
+	suite.assert = assert.New(suite.T())
+
+	allocator, err := common.NewBufferAllocator(common.BufferOptions{HugePages: common.HugePagesTHP, NumaPolicy: common.NumaLocal})
+	suite.assert.NoError(err)
+
+	bp := newBlockPool(4096, 10, context.TODO(), allocator)
+	suite.assert.NotNil(bp)
+	suite.assert.Equal(allocator, bp.allocator)
+
+	blk := bp.GetBlock(true)
+	suite.assert.NotNil(blk)
+	suite.assert.Len(blk.Data, 4096)
+	blk.Data[4095] = 1
+	bp.Release(blk)
+
+	// Blocks allocated while growing come from the same allocator
+	suite.assert.EqualValues(5, bp.Shrink(5))
+	suite.assert.EqualValues(5, bp.Grow(5))
+	suite.assert.EqualValues(10, bp.Size())
+
+	bp.Terminate()
+}
+
+func (suite *blockpoolTestSuite) TestNodePools() {
+	suite.assert = assert.New(suite.T())
+
+	// Pools of two nodes
+	bp := &BlockPool{blockSize: 2, maxBlocks: 20, ctx: context.TODO()}
+	for i := range 2 {
+		p := newNodeBlockPool(2, 10, context.TODO(), nil, i)
+		suite.assert.NotNil(p)
+		bp.nodePools = append(bp.nodePools, p)
+	}
+
+	suite.assert.EqualValues(20, bp.Size())
+	suite.assert.EqualValues(4, bp.MinSize())
+
+	// Blocks come from the node asked for first and from the other node once it has none free
+	block := bp.GetBlockOn(false, 3)
+	suite.assert.NotNil(block)
+	suite.assert.Equal(1, block.numa)
+	bp.Release(block)
+
+	var blocks []*Block
+	for i := range 12 {
+		block := bp.GetBlock(false)
+		suite.assert.NotNil(block)
+		suite.assert.Equal(i/9, block.numa)
+		blocks = append(blocks, block)
+	}
+
+	maxBlocks, priority, free, _ := bp.GetUsageDetails()
+	suite.assert.EqualValues(20, maxBlocks)
+	suite.assert.EqualValues(2, priority)
+	suite.assert.EqualValues(6, free)
+	suite.assert.Equal(uint32(60), bp.Usage())
+
+	// Priority callers take the priority blocks of the node asked for before regular ones of other nodes
+	block = bp.GetBlock(true)
+	suite.assert.NotNil(block)
+	suite.assert.Equal(0, block.numa)
+	blocks = append(blocks, block)
+
+	// Blocks go back to the node they are bound to
+	for _, b := range blocks {
+		bp.Release(b)
+	}
+	suite.assert.Len(bp.nodePools[0].blocksCh, 9)
+	suite.assert.Len(bp.nodePools[1].blocksCh, 9)
+	suite.assert.Len(bp.nodePools[0].priorityCh, 1)
+	suite.assert.Len(bp.nodePools[1].priorityCh, 1)
+
+	// Resizing is spread over the nodes
+	suite.assert.EqualValues(16, bp.Shrink(100))
+	suite.assert.EqualValues(2, bp.nodePools[0].Size())
+	suite.assert.EqualValues(2, bp.nodePools[1].Size())
+	suite.assert.EqualValues(7, bp.Grow(7))
+	suite.assert.EqualValues(6, bp.nodePools[0].Size())
+	suite.assert.EqualValues(5, bp.nodePools[1].Size())
+	suite.assert.EqualValues(9, bp.Grow(100))
+	suite.assert.EqualValues(20, bp.Size())
+
+	bp.Terminate()
+}
+
 func TestBlockPoolSuite(t *testing.T) {
 	suite.Run(t, new(blockpoolTestSuite))
 }
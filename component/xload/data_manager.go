@@ -37,6 +37,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/Azure/azure-storage-fuse/v2/common"
 	"github.com/Azure/azure-storage-fuse/v2/common/log"
 	"github.com/Azure/azure-storage-fuse/v2/internal"
 )
@@ -59,6 +60,7 @@ type remoteDataManagerOptions struct {
 	workerCount uint32
 	remote      internal.Component
 	statsMgr    *StatsManager
+	allocator   *common.BufferAllocator // Allocator of the blocks, to pin the workers to their NUMA nodes
 }
 
 func newRemoteDataManager(opts *remoteDataManagerOptions) (*remoteDataManager, error) {
@@ -76,6 +78,11 @@ func newRemoteDataManager(opts *remoteDataManagerOptions) (*remoteDataManager, e
 	rdm.SetRemote(opts.remote)
 	rdm.SetStatsManager(opts.statsMgr)
 	rdm.Init()
+
+	// Workers downloading into blocks run on the node the blocks are bound to
+	if rdm.GetThreadPool() != nil {
+		rdm.GetThreadPool().bindNodes(opts.allocator)
+	}
 	return rdm, nil
 }
 
@@ -0,0 +1,95 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package xload
+
+import (
+	"time"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+)
+
+// Interval at which memory pressure is checked
+var memoryCheckInterval = 5 * time.Second
+
+// startMemoryMonitor : Keep resizing the block pool as per the memory pressure until the pool context is cancelled
+func (xl *Xload) startMemoryMonitor() {
+	xl.memoryMonitorDone.Add(1)
+
+	go func() {
+		defer xl.memoryMonitorDone.Done()
+
+		ticker := time.NewTicker(memoryCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-xl.poolctx.Done():
+				return
+			case <-ticker.C:
+				mp, err := common.GetMemoryPressure()
+				if err != nil {
+					log.Warn("Xload::startMemoryMonitor : Failed to read memory pressure [%s]", err.Error())
+					continue
+				}
+				xl.checkMemoryPressure(mp)
+			}
+		}
+	}()
+}
+
+// checkMemoryPressure : Shrink the block pool under memory pressure and grow it back once the pressure is over
+func (xl *Xload) checkMemoryPressure(mp *common.MemoryPressure) {
+	size := xl.blockPool.Size()
+	adjust := mp.PoolAdjustment(xl.blockSize, size, xl.blockPool.MinSize(), xl.blockPool.maxBlocks)
+
+	var resized uint32
+	if adjust < 0 {
+		resized = xl.blockPool.Shrink(uint32(-adjust))
+	} else if adjust > 0 {
+		resized = xl.blockPool.Grow(uint32(adjust))
+	}
+
+	if resized == 0 {
+		return
+	}
+
+	log.Info("Xload::checkMemoryPressure : Block pool resized from %v to %v blocks, usage %v of %v, stall %.2f%%",
+		size, xl.blockPool.Size(), mp.Usage, mp.Limit, mp.SomeAvg10)
+	xl.statsMgr.AddStats(&StatsItem{
+		Component:  BLOCK_POOL,
+		PoolShrunk: adjust < 0,
+		PoolSize:   xl.blockPool.Size(),
+	})
+}
@@ -40,6 +40,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Azure/azure-storage-fuse/v2/common"
@@ -57,6 +58,7 @@ type splitter struct {
 	path        string
 	fileLocks   *common.LockMap
 	validateMD5 bool
+	nextNode    atomic.Uint32 // Files are spread over the NUMA nodes of the block pool, one node per file
 }
 
 // --------------------------------------------------------------------------------------------------------
@@ -189,6 +191,7 @@ func (ds *downloadSplitter) Process(item *WorkItem) (int, error) {
 
 	numBlocks := ((item.DataLen - 1) / ds.blockPool.GetBlockSize()) + 1
 	offset := int64(0)
+	numa := int(ds.nextNode.Add(1)) % ds.blockPool.Nodes()
 
 	wg := sync.WaitGroup{}
 	wg.Add(1)
@@ -240,7 +243,7 @@ func (ds *downloadSplitter) Process(item *WorkItem) (int, error) {
 	}()
 
 	for i := 0; i < int(numBlocks); i++ {
-		block := ds.blockPool.GetBlock(item.Priority)
+		block := ds.blockPool.GetBlockOn(item.Priority, numa)
 		if block == nil {
 			responseChannel <- &WorkItem{Err: fmt.Errorf("failed to get block from pool for file %s, offset %v", item.Path, offset)}
 		} else {
@@ -61,6 +61,8 @@ type StatsManager struct {
 	items           chan *StatsItem // channel to hold the stats items
 	done            chan bool       // channel to indicate if the stats manager has completed or not
 	pool            *BlockPool      // Object of block pool
+	poolShrinks     uint64          // number of times block pool was shrunk under memory pressure
+	poolGrows       uint64          // number of times block pool was grown back
 }
 
 type StatsItem struct {
@@ -72,6 +74,8 @@ type StatsItem struct {
 	Download         bool   // flag to denote upload or download
 	DiskIO           bool   // flag to denote if the item is a disk IO
 	BytesTransferred uint64 // bytes uploaded or downloaded for this file
+	PoolShrunk       bool   // flag to denote if the block pool was shrunk or grown
+	PoolSize         uint32 // number of blocks in the block pool after resize
 }
 
 type statsJSONData struct {
@@ -183,6 +187,14 @@ func (sm *StatsManager) statsProcessor() {
 				sm.bytesUploaded += item.BytesTransferred
 			}
 
+		case BLOCK_POOL:
+			log.Info("statsManager::statsProcessor : block pool resized to %v blocks, shrunk %v", item.PoolSize, item.PoolShrunk)
+			if item.PoolShrunk {
+				sm.poolShrinks += 1
+			} else {
+				sm.poolGrows += 1
+			}
+
 		case STATS_MANAGER:
 			sm.calculateBandwidth()
 
@@ -235,10 +247,10 @@ func (sm *StatsManager) calculateBandwidth() {
 	}
 
 	log.Crit("statsManager::calculateBandwidth : timestamp %v, %.2f%%, %v Done, %v Failed, "+
-		"%v Pending, %v Total, Bytes transferred %v, Throughput (Mbps): %.2f, Disk Speed (Mbps): %.2f, Blockpool usage: %v%%, (%v / %v / %v : %v), Time: %.2f",
+		"%v Pending, %v Total, Bytes transferred %v, Throughput (Mbps): %.2f, Disk Speed (Mbps): %.2f, Blockpool usage: %v%%, (%v / %v / %v : %v), Blockpool resizes: (%v shrinks / %v grows), Time: %.2f",
 		currTime.Format(time.RFC1123), percentCompleted, sm.success, sm.failed,
 		filesPending, sm.totalFiles, bytesTransferred, bandwidthMbps, diskSpeedMbps, poolusage,
-		maximum, pr, reg, waiting, timeLapsed)
+		maximum, pr, reg, waiting, sm.poolShrinks, sm.poolGrows, timeLapsed)
 
 	if sm.fileHandle != nil {
 		err := sm.marshalStatsData(&statsJSONData{
@@ -38,6 +38,7 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/Azure/azure-storage-fuse/v2/common"
 	"github.com/Azure/azure-storage-fuse/v2/common/log"
 )
 
@@ -49,15 +50,18 @@ type ThreadPool struct {
 	// Wait group to wait for all workers to finish
 	waitGroup sync.WaitGroup
 
-	// Channel to hold pending requests
-	priorityItems chan *WorkItem
-	workItems     chan *WorkItem
+	// Channels to hold pending requests, one per NUMA node the workers are split across
+	priorityItems []chan *WorkItem
+	workItems     []chan *WorkItem
 
 	// Reader method that will actually read the data
 	callback func(*WorkItem) (int, error)
 
 	// Context to cancel the thread pool
 	ctx context.Context
+
+	// Allocator of the blocks, set when workers are pinned to the NUMA nodes blocks are bound to
+	allocator *common.BufferAllocator
 }
 
 // NewThreadPool creates a new thread pool
@@ -66,32 +70,59 @@ func NewThreadPool(count uint32, callback func(*WorkItem) (int, error)) *ThreadP
 		return nil
 	}
 
-	return &ThreadPool{
-		worker:        count,
-		callback:      callback,
-		priorityItems: make(chan *WorkItem, count*2),
-		workItems:     make(chan *WorkItem, count*4),
+	threadPool := &ThreadPool{
+		worker:   count,
+		callback: callback,
+	}
+	threadPool.makeChannels(1)
+	return threadPool
+}
+
+func (threadPool *ThreadPool) makeChannels(nodes int) {
+	perNode := threadPool.worker / uint32(nodes)
+	threadPool.priorityItems = make([]chan *WorkItem, nodes)
+	threadPool.workItems = make([]chan *WorkItem, nodes)
+	for i := range nodes {
+		threadPool.priorityItems[i] = make(chan *WorkItem, perNode*2)
+		threadPool.workItems[i] = make(chan *WorkItem, perNode*4)
+	}
+}
+
+// bindNodes splits the workers across the NUMA nodes of the allocator and pins each to the CPUs of its node, so that
+// blocks are filled by workers local to their memory. To be called before Start.
+func (threadPool *ThreadPool) bindNodes(allocator *common.BufferAllocator) {
+	nodes := len(allocator.Nodes())
+	if nodes < 2 {
+		return
 	}
+
+	// Every node needs workers of its own, as its blocks are not handed to others
+	threadPool.allocator = allocator
+	threadPool.worker = max(threadPool.worker-threadPool.worker%uint32(nodes), uint32(nodes))
+	threadPool.makeChannels(nodes)
 }
 
 // Start all the workers and wait till they start receiving requests
 func (threadPool *ThreadPool) Start(ctx context.Context) {
 	threadPool.ctx = ctx
+	nodes := uint32(len(threadPool.workItems))
 
-	// 10% threads will listne only on high priority channel
-	highPriority := (threadPool.worker * 10) / 100
+	// 10% threads of each node will listne only on high priority channel
+	highPriority := (threadPool.worker / nodes * 10) / 100
 
 	for i := uint32(0); i < threadPool.worker; i++ {
 		threadPool.waitGroup.Add(1)
-		go threadPool.Do(i < highPriority)
+		go threadPool.Do(i/nodes < highPriority, int(i%nodes))
 	}
 }
 
 // Stop all the workers threads
 func (threadPool *ThreadPool) Stop() {
 	log.Debug("threadPool::Stop : Closing Channels")
-	close(threadPool.priorityItems)
-	close(threadPool.workItems)
+	for i := range threadPool.workItems {
+		close(threadPool.priorityItems[i])
+		close(threadPool.workItems[i])
+	}
 	threadPool.waitGroup.Wait()
 	log.Debug("threadPool::Stop : Threads terminated")
 }
@@ -105,10 +136,16 @@ func (threadPool *ThreadPool) Schedule(item *WorkItem) error {
 		log.Err("ThreadPool::Schedule : Thread pool is closed, cannot schedule workitem %s", item.Path)
 		return fmt.Errorf("thread pool is closed, cannot schedule workitem %s", item.Path)
 	default:
+		// Block is handed to the workers of the node it is bound to
+		node := 0
+		if item.Block != nil && item.Block.numa < len(threadPool.workItems) {
+			node = item.Block.numa
+		}
+
 		if item.Priority {
-			threadPool.priorityItems <- item
+			threadPool.priorityItems[node] <- item
 		} else {
-			threadPool.workItems <- item
+			threadPool.workItems[node] <- item
 		}
 	}
 
@@ -116,16 +153,26 @@ func (threadPool *ThreadPool) Schedule(item *WorkItem) error {
 }
 
 // Do is the core task to be executed by each worker thread
-func (threadPool *ThreadPool) Do(priority bool) {
+func (threadPool *ThreadPool) Do(priority bool, node int) {
 	defer threadPool.waitGroup.Done()
 
+	if threadPool.allocator != nil {
+		// Thread stays pinned to the node, so it is not unlocked and exits along with this worker
+		err := threadPool.allocator.LockToNode(node)
+		if err != nil {
+			log.Warn("ThreadPool::Do : Failed to pin worker to NUMA node %v [%s]", threadPool.allocator.Nodes()[node], err.Error())
+		}
+	}
+
+	priorityItems, workItems := threadPool.priorityItems[node], threadPool.workItems[node]
+
 	if priority {
 		// This thread will work only on high priority channel
 		for {
 			select {
 			case <-threadPool.ctx.Done(): // listen to cancellation signal
 				return
-			case item, ok := <-threadPool.priorityItems:
+			case item, ok := <-priorityItems:
 				if !ok {
 					return
 				}
@@ -138,12 +185,12 @@ func (threadPool *ThreadPool) Do(priority bool) {
 			select {
 			case <-threadPool.ctx.Done(): // listen to cancellation signal
 				return
-			case item, ok := <-threadPool.priorityItems:
+			case item, ok := <-priorityItems:
 				if !ok {
 					return
 				}
 				threadPool.process(item)
-			case item, ok := <-threadPool.workItems:
+			case item, ok := <-workItems:
 				if !ok {
 					return
 				}
@@ -139,6 +139,39 @@ func (suite *threadPoolTestSuite) TestPrioritySchedule() {
 	tp.Stop()
 }
 
+func (suite *threadPoolTestSuite) TestNodeSchedule() {
+	suite.assert = assert.New(suite.T())
+
+	callbackCnt := int32(0)
+	r := func(i *WorkItem) (int, error) {
+		atomic.AddInt32(&callbackCnt, 1)
+		return 0, nil
+	}
+
+	// Workers split across two nodes, not pinned as the host may not have them
+	tp := NewThreadPool(4, r)
+	suite.assert.NotNil(tp)
+	tp.makeChannels(2)
+	tp.ctx = context.TODO()
+
+	// Work goes to the workers of the node the block is bound to
+	for i := range 5 {
+		err := tp.Schedule(&WorkItem{Priority: i < 1, Block: &Block{numa: 1}})
+		suite.assert.NoError(err)
+	}
+	err := tp.Schedule(&WorkItem{})
+	suite.assert.NoError(err)
+	suite.assert.Len(tp.priorityItems[1], 1)
+	suite.assert.Len(tp.workItems[1], 4)
+	suite.assert.Len(tp.workItems[0], 1)
+
+	tp.Start(context.TODO())
+	suite.assert.Eventually(func() bool {
+		return atomic.LoadInt32(&callbackCnt) == 6
+	}, 2*time.Second, 10*time.Millisecond)
+	tp.Stop()
+}
+
 func TestThreadPoolSuite(t *testing.T) {
 	suite.Run(t, new(threadPoolTestSuite))
 }
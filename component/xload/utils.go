@@ -54,6 +54,7 @@ const (
 	LISTER            string = "LISTER"
 	SPLITTER          string = "SPLITTER"
 	DATA_MANAGER      string = "DATA_MANAGER"
+	BLOCK_POOL        string = "BLOCK_POOL"
 )
 
 // One workitem to be processed
@@ -41,6 +41,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Azure/azure-storage-fuse/v2/common"
@@ -67,6 +68,10 @@ type Xload struct {
 	poolSize          uint32             // Number of blocks in the pool
 	poolctx           context.Context    // context for the thread pool
 	poolCancelFunc    context.CancelFunc // cancel function for the thread pool
+	adaptiveMemory    bool               // resize block pool as per the memory pressure
+	memoryMonitorDone sync.WaitGroup     // wait group to wait for memory monitor to exit
+
+	bufferOptions common.BufferOptions // huge page and NUMA placement of block buffers
 }
 
 // Structure defining your config parameters
@@ -79,6 +84,9 @@ type XloadOptions struct {
 	CleanupOnStart bool    `config:"cleanup-on-start" yaml:"cleanup-on-start,omitempty"`
 	Workers        int32   `config:"workers" yaml:"workers,omitempty"`
 	PoolSize       uint32  `config:"pool-size" yaml:"pool-size,omitempty"`
+	AdaptiveMemory bool    `config:"adaptive-memory" yaml:"adaptive-memory,omitempty"`
+	HugePages      string  `config:"huge-pages" yaml:"huge-pages,omitempty"`
+	NumaPolicy     string  `config:"numa-policy" yaml:"numa-policy,omitempty"`
 	// TODO:: xload : add parallelism parameter
 }
 
@@ -226,10 +234,26 @@ func (xl *Xload) Configure(_ bool) error {
 		xl.poolSize = conf.PoolSize
 	}
 
+	xl.adaptiveMemory = conf.AdaptiveMemory
+
+	xl.bufferOptions = common.BufferOptions{
+		HugePages:  conf.HugePages,
+		NumaPolicy: conf.NumaPolicy,
+		OnFallback: func(reason string) {
+			log.Warn("Xload::allocateBlock : %s", reason)
+		},
+	}
+
+	_, err = common.NewBufferAllocator(common.BufferOptions{HugePages: conf.HugePages, NumaPolicy: conf.NumaPolicy})
+	if err != nil {
+		log.Err("Xload::Configure : %s", err.Error())
+		return fmt.Errorf("invalid buffer options in xload : %s", err.Error())
+	}
+
 	xl.poolctx, xl.poolCancelFunc = context.WithCancel(context.Background())
 
-	log.Crit("Xload::Configure : block size %v, mode %v, path %v, default permission %v, export progress %v, validate md5 %v", xl.blockSize,
-		xl.mode.String(), xl.path, xl.defaultPermission, xl.exportProgress, xl.validateMD5)
+	log.Crit("Xload::Configure : block size %v, mode %v, path %v, default permission %v, export progress %v, validate md5 %v, adaptive memory %v, huge pages %v, numa policy %v",
+		xl.blockSize, xl.mode.String(), xl.path, xl.defaultPermission, xl.exportProgress, xl.validateMD5, xl.adaptiveMemory, conf.HugePages, conf.NumaPolicy)
 
 	return nil
 }
@@ -238,14 +262,18 @@ func (xl *Xload) Configure(_ bool) error {
 func (xl *Xload) Start(ctx context.Context) error {
 	log.Trace("Xload::Start : Starting component %s", xl.Name())
 
-	xl.blockPool = NewBlockPool(xl.blockSize, xl.poolSize, xl.poolctx)
+	allocator, err := common.NewBufferAllocator(xl.bufferOptions)
+	if err != nil {
+		log.Err("Xload::Start : Failed to create buffer allocator [%s]", err.Error())
+		return err
+	}
+
+	xl.blockPool = newBlockPool(xl.blockSize, xl.poolSize, xl.poolctx, allocator)
 	if xl.blockPool == nil {
 		log.Err("Xload::Start : Failed to create block pool")
 		return fmt.Errorf("failed to create block pool")
 	}
 
-	var err error
-
 	// create stats manager
 	xl.statsMgr, err = NewStatsManager(xl.workerCount*2, xl.exportProgress, xl.blockPool)
 	if err != nil {
@@ -274,6 +302,10 @@ func (xl *Xload) Start(ctx context.Context) error {
 	}
 
 	xl.statsMgr.Start()
+	if xl.adaptiveMemory {
+		xl.startMemoryMonitor()
+	}
+
 	return xl.startComponents()
 }
 
@@ -291,6 +323,8 @@ func (xl *Xload) Stop() error {
 			xl.comps[i].Stop()
 		}
 
+		// Monitor exits on cancel of pool context, wait for it as it may still report to stats manager
+		xl.memoryMonitorDone.Wait()
 		xl.statsMgr.Stop()
 		xl.blockPool.Terminate()
 		stopCh <- 1
@@ -346,6 +380,7 @@ func (xl *Xload) createDownloader() error {
 		workerCount: xl.workerCount,
 		remote:      xl.NextComponent(),
 		statsMgr:    xl.statsMgr,
+		allocator:   xl.blockPool.allocator,
 	})
 	if err != nil {
 		log.Err("Xload::startUploader : failed to create remote data manager [%s]", err.Error())
@@ -522,4 +557,13 @@ func init() {
 
 	poolSize := config.AddInt32Flag("pool-size", 300, "number of blocks in the blockpool for preload")
 	config.BindPFlag(compName+".pool-size", poolSize)
+
+	adaptiveMemory := config.AddBoolFlag("adaptive-memory", false, "resize the blockpool for preload as per the memory pressure")
+	config.BindPFlag(compName+".adaptive-memory", adaptiveMemory)
+
+	hugePages := config.AddStringFlag("huge-pages", "", "back blockpool for preload with huge pages, supported values: none, thp, hugetlb")
+	config.BindPFlag(compName+".huge-pages", hugePages)
+
+	numaPolicy := config.AddStringFlag("numa-policy", "", "place blockpool for preload on the given NUMA node, or keep a pool on each node, pin workers to its CPUs and spread files over the nodes")
+	config.BindPFlag(compName+".numa-policy", numaPolicy)
 }
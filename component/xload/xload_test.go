@@ -302,6 +302,22 @@ func (suite *xloadTestSuite) TestConfigAllowOther() {
 	suite.assert.Equal(common.DefaultAllowOtherPermissionBits, suite.xload.defaultPermission)
 }
 
+func (suite *xloadTestSuite) TestConfigBufferOptions() {
+	defer suite.cleanupTest(false)
+	suite.cleanupTest(false) // teardown the default xload generated
+
+	testConfig := fmt.Sprintf("xload:\n  path: %s\n  huge-pages: 1gb\n\nloopbackfs:\n  path: %s\n\nread-only: true", suite.local_path, suite.fake_storage_path)
+	err := suite.setupTestHelper(testConfig, false)
+	suite.assert.Error(err)
+	suite.assert.Contains(err.Error(), "invalid huge-pages")
+
+	testConfig = fmt.Sprintf("xload:\n  path: %s\n  huge-pages: thp\n  numa-policy: local\n\nloopbackfs:\n  path: %s\n\nread-only: true", suite.local_path, suite.fake_storage_path)
+	err = suite.setupTestHelper(testConfig, false)
+	suite.assert.NoError(err)
+	suite.assert.Equal(common.HugePagesTHP, suite.xload.bufferOptions.HugePages)
+	suite.assert.Equal(common.NumaLocal, suite.xload.bufferOptions.NumaPolicy)
+}
+
 func (suite *xloadTestSuite) TestUnsupportedModes() {
 	defer suite.cleanupTest(false)
 	suite.cleanupTest(false) // teardown the default xload generated
@@ -0,0 +1,281 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"golang.org/x/sys/unix"
+)
+
+// Record of who did what to which path through the mount, kept apart from the debug log for compliance. Events are
+// written as one JSON object per line to a rotating local file or to syslog.
+
+const (
+	SinkFile   = "file"
+	SinkSyslog = "syslog"
+
+	defaultMaxFileSizeMB = 512
+	defaultFileCount     = 10
+	defaultFacility      = "authpriv"
+)
+
+// Config : Audit section of the config file
+type Config struct {
+	Enabled        bool     `config:"enabled" yaml:"enabled,omitempty"`
+	Sink           string   `config:"sink" yaml:"sink,omitempty"`
+	FilePath       string   `config:"file-path" yaml:"file-path,omitempty"`
+	MaxFileSizeMB  uint64   `config:"max-file-size-mb" yaml:"max-file-size-mb,omitempty"`
+	FileCount      int      `config:"file-count" yaml:"file-count,omitempty"`
+	SyslogFacility string   `config:"syslog-facility" yaml:"syslog-facility,omitempty"`
+	Operations     []string `config:"operations" yaml:"operations,omitempty"`
+	IncludePaths   []string `config:"include-paths" yaml:"include-paths,omitempty"`
+	ExcludePaths   []string `config:"exclude-paths" yaml:"exclude-paths,omitempty"`
+	SampleRatio    float64  `config:"sample-ratio" yaml:"sample-ratio,omitempty"`
+}
+
+// Caller : Identity of the process which issued the operation, as reported by fuse
+type Caller struct {
+	UID uint32 `json:"uid"`
+	GID uint32 `json:"gid"`
+	PID int32  `json:"pid"`
+}
+
+// Event : An operation on a path. Fields of the operation are filled in by the caller before the event is recorded.
+type Event struct {
+	Time        string `json:"time"`
+	Op          string `json:"op"`
+	Path        string `json:"path"`
+	Destination string `json:"destination,omitempty"`
+	Caller
+	Flags        string `json:"flags,omitempty"`
+	Mode         string `json:"mode,omitempty"`
+	Handle       uint64 `json:"handle,omitempty"`
+	Size         *int64 `json:"size,omitempty"`
+	BytesRead    *int64 `json:"bytes_read,omitempty"`
+	BytesWritten *int64 `json:"bytes_written,omitempty"`
+	MountPath    string `json:"mount_path"`
+	Result       string `json:"result"`
+}
+
+// sink : Destination of audit records
+type sink interface {
+	write(line []byte) error
+	close() error
+}
+
+// auditor : Filters and sink of the audit log
+type auditor struct {
+	sink        sink
+	operations  []string
+	include     []common.PathGlob
+	exclude     []common.PathGlob
+	sampleRatio float64
+}
+
+var current atomic.Pointer[auditor]
+
+// Enabled : Whether operations are being audited
+func Enabled() bool {
+	return current.Load() != nil
+}
+
+// Init : Start auditing operations as per the config
+func Init(cfg Config) error {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return fmt.Errorf("invalid sample-ratio %v, it shall be between 0 and 1", cfg.SampleRatio)
+	}
+
+	a := &auditor{
+		operations:  cfg.Operations,
+		sampleRatio: cfg.SampleRatio,
+	}
+	if a.sampleRatio == 0 {
+		a.sampleRatio = 1
+	}
+
+	var err error
+	if a.include, err = compileGlobs(cfg.IncludePaths); err != nil {
+		return err
+	}
+	if a.exclude, err = compileGlobs(cfg.ExcludePaths); err != nil {
+		return err
+	}
+
+	if cfg.Sink == "" {
+		cfg.Sink = SinkFile
+	}
+
+	switch cfg.Sink {
+	case SinkFile:
+		if cfg.FilePath == "" {
+			cfg.FilePath = filepath.Join(common.DefaultWorkDir, "audit.log")
+		}
+		if cfg.MaxFileSizeMB == 0 {
+			cfg.MaxFileSizeMB = defaultMaxFileSizeMB
+		}
+		if cfg.FileCount == 0 {
+			cfg.FileCount = defaultFileCount
+		}
+		a.sink, err = newFileSink(common.ExpandPath(cfg.FilePath), cfg.MaxFileSizeMB*common.MbToBytes, cfg.FileCount)
+	case SinkSyslog:
+		if cfg.SyslogFacility == "" {
+			cfg.SyslogFacility = defaultFacility
+		}
+		a.sink, err = newSyslogSink(cfg.SyslogFacility)
+	default:
+		err = fmt.Errorf("invalid sink %s, it shall be %s or %s", cfg.Sink, SinkFile, SinkSyslog)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if old := current.Swap(a); old != nil {
+		_ = old.sink.close()
+	}
+	log.Info("audit::Init : Auditing operations to %s sink, sample ratio %v", cfg.Sink, a.sampleRatio)
+	return nil
+}
+
+// Shutdown : Stop auditing and close the sink
+func Shutdown() {
+	a := current.Swap(nil)
+	if a == nil {
+		return
+	}
+
+	err := a.sink.close()
+	if err != nil {
+		log.Err("audit::Shutdown : Failed to close audit sink [%s]", err.Error())
+	}
+}
+
+func compileGlobs(patterns []string) ([]common.PathGlob, error) {
+	globs := make([]common.PathGlob, 0, len(patterns))
+	for _, pattern := range patterns {
+		glob, err := common.NewPathGlob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid audit path filter %s [%s]", pattern, err.Error())
+		}
+		globs = append(globs, glob)
+	}
+	return globs, nil
+}
+
+// Audits : Whether operations of this kind are audited at all, irrespective of their path
+func Audits(op string) bool {
+	a := current.Load()
+	return a != nil && (len(a.operations) == 0 || slices.Contains(a.operations, op))
+}
+
+// Wanted : Whether an operation on the path shall be audited, as per the operation and path filters and sampling.
+// Paths excluded take precedence over the ones included, and all paths are included when no include filter is set.
+func Wanted(op string, path string) bool {
+	a := current.Load()
+	if a == nil {
+		return false
+	}
+
+	if len(a.operations) > 0 && !slices.Contains(a.operations, op) {
+		return false
+	}
+
+	for _, glob := range a.exclude {
+		if glob.Match(path) {
+			return false
+		}
+	}
+
+	if len(a.include) > 0 && !slices.ContainsFunc(a.include, func(glob common.PathGlob) bool { return glob.Match(path) }) {
+		return false
+	}
+
+	return a.sampleRatio >= 1 || rand.Float64() < a.sampleRatio
+}
+
+// NewEvent : Event for an operation issued by the caller on the path
+func NewEvent(op string, path string, caller Caller) *Event {
+	return &Event{
+		Op:     op,
+		Path:   path,
+		Caller: caller,
+	}
+}
+
+// Record : Complete the event with the outcome of the operation and write it out. Nil events are ignored so that
+// callers can record unconditionally what they got from a filtered NewEvent.
+func Record(ev *Event, errno syscall.Errno) {
+	a := current.Load()
+	if a == nil || ev == nil {
+		return
+	}
+
+	ev.Time = time.Now().Format(time.RFC3339Nano)
+	ev.MountPath = common.MountPath
+	ev.Result = "ok"
+	if errno != 0 {
+		ev.Result = unix.ErrnoName(errno)
+		if ev.Result == "" {
+			ev.Result = errno.Error()
+		}
+	}
+
+	line, err := json.Marshal(ev)
+	if err == nil {
+		err = a.sink.write(line)
+	}
+
+	if err != nil {
+		log.Err("audit::Record : Failed to record %s of %s [%s]", ev.Op, ev.Path, err.Error())
+	}
+}
+
+// Int64 : Pointer to the value, for the optional numeric fields of an event
+func Int64(v int64) *int64 {
+	return &v
+}
@@ -0,0 +1,210 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type auditTestSuite struct {
+	suite.Suite
+	assert *assert.Assertions
+	path   string
+}
+
+func (suite *auditTestSuite) SetupTest() {
+	err := log.SetDefaultLogger("silent", common.LogConfig{})
+	if err != nil {
+		panic("Unable to set silent logger as default.")
+	}
+	suite.assert = assert.New(suite.T())
+	suite.path = filepath.Join(suite.T().TempDir(), "audit.log")
+}
+
+func (suite *auditTestSuite) TearDownTest() {
+	Shutdown()
+}
+
+func (suite *auditTestSuite) readEvents(path string) []map[string]any {
+	data, err := os.ReadFile(path)
+	suite.Require().NoError(err)
+
+	var events []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if line == "" {
+			continue
+		}
+		event := map[string]any{}
+		suite.Require().NoError(json.Unmarshal([]byte(line), &event))
+		events = append(events, event)
+	}
+	return events
+}
+
+func (suite *auditTestSuite) TestDisabled() {
+	suite.assert.NoError(Init(Config{FilePath: suite.path}))
+	suite.assert.False(Enabled())
+	suite.assert.False(Wanted("open", "a.txt"))
+	suite.assert.False(Audits("open"))
+
+	// Recording without auditing is a no-op
+	Record(NewEvent("open", "a.txt", Caller{}), 0)
+	Record(nil, 0)
+	suite.assert.NoFileExists(suite.path)
+}
+
+func (suite *auditTestSuite) TestInvalidConfig() {
+	suite.assert.Error(Init(Config{Enabled: true, FilePath: suite.path, SampleRatio: 1.5}))
+	suite.assert.Error(Init(Config{Enabled: true, FilePath: suite.path, Sink: "kafka"}))
+	suite.assert.Error(Init(Config{Enabled: true, FilePath: suite.path, IncludePaths: []string{"[a"}}))
+	suite.assert.Error(Init(Config{Enabled: true, Sink: SinkSyslog, SyslogFacility: "mail2"}))
+	suite.assert.False(Enabled())
+}
+
+func (suite *auditTestSuite) TestRecord() {
+	suite.Require().NoError(Init(Config{Enabled: true, FilePath: suite.path}))
+	suite.assert.True(Enabled())
+
+	caller := Caller{UID: 1000, GID: 100, PID: 4242}
+	suite.Require().True(Wanted("open", "dir/a.txt"))
+	ev := NewEvent("open", "dir/a.txt", caller)
+	ev.Flags = "O_RDONLY"
+	ev.Handle = 7
+	Record(ev, 0)
+
+	ev = NewEvent("rename", "dir/a.txt", caller)
+	ev.Destination = "dir/b.txt"
+	Record(ev, syscall.EACCES)
+
+	ev = NewEvent("release", "dir/b.txt", caller)
+	ev.BytesRead = Int64(0)
+	ev.BytesWritten = Int64(4096)
+	Record(ev, 0)
+	Shutdown()
+
+	events := suite.readEvents(suite.path)
+	suite.Require().Len(events, 3)
+
+	suite.assert.Equal("open", events[0]["op"])
+	suite.assert.Equal("dir/a.txt", events[0]["path"])
+	suite.assert.EqualValues(1000, events[0]["uid"])
+	suite.assert.EqualValues(100, events[0]["gid"])
+	suite.assert.EqualValues(4242, events[0]["pid"])
+	suite.assert.Equal("O_RDONLY", events[0]["flags"])
+	suite.assert.EqualValues(7, events[0]["handle"])
+	suite.assert.Equal("ok", events[0]["result"])
+	suite.assert.NotEmpty(events[0]["time"])
+	suite.assert.NotContains(events[0], "bytes_read")
+
+	suite.assert.Equal("dir/b.txt", events[1]["destination"])
+	suite.assert.Equal("EACCES", events[1]["result"])
+
+	suite.assert.EqualValues(0, events[2]["bytes_read"])
+	suite.assert.EqualValues(4096, events[2]["bytes_written"])
+
+	fi, err := os.Stat(suite.path)
+	suite.Require().NoError(err)
+	suite.assert.Equal(os.FileMode(0600), fi.Mode().Perm())
+}
+
+func (suite *auditTestSuite) TestFilters() {
+	suite.Require().NoError(Init(Config{
+		Enabled:      true,
+		FilePath:     suite.path,
+		Operations:   []string{"open", "unlink"},
+		IncludePaths: []string{"secure/**"},
+		ExcludePaths: []string{"secure/tmp/**"},
+	}))
+
+	suite.assert.True(Wanted("open", "secure/a.txt"))
+	suite.assert.True(Wanted("unlink", "secure/dir/b.txt"))
+	suite.assert.False(Wanted("rename", "secure/a.txt"), "operation not audited")
+	suite.assert.False(Wanted("open", "public/a.txt"), "path not included")
+	suite.assert.False(Wanted("open", "secure/tmp/a.txt"), "exclusion wins over inclusion")
+
+	suite.assert.True(Audits("open"))
+	suite.assert.False(Audits("release"))
+}
+
+func (suite *auditTestSuite) TestSampling() {
+	suite.Require().NoError(Init(Config{Enabled: true, FilePath: suite.path, SampleRatio: 0.5}))
+
+	wanted := 0
+	for range 1000 {
+		if Wanted("open", "a.txt") {
+			wanted++
+		}
+	}
+	suite.assert.Greater(wanted, 300)
+	suite.assert.Less(wanted, 700)
+}
+
+func (suite *auditTestSuite) TestFileRotation() {
+	sink, err := newFileSink(suite.path, 100, 3)
+	suite.Require().NoError(err)
+
+	for i := range 10 {
+		suite.Require().NoError(sink.write([]byte(fmt.Sprintf(`{"op":"open","seq":%d,"padding":"%s"}`, i, strings.Repeat("x", 40)))))
+	}
+	suite.Require().NoError(sink.close())
+	suite.assert.ErrorIs(sink.write([]byte("{}")), os.ErrClosed)
+
+	// Each record is over half the limit, so every second record rotates and only the newest files are kept
+	suite.assert.FileExists(suite.path + ".1")
+	suite.assert.FileExists(suite.path + ".2")
+	suite.assert.NoFileExists(suite.path + ".3")
+
+	latest := suite.readEvents(suite.path + ".1")
+	suite.Require().Len(latest, 2)
+	suite.assert.EqualValues(9, latest[1]["seq"])
+
+	older := suite.readEvents(suite.path + ".2")
+	suite.Require().Len(older, 2)
+	suite.assert.EqualValues(7, older[1]["seq"])
+}
+
+func TestAuditTestSuite(t *testing.T) {
+	suite.Run(t, new(auditTestSuite))
+}
@@ -0,0 +1,180 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package audit
+
+import (
+	"fmt"
+	"log/syslog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+)
+
+// fileSink : Append records to a local file, rotated once it grows beyond the size limit. Rotated files are kept as
+// <path>.1 to <path>.<count-1>, the oldest one being removed. Records are written synchronously so that an operation
+// which completed is on record even if the process dies right after.
+type fileSink struct {
+	mu      sync.Mutex
+	path    string
+	maxSize uint64
+	count   int
+	file    *os.File
+	size    uint64
+}
+
+func newFileSink(path string, maxSize uint64, count int) (*fileSink, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create directory for audit log %s [%s]", path, err.Error())
+	}
+
+	s := &fileSink{path: path, maxSize: maxSize, count: count}
+	err = s.open()
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *fileSink) open() error {
+	// Audit records name the files users touched, so they are readable by the owner only
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log %s [%s]", s.path, err.Error())
+	}
+
+	s.file = f
+	s.size = 0
+	if fi, err := f.Stat(); err == nil {
+		s.size = uint64(fi.Size())
+	}
+	return nil
+}
+
+func (s *fileSink) write(line []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return os.ErrClosed
+	}
+
+	n, err := s.file.Write(append(line, '\n'))
+	s.size += uint64(n)
+	if err != nil {
+		return err
+	}
+
+	if s.size >= s.maxSize {
+		return s.rotate()
+	}
+	return nil
+}
+
+// rotate : Shift the rotated files by one and start a new file
+func (s *fileSink) rotate() error {
+	err := s.file.Close()
+	s.file = nil
+	if err != nil {
+		return err
+	}
+
+	if s.count > 1 {
+		_ = os.Remove(fmt.Sprintf("%s.%d", s.path, s.count-1))
+		for i := s.count - 2; i > 0; i-- {
+			_ = os.Rename(fmt.Sprintf("%s.%d", s.path, i), fmt.Sprintf("%s.%d", s.path, i+1))
+		}
+		_ = os.Rename(s.path, s.path+".1")
+	} else {
+		_ = os.Remove(s.path)
+	}
+
+	return s.open()
+}
+
+func (s *fileSink) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// syslogSink : Send records to the local syslog daemon under the given facility
+type syslogSink struct {
+	writer *syslog.Writer
+}
+
+var facilities = map[string]syslog.Priority{
+	"user":     syslog.LOG_USER,
+	"daemon":   syslog.LOG_DAEMON,
+	"auth":     syslog.LOG_AUTH,
+	"authpriv": syslog.LOG_AUTHPRIV,
+	"local0":   syslog.LOG_LOCAL0,
+	"local1":   syslog.LOG_LOCAL1,
+	"local2":   syslog.LOG_LOCAL2,
+	"local3":   syslog.LOG_LOCAL3,
+	"local4":   syslog.LOG_LOCAL4,
+	"local5":   syslog.LOG_LOCAL5,
+	"local6":   syslog.LOG_LOCAL6,
+	"local7":   syslog.LOG_LOCAL7,
+}
+
+func newSyslogSink(facility string) (*syslogSink, error) {
+	priority, ok := facilities[facility]
+	if !ok {
+		return nil, fmt.Errorf("invalid syslog-facility %s", facility)
+	}
+
+	w, err := syslog.New(priority|syslog.LOG_INFO, common.FileSystemName+"-audit")
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog [%s]", err.Error())
+	}
+	return &syslogSink{writer: w}, nil
+}
+
+func (s *syslogSink) write(line []byte) error {
+	return s.writer.Info(string(line))
+}
+
+func (s *syslogSink) close() error {
+	return s.writer.Close()
+}
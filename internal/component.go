@@ -151,3 +151,39 @@ type Component interface {
 	StageData(StageDataOptions) error
 	CommitData(CommitDataOptions) error
 }
+
+// PathInvalidator : Optional interface for components which cache data or metadata of a path.
+// Components implementing this can be asked to drop whatever they hold for a path, e.g. when the
+// path has been modified in the container by some other node.
+type PathInvalidator interface {
+	InvalidatePath(name string)
+	InvalidateDir(name string)
+}
+
+// CacheController : Optional interface for components which cache contents of files and can be asked by the user,
+// through the control socket, to bring a file in ahead of use, keep it irrespective of eviction or drop it.
+type CacheController interface {
+	WarmFile(name string) error  // Download the file in cache
+	PinFile(name string) error   // Download the file in cache and exempt it from eviction
+	UnpinFile(name string) error // Make a pinned file evictable again
+	EvictFile(name string) error // Unpin and remove the file from cache
+}
+
+// CacheDropper : Optional interface for components which can be asked to drop all they have cached, e.g. during an
+// incident. Data in use by open handles and pinned files are retained.
+type CacheDropper interface {
+	DropCache()
+}
+
+// StateReporter : Optional interface for components which can describe their runtime state for diagnostics
+type StateReporter interface {
+	State() map[string]any
+}
+
+// Drainer : Optional interface for the component serving the kernel, which can be asked to stop accepting new opens
+// so that the mount can be quiesced, e.g. ahead of an upgrade. Handles already open keep being served.
+type Drainer interface {
+	Drain() error // Hold new opens and mark handles with pending native writes as dirty
+	Resume()      // Let held opens through and accept new ones again
+	Draining() bool
+}
@@ -146,6 +146,7 @@ type CopyFromFileOptions struct {
 	Name     string
 	File     *os.File
 	Metadata map[string]*string
+	NewETag  *string
 }
 
 type FlushFileOptions struct {
@@ -178,6 +179,8 @@ type ReadLinkOptions struct {
 type GetAttrOptions struct {
 	Name             string
 	RetrieveMetadata bool
+	// SkipCache fetches the attributes from storage instead of any attribute cache in the pipeline
+	SkipCache bool
 }
 
 type ChmodOptions struct {
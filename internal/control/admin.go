@@ -0,0 +1,260 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package control
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/Azure/azure-storage-fuse/v2/internal"
+	"github.com/Azure/azure-storage-fuse/v2/internal/handlemap"
+	"github.com/Azure/azure-storage-fuse/v2/internal/reqlog"
+)
+
+// Methods served for 'blobfuse2 ctl' command
+const (
+	MethodHandlesList  = "handles.list"
+	MethodCacheDrop    = "cache.drop"
+	MethodLogLevel     = "log.level"
+	MethodFilesFlush   = "files.flush"
+	MethodPipelineDump = "pipeline.dump"
+	MethodRequestsList = "requests.list"
+)
+
+// HandleInfo : An open handle of the mount
+type HandleInfo struct {
+	ID      uint64 `json:"id"`
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	Dirty   bool   `json:"dirty"`
+	Fsynced bool   `json:"fsynced"`
+	Cached  bool   `json:"cached"`
+	Ops     uint64 `json:"ops"`
+}
+
+// DropParams : Components whose cache is to be dropped, all of them if empty
+type DropParams struct {
+	Components []string `json:"components,omitempty"`
+}
+
+// DropResult : Components whose cache was dropped
+type DropResult struct {
+	Components []string `json:"components"`
+}
+
+// LogLevelParams : Log level to be set
+type LogLevelParams struct {
+	Level string `json:"level"`
+}
+
+// LogLevelResult : Log level before and after the change
+type LogLevelResult struct {
+	Previous string `json:"previous"`
+	Current  string `json:"current"`
+}
+
+// FlushParams : Path of the file to be flushed, all dirty files if empty
+type FlushParams struct {
+	Path string `json:"path,omitempty"`
+}
+
+// FlushResult : Files flushed and the ones which failed
+type FlushResult struct {
+	Flushed []string          `json:"flushed"`
+	Failed  map[string]string `json:"failed,omitempty"`
+}
+
+// ComponentState : A component of the pipeline along with the state it reports
+type ComponentState struct {
+	Name     string         `json:"name"`
+	Priority int            `json:"priority"`
+	State    map[string]any `json:"state,omitempty"`
+}
+
+// PipelineDump : Components of the pipeline in order of chaining, and the number of open handles
+type PipelineDump struct {
+	Components  []ComponentState `json:"components"`
+	OpenHandles int              `json:"open-handles"`
+}
+
+// RegisterAdminHandlers : Serve methods used by operators to inspect and steer the mount
+func RegisterAdminHandlers(s *Server, components []internal.Component) {
+	if len(components) == 0 {
+		return
+	}
+
+	s.Handle(MethodHandlesList, func(_ json.RawMessage) (any, error) {
+		return listHandles(), nil
+	})
+
+	s.Handle(MethodCacheDrop, func(params json.RawMessage) (any, error) {
+		var p DropParams
+		if len(params) > 0 {
+			err := json.Unmarshal(params, &p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid params [%s]", err.Error())
+			}
+		}
+		return dropCaches(components, p.Components)
+	})
+
+	s.Handle(MethodLogLevel, func(params json.RawMessage) (any, error) {
+		var p LogLevelParams
+		err := json.Unmarshal(params, &p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid params [%s]", err.Error())
+		}
+
+		var level common.LogLevel
+		err = level.Parse(p.Level)
+		if err != nil || level == common.ELogLevel.INVALID() {
+			return nil, fmt.Errorf("invalid log level %s", p.Level)
+		}
+
+		previous := log.GetLogLevel()
+		log.SetLogLevel(level)
+		return LogLevelResult{Previous: previous.String(), Current: level.String()}, nil
+	})
+
+	// Requests enter the pipeline from the top, the same way libfuse hands them over
+	head := components[0]
+	s.Handle(MethodFilesFlush, func(params json.RawMessage) (any, error) {
+		var p FlushParams
+		if len(params) > 0 {
+			err := json.Unmarshal(params, &p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid params [%s]", err.Error())
+			}
+		}
+		return flushFiles(head, p.Path), nil
+	})
+
+	s.Handle(MethodPipelineDump, func(_ json.RawMessage) (any, error) {
+		dump := PipelineDump{OpenHandles: len(listHandles())}
+		for _, comp := range components {
+			state := ComponentState{Name: comp.Name(), Priority: int(comp.Priority())}
+			if r, ok := comp.(internal.StateReporter); ok {
+				state.State = r.State()
+			}
+			dump.Components = append(dump.Components, state)
+		}
+		return dump, nil
+	})
+
+	s.Handle(MethodRequestsList, func(_ json.RawMessage) (any, error) {
+		return reqlog.Recent(), nil
+	})
+
+	registerDrainHandlers(s, components)
+	registerStatsHandlers(s)
+}
+
+// listHandles : Open handles of the mount, sorted by ID
+func listHandles() []HandleInfo {
+	handles := make([]HandleInfo, 0)
+	handlemap.GetHandles().Range(func(_, val any) bool {
+		h := val.(*handlemap.Handle)
+		handles = append(handles, HandleInfo{
+			ID:      uint64(h.ID),
+			Path:    h.Path,
+			Size:    h.Size,
+			Dirty:   h.Dirty(),
+			Fsynced: h.Fsynced(),
+			Cached:  h.Cached(),
+			Ops:     h.OptCnt,
+		})
+		return true
+	})
+
+	sort.Slice(handles, func(i, j int) bool { return handles[i].ID < handles[j].ID })
+	return handles
+}
+
+// dropCaches : Ask the named components, or all which can, to drop their cache
+func dropCaches(components []internal.Component, names []string) (DropResult, error) {
+	result := DropResult{Components: make([]string, 0)}
+
+	for _, name := range names {
+		found := slices.ContainsFunc(components, func(comp internal.Component) bool {
+			_, ok := comp.(internal.CacheDropper)
+			return ok && comp.Name() == name
+		})
+		if !found {
+			return result, fmt.Errorf("%s is not a caching component in the pipeline", name)
+		}
+	}
+
+	for _, comp := range components {
+		dropper, ok := comp.(internal.CacheDropper)
+		if !ok || (len(names) > 0 && !slices.Contains(names, comp.Name())) {
+			continue
+		}
+
+		log.Info("control::dropCaches : Dropping cache of %s", comp.Name())
+		dropper.DropCache()
+		result.Components = append(result.Components, comp.Name())
+	}
+
+	return result, nil
+}
+
+// flushFiles : Flush dirty handles, all of them or only the ones open on given path
+func flushFiles(head internal.Component, path string) FlushResult {
+	result := FlushResult{Flushed: make([]string, 0)}
+
+	handlemap.GetHandles().Range(func(_, val any) bool {
+		h := val.(*handlemap.Handle)
+		if !h.Dirty() || (path != "" && h.Path != path) {
+			return true
+		}
+
+		err := head.FlushFile(internal.FlushFileOptions{Handle: h})
+		if err != nil {
+			log.Err("control::flushFiles : Failed to flush %v=>%s [%s]", h.ID, h.Path, err.Error())
+			if result.Failed == nil {
+				result.Failed = make(map[string]string)
+			}
+			result.Failed[h.Path] = err.Error()
+		} else {
+			result.Flushed = append(result.Flushed, h.Path)
+		}
+		return true
+	})
+
+	return result
+}
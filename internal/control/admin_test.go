@@ -0,0 +1,177 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package control
+
+import (
+	"github.com/Azure/azure-storage-fuse/v2/internal"
+	"github.com/Azure/azure-storage-fuse/v2/internal/handlemap"
+	"github.com/Azure/azure-storage-fuse/v2/internal/reqlog"
+)
+
+// fakeDropper : Caching component which counts the drops requested and reports a fixed state
+type fakeDropper struct {
+	internal.BaseComponent
+	drops int
+}
+
+func (f *fakeDropper) DropCache()            { f.drops++ }
+func (f *fakeDropper) State() map[string]any { return map[string]any{"drops": f.drops} }
+
+// fakeFlusher : Head of the pipeline, records the handles flushed through it
+type fakeFlusher struct {
+	internal.BaseComponent
+	flushed []handlemap.HandleID
+}
+
+func (f *fakeFlusher) FlushFile(options internal.FlushFileOptions) error {
+	f.flushed = append(f.flushed, options.Handle.ID)
+	options.Handle.Flags.Clear(handlemap.HandleFlagDirty)
+	return nil
+}
+
+func (suite *controlTestSuite) adminPipeline() (*fakeFlusher, *fakeDropper, *fakeDropper) {
+	head := &fakeFlusher{}
+	head.SetName("libfuse")
+	attr := &fakeDropper{}
+	attr.SetName("attr_cache")
+	file := &fakeDropper{}
+	file.SetName("file_cache")
+
+	RegisterAdminHandlers(suite.server, []internal.Component{head, attr, file})
+	suite.assert.NoError(suite.server.Start())
+	return head, attr, file
+}
+
+func (suite *controlTestSuite) addHandle(path string, dirty bool) *handlemap.Handle {
+	h := handlemap.NewHandle(path)
+	if dirty {
+		h.Flags.Set(handlemap.HandleFlagDirty)
+	}
+	handlemap.Add(h)
+	suite.T().Cleanup(func() { handlemap.Delete(h.ID) })
+	return h
+}
+
+func (suite *controlTestSuite) TestListHandles() {
+	suite.adminPipeline()
+
+	clean := suite.addHandle("dir/clean", false)
+	dirty := suite.addHandle("dir/dirty", true)
+
+	var handles []HandleInfo
+	suite.assert.NoError(Call(suite.server.path, MethodHandlesList, nil, &handles))
+	suite.assert.Len(handles, 2)
+	suite.assert.Equal(uint64(clean.ID), handles[0].ID)
+	suite.assert.Equal("dir/clean", handles[0].Path)
+	suite.assert.False(handles[0].Dirty)
+	suite.assert.Equal(uint64(dirty.ID), handles[1].ID)
+	suite.assert.True(handles[1].Dirty)
+}
+
+func (suite *controlTestSuite) TestDropCache() {
+	_, attr, file := suite.adminPipeline()
+
+	var result DropResult
+	suite.assert.NoError(Call(suite.server.path, MethodCacheDrop, DropParams{}, &result))
+	suite.assert.Equal([]string{"attr_cache", "file_cache"}, result.Components)
+
+	suite.assert.NoError(Call(suite.server.path, MethodCacheDrop, DropParams{Components: []string{"file_cache"}}, &result))
+	suite.assert.Equal([]string{"file_cache"}, result.Components)
+	suite.assert.Equal(1, attr.drops)
+	suite.assert.Equal(2, file.drops)
+
+	// Component which does not cache anything, or is not in the pipeline, fails the request as a whole
+	for _, name := range []string{"libfuse", "block_cache"} {
+		err := Call(suite.server.path, MethodCacheDrop, DropParams{Components: []string{"attr_cache", name}}, &result)
+		suite.assert.EqualError(err, name+" is not a caching component in the pipeline")
+	}
+	suite.assert.Equal(1, attr.drops)
+}
+
+func (suite *controlTestSuite) TestLogLevel() {
+	suite.adminPipeline()
+
+	var result LogLevelResult
+	suite.assert.NoError(Call(suite.server.path, MethodLogLevel, LogLevelParams{Level: "log_warning"}, &result))
+	suite.assert.Equal("LOG_WARNING", result.Current)
+
+	err := Call(suite.server.path, MethodLogLevel, LogLevelParams{Level: "verbose"}, &result)
+	suite.assert.EqualError(err, "invalid log level verbose")
+}
+
+func (suite *controlTestSuite) TestFlushFiles() {
+	head, _, _ := suite.adminPipeline()
+
+	suite.addHandle("clean", false)
+	first := suite.addHandle("first", true)
+	second := suite.addHandle("second", true)
+
+	var result FlushResult
+	suite.assert.NoError(Call(suite.server.path, MethodFilesFlush, FlushParams{Path: "first"}, &result))
+	suite.assert.Equal([]string{"first"}, result.Flushed)
+	suite.assert.Equal([]handlemap.HandleID{first.ID}, head.flushed)
+
+	// Flushed file is no longer dirty, so only the remaining one goes
+	suite.assert.NoError(Call(suite.server.path, MethodFilesFlush, nil, &result))
+	suite.assert.Equal([]string{"second"}, result.Flushed)
+	suite.assert.Equal([]handlemap.HandleID{first.ID, second.ID}, head.flushed)
+}
+
+func (suite *controlTestSuite) TestDumpPipeline() {
+	suite.adminPipeline()
+	suite.addHandle("open", false)
+
+	var dump PipelineDump
+	suite.assert.NoError(Call(suite.server.path, MethodPipelineDump, nil, &dump))
+	suite.assert.Equal(1, dump.OpenHandles)
+	suite.assert.Len(dump.Components, 3)
+	suite.assert.Equal("libfuse", dump.Components[0].Name)
+	suite.assert.Nil(dump.Components[0].State)
+	suite.assert.Equal("attr_cache", dump.Components[1].Name)
+	suite.assert.EqualValues(0, dump.Components[1].State["drops"])
+}
+
+func (suite *controlTestSuite) TestListRequests() {
+	suite.adminPipeline()
+	reqlog.Record(reqlog.Entry{Method: "GET", Path: "/container/listed", Status: 404, RequestID: "request-1", Retries: 1})
+
+	var requests []reqlog.Entry
+	suite.assert.NoError(Call(suite.server.path, MethodRequestsList, nil, &requests))
+	suite.Require().NotEmpty(requests)
+	last := requests[len(requests)-1]
+	suite.assert.Equal("/container/listed", last.Path)
+	suite.assert.Equal("request-1", last.RequestID)
+	suite.assert.Equal(1, last.Retries)
+	suite.assert.True(last.Failed())
+}
@@ -0,0 +1,158 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package control
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/Azure/azure-storage-fuse/v2/internal"
+)
+
+// Methods served for 'blobfuse2 cache' command
+const (
+	MethodCacheWarm  = "cache.warm"
+	MethodCachePin   = "cache.pin"
+	MethodCacheUnpin = "cache.unpin"
+	MethodCacheEvict = "cache.evict"
+)
+
+// CacheParams : Path relative to the mount point, a directory applies to all files under it
+type CacheParams struct {
+	Path string `json:"path"`
+}
+
+// CacheResult : Number of files the request was applied to
+type CacheResult struct {
+	Files int `json:"files"`
+}
+
+// RegisterCacheHandlers : Serve cache methods through the components in the pipeline implementing CacheController.
+// Directories are expanded by listing them from the last component, the one holding the data.
+func RegisterCacheHandlers(s *Server, components []internal.Component) {
+	if len(components) == 0 {
+		return
+	}
+
+	controllers := make([]internal.CacheController, 0)
+	for _, comp := range components {
+		if ctl, ok := comp.(internal.CacheController); ok {
+			log.Info("control::RegisterCacheHandlers : %s will serve cache requests", comp.Name())
+			controllers = append(controllers, ctl)
+		}
+	}
+	lister := components[len(components)-1]
+
+	register := func(method string, op func(internal.CacheController, string) error) {
+		s.Handle(method, func(params json.RawMessage) (any, error) {
+			var p CacheParams
+			err := json.Unmarshal(params, &p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid params [%s]", err.Error())
+			}
+
+			if len(controllers) == 0 {
+				return nil, fmt.Errorf("no component in pipeline caches file contents")
+			}
+
+			files, err := listFiles(lister, strings.Trim(path.Clean("/"+p.Path), "/"))
+			if err != nil {
+				return nil, err
+			}
+
+			for _, name := range files {
+				for _, ctl := range controllers {
+					err = op(ctl, name)
+					if err != nil {
+						return nil, fmt.Errorf("%s [%s]", name, err.Error())
+					}
+				}
+			}
+
+			return CacheResult{Files: len(files)}, nil
+		})
+	}
+
+	register(MethodCacheWarm, internal.CacheController.WarmFile)
+	register(MethodCachePin, internal.CacheController.PinFile)
+	register(MethodCacheUnpin, internal.CacheController.UnpinFile)
+	register(MethodCacheEvict, internal.CacheController.EvictFile)
+}
+
+// listFiles : The file itself, or all files under the directory
+func listFiles(comp internal.Component, name string) ([]string, error) {
+	if name != "" {
+		attr, err := comp.GetAttr(internal.GetAttrOptions{Name: name})
+		if err != nil {
+			return nil, fmt.Errorf("%s [%s]", name, err.Error())
+		}
+
+		if !attr.IsDir() {
+			return []string{name}, nil
+		}
+	}
+
+	files := make([]string, 0)
+	dirs := []string{name}
+	for len(dirs) > 0 {
+		dir := dirs[0]
+		dirs = dirs[1:]
+
+		token := ""
+		for {
+			entries, next, err := comp.StreamDir(internal.StreamDirOptions{Name: dir, Token: token})
+			if err != nil {
+				return nil, fmt.Errorf("%s [%s]", dir, err.Error())
+			}
+
+			for _, entry := range entries {
+				if entry.IsDir() {
+					dirs = append(dirs, entry.Path)
+				} else if !entry.IsSymlink() {
+					files = append(files, entry.Path)
+				}
+			}
+
+			token = next
+			if token == "" {
+				break
+			}
+		}
+	}
+
+	return files, nil
+}
@@ -0,0 +1,281 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+
+	"golang.org/x/sys/unix"
+)
+
+// Control socket lets commands run by the user talk to a running mount. Each connection carries one request and
+// one response, both encoded as a single line of JSON.
+
+// Request : Method to be invoked in the mount process along with its parameters
+type Request struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// Response : Result of the method, or the error it failed with
+type Response struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// HandlerFunc : Serves one method, params are left for the handler to decode
+type HandlerFunc func(params json.RawMessage) (any, error)
+
+// Server : Listens on the control socket of a mount and dispatches requests to registered handlers
+type Server struct {
+	path     string
+	listener net.Listener
+	handlers map[string]HandlerFunc
+	wg       sync.WaitGroup
+	owner    int // Only this uid, the one which mounted, can control the mount
+}
+
+// SocketPath : Control socket of the mount at given path, named like the pid file of the mount
+func SocketPath(mountPath string) string {
+	name := strings.ReplaceAll(filepath.Clean(mountPath), "/", "_") + ".sock"
+	return filepath.Join(os.ExpandEnv(common.DefaultWorkDir), name)
+}
+
+// NewServer : Create a server for the given socket path, handlers shall be registered before it is started
+func NewServer(path string) *Server {
+	return &Server{
+		path:     path,
+		handlers: make(map[string]HandlerFunc),
+		owner:    os.Getuid(),
+	}
+}
+
+// Handle : Register the handler for a method
+func (s *Server) Handle(method string, fn HandlerFunc) {
+	s.handlers[method] = fn
+}
+
+// Start : Create the socket and start serving requests in background
+func (s *Server) Start() error {
+	err := os.MkdirAll(filepath.Dir(s.path), 0755)
+	if err != nil {
+		return err
+	}
+
+	// Socket left behind by an earlier mount at the same path which did not exit cleanly
+	if conn, err := net.Dial("unix", s.path); err == nil {
+		conn.Close()
+		return fmt.Errorf("control socket %s is in use", s.path)
+	}
+	_ = os.Remove(s.path)
+
+	// Only the user who mounted can control the mount. The socket is created in a private directory and moved in
+	// place once its permissions are set, so that nobody can connect while it still has the permissions of umask.
+	dir, err := os.MkdirTemp(filepath.Dir(s.path), ".ctl")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	tmp := filepath.Join(dir, filepath.Base(s.path))
+	listener, err := net.Listen("unix", tmp)
+	if err != nil {
+		return err
+	}
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+
+	err = os.Chmod(tmp, 0600)
+	if err == nil {
+		err = os.Rename(tmp, s.path)
+	}
+	if err != nil {
+		listener.Close()
+		return err
+	}
+	s.listener = listener
+
+	log.Info("control::Start : Listening on %s", s.path)
+
+	s.wg.Add(1)
+	go s.serve()
+	return nil
+}
+
+// Stop : Stop accepting requests and remove the socket
+func (s *Server) Stop() {
+	if s.listener == nil {
+		return
+	}
+
+	s.listener.Close()
+	s.wg.Wait()
+	_ = os.Remove(s.path)
+	s.listener = nil
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Err("control::serve : Failed to accept connection [%s]", err.Error())
+			}
+			return
+		}
+
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	uid, err := peerUID(conn)
+	if err != nil || uid != s.owner {
+		log.Err("control::serveConn : Rejecting connection from uid %d, mount is owned by %d [%v]", uid, s.owner, err)
+		_ = json.NewEncoder(conn).Encode(Response{Error: "permission denied"})
+		return
+	}
+
+	var req Request
+	err = json.NewDecoder(bufio.NewReader(conn)).Decode(&req)
+	if err != nil {
+		log.Err("control::serveConn : Failed to decode request [%s]", err.Error())
+		_ = json.NewEncoder(conn).Encode(Response{Error: "invalid request"})
+		return
+	}
+
+	_ = json.NewEncoder(conn).Encode(s.dispatch(req))
+}
+
+// peerUID : User of the process on the other end of the connection
+func peerUID(conn net.Conn) (int, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return -1, fmt.Errorf("not a unix socket connection")
+	}
+
+	raw, err := unixConn.SyscallConn()
+	if err != nil {
+		return -1, err
+	}
+
+	var cred *unix.Ucred
+	var credErr error
+	err = raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	})
+	if err == nil {
+		err = credErr
+	}
+	if err != nil {
+		return -1, err
+	}
+
+	return int(cred.Uid), nil
+}
+
+// dispatch : Invoke the handler of the method and wrap its result in a response
+func (s *Server) dispatch(req Request) Response {
+	fn, found := s.handlers[req.Method]
+	if !found {
+		return Response{Error: fmt.Sprintf("unknown method %s", req.Method)}
+	}
+
+	log.Info("control::dispatch : %s %s", req.Method, string(req.Params))
+
+	result, err := fn(req.Params)
+	if err != nil {
+		log.Err("control::dispatch : %s failed [%s]", req.Method, err.Error())
+		return Response{Error: err.Error()}
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return Response{Error: err.Error()}
+	}
+
+	return Response{Result: data}
+}
+
+// Call : Invoke a method in the mount process listening on given socket, decoding its result in result if not nil
+func Call(path string, method string, params any, result any) error {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s [%s]", path, err.Error())
+	}
+	defer conn.Close()
+
+	req := Request{Method: method}
+	if params != nil {
+		req.Params, err = json.Marshal(params)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = json.NewEncoder(conn).Encode(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request [%s]", err.Error())
+	}
+
+	var resp Response
+	err = json.NewDecoder(bufio.NewReader(conn)).Decode(&resp)
+	if err != nil {
+		return fmt.Errorf("failed to read response [%s]", err.Error())
+	}
+
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+
+	if result != nil && len(resp.Result) > 0 {
+		return json.Unmarshal(resp.Result, result)
+	}
+
+	return nil
+}
@@ -0,0 +1,218 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/config"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/Azure/azure-storage-fuse/v2/component/loopback"
+	"github.com/Azure/azure-storage-fuse/v2/internal"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type controlTestSuite struct {
+	suite.Suite
+	assert *assert.Assertions
+	dir    string
+	server *Server
+}
+
+// fakeCache : Records the cache requests it receives
+type fakeCache struct {
+	internal.BaseComponent
+	calls []string
+	fail  string
+}
+
+func (f *fakeCache) record(op, name string) error {
+	if name == f.fail {
+		return errors.New("failed")
+	}
+	f.calls = append(f.calls, op+" "+name)
+	return nil
+}
+
+func (f *fakeCache) WarmFile(name string) error  { return f.record("warm", name) }
+func (f *fakeCache) PinFile(name string) error   { return f.record("pin", name) }
+func (f *fakeCache) UnpinFile(name string) error { return f.record("unpin", name) }
+func (f *fakeCache) EvictFile(name string) error { return f.record("evict", name) }
+
+func (suite *controlTestSuite) SetupTest() {
+	err := log.SetDefaultLogger("silent", common.LogConfig{Level: common.ELogLevel.LOG_DEBUG()})
+	if err != nil {
+		panic("Unable to set silent logger as default.")
+	}
+	suite.assert = assert.New(suite.T())
+
+	suite.dir, err = os.MkdirTemp("", "control")
+	suite.assert.NoError(err)
+
+	suite.server = NewServer(filepath.Join(suite.dir, "ctl.sock"))
+}
+
+func (suite *controlTestSuite) TearDownTest() {
+	suite.server.Stop()
+	os.RemoveAll(suite.dir)
+}
+
+func (suite *controlTestSuite) TestSocketPath() {
+	path := SocketPath("/mnt/blob/")
+	suite.assert.Equal("_mnt_blob.sock", filepath.Base(path))
+	suite.assert.Equal(os.ExpandEnv(common.DefaultWorkDir), filepath.Dir(path))
+}
+
+func (suite *controlTestSuite) TestCall() {
+	suite.server.Handle("echo", func(params json.RawMessage) (any, error) {
+		var msg string
+		err := json.Unmarshal(params, &msg)
+		return strings.ToUpper(msg), err
+	})
+	suite.server.Handle("fail", func(params json.RawMessage) (any, error) {
+		return nil, fmt.Errorf("request failed")
+	})
+	suite.assert.NoError(suite.server.Start())
+
+	info, err := os.Stat(suite.server.path)
+	suite.assert.NoError(err)
+	suite.assert.Equal(os.FileMode(0600), info.Mode().Perm())
+
+	var result string
+	suite.assert.NoError(Call(suite.server.path, "echo", "hello", &result))
+	suite.assert.Equal("HELLO", result)
+
+	err = Call(suite.server.path, "fail", nil, nil)
+	suite.assert.EqualError(err, "request failed")
+
+	err = Call(suite.server.path, "missing", nil, nil)
+	suite.assert.EqualError(err, "unknown method missing")
+
+	// Second mount can not take over the socket of a live one
+	suite.assert.Error(NewServer(suite.server.path).Start())
+
+	suite.server.Stop()
+	suite.assert.NoFileExists(suite.server.path)
+	suite.assert.Error(Call(suite.server.path, "echo", "hello", &result))
+}
+
+func (suite *controlTestSuite) TestPeerNotOwner() {
+	called := false
+	suite.server.Handle("drain", func(_ json.RawMessage) (any, error) {
+		called = true
+		return nil, nil
+	})
+	suite.server.owner = os.Getuid() + 1
+	suite.assert.NoError(suite.server.Start())
+
+	// Private directory the socket was created in is gone
+	entries, err := os.ReadDir(suite.dir)
+	suite.assert.NoError(err)
+	suite.assert.Len(entries, 1)
+
+	err = Call(suite.server.path, "drain", nil, nil)
+	suite.assert.EqualError(err, "permission denied")
+	suite.assert.False(called)
+}
+
+func (suite *controlTestSuite) TestStaleSocket() {
+	// Socket file left behind by a mount which crashed is replaced
+	suite.assert.NoError(os.WriteFile(suite.server.path, nil, 0600))
+	suite.assert.NoError(suite.server.Start())
+}
+
+func (suite *controlTestSuite) TestCacheHandlers() {
+	storage := filepath.Join(suite.dir, "storage")
+	suite.assert.NoError(os.MkdirAll(filepath.Join(storage, "data/sub"), 0777))
+	for _, name := range []string{"data/a", "data/sub/b", "other"} {
+		suite.assert.NoError(os.WriteFile(filepath.Join(storage, name), []byte("test"), 0777))
+	}
+
+	suite.assert.NoError(config.ReadConfigFromReader(strings.NewReader("loopbackfs:\n  path: " + storage)))
+	lfs := loopback.NewLoopbackFSComponent()
+	suite.assert.NoError(lfs.Configure(true))
+
+	cache := &fakeCache{}
+	cache.SetNextComponent(lfs)
+	RegisterCacheHandlers(suite.server, []internal.Component{cache, lfs})
+	suite.assert.NoError(suite.server.Start())
+
+	var result CacheResult
+	suite.assert.NoError(Call(suite.server.path, MethodCachePin, CacheParams{Path: "/data/"}, &result))
+	suite.assert.Equal(2, result.Files)
+	suite.assert.ElementsMatch([]string{"pin data/a", "pin data/sub/b"}, cache.calls)
+
+	cache.calls = nil
+	suite.assert.NoError(Call(suite.server.path, MethodCacheWarm, CacheParams{Path: "other"}, &result))
+	suite.assert.NoError(Call(suite.server.path, MethodCacheUnpin, CacheParams{Path: "data/a"}, &result))
+	suite.assert.NoError(Call(suite.server.path, MethodCacheEvict, CacheParams{Path: "data/sub/b"}, &result))
+	suite.assert.Equal([]string{"warm other", "unpin data/a", "evict data/sub/b"}, cache.calls)
+
+	// Whole mount is walked for the root
+	suite.assert.NoError(Call(suite.server.path, MethodCacheWarm, CacheParams{Path: "/"}, &result))
+	suite.assert.Equal(3, result.Files)
+
+	err := Call(suite.server.path, MethodCacheWarm, CacheParams{Path: "missing"}, &result)
+	suite.assert.Error(err)
+	suite.assert.Contains(err.Error(), "missing")
+
+	cache.fail = "other"
+	err = Call(suite.server.path, MethodCachePin, CacheParams{Path: "other"}, &result)
+	suite.assert.EqualError(err, "other [failed]")
+}
+
+func (suite *controlTestSuite) TestCacheHandlersWithoutCache() {
+	suite.assert.NoError(config.ReadConfigFromReader(strings.NewReader("loopbackfs:\n  path: " + suite.dir)))
+	lfs := loopback.NewLoopbackFSComponent()
+	suite.assert.NoError(lfs.Configure(true))
+
+	RegisterCacheHandlers(suite.server, []internal.Component{lfs})
+	suite.assert.NoError(suite.server.Start())
+
+	err := Call(suite.server.path, MethodCachePin, CacheParams{Path: "data"}, nil)
+	suite.assert.EqualError(err, "no component in pipeline caches file contents")
+}
+
+func TestControlSuite(t *testing.T) {
+	suite.Run(t, new(controlTestSuite))
+}
@@ -0,0 +1,210 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/Azure/azure-storage-fuse/v2/internal"
+)
+
+// Draining quiesces a mount without unmounting it: new opens are held until the mount resumes, dirty handles are
+// uploaded and the handles still open are persisted next to the control socket. The operator can then tell when it is
+// safe to upgrade or restart the mount and which applications would be affected, and the next mount on the same path
+// warms the files in the persisted map so that jobs reopening them find them in cache.
+//
+// This is not a live upgrade: the /dev/fuse descriptor is not passed to a new process, which has to mount again once
+// this one unmounts. libfuse's high-level API keeps the node ids the kernel refers to private to the process, so a new
+// process could not serve the files open at the time of a handover. Handing the mount over is left for when libfuse is
+// driven through its low-level API.
+
+// Methods served for 'blobfuse2 ctl drain' and 'blobfuse2 ctl resume'
+const (
+	MethodMountDrain  = "mount.drain"
+	MethodMountResume = "mount.resume"
+)
+
+// DrainResult : Files flushed while draining, the ones which failed and where the open handles were persisted
+type DrainResult struct {
+	Flushed   []string          `json:"flushed"`
+	Failed    map[string]string `json:"failed,omitempty"`
+	Handles   int               `json:"handles"`
+	HandleMap string            `json:"handle-map"`
+}
+
+// HandleMap : Handles open on a drained mount, as persisted on disk
+type HandleMap struct {
+	DrainedAt time.Time    `json:"drained-at"`
+	Handles   []HandleInfo `json:"handles"`
+}
+
+// HandleMapPath : File the handle map of the mount served on given control socket is persisted to
+func HandleMapPath(socketPath string) string {
+	return strings.TrimSuffix(socketPath, ".sock") + ".handles.json"
+}
+
+// registerDrainHandlers : Serve drain and resume through the component of the pipeline which talks to the kernel
+func registerDrainHandlers(s *Server, components []internal.Component) {
+	var drainer internal.Drainer
+	for _, comp := range components {
+		if d, ok := comp.(internal.Drainer); ok {
+			drainer = d
+			break
+		}
+	}
+
+	head := components[0]
+	path := HandleMapPath(s.path)
+
+	s.Handle(MethodMountDrain, func(_ json.RawMessage) (any, error) {
+		if drainer == nil {
+			return nil, errors.New("no component in the pipeline can drain the mount")
+		}
+
+		err := drainer.Drain()
+		if err != nil {
+			return nil, err
+		}
+
+		flushed := flushFiles(head, "")
+		handles := listHandles()
+		err = saveHandleMap(path, handles)
+		if err != nil {
+			return nil, fmt.Errorf("failed to persist handle map to %s [%s]", path, err.Error())
+		}
+
+		log.Info("control::drain : Mount drained, %d handles open, %d files failed to flush", len(handles), len(flushed.Failed))
+		return DrainResult{
+			Flushed:   flushed.Flushed,
+			Failed:    flushed.Failed,
+			Handles:   len(handles),
+			HandleMap: path,
+		}, nil
+	})
+
+	s.Handle(MethodMountResume, func(_ json.RawMessage) (any, error) {
+		if drainer == nil || !drainer.Draining() {
+			return nil, errors.New("mount is not draining")
+		}
+
+		drainer.Resume()
+		// Handles open after resuming are no longer described by the persisted map
+		_ = os.Remove(path)
+		return struct{}{}, nil
+	})
+}
+
+// ReloadHandleMap : Warm the files which were open when the mount was last drained through the components caching
+// file contents. The map is consumed, and the number of files warmed is returned.
+func ReloadHandleMap(path string, components []internal.Component) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	// Map describes the mount at the time of the drain only, so it is acted upon once whether or not files get warmed
+	_ = os.Remove(path)
+
+	var handleMap HandleMap
+	err = json.Unmarshal(data, &handleMap)
+	if err != nil {
+		return 0, fmt.Errorf("invalid handle map %s [%s]", path, err.Error())
+	}
+
+	controllers := make([]internal.CacheController, 0)
+	for _, comp := range components {
+		if ctl, ok := comp.(internal.CacheController); ok {
+			controllers = append(controllers, ctl)
+		}
+	}
+
+	warmed := 0
+	seen := make(map[string]bool)
+	for _, h := range handleMap.Handles {
+		if seen[h.Path] || len(controllers) == 0 {
+			continue
+		}
+		seen[h.Path] = true
+
+		err = nil
+		for _, ctl := range controllers {
+			err = ctl.WarmFile(h.Path)
+			if err != nil {
+				log.Err("control::ReloadHandleMap : Failed to warm %s [%s]", h.Path, err.Error())
+				break
+			}
+		}
+
+		if err == nil {
+			warmed++
+		}
+	}
+
+	log.Info("control::ReloadHandleMap : Warmed %d of %d files open when the mount was drained at %v", warmed, len(seen), handleMap.DrainedAt)
+	return warmed, nil
+}
+
+// saveHandleMap : Persist the handles, replacing the map of an earlier drain atomically
+func saveHandleMap(path string, handles []HandleInfo) error {
+	data, err := json.MarshalIndent(HandleMap{DrainedAt: time.Now().UTC(), Handles: handles}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(0600)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,114 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package control
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/Azure/azure-storage-fuse/v2/internal"
+)
+
+// fakeFuse : Head of the pipeline which can be drained
+type fakeFuse struct {
+	fakeFlusher
+	draining bool
+}
+
+func (f *fakeFuse) Drain() error   { f.draining = true; return nil }
+func (f *fakeFuse) Resume()        { f.draining = false }
+func (f *fakeFuse) Draining() bool { return f.draining }
+
+func (suite *controlTestSuite) TestDrain() {
+	head := &fakeFuse{}
+	head.SetName("libfuse")
+	RegisterAdminHandlers(suite.server, []internal.Component{head})
+	suite.assert.NoError(suite.server.Start())
+
+	suite.addHandle("clean", false)
+	dirty := suite.addHandle("dirty", true)
+
+	var result DrainResult
+	suite.assert.NoError(Call(suite.server.path, MethodMountDrain, nil, &result))
+	suite.assert.True(head.draining)
+	suite.assert.Equal([]string{"dirty"}, result.Flushed)
+	suite.assert.Equal(2, result.Handles)
+	suite.assert.Equal(HandleMapPath(suite.server.path), result.HandleMap)
+
+	data, err := os.ReadFile(result.HandleMap)
+	suite.assert.NoError(err)
+	var persisted HandleMap
+	suite.assert.NoError(json.Unmarshal(data, &persisted))
+	suite.assert.Len(persisted.Handles, 2)
+	suite.assert.Equal(uint64(dirty.ID), persisted.Handles[1].ID)
+	suite.assert.False(persisted.Handles[1].Dirty)
+	suite.assert.False(persisted.DrainedAt.IsZero())
+
+	// Resuming accepts opens again and discards the now stale handle map
+	suite.assert.NoError(Call(suite.server.path, MethodMountResume, nil, nil))
+	suite.assert.False(head.draining)
+	suite.assert.NoFileExists(result.HandleMap)
+
+	err = Call(suite.server.path, MethodMountResume, nil, nil)
+	suite.assert.EqualError(err, "mount is not draining")
+}
+
+func (suite *controlTestSuite) TestDrainWithoutDrainer() {
+	suite.adminPipeline()
+
+	err := Call(suite.server.path, MethodMountDrain, nil, nil)
+	suite.assert.EqualError(err, "no component in the pipeline can drain the mount")
+}
+
+func (suite *controlTestSuite) TestReloadHandleMap() {
+	path := HandleMapPath(suite.server.path)
+	handles := []HandleInfo{{ID: 1, Path: "a"}, {ID: 2, Path: "b"}, {ID: 3, Path: "a"}, {ID: 4, Path: "fail"}}
+	suite.assert.NoError(saveHandleMap(path, handles))
+
+	// Each file is warmed once and the map is consumed
+	cache := &fakeCache{fail: "fail"}
+	warmed, err := ReloadHandleMap(path, []internal.Component{&fakeFuse{}, cache})
+	suite.assert.NoError(err)
+	suite.assert.Equal(2, warmed)
+	suite.assert.Equal([]string{"warm a", "warm b"}, cache.calls)
+	suite.assert.NoFileExists(path)
+
+	_, err = ReloadHandleMap(path, []internal.Component{cache})
+	suite.assert.ErrorIs(err, os.ErrNotExist)
+
+	suite.assert.NoError(os.WriteFile(path, []byte("{"), 0600))
+	_, err = ReloadHandleMap(path, []internal.Component{cache})
+	suite.assert.Error(err)
+	suite.assert.NoFileExists(path)
+}
@@ -0,0 +1,100 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package control
+
+import (
+	"syscall"
+	"time"
+
+	"github.com/Azure/azure-storage-fuse/v2/internal"
+	"github.com/Azure/azure-storage-fuse/v2/internal/metrics"
+	"github.com/Azure/azure-storage-fuse/v2/internal/stats_manager"
+)
+
+type fakePool struct{}
+
+func (fakePool) Size() uint32  { return 16 }
+func (fakePool) Usage() uint32 { return 25 }
+
+func (suite *controlTestSuite) TestStatsSnapshot() {
+	defer metrics.Disable()
+
+	head := &fakeFlusher{}
+	head.SetName("libfuse")
+	RegisterAdminHandlers(suite.server, []internal.Component{head})
+	suite.assert.NoError(suite.server.Start())
+
+	lf := stats_manager.NewStatsCollector("libfuse")
+	defer lf.Destroy()
+	bc := stats_manager.NewStatsCollector("block_cache")
+	defer bc.Destroy()
+	az := stats_manager.NewStatsCollector("azstorage")
+	defer az.Destroy()
+
+	// The pool is known before recording starts
+	bc.ExportBlockPool(fakePool{})
+	lf.ObserveOperation("read", time.Millisecond, 0)
+
+	var snap stats_manager.Snapshot
+	suite.assert.NoError(Call(suite.server.path, MethodStatsSnapshot, nil, &snap))
+	suite.assert.True(metrics.Enabled())
+	suite.assert.Empty(snap.Operations)
+	suite.assert.Equal([]stats_manager.BlockPoolStat{{Component: "block_cache", Blocks: 16, UsagePercent: 25}}, snap.BlockPools)
+
+	lf.ObserveOperation("read", time.Millisecond, 0)
+	lf.ObserveOperation("read", time.Second, syscall.EIO)
+	lf.ObservePath("dir/a.txt")
+	lf.ObservePath("dir/a.txt")
+	lf.ObservePath("b.txt")
+	bc.CacheLookup(true)
+	bc.CacheLookup(false)
+	bc.CacheLookup(true)
+	az.AddBytes(stats_manager.Download, 4096)
+	done := az.StartTransfer(stats_manager.Download)
+
+	suite.assert.NoError(Call(suite.server.path, MethodStatsSnapshot, nil, &snap))
+	suite.assert.Equal(metrics.LatencyBuckets, snap.LatencyBounds)
+	suite.assert.Len(snap.Operations, 1)
+	suite.assert.Equal("read", snap.Operations[0].Operation)
+	suite.assert.EqualValues(2, snap.Operations[0].Count)
+	suite.assert.EqualValues(1, snap.Operations[0].Errors)
+	suite.assert.Len(snap.Operations[0].Buckets, len(metrics.LatencyBuckets)+1)
+	suite.assert.Equal([]stats_manager.CacheStat{{Component: "block_cache", Hits: 2, Misses: 1}}, snap.Caches)
+	suite.assert.Equal([]stats_manager.TransferStat{{Component: "azstorage", Direction: stats_manager.Download, Bytes: 4096, InFlight: 1}}, snap.Transfers)
+	suite.assert.Equal([]stats_manager.PathStat{{Path: "dir/a.txt", Ops: 2}, {Path: "b.txt", Ops: 1}}, snap.BusyPaths)
+
+	done()
+	suite.assert.NoError(Call(suite.server.path, MethodStatsSnapshot, nil, &snap))
+	suite.assert.Zero(snap.Transfers[0].InFlight)
+}
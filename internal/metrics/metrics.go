@@ -0,0 +1,473 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Metrics of the mount rendered in the Prometheus text exposition format, or in OpenMetrics when the scraper asks for it.
+// Only the small subset needed by blobfuse2 is implemented: counters, gauges and histograms, each with a fixed set of
+// labels. Observations are lock free once a
+// series exists so that they can be made from the hot path of file system operations.
+
+// LatencyBuckets : Upper bounds in seconds of histogram buckets for latency of file system operations
+var LatencyBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+var enabled atomic.Bool
+
+// Enable : Start recording observations, they are dropped until metrics are enabled
+func Enable() {
+	enabled.Store(true)
+}
+
+// Disable : Stop recording observations
+func Disable() {
+	enabled.Store(false)
+}
+
+// Enabled : Whether observations are being recorded
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// family : A metric and all its series, rendered as one block of the exposition
+type family interface {
+	name() string
+	write(w io.Writer, openMetrics bool)
+}
+
+// Registry : Set of metric families exported together
+type Registry struct {
+	mu       sync.RWMutex
+	families map[string]family
+}
+
+// NewRegistry : Create an empty registry
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]family)}
+}
+
+var defaultRegistry = NewRegistry()
+
+// Default : Registry exported on the metrics endpoint of the mount
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// register : Add the family, or return the one already registered under the same name so that components started
+// more than once in a process share their metrics
+func register[T family](r *Registry, f T) T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.families[f.name()]; ok {
+		if same, ok := existing.(T); ok {
+			return same
+		}
+		panic(fmt.Sprintf("metric %s registered with different types", f.name()))
+	}
+
+	r.families[f.name()] = f
+	return f
+}
+
+// Write : Render all families sorted by name in the Prometheus text format
+func (r *Registry) Write(w io.Writer) {
+	r.write(w, false)
+}
+
+// WriteOpenMetrics : Render all families sorted by name in the OpenMetrics text format
+func (r *Registry) WriteOpenMetrics(w io.Writer) {
+	r.write(w, true)
+	fmt.Fprint(w, "# EOF\n")
+}
+
+func (r *Registry) write(w io.Writer, openMetrics bool) {
+	r.mu.RLock()
+	families := make([]family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.RUnlock()
+
+	slices.SortFunc(families, func(a, b family) int { return strings.Compare(a.name(), b.name()) })
+	for _, f := range families {
+		f.write(w, openMetrics)
+	}
+}
+
+// desc : Name, help and label names shared by all series of a family
+type desc struct {
+	metric string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d *desc) name() string {
+	return d.metric
+}
+
+// header : Render help and type of the family. OpenMetrics names a counter family without the _total suffix its
+// samples carry.
+func (d *desc) header(w io.Writer, openMetrics bool) {
+	name := d.metric
+	if openMetrics && d.kind == "counter" {
+		name = strings.TrimSuffix(name, "_total")
+	}
+
+	help := strings.ReplaceAll(d.help, "\n", " ")
+	if openMetrics {
+		help = escapeLabel(d.help)
+	}
+
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, d.kind)
+}
+
+// labelPairs : Render label names and values as name="value" pairs, without braces
+func (d *desc) labelPairs(values []string) string {
+	pairs := make([]string, 0, len(values))
+	for i, v := range values {
+		pairs = append(pairs, d.labels[i]+`="`+escapeLabel(v)+`"`)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// seriesSet : Series of a family keyed by their label values
+type seriesSet[T any] struct {
+	desc
+	series sync.Map // joined label values -> *entry[T]
+	create func() *T
+}
+
+type entry[T any] struct {
+	values []string
+	val    *T
+}
+
+func (s *seriesSet[T]) with(values []string) *T {
+	if len(values) != len(s.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", s.metric, len(s.labels), len(values)))
+	}
+
+	key := strings.Join(values, "\xff")
+	if e, ok := s.series.Load(key); ok {
+		return e.(*entry[T]).val
+	}
+
+	e, _ := s.series.LoadOrStore(key, &entry[T]{values: slices.Clone(values), val: s.create()})
+	return e.(*entry[T]).val
+}
+
+func (s *seriesSet[T]) delete(values []string) {
+	s.series.Delete(strings.Join(values, "\xff"))
+}
+
+// sorted : Series ordered by label values so that the exposition is stable
+func (s *seriesSet[T]) sorted() []*entry[T] {
+	entries := make([]*entry[T], 0)
+	s.series.Range(func(_, e any) bool {
+		entries = append(entries, e.(*entry[T]))
+		return true
+	})
+	slices.SortFunc(entries, func(a, b *entry[T]) int { return slices.Compare(a.values, b.values) })
+	return entries
+}
+
+// atomicFloat : float64 updated atomically through its bit pattern
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) load() float64 {
+	return math.Float64frombits(f.bits.Load())
+}
+
+func (f *atomicFloat) store(v float64) {
+	f.bits.Store(math.Float64bits(v))
+}
+
+func (f *atomicFloat) add(v float64) {
+	for {
+		old := f.bits.Load()
+		if f.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// CounterVec : Monotonically increasing values, one per combination of label values
+type CounterVec struct {
+	seriesSet[atomicFloat]
+}
+
+// NewCounterVec : Create a counter family in the default registry
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return Default().NewCounterVec(name, help, labels...)
+}
+
+// NewCounterVec : Create a counter family in the registry
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{}
+	c.desc = desc{metric: name, help: help, kind: "counter", labels: labels}
+	c.create = func() *atomicFloat { return &atomicFloat{} }
+	return register(r, c)
+}
+
+// Add : Increase the counter of given label values, negative values are ignored
+func (c *CounterVec) Add(v float64, values ...string) {
+	if !Enabled() || v < 0 {
+		return
+	}
+	c.with(values).add(v)
+}
+
+// Value : Current value of the counter of given label values
+func (c *CounterVec) Value(values ...string) float64 {
+	return c.with(values).load()
+}
+
+// Each : Call fn with the label values and value of each counter, in order of label values
+func (c *CounterVec) Each(fn func(values []string, v float64)) {
+	for _, e := range c.sorted() {
+		fn(e.values, e.val.load())
+	}
+}
+
+func (c *CounterVec) write(w io.Writer, openMetrics bool) {
+	c.header(w, openMetrics)
+
+	sample := c.metric
+	if openMetrics && !strings.HasSuffix(sample, "_total") {
+		sample += "_total"
+	}
+	for _, e := range c.sorted() {
+		writeSample(w, sample, c.labelPairs(e.values), e.val.load())
+	}
+}
+
+// gauge : Value set by the caller or computed when the metrics are rendered
+type gauge struct {
+	atomicFloat
+	fn atomic.Pointer[func() float64]
+}
+
+func (g *gauge) value() float64 {
+	if fn := g.fn.Load(); fn != nil {
+		return (*fn)()
+	}
+	return g.load()
+}
+
+// GaugeVec : Values which go up and down, one per combination of label values
+type GaugeVec struct {
+	seriesSet[gauge]
+}
+
+// NewGaugeVec : Create a gauge family in the default registry
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return Default().NewGaugeVec(name, help, labels...)
+}
+
+// NewGaugeVec : Create a gauge family in the registry
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{}
+	g.desc = desc{metric: name, help: help, kind: "gauge", labels: labels}
+	g.create = func() *gauge { return &gauge{} }
+	return register(r, g)
+}
+
+// Set : Set the gauge of given label values
+func (g *GaugeVec) Set(v float64, values ...string) {
+	if !Enabled() {
+		return
+	}
+	g.with(values).store(v)
+}
+
+// Add : Add to the gauge of given label values, v may be negative
+func (g *GaugeVec) Add(v float64, values ...string) {
+	if !Enabled() {
+		return
+	}
+	g.with(values).add(v)
+}
+
+// SetFunc : Compute the gauge of given label values each time the metrics are rendered
+func (g *GaugeVec) SetFunc(fn func() float64, values ...string) {
+	if !Enabled() {
+		return
+	}
+	g.with(values).fn.Store(&fn)
+}
+
+// Delete : Remove the series of given label values, e.g. when the component reporting it stops
+func (g *GaugeVec) Delete(values ...string) {
+	g.delete(values)
+}
+
+// Value : Current value of the gauge of given label values
+func (g *GaugeVec) Value(values ...string) float64 {
+	return g.with(values).value()
+}
+
+// Each : Call fn with the label values and value of each gauge, in order of label values
+func (g *GaugeVec) Each(fn func(values []string, v float64)) {
+	for _, e := range g.sorted() {
+		fn(e.values, e.val.value())
+	}
+}
+
+func (g *GaugeVec) write(w io.Writer, openMetrics bool) {
+	g.header(w, openMetrics)
+	for _, e := range g.sorted() {
+		writeSample(w, g.metric, g.labelPairs(e.values), e.val.value())
+	}
+}
+
+// histogram : Count of observations per bucket, not cumulative, along with their sum
+type histogram struct {
+	buckets []atomic.Uint64 // One per upper bound and a last one for +Inf
+	count   atomic.Uint64
+	sum     atomicFloat
+}
+
+// HistogramVec : Distribution of observed values, one per combination of label values
+type HistogramVec struct {
+	seriesSet[histogram]
+	bounds []float64
+}
+
+// NewHistogramVec : Create a histogram family in the default registry
+func NewHistogramVec(name, help string, bounds []float64, labels ...string) *HistogramVec {
+	return Default().NewHistogramVec(name, help, bounds, labels...)
+}
+
+// NewHistogramVec : Create a histogram family in the registry, bounds are the upper bounds of buckets in increasing order
+func (r *Registry) NewHistogramVec(name, help string, bounds []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{bounds: slices.Clone(bounds)}
+	h.desc = desc{metric: name, help: help, kind: "histogram", labels: labels}
+	h.create = func() *histogram { return &histogram{buckets: make([]atomic.Uint64, len(h.bounds)+1)} }
+	return register(r, h)
+}
+
+// Observe : Record a value in the histogram of given label values
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	if !Enabled() {
+		return
+	}
+
+	s := h.with(values)
+	idx, _ := slices.BinarySearch(h.bounds, v)
+	s.buckets[idx].Add(1)
+	s.sum.add(v)
+	s.count.Add(1)
+}
+
+// Count : Number of values observed in the histogram of given label values
+func (h *HistogramVec) Count(values ...string) uint64 {
+	return h.with(values).count.Load()
+}
+
+// Bounds : Upper bounds of the buckets, the last bucket counting values above them
+func (h *HistogramVec) Bounds() []float64 {
+	return slices.Clone(h.bounds)
+}
+
+// Each : Call fn with the label values and the count of each bucket, not cumulative, of each histogram
+func (h *HistogramVec) Each(fn func(values []string, buckets []uint64)) {
+	for _, e := range h.sorted() {
+		buckets := make([]uint64, len(e.val.buckets))
+		for i := range e.val.buckets {
+			buckets[i] = e.val.buckets[i].Load()
+		}
+		fn(e.values, buckets)
+	}
+}
+
+func (h *HistogramVec) write(w io.Writer, openMetrics bool) {
+	h.header(w, openMetrics)
+	for _, e := range h.sorted() {
+		labels := h.labelPairs(e.values)
+		if labels != "" {
+			labels += ","
+		}
+
+		var cumulative uint64
+		for i := range e.val.buckets {
+			cumulative += e.val.buckets[i].Load()
+			le := "+Inf"
+			if i < len(h.bounds) {
+				le = formatValue(h.bounds[i])
+			}
+			writeSample(w, h.metric+"_bucket", labels+`le="`+le+`"`, float64(cumulative))
+		}
+
+		writeSample(w, h.metric+"_sum", h.labelPairs(e.values), e.val.sum.load())
+		writeSample(w, h.metric+"_count", h.labelPairs(e.values), float64(e.val.count.Load()))
+	}
+}
+
+func writeSample(w io.Writer, name string, labels string, v float64) {
+	if labels == "" {
+		fmt.Fprintf(w, "%s %s\n", name, formatValue(v))
+		return
+	}
+	fmt.Fprintf(w, "%s{%s} %s\n", name, labels, formatValue(v))
+}
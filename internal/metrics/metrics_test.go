@@ -0,0 +1,222 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package metrics
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type metricsTestSuite struct {
+	suite.Suite
+	assert *assert.Assertions
+	reg    *Registry
+}
+
+func (suite *metricsTestSuite) SetupTest() {
+	suite.assert = assert.New(suite.T())
+	suite.reg = NewRegistry()
+	Enable()
+}
+
+func (suite *metricsTestSuite) TearDownTest() {
+	Disable()
+}
+
+func (suite *metricsTestSuite) render() string {
+	var out bytes.Buffer
+	suite.reg.Write(&out)
+	return out.String()
+}
+
+func (suite *metricsTestSuite) TestCounter() {
+	c := suite.reg.NewCounterVec("test_errors_total", "Errors seen", "operation", "errno")
+	c.Add(1, "open", "ENOENT")
+	c.Add(2, "open", "ENOENT")
+	c.Add(1, "read", "EIO")
+	c.Add(-5, "read", "EIO")
+
+	suite.assert.EqualValues(3, c.Value("open", "ENOENT"))
+	suite.assert.Equal(`# HELP test_errors_total Errors seen
+# TYPE test_errors_total counter
+test_errors_total{operation="open",errno="ENOENT"} 3
+test_errors_total{operation="read",errno="EIO"} 1
+`, suite.render())
+
+	// Registering again hands back the same family
+	suite.assert.Same(c, suite.reg.NewCounterVec("test_errors_total", "Errors seen", "operation", "errno"))
+	suite.assert.Panics(func() { suite.reg.NewGaugeVec("test_errors_total", "Errors seen") })
+}
+
+func (suite *metricsTestSuite) TestGauge() {
+	g := suite.reg.NewGaugeVec("test_pool_blocks", "Blocks in pool", "component")
+	g.Set(10, "block_cache")
+	g.Add(-4, "block_cache")
+	used := 3.0
+	g.SetFunc(func() float64 { return used }, "xload")
+	used = 7
+
+	suite.assert.EqualValues(6, g.Value("block_cache"))
+	suite.assert.Contains(suite.render(), "test_pool_blocks{component=\"block_cache\"} 6\ntest_pool_blocks{component=\"xload\"} 7\n")
+
+	g.Delete("xload")
+	suite.assert.NotContains(suite.render(), "xload")
+}
+
+func (suite *metricsTestSuite) TestHistogram() {
+	h := suite.reg.NewHistogramVec("test_duration_seconds", "Latency", []float64{0.1, 1}, "operation")
+	h.Observe(0.05, "read")
+	h.Observe(0.1, "read")
+	h.Observe(0.5, "read")
+	h.Observe(3, "read")
+
+	suite.assert.EqualValues(4, h.Count("read"))
+	suite.assert.Equal(`# HELP test_duration_seconds Latency
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{operation="read",le="0.1"} 2
+test_duration_seconds_bucket{operation="read",le="1"} 3
+test_duration_seconds_bucket{operation="read",le="+Inf"} 4
+test_duration_seconds_sum{operation="read"} 3.65
+test_duration_seconds_count{operation="read"} 4
+`, suite.render())
+}
+
+func (suite *metricsTestSuite) TestEach() {
+	c := suite.reg.NewCounterVec("test_lookups_total", "Lookups", "result")
+	c.Add(3, "miss")
+	c.Add(5, "hit")
+	g := suite.reg.NewGaugeVec("test_blocks", "Blocks")
+	g.SetFunc(func() float64 { return 4 })
+	h := suite.reg.NewHistogramVec("test_latency_seconds", "Latency", []float64{0.1, 1}, "operation")
+	h.Observe(0.5, "read")
+	h.Observe(2, "read")
+
+	counters := make(map[string]float64)
+	c.Each(func(values []string, v float64) { counters[values[0]] = v })
+	suite.assert.Equal(map[string]float64{"hit": 5, "miss": 3}, counters)
+
+	g.Each(func(values []string, v float64) {
+		suite.assert.Empty(values)
+		suite.assert.EqualValues(4, v)
+	})
+
+	suite.assert.Equal([]float64{0.1, 1}, h.Bounds())
+	h.Each(func(values []string, buckets []uint64) {
+		suite.assert.Equal([]string{"read"}, values)
+		suite.assert.Equal([]uint64{0, 1, 1}, buckets)
+	})
+}
+
+func (suite *metricsTestSuite) TestDisabled() {
+	c := suite.reg.NewCounterVec("test_total", "Total")
+	Disable()
+	c.Add(1)
+	Enable()
+	c.Add(2)
+	suite.assert.EqualValues(2, c.Value())
+	suite.assert.Contains(suite.render(), "\ntest_total 2\n")
+}
+
+func (suite *metricsTestSuite) TestLabelEscaping() {
+	c := suite.reg.NewCounterVec("test_paths_total", "Paths", "path")
+	c.Add(1, "dir\\a\"b\nc")
+	suite.assert.Contains(suite.render(), `test_paths_total{path="dir\\a\"b\nc"} 1`)
+}
+
+func (suite *metricsTestSuite) TestOpenMetrics() {
+	c := suite.reg.NewCounterVec("test_errors_total", "Errors seen", "errno")
+	c.Add(2, "ENOENT")
+	d := suite.reg.NewCounterVec("test_retries", "Retries \"done\"")
+	d.Add(1)
+	g := suite.reg.NewGaugeVec("test_pool_blocks", "Blocks in pool")
+	g.Set(4)
+
+	var out bytes.Buffer
+	suite.reg.WriteOpenMetrics(&out)
+	suite.assert.Equal(`# HELP test_errors Errors seen
+# TYPE test_errors counter
+test_errors_total{errno="ENOENT"} 2
+# HELP test_pool_blocks Blocks in pool
+# TYPE test_pool_blocks gauge
+test_pool_blocks 4
+# HELP test_retries Retries \"done\"
+# TYPE test_retries counter
+test_retries_total 1
+# EOF
+`, out.String())
+}
+
+func (suite *metricsTestSuite) TestServer() {
+	c := NewCounterVec("blobfuse2_test_scrapes_total", "Scrapes in test")
+
+	s, err := Start("127.0.0.1:0")
+	suite.assert.NoError(err)
+	defer s.Stop()
+
+	c.Add(1)
+	resp, err := http.Get("http://" + s.Addr() + "/metrics")
+	suite.assert.NoError(err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	suite.assert.Equal(http.StatusOK, resp.StatusCode)
+	suite.assert.Equal(ContentType, resp.Header.Get("Content-Type"))
+	suite.assert.Contains(string(body), "# TYPE blobfuse2_test_scrapes_total counter\nblobfuse2_test_scrapes_total 1\n")
+
+	req, _ := http.NewRequest(http.MethodGet, "http://"+s.Addr()+"/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text;version=1.0.0,text/plain;version=0.0.4;q=0.5")
+	resp, err = http.DefaultClient.Do(req)
+	suite.assert.NoError(err)
+	defer resp.Body.Close()
+	body, _ = io.ReadAll(resp.Body)
+
+	suite.assert.Equal(OpenMetricsContentType, resp.Header.Get("Content-Type"))
+	suite.assert.Contains(string(body), "# TYPE blobfuse2_test_scrapes counter\nblobfuse2_test_scrapes_total 1\n")
+	suite.assert.True(strings.HasSuffix(string(body), "# EOF\n"))
+
+	resp, err = http.Post("http://"+s.Addr()+"/metrics", "text/plain", strings.NewReader(""))
+	suite.assert.NoError(err)
+	resp.Body.Close()
+	suite.assert.Equal(http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestMetrics(t *testing.T) {
+	suite.Run(t, new(metricsTestSuite))
+}
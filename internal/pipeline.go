@@ -39,7 +39,9 @@ import (
 	"fmt"
 
 	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/exectime"
 	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/Azure/azure-storage-fuse/v2/internal/tracing"
 )
 
 // Pipeline: Base pipeline structure holding list of components deployed along with the head of pipeline
@@ -106,14 +108,24 @@ func NewPipeline(components []string, isParent bool) (*Pipeline, error) {
 	}, nil
 }
 
-// Create : Use the initialized objects to form a pipeline by registering next component to each component
+// Components : List of components in the pipeline, in the order of chaining
+func (p *Pipeline) Components() []Component {
+	return p.components
+}
+
+// Create : Use the initialized objects to form a pipeline by registering next component to each component.
+// When tracing or latency collection is enabled each component is handed its next one wrapped for tracing.
 func (p *Pipeline) Create() {
 	p.Header = p.components[0]
 	curComp := p.Header
 
 	for i := 1; i < len(p.components); i++ {
 		nextComp := p.components[i]
-		curComp.SetNextComponent(nextComp)
+		if tracing.Enabled() || exectime.LatencyEnabled() {
+			curComp.SetNextComponent(NewTracedComponent(nextComp))
+		} else {
+			curComp.SetNextComponent(nextComp)
+		}
 		curComp = nextComp
 	}
 }
@@ -142,6 +142,13 @@ func (s *pipelineTestSuite) TestStartStopCreateNewPipeline() {
 	s.assert.NoError(err)
 }
 
+func (s *pipelineTestSuite) TestComponents() {
+	p, err := NewPipeline([]string{"ComponentA", "ComponentB"}, false)
+	s.assert.NoError(err)
+	s.assert.Len(p.Components(), 2)
+	s.assert.Equal(p.components, p.Components())
+}
+
 func (s *pipelineTestSuite) TestStreamToBlockCacheConfig() {
 	p, err := NewPipeline([]string{"stream"}, false)
 	s.assert.NoError(err)
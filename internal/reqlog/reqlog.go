@@ -0,0 +1,134 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package reqlog
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Rolling log of the most recent requests made to the storage service, so that the request IDs of failed calls can
+// be quoted when raising a support ticket. It is filled in by the azstorage component and dumped on SIGUSR1 or
+// through 'blobfuse2 ctl requests'.
+
+// DefaultSize : Number of requests kept in the log of the mount
+const DefaultSize = 1024
+
+// Entry : A request to the storage service, after all its attempts. Request ID is the one of the last attempt.
+type Entry struct {
+	Time      time.Time     `json:"time"`
+	Method    string        `json:"method"`
+	Path      string        `json:"path"`
+	Status    int           `json:"status"`
+	RequestID string        `json:"request-id"`
+	Retries   int           `json:"retries"`
+	Latency   time.Duration `json:"latency"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// Failed : Whether the request failed, either with an error response or without reaching the service
+func (e *Entry) Failed() bool {
+	return e.Error != "" || e.Status >= 400
+}
+
+// Ring : Bounded log of requests, the oldest entry is overwritten once it is full
+type Ring struct {
+	sync.Mutex
+	entries []Entry
+	next    int
+	full    bool
+}
+
+// NewRing : Create a log holding the given number of requests
+func NewRing(size int) *Ring {
+	return &Ring{entries: make([]Entry, max(size, 1))}
+}
+
+// Add : Add a request to the log
+func (r *Ring) Add(e Entry) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.entries[r.next] = e
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Entries : Requests in the log, oldest first
+func (r *Ring) Entries() []Entry {
+	r.Lock()
+	defer r.Unlock()
+
+	if !r.full {
+		return append([]Entry(nil), r.entries[:r.next]...)
+	}
+	return append(append(make([]Entry, 0, len(r.entries)), r.entries[r.next:]...), r.entries[:r.next]...)
+}
+
+var recent = NewRing(DefaultSize)
+
+// Record : Add a request to the log of the mount
+func Record(e Entry) {
+	recent.Add(e)
+}
+
+// Recent : Requests in the log of the mount, oldest first
+func Recent() []Entry {
+	return recent.Entries()
+}
+
+// Write : Tabulate the requests, one per line. Error of a request that did not reach the service follows its path.
+func Write(w io.Writer, entries []Entry) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tMETHOD\tSTATUS\tRETRIES\tLATENCY\tREQUEST ID\tPATH")
+	for _, e := range entries {
+		status, path := "-", e.Path
+		if e.Status != 0 {
+			status = strconv.Itoa(e.Status)
+		}
+		if e.Error != "" {
+			path += " [" + e.Error + "]"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n", e.Time.UTC().Format(time.RFC3339Nano), e.Method, status,
+			e.Retries, e.Latency.Round(time.Microsecond), cmp.Or(e.RequestID, "-"), path)
+	}
+	return tw.Flush()
+}
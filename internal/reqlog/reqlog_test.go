@@ -0,0 +1,101 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package reqlog
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type reqlogTestSuite struct {
+	suite.Suite
+	assert *assert.Assertions
+}
+
+func (s *reqlogTestSuite) SetupTest() {
+	s.assert = assert.New(s.T())
+}
+
+func (s *reqlogTestSuite) TestRing() {
+	r := NewRing(3)
+	s.assert.Empty(r.Entries())
+
+	r.Add(Entry{Path: "/c/1"})
+	r.Add(Entry{Path: "/c/2"})
+	s.assert.Equal([]Entry{{Path: "/c/1"}, {Path: "/c/2"}}, r.Entries())
+
+	for i := 3; i <= 7; i++ {
+		r.Add(Entry{Path: "/c/" + strconv.Itoa(i)})
+	}
+	s.assert.Equal([]Entry{{Path: "/c/5"}, {Path: "/c/6"}, {Path: "/c/7"}}, r.Entries())
+
+	// Entries handed out are not overwritten by later requests
+	entries := r.Entries()
+	r.Add(Entry{Path: "/c/8"})
+	s.assert.Equal("/c/5", entries[0].Path)
+}
+
+func (s *reqlogTestSuite) TestFailed() {
+	s.assert.False((&Entry{Status: 200}).Failed())
+	s.assert.False((&Entry{Status: 304}).Failed())
+	s.assert.True((&Entry{Status: 404}).Failed())
+	s.assert.True((&Entry{Error: "connection reset by peer"}).Failed())
+}
+
+func (s *reqlogTestSuite) TestWrite() {
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Time: now, Method: "GET", Path: "/c/blob", Status: 200, RequestID: "id-1", Latency: 1500 * time.Microsecond},
+		{Time: now, Method: "PUT", Path: "/c/blob?comp=block", Retries: 2, Latency: time.Second, Error: "connection reset by peer"},
+	}
+
+	buf := new(bytes.Buffer)
+	s.Require().NoError(Write(buf, entries))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	s.Require().Len(lines, 3)
+	s.assert.Equal([]string{"TIME", "METHOD", "STATUS", "RETRIES", "LATENCY", "REQUEST", "ID", "PATH"}, strings.Fields(lines[0]))
+	s.assert.Equal([]string{"2026-10-19T10:00:00Z", "GET", "200", "0", "1.5ms", "id-1", "/c/blob"}, strings.Fields(lines[1]))
+	s.assert.Equal([]string{"2026-10-19T10:00:00Z", "PUT", "-", "2", "1s", "-", "/c/blob?comp=block", "[connection", "reset", "by", "peer]"},
+		strings.Fields(lines[2]))
+}
+
+func TestReqlogTestSuite(t *testing.T) {
+	suite.Run(t, new(reqlogTestSuite))
+}
@@ -46,12 +46,15 @@ import (
 
 	"github.com/Azure/azure-storage-fuse/v2/common"
 	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/Azure/azure-storage-fuse/v2/internal/metrics"
 )
 
 type StatsCollector struct {
 	channel    chan ChannelMsg
 	workerDone sync.WaitGroup
 	compIdx    int
+	name       string
+	ratioOnce  sync.Once // Hit ratio of the cache of the component is exported on first lookup
 }
 
 type PipeMsg struct {
@@ -95,7 +98,7 @@ type statsManagerOpt struct {
 var stMgrOpt statsManagerOpt
 
 func NewStatsCollector(componentName string) *StatsCollector {
-	sc := &StatsCollector{}
+	sc := &StatsCollector{name: componentName}
 
 	if common.MonitorBfs() {
 		sc.channel = make(chan ChannelMsg, 10000)
@@ -135,6 +138,8 @@ func (sc *StatsCollector) Init() {
 }
 
 func (sc *StatsCollector) Destroy() {
+	sc.unexportMetrics()
+
 	if common.MonitorBfs() {
 		close(sc.channel)
 		sc.workerDone.Wait()
@@ -168,6 +173,10 @@ func (sc *StatsCollector) PushEvents(op string, path string, mp map[string]any)
 }
 
 func (sc *StatsCollector) UpdateStats(op string, key string, val any) {
+	if metrics.Enabled() {
+		sc.exportStat(op, key, val)
+	}
+
 	if common.MonitorBfs() {
 		st := Stats{
 			Timestamp: time.Now().Format(time.RFC3339),
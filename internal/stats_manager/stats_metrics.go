@@ -0,0 +1,213 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package stats_manager
+
+import (
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/internal/metrics"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// Directions of data transferred to and from storage
+	Download = "download"
+	Upload   = "upload"
+
+	// Stat counting operations of a component which failed for reasons other than the answer being negative
+	FailedOperations = "Failed Operations"
+)
+
+// Metrics fed by the collectors of components, served on the metrics endpoint of the mount when it is enabled.
+// Unlike stats sent to the health monitor these are recorded irrespective of monitoring being enabled.
+var (
+	operationDuration = metrics.NewHistogramVec("blobfuse2_operation_duration_seconds",
+		"Latency of file system operations served by a component", metrics.LatencyBuckets, "component", "operation")
+	operationErrors = metrics.NewCounterVec("blobfuse2_operation_errors_total",
+		"File system operations which failed, by errno", "component", "operation", "errno")
+	bytesTransferred = metrics.NewCounterVec("blobfuse2_bytes_transferred_total",
+		"Bytes transferred to and from storage", "component", "direction")
+	transfersInFlight = metrics.NewGaugeVec("blobfuse2_transfers_in_flight",
+		"Transfers to and from storage in progress", "component", "direction")
+	cacheLookups = metrics.NewCounterVec("blobfuse2_cache_lookups_total",
+		"Lookups in cache by result, hit or miss", "component", "result")
+	cacheHitRatio = metrics.NewGaugeVec("blobfuse2_cache_hit_ratio",
+		"Ratio of cache lookups which were hits since mount", "component")
+	blockPoolBlocks = metrics.NewGaugeVec("blobfuse2_block_pool_blocks",
+		"Blocks the block pool of a component holds", "component")
+	blockPoolUsage = metrics.NewGaugeVec("blobfuse2_block_pool_usage_ratio",
+		"Ratio of blocks of the block pool of a component in use", "component")
+	componentStats = metrics.NewGaugeVec("blobfuse2_component_stat",
+		"Numeric stats a component collects for the health monitor", "component", "stat")
+)
+
+// Block pools of components by name, kept even when metrics are off so that they can be reported once enabled
+var blockPools sync.Map
+
+// BlockPool : Pool of blocks whose size and usage are exported
+type BlockPool interface {
+	Size() uint32  // Blocks held by the pool
+	Usage() uint32 // Percentage of blocks in use
+}
+
+// ObserveOperation : Record latency of an operation served by the component and its errno if it failed. Failures
+// are also counted in stats for the health monitor, unless the errno only answers the operation, e.g. ENOENT for
+// a lookup of a missing path.
+func (sc *StatsCollector) ObserveOperation(op string, elapsed time.Duration, errno syscall.Errno) {
+	if errno != 0 && common.MonitorBfs() && !answerErrno(errno) {
+		sc.UpdateStats(Increment, FailedOperations, int64(1))
+	}
+
+	if !metrics.Enabled() {
+		return
+	}
+
+	operationDuration.Observe(elapsed.Seconds(), sc.name, op)
+	if errno != 0 {
+		operationErrors.Add(1, sc.name, op, errnoName(errno))
+	}
+}
+
+// AddBytes : Record bytes transferred to or from storage
+func (sc *StatsCollector) AddBytes(direction string, count int64) {
+	if !metrics.Enabled() || count <= 0 {
+		return
+	}
+	bytesTransferred.Add(float64(count), sc.name, direction)
+}
+
+// StartTransfer : Count a transfer to or from storage as in progress until the returned func is called
+func (sc *StatsCollector) StartTransfer(direction string) func() {
+	if !metrics.Enabled() {
+		return func() {}
+	}
+
+	transfersInFlight.Add(1, sc.name, direction)
+	return func() { transfersInFlight.Add(-1, sc.name, direction) }
+}
+
+// ObservePath : Count an operation on the path towards the busiest paths of the mount
+func (sc *StatsCollector) ObservePath(path string) {
+	if !metrics.Enabled() {
+		return
+	}
+	busyPaths.observe(path, time.Now())
+}
+
+// CacheLookup : Record whether a lookup in the cache of the component was a hit
+func (sc *StatsCollector) CacheLookup(hit bool) {
+	if !metrics.Enabled() {
+		return
+	}
+
+	sc.ratioOnce.Do(func() {
+		cacheHitRatio.SetFunc(func() float64 {
+			hits := cacheLookups.Value(sc.name, "hit")
+			total := hits + cacheLookups.Value(sc.name, "miss")
+			if total == 0 {
+				return 0
+			}
+			return hits / total
+		}, sc.name)
+	})
+
+	if hit {
+		cacheLookups.Add(1, sc.name, "hit")
+	} else {
+		cacheLookups.Add(1, sc.name, "miss")
+	}
+}
+
+// ExportBlockPool : Export size and usage of the block pool of the component until the collector is destroyed
+func (sc *StatsCollector) ExportBlockPool(pool BlockPool) {
+	blockPools.Store(sc.name, pool)
+	blockPoolBlocks.SetFunc(func() float64 { return float64(pool.Size()) }, sc.name)
+	blockPoolUsage.SetFunc(func() float64 { return float64(pool.Usage()) / 100 }, sc.name)
+}
+
+// exportStat : Mirror a numeric stat sent to the health monitor in metrics
+func (sc *StatsCollector) exportStat(op string, key string, val any) {
+	var v float64
+	switch n := val.(type) {
+	case int64:
+		v = float64(n)
+	case int:
+		v = float64(n)
+	case uint64:
+		v = float64(n)
+	case float64:
+		v = n
+	default:
+		// Stats like cache usage are sent to the monitor as formatted strings
+		return
+	}
+
+	switch op {
+	case Increment:
+		componentStats.Add(v, sc.name, key)
+	case Decrement:
+		componentStats.Add(-v, sc.name, key)
+	case Replace:
+		componentStats.Set(v, sc.name, key)
+	}
+}
+
+// unexportMetrics : Remove metrics computed from state of the component, which goes away with it
+func (sc *StatsCollector) unexportMetrics() {
+	blockPools.Delete(sc.name)
+	blockPoolBlocks.Delete(sc.name)
+	blockPoolUsage.Delete(sc.name)
+	cacheHitRatio.Delete(sc.name)
+}
+
+// answerErrno : Whether the errno is the expected answer to an operation rather than a failure to serve it
+func answerErrno(errno syscall.Errno) bool {
+	switch errno {
+	case syscall.ENOENT, syscall.EEXIST, syscall.ENOTEMPTY, syscall.ENODATA, syscall.ENOTDIR, syscall.EISDIR:
+		return true
+	}
+	return false
+}
+
+func errnoName(errno syscall.Errno) string {
+	if name := unix.ErrnoName(errno); name != "" {
+		return name
+	}
+	return strconv.Itoa(int(errno))
+}
@@ -0,0 +1,242 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package stats_manager
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// Paths tracked per window, the least busy one gives way to a new path once full
+	busyPathsCapacity = 1024
+	// Busiest paths are counted over the current and the previous window
+	busyPathsWindow = 10 * time.Second
+	// Busiest paths returned in a snapshot
+	busyPathsTop = 20
+)
+
+// Snapshot : Cumulative metrics recorded by the collectors, taken by 'blobfuse2 top' once a second. Rates are the
+// difference of two snapshots over the time between them.
+type Snapshot struct {
+	Time          time.Time       `json:"time"`
+	LatencyBounds []float64       `json:"latency-bounds"`
+	Operations    []OperationStat `json:"operations"`
+	Transfers     []TransferStat  `json:"transfers"`
+	Caches        []CacheStat     `json:"caches"`
+	BlockPools    []BlockPoolStat `json:"block-pools"`
+	BusyPaths     []PathStat      `json:"busy-paths"`
+}
+
+// OperationStat : Operations served by a component, with their latency counted in buckets of LatencyBounds
+type OperationStat struct {
+	Component string   `json:"component"`
+	Operation string   `json:"operation"`
+	Count     uint64   `json:"count"`
+	Errors    uint64   `json:"errors"`
+	Buckets   []uint64 `json:"buckets"`
+}
+
+// TransferStat : Bytes transferred to or from storage by a component and the transfers in progress
+type TransferStat struct {
+	Component string `json:"component"`
+	Direction string `json:"direction"`
+	Bytes     uint64 `json:"bytes"`
+	InFlight  int64  `json:"in-flight"`
+}
+
+// CacheStat : Lookups in the cache of a component
+type CacheStat struct {
+	Component string `json:"component"`
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+}
+
+// BlockPoolStat : Size and usage of the block pool of a component
+type BlockPoolStat struct {
+	Component    string `json:"component"`
+	Blocks       uint32 `json:"blocks"`
+	UsagePercent uint32 `json:"usage-percent"`
+}
+
+// PathStat : Operations on a path over the last 10 to 20 seconds
+type PathStat struct {
+	Path string `json:"path"`
+	Ops  uint64 `json:"ops"`
+}
+
+// TakeSnapshot : Current values of the metrics fed by the collectors, empty unless metrics are enabled
+func TakeSnapshot() Snapshot {
+	snap := Snapshot{
+		Time:          time.Now(),
+		LatencyBounds: operationDuration.Bounds(),
+		Operations:    make([]OperationStat, 0),
+		Transfers:     make([]TransferStat, 0),
+		Caches:        make([]CacheStat, 0),
+		BlockPools:    make([]BlockPoolStat, 0),
+	}
+
+	operationDuration.Each(func(values []string, buckets []uint64) {
+		op := OperationStat{Component: values[0], Operation: values[1], Buckets: buckets}
+		for _, b := range buckets {
+			op.Count += b
+		}
+		snap.Operations = append(snap.Operations, op)
+	})
+
+	// Errors are counted per errno, which top does not break down
+	operationErrors.Each(func(values []string, v float64) {
+		for i := range snap.Operations {
+			if snap.Operations[i].Component == values[0] && snap.Operations[i].Operation == values[1] {
+				snap.Operations[i].Errors += uint64(v)
+			}
+		}
+	})
+
+	transfer := func(component, direction string) *TransferStat {
+		for i := range snap.Transfers {
+			if snap.Transfers[i].Component == component && snap.Transfers[i].Direction == direction {
+				return &snap.Transfers[i]
+			}
+		}
+		snap.Transfers = append(snap.Transfers, TransferStat{Component: component, Direction: direction})
+		return &snap.Transfers[len(snap.Transfers)-1]
+	}
+	bytesTransferred.Each(func(values []string, v float64) {
+		transfer(values[0], values[1]).Bytes = uint64(v)
+	})
+	transfersInFlight.Each(func(values []string, v float64) {
+		transfer(values[0], values[1]).InFlight = int64(v)
+	})
+
+	cacheLookups.Each(func(values []string, v float64) {
+		idx := slices.IndexFunc(snap.Caches, func(c CacheStat) bool { return c.Component == values[0] })
+		if idx == -1 {
+			snap.Caches = append(snap.Caches, CacheStat{Component: values[0]})
+			idx = len(snap.Caches) - 1
+		}
+		if values[1] == "hit" {
+			snap.Caches[idx].Hits = uint64(v)
+		} else {
+			snap.Caches[idx].Misses = uint64(v)
+		}
+	})
+
+	blockPools.Range(func(name, pool any) bool {
+		snap.BlockPools = append(snap.BlockPools, BlockPoolStat{
+			Component:    name.(string),
+			Blocks:       pool.(BlockPool).Size(),
+			UsagePercent: pool.(BlockPool).Usage(),
+		})
+		return true
+	})
+	slices.SortFunc(snap.BlockPools, func(a, b BlockPoolStat) int { return cmp.Compare(a.Component, b.Component) })
+
+	snap.BusyPaths = busyPaths.top(busyPathsTop, snap.Time)
+	return snap
+}
+
+// pathTracker : Approximate count of operations on the busiest paths in bounded memory. Once a window is full, a new
+// path replaces the least busy one and starts from its count, so that a busy path is not lost to a stream of new
+// ones. Counts may thus be overestimated by the count of the path they replaced.
+type pathTracker struct {
+	sync.Mutex
+	current  map[string]uint64
+	previous map[string]uint64
+	start    time.Time
+}
+
+var busyPaths = &pathTracker{current: make(map[string]uint64)}
+
+func (t *pathTracker) observe(path string, now time.Time) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.rotate(now)
+
+	if _, found := t.current[path]; !found && len(t.current) >= busyPathsCapacity {
+		var least string
+		var count uint64
+		for p, c := range t.current {
+			if least == "" || c < count {
+				least, count = p, c
+			}
+		}
+		delete(t.current, least)
+		t.current[path] = count
+	}
+	t.current[path]++
+}
+
+// rotate : Start a new window once the current one is over, dropping both if nothing was observed for a whole window
+func (t *pathTracker) rotate(now time.Time) {
+	switch elapsed := now.Sub(t.start); {
+	case elapsed >= 2*busyPathsWindow:
+		t.previous = nil
+	case elapsed >= busyPathsWindow:
+		t.previous = t.current
+	default:
+		return
+	}
+	t.current = make(map[string]uint64)
+	t.start = now
+}
+
+// top : Busiest paths over the current and previous window, busiest first
+func (t *pathTracker) top(n int, now time.Time) []PathStat {
+	t.Lock()
+	defer t.Unlock()
+
+	t.rotate(now)
+
+	counts := make(map[string]uint64, len(t.current)+len(t.previous))
+	for p, c := range t.previous {
+		counts[p] += c
+	}
+	for p, c := range t.current {
+		counts[p] += c
+	}
+
+	paths := make([]PathStat, 0, len(counts))
+	for p, c := range counts {
+		paths = append(paths, PathStat{Path: p, Ops: c})
+	}
+	slices.SortFunc(paths, func(a, b PathStat) int {
+		return cmp.Or(cmp.Compare(b.Ops, a.Ops), cmp.Compare(a.Path, b.Path))
+	})
+
+	return paths[:min(n, len(paths))]
+}
@@ -0,0 +1,97 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package stats_manager
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type snapshotTestSuite struct {
+	suite.Suite
+	assert *assert.Assertions
+}
+
+func (suite *snapshotTestSuite) SetupTest() {
+	suite.assert = assert.New(suite.T())
+}
+
+func (suite *snapshotTestSuite) TestBusyPathsWindow() {
+	t := &pathTracker{current: make(map[string]uint64)}
+	now := time.Now()
+
+	t.observe("a", now)
+	t.observe("a", now)
+	t.observe("b", now)
+	suite.assert.Equal([]PathStat{{Path: "a", Ops: 2}, {Path: "b", Ops: 1}}, t.top(10, now))
+	suite.assert.Equal([]PathStat{{Path: "a", Ops: 2}}, t.top(1, now))
+
+	// The previous window still counts
+	now = now.Add(busyPathsWindow)
+	t.observe("b", now)
+	t.observe("b", now)
+	suite.assert.Equal([]PathStat{{Path: "b", Ops: 3}, {Path: "a", Ops: 2}}, t.top(10, now))
+
+	now = now.Add(busyPathsWindow)
+	suite.assert.Equal([]PathStat{{Path: "b", Ops: 2}}, t.top(10, now))
+
+	// Nothing observed for two windows
+	now = now.Add(2 * busyPathsWindow)
+	suite.assert.Empty(t.top(10, now))
+}
+
+func (suite *snapshotTestSuite) TestBusyPathsCapacity() {
+	t := &pathTracker{current: make(map[string]uint64)}
+	now := time.Now()
+
+	for i := range busyPathsCapacity {
+		t.observe(fmt.Sprintf("file%d", i), now)
+		t.observe(fmt.Sprintf("file%d", i), now)
+	}
+	t.observe("hot", now)
+	t.observe("file0", now)
+
+	// A new path replaces the least busy one and starts from its count
+	suite.assert.Len(t.current, busyPathsCapacity)
+	suite.assert.EqualValues(3, t.current["hot"])
+	suite.assert.EqualValues(3, t.current["file0"])
+}
+
+func TestSnapshot(t *testing.T) {
+	suite.Run(t, new(snapshotTestSuite))
+}
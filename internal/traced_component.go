@@ -0,0 +1,308 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package internal
+
+import (
+	"syscall"
+	"time"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/exectime"
+	"github.com/Azure/azure-storage-fuse/v2/internal/handlemap"
+	"github.com/Azure/azure-storage-fuse/v2/internal/tracing"
+)
+
+// TracedComponent : Wraps a component of the pipeline so that each call made into it is recorded as a span, child of
+// the span of the fuse operation or the component call that made it, and its latency is added to the histogram of the
+// component and operation. Methods which do not take part in file system operations are passed through as is.
+type TracedComponent struct {
+	Component
+}
+
+// NewTracedComponent : Wrap the component to trace and time calls made into it
+func NewTracedComponent(c Component) Component {
+	return &TracedComponent{Component: c}
+}
+
+// Unwrap : Component wrapped for tracing, or the component itself when it is not wrapped.
+// Optional interfaces of a component, e.g. PathInvalidator, shall be looked up on the unwrapped component.
+func Unwrap(c Component) Component {
+	if tc, ok := c.(*TracedComponent); ok {
+		return tc.Component
+	}
+	return c
+}
+
+// call : Span of a call into the wrapped component, and when it started to record its latency
+type call struct {
+	span  *tracing.Span
+	name  string
+	start time.Time
+}
+
+func (tc *TracedComponent) start(op string, path string, attrs ...tracing.Attribute) call {
+	name := tc.Component.Name() + "." + op
+	if tracing.Enabled() && path != "" {
+		attrs = append(attrs, tracing.String("path", path))
+	}
+	return call{
+		span:  tracing.Start(name, tracing.KindInternal, attrs...),
+		name:  name,
+		start: time.Now(),
+	}
+}
+
+// End : End the span of the call and record its latency
+func (c call) End(err error) {
+	exectime.Observe(c.name, time.Since(c.start))
+	c.span.End(err)
+}
+
+func handlePath(handle *handlemap.Handle) string {
+	if handle == nil {
+		return ""
+	}
+	return handle.Path
+}
+
+func readPath(options *ReadInBufferOptions) string {
+	if options.Handle != nil {
+		return options.Handle.Path
+	}
+	return options.Path
+}
+
+func (tc *TracedComponent) CreateDir(options CreateDirOptions) (err error) {
+	span := tc.start("CreateDir", options.Name)
+	defer func() { span.End(err) }()
+	return tc.Component.CreateDir(options)
+}
+
+func (tc *TracedComponent) DeleteDir(options DeleteDirOptions) (err error) {
+	span := tc.start("DeleteDir", options.Name)
+	defer func() { span.End(err) }()
+	return tc.Component.DeleteDir(options)
+}
+
+func (tc *TracedComponent) OpenDir(options OpenDirOptions) (err error) {
+	span := tc.start("OpenDir", options.Name)
+	defer func() { span.End(err) }()
+	return tc.Component.OpenDir(options)
+}
+
+func (tc *TracedComponent) ReadDir(options ReadDirOptions) (attrs []*ObjAttr, err error) {
+	span := tc.start("ReadDir", options.Name)
+	defer func() { span.End(err) }()
+	return tc.Component.ReadDir(options)
+}
+
+func (tc *TracedComponent) StreamDir(options StreamDirOptions) (attrs []*ObjAttr, token string, err error) {
+	span := tc.start("StreamDir", options.Name)
+	defer func() { span.End(err) }()
+	return tc.Component.StreamDir(options)
+}
+
+func (tc *TracedComponent) CloseDir(options CloseDirOptions) (err error) {
+	span := tc.start("CloseDir", options.Name)
+	defer func() { span.End(err) }()
+	return tc.Component.CloseDir(options)
+}
+
+func (tc *TracedComponent) RenameDir(options RenameDirOptions) (err error) {
+	span := tc.start("RenameDir", options.Src, tracing.String("destination", options.Dst))
+	defer func() { span.End(err) }()
+	return tc.Component.RenameDir(options)
+}
+
+func (tc *TracedComponent) CreateFile(options CreateFileOptions) (handle *handlemap.Handle, err error) {
+	span := tc.start("CreateFile", options.Name)
+	defer func() { span.End(err) }()
+	return tc.Component.CreateFile(options)
+}
+
+func (tc *TracedComponent) DeleteFile(options DeleteFileOptions) (err error) {
+	span := tc.start("DeleteFile", options.Name)
+	defer func() { span.End(err) }()
+	return tc.Component.DeleteFile(options)
+}
+
+func (tc *TracedComponent) OpenFile(options OpenFileOptions) (handle *handlemap.Handle, err error) {
+	span := tc.start("OpenFile", options.Name)
+	defer func() { span.End(err) }()
+	return tc.Component.OpenFile(options)
+}
+
+func (tc *TracedComponent) ReadFile(options ReadFileOptions) (data []byte, err error) {
+	span := tc.start("ReadFile", handlePath(options.Handle))
+	defer func() { span.End(err) }()
+	return tc.Component.ReadFile(options)
+}
+
+func (tc *TracedComponent) ReadInBuffer(options *ReadInBufferOptions) (n int, err error) {
+	span := tc.start("ReadInBuffer", readPath(options), tracing.Int("offset", options.Offset), tracing.Int("size", int64(len(options.Data))))
+	defer func() { span.End(err) }()
+	return tc.Component.ReadInBuffer(options)
+}
+
+func (tc *TracedComponent) WriteFile(options *WriteFileOptions) (n int, err error) {
+	span := tc.start("WriteFile", handlePath(options.Handle), tracing.Int("offset", options.Offset), tracing.Int("size", int64(len(options.Data))))
+	defer func() { span.End(err) }()
+	return tc.Component.WriteFile(options)
+}
+
+func (tc *TracedComponent) SyncFile(options SyncFileOptions) (err error) {
+	span := tc.start("SyncFile", handlePath(options.Handle))
+	defer func() { span.End(err) }()
+	return tc.Component.SyncFile(options)
+}
+
+func (tc *TracedComponent) FlushFile(options FlushFileOptions) (err error) {
+	span := tc.start("FlushFile", handlePath(options.Handle))
+	defer func() { span.End(err) }()
+	return tc.Component.FlushFile(options)
+}
+
+func (tc *TracedComponent) ReleaseFile(options ReleaseFileOptions) (err error) {
+	span := tc.start("ReleaseFile", handlePath(options.Handle))
+	defer func() { span.End(err) }()
+	return tc.Component.ReleaseFile(options)
+}
+
+func (tc *TracedComponent) RenameFile(options RenameFileOptions) (err error) {
+	span := tc.start("RenameFile", options.Src, tracing.String("destination", options.Dst))
+	defer func() { span.End(err) }()
+	return tc.Component.RenameFile(options)
+}
+
+func (tc *TracedComponent) CopyToFile(options CopyToFileOptions) (err error) {
+	span := tc.start("CopyToFile", options.Name, tracing.Int("offset", options.Offset), tracing.Int("size", options.Count))
+	defer func() { span.End(err) }()
+	return tc.Component.CopyToFile(options)
+}
+
+func (tc *TracedComponent) CopyFromFile(options CopyFromFileOptions) (err error) {
+	span := tc.start("CopyFromFile", options.Name)
+	defer func() { span.End(err) }()
+	return tc.Component.CopyFromFile(options)
+}
+
+func (tc *TracedComponent) SyncDir(options SyncDirOptions) (err error) {
+	span := tc.start("SyncDir", options.Name)
+	defer func() { span.End(err) }()
+	return tc.Component.SyncDir(options)
+}
+
+func (tc *TracedComponent) UnlinkFile(options UnlinkFileOptions) (err error) {
+	span := tc.start("UnlinkFile", options.Name)
+	defer func() { span.End(err) }()
+	return tc.Component.UnlinkFile(options)
+}
+
+func (tc *TracedComponent) CreateLink(options CreateLinkOptions) (err error) {
+	span := tc.start("CreateLink", options.Name)
+	defer func() { span.End(err) }()
+	return tc.Component.CreateLink(options)
+}
+
+func (tc *TracedComponent) ReadLink(options ReadLinkOptions) (target string, err error) {
+	span := tc.start("ReadLink", options.Name)
+	defer func() { span.End(err) }()
+	return tc.Component.ReadLink(options)
+}
+
+func (tc *TracedComponent) GetAttr(options GetAttrOptions) (attr *ObjAttr, err error) {
+	span := tc.start("GetAttr", options.Name)
+	defer func() { span.End(err) }()
+	return tc.Component.GetAttr(options)
+}
+
+func (tc *TracedComponent) Chmod(options ChmodOptions) (err error) {
+	span := tc.start("Chmod", options.Name)
+	defer func() { span.End(err) }()
+	return tc.Component.Chmod(options)
+}
+
+func (tc *TracedComponent) Chown(options ChownOptions) (err error) {
+	span := tc.start("Chown", options.Name)
+	defer func() { span.End(err) }()
+	return tc.Component.Chown(options)
+}
+
+func (tc *TracedComponent) TruncateFile(options TruncateFileOptions) (err error) {
+	span := tc.start("TruncateFile", options.Name, tracing.Int("size", options.NewSize))
+	defer func() { span.End(err) }()
+	return tc.Component.TruncateFile(options)
+}
+
+func (tc *TracedComponent) GetFileBlockOffsets(options GetFileBlockOffsetsOptions) (offsets *common.BlockOffsetList, err error) {
+	span := tc.start("GetFileBlockOffsets", options.Name)
+	defer func() { span.End(err) }()
+	return tc.Component.GetFileBlockOffsets(options)
+}
+
+func (tc *TracedComponent) FileUsed(name string) (err error) {
+	span := tc.start("FileUsed", name)
+	defer func() { span.End(err) }()
+	return tc.Component.FileUsed(name)
+}
+
+func (tc *TracedComponent) GetCommittedBlockList(name string) (list *CommittedBlockList, err error) {
+	span := tc.start("GetCommittedBlockList", name)
+	defer func() { span.End(err) }()
+	return tc.Component.GetCommittedBlockList(name)
+}
+
+func (tc *TracedComponent) StageData(options StageDataOptions) (err error) {
+	span := tc.start("StageData", options.Name, tracing.Int("offset", int64(options.Offset)), tracing.Int("size", int64(len(options.Data))))
+	defer func() { span.End(err) }()
+	return tc.Component.StageData(options)
+}
+
+func (tc *TracedComponent) CommitData(options CommitDataOptions) (err error) {
+	span := tc.start("CommitData", options.Name, tracing.Int("blocks", int64(len(options.List))))
+	defer func() { span.End(err) }()
+	return tc.Component.CommitData(options)
+}
+
+func (tc *TracedComponent) IsDirEmpty(options IsDirEmptyOptions) bool {
+	span := tc.start("IsDirEmpty", options.Name)
+	defer span.End(nil)
+	return tc.Component.IsDirEmpty(options)
+}
+
+func (tc *TracedComponent) StatFs() (stat *syscall.Statfs_t, populated bool, err error) {
+	span := tc.start("StatFs", "")
+	defer func() { span.End(err) }()
+	return tc.Component.StatFs()
+}
@@ -0,0 +1,213 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package internal
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/exectime"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/Azure/azure-storage-fuse/v2/internal/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// tracedLeaf : Last component of the test pipeline, fails lookup of missing paths
+type tracedLeaf struct {
+	BaseComponent
+}
+
+func (l *tracedLeaf) GetAttr(options GetAttrOptions) (*ObjAttr, error) {
+	if options.Name == "missing" {
+		return nil, syscall.ENOENT
+	}
+	return &ObjAttr{Path: options.Name}, nil
+}
+
+func (l *tracedLeaf) InvalidatePath(name string) {}
+func (l *tracedLeaf) InvalidateDir(name string)  {}
+
+type tracedComponentTestSuite struct {
+	suite.Suite
+	assert *assert.Assertions
+	path   string
+}
+
+func (s *tracedComponentTestSuite) SetupTest() {
+	err := log.SetDefaultLogger("silent", common.LogConfig{})
+	if err != nil {
+		panic("Unable to set silent logger as default.")
+	}
+	s.assert = assert.New(s.T())
+	s.path = filepath.Join(s.T().TempDir(), "traces.json")
+}
+
+func (s *tracedComponentTestSuite) TearDownTest() {
+	tracing.Shutdown()
+}
+
+func (s *tracedComponentTestSuite) newPipeline() *Pipeline {
+	head := &ComponentA{}
+	head.SetName("head")
+	mid := &ComponentB{}
+	mid.SetName("mid")
+	leaf := &tracedLeaf{}
+	leaf.SetName("leaf")
+
+	p := &Pipeline{components: []Component{head, mid, leaf}}
+	p.Create()
+	return p
+}
+
+// spans : Name, parent and status message of each exported span, keyed by span id
+func (s *tracedComponentTestSuite) spans() map[string][3]string {
+	f, err := os.Open(s.path)
+	s.Require().NoError(err)
+	defer f.Close()
+
+	spans := make(map[string][3]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []struct {
+						SpanID       string `json:"spanId"`
+						ParentSpanID string `json:"parentSpanId"`
+						Name         string `json:"name"`
+						Status       struct {
+							Message string `json:"message"`
+						} `json:"status"`
+					} `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		s.Require().NoError(json.Unmarshal(scanner.Bytes(), &req))
+		for _, span := range req.ResourceSpans[0].ScopeSpans[0].Spans {
+			spans[span.SpanID] = [3]string{span.Name, span.ParentSpanID, span.Status.Message}
+		}
+	}
+	return spans
+}
+
+func (s *tracedComponentTestSuite) TestNotWrappedWhenDisabled() {
+	p := s.newPipeline()
+	_, ok := p.Header.NextComponent().(*TracedComponent)
+	s.assert.False(ok)
+}
+
+func (s *tracedComponentTestSuite) TestChildSpans() {
+	s.Require().NoError(tracing.Init(tracing.Config{Enabled: true, Exporter: tracing.ExporterFile, FilePath: s.path}))
+	p := s.newPipeline()
+
+	next := p.Header.NextComponent()
+	s.Require().IsType(&TracedComponent{}, next)
+	s.assert.Equal("mid", next.Name())
+
+	// Optional interfaces are found on the unwrapped component
+	leaf := next.NextComponent()
+	_, ok := leaf.(PathInvalidator)
+	s.assert.False(ok)
+	_, ok = Unwrap(leaf).(PathInvalidator)
+	s.assert.True(ok)
+	s.assert.Same(p.components[0], Unwrap(p.Header))
+
+	root := tracing.Start("fuse.getattr", tracing.KindServer)
+	attr, err := p.Header.GetAttr(GetAttrOptions{Name: "dir/file"})
+	s.assert.NoError(err)
+	s.assert.Equal("dir/file", attr.Path)
+	root.End(nil)
+
+	_, err = p.Header.GetAttr(GetAttrOptions{Name: "missing"})
+	s.assert.Equal(syscall.ENOENT, err)
+	tracing.Shutdown()
+
+	spans := s.spans()
+	s.Require().Len(spans, 5)
+
+	byName := make(map[string][]string)
+	for id, span := range spans {
+		byName[span[0]] = append(byName[span[0]], id)
+	}
+	s.Require().Len(byName["mid.GetAttr"], 2)
+	s.Require().Len(byName["leaf.GetAttr"], 2)
+	s.Require().Len(byName["fuse.getattr"], 1)
+
+	for _, id := range byName["leaf.GetAttr"] {
+		parent := spans[spans[id][1]]
+		s.assert.Equal("mid.GetAttr", parent[0])
+		s.assert.Equal(spans[id][2], parent[2], "error propagates through the pipeline")
+
+		if parent[2] == "" {
+			s.assert.Equal(byName["fuse.getattr"][0], parent[1])
+		} else {
+			s.assert.Equal(syscall.ENOENT.Error(), parent[2])
+			s.assert.Empty(parent[1])
+		}
+	}
+}
+
+func (s *tracedComponentTestSuite) TestLatencyWithoutTracing() {
+	exectime.EnableLatency()
+	defer exectime.DisableLatency()
+
+	p := s.newPipeline()
+	s.Require().IsType(&TracedComponent{}, p.Header.NextComponent())
+
+	_, err := p.Header.GetAttr(GetAttrOptions{Name: "dir/file"})
+	s.assert.NoError(err)
+	_, err = p.Header.GetAttr(GetAttrOptions{Name: "missing"})
+	s.assert.Equal(syscall.ENOENT, err)
+
+	report := exectime.LatencyReport()
+	s.Require().Len(report, 2)
+	s.assert.Equal("leaf.GetAttr", report[0].Key)
+	s.assert.EqualValues(2, report[0].Count)
+	s.assert.Equal("mid.GetAttr", report[1].Key)
+	s.assert.EqualValues(2, report[1].Count)
+	s.assert.GreaterOrEqual(report[1].Max, report[0].Max)
+
+	_, err = os.Stat(s.path)
+	s.assert.True(os.IsNotExist(err), "no spans exported")
+}
+
+func TestTracedComponentTestSuite(t *testing.T) {
+	suite.Run(t, new(tracedComponentTestSuite))
+}
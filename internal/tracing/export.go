@@ -0,0 +1,370 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+)
+
+const (
+	queueSize      = 4096
+	maxBatchSize   = 512
+	exportInterval = 5 * time.Second
+	exportTimeout  = 10 * time.Second
+)
+
+// exporter : Destination of ended spans
+type exporter interface {
+	name() string
+	export(payload []byte) error
+	close() error
+}
+
+// processor : Queue ended spans and export them in batches from background, so that ending a span never blocks.
+// Spans are dropped when the exporter can not keep up.
+type processor struct {
+	exp      exporter
+	resource otlpResource
+	queue    chan *Span
+	dropped  atomic.Uint64
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newProcessor(exp exporter, resource otlpResource) *processor {
+	p := &processor{
+		exp:      exp,
+		resource: resource,
+		queue:    make(chan *Span, queueSize),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	go p.run()
+	return p
+}
+
+func (p *processor) enqueue(s *Span) {
+	select {
+	case p.queue <- s:
+	default:
+		p.dropped.Add(1)
+	}
+}
+
+func (p *processor) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, maxBatchSize)
+	for {
+		select {
+		case s := <-p.queue:
+			batch = append(batch, s)
+			if len(batch) == maxBatchSize {
+				batch = p.flush(batch)
+			}
+		case <-ticker.C:
+			batch = p.flush(batch)
+		case <-p.stop:
+			for {
+				select {
+				case s := <-p.queue:
+					batch = append(batch, s)
+					if len(batch) == maxBatchSize {
+						batch = p.flush(batch)
+					}
+				default:
+					p.flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush : Export the batch and return it emptied for reuse
+func (p *processor) flush(batch []*Span) []*Span {
+	if dropped := p.dropped.Swap(0); dropped > 0 {
+		log.Warn("tracing::flush : Dropped %d spans as the %s exporter is not keeping up", dropped, p.exp.name())
+	}
+
+	if len(batch) == 0 {
+		return batch
+	}
+
+	payload, err := json.Marshal(p.request(batch))
+	if err == nil {
+		err = p.exp.export(payload)
+	}
+
+	if err != nil {
+		log.Err("tracing::flush : Failed to export %d spans through %s exporter [%s]", len(batch), p.exp.name(), err.Error())
+	}
+
+	clear(batch)
+	return batch[:0]
+}
+
+func (p *processor) shutdown() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+		<-p.done
+
+		err := p.exp.close()
+		if err != nil {
+			log.Err("tracing::shutdown : Failed to close %s exporter [%s]", p.exp.name(), err.Error())
+		}
+	})
+}
+
+// ---------------------------------------------------------------------------------------------------------------------------------------------------
+// OTLP/JSON encoding of spans, see opentelemetry-proto ExportTraceServiceRequest
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              Kind           `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+func newResource(serviceName string) otlpResource {
+	hostname, _ := os.Hostname()
+	return otlpResource{
+		Attributes: encodeAttributes([]Attribute{
+			String("service.name", serviceName),
+			String("service.version", common.Blobfuse2Version),
+			String("host.name", hostname),
+			Int("process.pid", int64(os.Getpid())),
+			String("blobfuse2.mount_path", common.MountPath),
+		}),
+	}
+}
+
+func (p *processor) request(batch []*Span) otlpRequest {
+	spans := make([]otlpSpan, 0, len(batch))
+	for _, s := range batch {
+		span := otlpSpan{
+			TraceID:           hex.EncodeToString(s.traceID[:]),
+			SpanID:            hex.EncodeToString(s.spanID[:]),
+			Name:              s.name,
+			Kind:              s.kind,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Attributes:        encodeAttributes(s.attrs),
+			Status:            otlpStatus{Code: s.status, Message: s.message},
+		}
+
+		if s.parentID != [8]byte{} {
+			span.ParentSpanID = hex.EncodeToString(s.parentID[:])
+		}
+		spans = append(spans, span)
+	}
+
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: p.resource,
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: common.FileSystemName, Version: common.Blobfuse2Version},
+				Spans: spans,
+			}},
+		}},
+	}
+}
+
+func encodeAttributes(attrs []Attribute) []otlpKeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	kvs := make([]otlpKeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		var value otlpAnyValue
+		switch v := attr.Value.(type) {
+		case string:
+			value.StringValue = &v
+		case int64:
+			i := strconv.FormatInt(v, 10)
+			value.IntValue = &i
+		case int:
+			i := strconv.Itoa(v)
+			value.IntValue = &i
+		case bool:
+			value.BoolValue = &v
+		case float64:
+			value.DoubleValue = &v
+		default:
+			s := fmt.Sprint(v)
+			value.StringValue = &s
+		}
+		kvs = append(kvs, otlpKeyValue{Key: attr.Key, Value: value})
+	}
+
+	return kvs
+}
+
+// ---------------------------------------------------------------------------------------------------------------------------------------------------
+// Exporter posting spans to the OTLP/HTTP endpoint of a collector
+
+type otlpExporter struct {
+	endpoint string
+	client   *http.Client
+}
+
+func newOTLPExporter(endpoint string) *otlpExporter {
+	return &otlpExporter{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: exportTimeout},
+	}
+}
+
+func (e *otlpExporter) name() string {
+	return ExporterOTLP
+}
+
+func (e *otlpExporter) export(payload []byte) error {
+	resp, err := e.client.Post(e.endpoint, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("collector at %s responded %s", e.endpoint, resp.Status)
+	}
+
+	return nil
+}
+
+func (e *otlpExporter) close() error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+// ---------------------------------------------------------------------------------------------------------------------------------------------------
+// Exporter appending spans to a local file, one OTLP/JSON request per line as read by the otlpjson file receiver of
+// the collector
+
+type fileExporter struct {
+	path string
+	file *os.File
+}
+
+func newFileExporter(path string) (*fileExporter, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create directory for traces file %s [%s]", path, err.Error())
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open traces file %s [%s]", path, err.Error())
+	}
+
+	return &fileExporter{path: path, file: f}, nil
+}
+
+func (e *fileExporter) name() string {
+	return ExporterFile
+}
+
+func (e *fileExporter) export(payload []byte) error {
+	_, err := e.file.Write(append(payload, '\n'))
+	return err
+}
+
+func (e *fileExporter) close() error {
+	return e.file.Close()
+}
@@ -0,0 +1,313 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package tracing
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+)
+
+// Spans of file system operations as they travel through the pipeline, exported in the OTLP/JSON format either to an
+// OpenTelemetry collector over HTTP or to a local file. Component methods carry no context, so the span in progress
+// is tracked per goroutine: a span started while another is in progress on the same goroutine becomes its child.
+// Work handed to other goroutines, e.g. prefetch in block_cache, starts traces of its own.
+// Only spans of sampled traces are tracked, and the sampling decision of a trace is taken before its goroutine is
+// looked up, so that operations which are not sampled cost no more than a random draw. With a sample ratio below 1
+// traces are started by fuse operations only, as a call found without a sampled parent may belong to an operation
+// which was dropped.
+
+const (
+	ExporterOTLP = "otlp"
+	ExporterFile = "file"
+
+	// DefaultEndpoint : OTLP/HTTP traces endpoint of a collector running on this node
+	DefaultEndpoint = "http://localhost:4318/v1/traces"
+)
+
+// Config : Tracing section of the config file
+type Config struct {
+	Enabled     bool    `config:"enabled" yaml:"enabled,omitempty"`
+	Exporter    string  `config:"exporter" yaml:"exporter,omitempty"`
+	Endpoint    string  `config:"endpoint" yaml:"endpoint,omitempty"`
+	FilePath    string  `config:"file-path" yaml:"file-path,omitempty"`
+	SampleRatio float64 `config:"sample-ratio" yaml:"sample-ratio,omitempty"`
+	ServiceName string  `config:"service-name" yaml:"service-name,omitempty"`
+}
+
+// Kind : Role of the span in the trace, values match SpanKind of OTLP
+type Kind int
+
+const (
+	KindInternal Kind = 1
+	KindServer   Kind = 2
+	KindClient   Kind = 3
+)
+
+const (
+	statusOk    = 1
+	statusError = 2
+)
+
+// Attribute : Key value pair describing a span
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// String : Attribute with a string value
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int : Attribute with an integer value
+func Int(key string, value int64) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Span : A timed operation within a trace. All methods are no-op on a nil span, which is what Start returns while
+// tracing is disabled.
+type Span struct {
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	name     string
+	kind     Kind
+	start    time.Time
+	end      time.Time
+	attrs    []Attribute
+	status   int
+	message  string
+
+	goid uint64
+	prev *Span // Span in progress on the goroutine when this one started
+}
+
+var (
+	enabled     atomic.Bool
+	sampleRatio float64
+	proc        *processor
+
+	// Span in progress per goroutine id, and the number of spans in progress
+	active     sync.Map
+	inProgress atomic.Int64
+)
+
+// Enabled : Whether spans are being recorded
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Init : Start recording spans and exporting them as per the config
+func Init(cfg Config) error {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return fmt.Errorf("invalid sample-ratio %v, it shall be between 0 and 1", cfg.SampleRatio)
+	}
+
+	if cfg.SampleRatio == 0 {
+		cfg.SampleRatio = 1
+	}
+
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = common.FileSystemName
+	}
+
+	var exp exporter
+	var err error
+
+	switch cfg.Exporter {
+	case "", ExporterOTLP:
+		if cfg.Endpoint == "" {
+			cfg.Endpoint = DefaultEndpoint
+		}
+		exp = newOTLPExporter(cfg.Endpoint)
+	case ExporterFile:
+		if cfg.FilePath == "" {
+			cfg.FilePath = filepath.Join(common.DefaultWorkDir, "traces.json")
+		}
+		exp, err = newFileExporter(common.ExpandPath(cfg.FilePath))
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid exporter %s, it shall be %s or %s", cfg.Exporter, ExporterOTLP, ExporterFile)
+	}
+
+	sampleRatio = cfg.SampleRatio
+	proc = newProcessor(exp, newResource(cfg.ServiceName))
+	enabled.Store(true)
+
+	log.Info("tracing::Init : Exporting spans through %s exporter, sample ratio %v", exp.name(), sampleRatio)
+	return nil
+}
+
+// Shutdown : Stop recording spans and export the ones still queued
+func Shutdown() {
+	if !enabled.Swap(false) {
+		return
+	}
+
+	proc.shutdown()
+}
+
+// Start : Start a span, child of the one in progress on the calling goroutine if any. The span must be ended on the
+// goroutine that started it. Nil is returned when the trace of the span is not sampled.
+func Start(name string, kind Kind, attrs ...Attribute) *Span {
+	if !enabled.Load() {
+		return nil
+	}
+
+	// While no span is in progress the span cannot have a parent, so the goroutine is looked up only for the spans
+	// of sampled traces
+	var goid uint64
+	var parent *Span
+	if inProgress.Load() > 0 {
+		goid = common.GetGoroutineID()
+		if p, ok := active.Load(goid); ok {
+			parent = p.(*Span)
+		}
+	}
+
+	if parent == nil {
+		if !sampleRoot(kind) {
+			return nil
+		}
+		if goid == 0 {
+			goid = common.GetGoroutineID()
+		}
+	}
+
+	s := &Span{
+		name:  name,
+		kind:  kind,
+		start: time.Now(),
+		attrs: attrs,
+		goid:  goid,
+		prev:  parent,
+	}
+
+	if parent != nil {
+		s.traceID = parent.traceID
+		s.parentID = parent.spanID
+	} else {
+		fillRandom(s.traceID[:])
+	}
+
+	fillRandom(s.spanID[:])
+	active.Store(goid, s)
+	inProgress.Add(1)
+	return s
+}
+
+// sampleRoot : Whether a span without a parent starts a trace that is recorded
+func sampleRoot(kind Kind) bool {
+	if sampleRatio >= 1 {
+		return true
+	}
+	return kind == KindServer && rand.Float64() < sampleRatio
+}
+
+// SetAttributes : Add attributes to the span
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// End : Complete the span, a non nil err marks the operation as failed
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+
+	s.end = time.Now()
+	if err != nil {
+		s.status = statusError
+		s.message = err.Error()
+	} else {
+		s.status = statusOk
+	}
+
+	if s.prev != nil {
+		active.Store(s.goid, s.prev)
+	} else {
+		active.Delete(s.goid)
+	}
+	inProgress.Add(-1)
+
+	if enabled.Load() {
+		proc.enqueue(s)
+	}
+}
+
+// TraceID : Hex encoded id of the trace the span belongs to
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+
+	return hex.EncodeToString(s.traceID[:])
+}
+
+func fillRandom(b []byte) {
+	for {
+		for i := 0; i < len(b); i += 8 {
+			v := rand.Uint64()
+			for j := i; j < len(b) && j < i+8; j++ {
+				b[j] = byte(v)
+				v >>= 8
+			}
+		}
+
+		// All zero ids are invalid in OTLP
+		for _, c := range b {
+			if c != 0 {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,248 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package tracing
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type tracingTestSuite struct {
+	suite.Suite
+	assert *assert.Assertions
+}
+
+func (suite *tracingTestSuite) SetupTest() {
+	err := log.SetDefaultLogger("silent", common.LogConfig{})
+	if err != nil {
+		panic("Unable to set silent logger as default.")
+	}
+	suite.assert = assert.New(suite.T())
+}
+
+func (suite *tracingTestSuite) TearDownTest() {
+	Shutdown()
+}
+
+// readSpans : Spans written by the file exporter, keyed by name
+func (suite *tracingTestSuite) readSpans(path string) map[string]otlpSpan {
+	f, err := os.Open(path)
+	suite.Require().NoError(err)
+	defer f.Close()
+
+	spans := make(map[string]otlpSpan)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		var req otlpRequest
+		suite.Require().NoError(json.Unmarshal(scanner.Bytes(), &req))
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, s := range ss.Spans {
+					spans[s.Name] = s
+				}
+			}
+		}
+	}
+	suite.Require().NoError(scanner.Err())
+	return spans
+}
+
+func (suite *tracingTestSuite) TestDisabled() {
+	suite.assert.False(Enabled())
+	suite.assert.NoError(Init(Config{}))
+	suite.assert.False(Enabled())
+
+	span := Start("op", KindInternal)
+	suite.assert.Nil(span)
+
+	// Methods of a nil span are no-op
+	span.SetAttributes(String("key", "value"))
+	span.End(errors.New("failed"))
+	suite.assert.Empty(span.TraceID())
+}
+
+func (suite *tracingTestSuite) TestInvalidConfig() {
+	suite.assert.Error(Init(Config{Enabled: true, SampleRatio: 2}))
+	suite.assert.Error(Init(Config{Enabled: true, Exporter: "jaeger"}))
+	suite.assert.False(Enabled())
+}
+
+func (suite *tracingTestSuite) TestFileExporter() {
+	path := filepath.Join(suite.T().TempDir(), "traces.json")
+	suite.Require().NoError(Init(Config{Enabled: true, Exporter: ExporterFile, FilePath: path}))
+	suite.assert.True(Enabled())
+
+	root := Start("fuse.read", KindServer)
+	child := Start("block_cache.ReadInBuffer", KindInternal, String("path", "dir/file"), Int("offset", 4096))
+	grandChild := Start("HTTP GET", KindClient)
+	grandChild.End(nil)
+	child.End(errors.New("download failed"))
+
+	// Back at the root, a new span is a sibling of the ended one
+	sibling := Start("attr_cache.GetAttr", KindInternal)
+	sibling.End(nil)
+	root.End(nil)
+
+	// Nothing in progress any more, so this one starts a trace of its own
+	other := Start("fuse.getattr", KindServer)
+	other.End(nil)
+
+	suite.assert.Equal(root.TraceID(), grandChild.TraceID())
+	suite.assert.NotEqual(root.TraceID(), other.TraceID())
+
+	Shutdown()
+	suite.assert.False(Enabled())
+
+	spans := suite.readSpans(path)
+	suite.Require().Len(spans, 5)
+
+	rootSpan := spans["fuse.read"]
+	suite.assert.Empty(rootSpan.ParentSpanID)
+	suite.assert.Equal(KindServer, rootSpan.Kind)
+	suite.assert.Equal(statusOk, rootSpan.Status.Code)
+	suite.assert.Len(rootSpan.TraceID, 32)
+	suite.assert.Len(rootSpan.SpanID, 16)
+
+	childSpan := spans["block_cache.ReadInBuffer"]
+	suite.assert.Equal(rootSpan.SpanID, childSpan.ParentSpanID)
+	suite.assert.Equal(rootSpan.TraceID, childSpan.TraceID)
+	suite.assert.Equal(statusError, childSpan.Status.Code)
+	suite.assert.Equal("download failed", childSpan.Status.Message)
+	suite.Require().Len(childSpan.Attributes, 2)
+	suite.assert.Equal("dir/file", *childSpan.Attributes[0].Value.StringValue)
+	suite.assert.Equal("4096", *childSpan.Attributes[1].Value.IntValue)
+
+	suite.assert.Equal(childSpan.SpanID, spans["HTTP GET"].ParentSpanID)
+	suite.assert.Equal(rootSpan.SpanID, spans["attr_cache.GetAttr"].ParentSpanID)
+	suite.assert.Empty(spans["fuse.getattr"].ParentSpanID)
+	suite.assert.LessOrEqual(rootSpan.StartTimeUnixNano, rootSpan.EndTimeUnixNano)
+}
+
+func (suite *tracingTestSuite) TestGoroutinesTracedApart() {
+	path := filepath.Join(suite.T().TempDir(), "traces.json")
+	suite.Require().NoError(Init(Config{Enabled: true, Exporter: ExporterFile, FilePath: path}))
+
+	root := Start("root", KindServer)
+
+	var other *Span
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		other = Start("worker", KindInternal)
+		other.End(nil)
+	}()
+	wg.Wait()
+	root.End(nil)
+
+	suite.assert.NotEqual(root.TraceID(), other.TraceID())
+}
+
+func (suite *tracingTestSuite) TestSampling() {
+	path := filepath.Join(suite.T().TempDir(), "traces.json")
+	suite.Require().NoError(Init(Config{Enabled: true, Exporter: ExporterFile, FilePath: path, SampleRatio: 0.000001}))
+
+	for range 100 {
+		root := Start("root", KindServer)
+		child := Start("child", KindInternal)
+		suite.assert.Nil(root)
+		suite.assert.Nil(child)
+		child.End(nil)
+		root.End(nil)
+	}
+
+	// Spans of traces that are not sampled are not tracked
+	suite.assert.Zero(inProgress.Load())
+	active.Range(func(key, value any) bool {
+		suite.assert.Fail("span of a dropped trace tracked", "goroutine %v", key)
+		return true
+	})
+	Shutdown()
+
+	// Children follow the decision of the root, so whole traces are either kept or dropped
+	spans := suite.readSpans(path)
+	suite.assert.Empty(spans)
+}
+
+func (suite *tracingTestSuite) TestOTLPExporter() {
+	var mu sync.Mutex
+	var requests []otlpRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		suite.assert.Equal(http.MethodPost, r.Method)
+		suite.assert.Equal("application/json", r.Header.Get("Content-Type"))
+
+		body, err := io.ReadAll(r.Body)
+		suite.assert.NoError(err)
+
+		var req otlpRequest
+		suite.assert.NoError(json.Unmarshal(body, &req))
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	suite.Require().NoError(Init(Config{Enabled: true, Exporter: ExporterOTLP, Endpoint: server.URL + "/v1/traces", ServiceName: "test-mount"}))
+	span := Start("fuse.open", KindServer)
+	span.End(nil)
+	Shutdown()
+
+	mu.Lock()
+	defer mu.Unlock()
+	suite.Require().Len(requests, 1)
+	rs := requests[0].ResourceSpans[0]
+	suite.assert.Equal("service.name", rs.Resource.Attributes[0].Key)
+	suite.assert.Equal("test-mount", *rs.Resource.Attributes[0].Value.StringValue)
+	suite.assert.Equal(common.FileSystemName, rs.ScopeSpans[0].Scope.Name)
+	suite.Require().Len(rs.ScopeSpans[0].Spans, 1)
+	suite.assert.Equal("fuse.open", rs.ScopeSpans[0].Spans[0].Name)
+}
+
+func TestTracingTestSuite(t *testing.T) {
+	suite.Run(t, new(tracingTestSuite))
+}
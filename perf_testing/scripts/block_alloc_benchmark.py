@@ -0,0 +1,168 @@
+#!/usr/bin/env python3
+
+import argparse
+import json
+import os
+import re
+import statistics
+import subprocess
+import sys
+from datetime import datetime, timezone
+from pathlib import Path
+from typing import Any
+
+
+REPO_ROOT = Path(__file__).resolve().parents[2]
+BENCHMARK = "BenchmarkBufferAllocator"
+BENCHMARK_LINE = re.compile(
+    rf"^{BENCHMARK}/(?P<name>\S+)\s+(?P<iterations>\d+)\s+(?P<ns>[\d.]+) ns/op\s+(?P<mbs>[\d.]+) MB/s"
+)
+FALLBACK_LINE = re.compile(r"^\s+\S+\.go:\d+: fallback: (?P<reason>.+)$")
+
+
+def utc_now() -> str:
+    return datetime.now(timezone.utc).isoformat().replace("+00:00", "Z")
+
+
+def read_text(path: Path) -> str:
+    try:
+        return path.read_text(encoding="utf-8").strip()
+    except OSError:
+        return "unknown"
+
+
+def meminfo_value(meminfo: str, key: str) -> str:
+    for line in meminfo.splitlines():
+        if line.startswith(f"{key}:"):
+            return line.split(":", 1)[1].strip()
+    return "unknown"
+
+
+def host_environment(proc_root: Path = Path("/proc"), sys_root: Path = Path("/sys")) -> dict[str, Any]:
+    meminfo = read_text(proc_root / "meminfo")
+    return {
+        "cpu_count": os.cpu_count(),
+        "numa_nodes": read_text(sys_root / "devices/system/node/has_memory"),
+        "hugepages_total": meminfo_value(meminfo, "HugePages_Total"),
+        "hugepage_size": meminfo_value(meminfo, "Hugepagesize"),
+        "transparent_hugepage": read_text(sys_root / "kernel/mm/transparent_hugepage/enabled"),
+    }
+
+
+def parse_output(output: str, cpu: int) -> dict[str, dict[str, Any]]:
+    # go test appends -<GOMAXPROCS> to the name unless it is 1, strip it so modes like none-local stay intact
+    suffix = f"-{cpu}" if cpu > 1 else ""
+    modes: dict[str, dict[str, Any]] = {}
+    current = None
+    for line in output.splitlines():
+        match = BENCHMARK_LINE.match(line)
+        if match:
+            name = match["name"]
+            if suffix and name.endswith(suffix):
+                name = name[: -len(suffix)]
+            mode = modes.setdefault(name, {"ns_per_op": [], "mb_per_s": [], "fallbacks": []})
+            mode["ns_per_op"].append(float(match["ns"]))
+            mode["mb_per_s"].append(float(match["mbs"]))
+            continue
+
+        if line.startswith(f"--- BENCH: {BENCHMARK}/"):
+            current = line.split("/", 1)[1].strip()
+            if suffix and current.endswith(suffix):
+                current = current[: -len(suffix)]
+            continue
+
+        fallback = FALLBACK_LINE.match(line)
+        if fallback and current is not None:
+            reasons = modes.setdefault(current, {"ns_per_op": [], "mb_per_s": [], "fallbacks": []})["fallbacks"]
+            if fallback["reason"] not in reasons:
+                reasons.append(fallback["reason"])
+    return modes
+
+
+def summarize(modes: dict[str, dict[str, Any]]) -> list[dict[str, Any]]:
+    baseline = None
+    if modes.get("none", {}).get("mb_per_s"):
+        baseline = statistics.median(modes["none"]["mb_per_s"])
+
+    results = []
+    for name, mode in modes.items():
+        if not mode["mb_per_s"]:
+            continue
+        throughput = statistics.median(mode["mb_per_s"])
+        results.append(
+            {
+                "mode": name,
+                "samples": len(mode["mb_per_s"]),
+                "ns_per_op": statistics.median(mode["ns_per_op"]),
+                "throughput_mb_s": throughput,
+                "speedup": round(throughput / baseline, 3) if baseline else None,
+                "local_over_remote": None,
+                "fallbacks": mode["fallbacks"],
+            }
+        )
+
+    # A block copied within the node the worker runs on against one copied from another node
+    throughputs = {result["mode"]: result["throughput_mb_s"] for result in results}
+    for result in results:
+        if result["mode"].endswith("-local"):
+            remote = throughputs.get(result["mode"].removesuffix("-local") + "-remote")
+            if remote:
+                result["local_over_remote"] = round(result["throughput_mb_s"] / remote, 3)
+    return results
+
+
+def render_markdown(report: dict[str, Any]) -> str:
+    lines = [
+        "| Mode | Copy (MB/s) | Speedup over none | Local over remote | Fallback |",
+        "| --- | ---: | ---: | ---: | --- |",
+    ]
+    for result in report["results"]:
+        speedup = "n/a" if result["speedup"] is None else f"{result['speedup']:.2f}x"
+        local = "n/a" if result["local_over_remote"] is None else f"{result['local_over_remote']:.2f}x"
+        fallback = "; ".join(result["fallbacks"]) or "none"
+        lines.append(f"| {result['mode']} | {result['throughput_mb_s']:.0f} | {speedup} | {local} | {fallback} |")
+    lines.append("")
+    return "\n".join(lines)
+
+
+def parse_args() -> argparse.Namespace:
+    parser = argparse.ArgumentParser(description="Benchmark huge page and NUMA placement of Blobfuse2 block buffers")
+    parser.add_argument("--repo", type=Path, default=REPO_ROOT)
+    parser.add_argument("--benchtime", default="50x")
+    parser.add_argument("--count", type=int, default=5)
+    parser.add_argument("--cpu", type=int, default=os.cpu_count() or 1)
+    parser.add_argument("--output", type=Path)
+    return parser.parse_args()
+
+
+def main() -> int:
+    args = parse_args()
+    command = [
+        "go", "test", "-run", "^$", "-bench", f"^{BENCHMARK}$",
+        "-benchtime", args.benchtime, "-count", str(args.count), "-cpu", str(args.cpu), "./common/",
+    ]
+    try:
+        completed = subprocess.run(command, cwd=args.repo, capture_output=True, text=True, check=True)
+        report = {
+            "schema_version": 1,
+            "generated_at": utc_now(),
+            "environment": host_environment(),
+            "results": summarize(parse_output(completed.stdout, args.cpu)),
+        }
+        if not report["results"]:
+            raise ValueError(f"No {BENCHMARK} results in go test output")
+        if args.output:
+            args.output.parent.mkdir(parents=True, exist_ok=True)
+            args.output.write_text(json.dumps(report, indent=2) + "\n", encoding="utf-8")
+        print(render_markdown(report))
+        return 0
+    except subprocess.CalledProcessError as error:
+        print(f"Benchmark failed: {error.stdout}{error.stderr}", file=sys.stderr)
+        return 1
+    except Exception as error:
+        print(f"Benchmark failed: {error}", file=sys.stderr)
+        return 1
+
+
+if __name__ == "__main__":
+    sys.exit(main())
@@ -24,6 +24,7 @@ runner = load_module("run_benchmark", "perf_testing/scripts/run_benchmark.py")
 comparator = load_module("compare_benchmarks", "perf_testing/scripts/compare_benchmarks.py")
 publisher = load_module("publish_benchmarks", "perf_testing/scripts/publish_benchmarks.py")
 regression_checker = load_module("check_regressions", "perf_testing/scripts/check_regressions.py")
+alloc_benchmark = load_module("block_alloc_benchmark", "perf_testing/scripts/block_alloc_benchmark.py")
 
 
 def make_summary(
@@ -1058,5 +1059,59 @@ class PublicationTests(unittest.TestCase):
             self.assertTrue((site / "developer/index.html").exists())
 
 
+
+class BlockAllocBenchmarkTests(unittest.TestCase):
+    OUTPUT = "\n".join(
+        [
+            "goos: linux",
+            "BenchmarkBufferAllocator/none-4         \t      50\t   8000000 ns/op\t2000.00 MB/s",
+            "BenchmarkBufferAllocator/none-4         \t      50\t  10000000 ns/op\t1600.00 MB/s",
+            "BenchmarkBufferAllocator/hugetlb-4      \t      50\t   2000000 ns/op\t8000.00 MB/s",
+            "--- BENCH: BenchmarkBufferAllocator/hugetlb-4",
+            "    buffer_alloc_test.go:208: fallback: failed to map huge pages [cannot allocate memory]",
+            "BenchmarkBufferAllocator/none-local-4   \t      50\t   8000000 ns/op\t2000.00 MB/s",
+            "BenchmarkBufferAllocator/none-remote-4  \t      50\t  12500000 ns/op\t1280.00 MB/s",
+            "PASS",
+        ]
+    )
+
+    def test_parser_strips_gomaxprocs_suffix_only(self):
+        modes = alloc_benchmark.parse_output(self.OUTPUT, 4)
+
+        self.assertEqual(list(modes), ["none", "hugetlb", "none-local", "none-remote"])
+        self.assertEqual(modes["none"]["mb_per_s"], [2000.0, 1600.0])
+        self.assertEqual(modes["hugetlb"]["fallbacks"], ["failed to map huge pages [cannot allocate memory]"])
+
+    def test_summary_reports_speedup_over_regular_pages(self):
+        results = {item["mode"]: item for item in alloc_benchmark.summarize(alloc_benchmark.parse_output(self.OUTPUT, 4))}
+
+        self.assertEqual(results["none"]["throughput_mb_s"], 1800.0)
+        self.assertEqual(results["none"]["speedup"], 1.0)
+        self.assertEqual(results["hugetlb"]["speedup"], 4.444)
+        self.assertEqual(results["none-local"]["samples"], 1)
+
+    def test_summary_reports_local_over_remote_node(self):
+        results = {item["mode"]: item for item in alloc_benchmark.summarize(alloc_benchmark.parse_output(self.OUTPUT, 4))}
+
+        self.assertEqual(results["none-local"]["local_over_remote"], 1.562)
+        self.assertIsNone(results["none-remote"]["local_over_remote"])
+        self.assertIsNone(results["none"]["local_over_remote"])
+        self.assertIn("| none-local | 2000 | 1.11x | 1.56x | none |", alloc_benchmark.render_markdown({"results": list(results.values())}))
+
+    def test_environment_reports_hugepage_and_numa_setup(self):
+        with tempfile.TemporaryDirectory() as temporary:
+            root = Path(temporary)
+            (root / "proc").mkdir()
+            (root / "proc/meminfo").write_text("HugePages_Total:      64\nHugepagesize:       2048 kB\n")
+            (root / "sys/devices/system/node").mkdir(parents=True)
+            (root / "sys/devices/system/node/has_memory").write_text("0-1\n")
+
+            environment = alloc_benchmark.host_environment(root / "proc", root / "sys")
+
+        self.assertEqual(environment["hugepages_total"], "64")
+        self.assertEqual(environment["hugepage_size"], "2048 kB")
+        self.assertEqual(environment["numa_nodes"], "0-1")
+        self.assertEqual(environment["transparent_hugepage"], "unknown")
+
 if __name__ == "__main__":
     unittest.main()
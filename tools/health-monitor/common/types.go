@@ -44,6 +44,9 @@ const (
 	MemoryProfiler    = "memory_profiler"
 	CpuMemoryProfiler = "cpu_mem_profiler"
 	NetworkProfiler   = "network_profiler"
+	DiskIOMonitor     = "disk_io_monitor"
+	FuseQueueMonitor  = "fuse_queue_monitor"
+	FdMonitor         = "fd_monitor"
 
 	BfuseMon = "bfusemon"
 
@@ -51,6 +54,30 @@ const (
 	OutputFileExtension = "json"
 	OutputFileCount     = 10
 	OutputFileSizeinMB  = 10
+
+	JSONExporter        = "json"
+	OpenMetricsExporter = "openmetrics"
+
+	DefaultOpenMetricsAddress = "localhost:9465"
+)
+
+// Signals alert rules can watch
+const (
+	CacheUsagePercent           = "cache_usage_percent"
+	CpuPercent                  = "cpu_percent"
+	MemoryBytes                 = "memory_bytes"
+	ErrorsPerMin                = "errors_per_min"
+	SecondsSinceStorageSuccess  = "seconds_since_storage_success"
+	OpenFdsPercent              = "open_fds_percent"
+	FuseRequestsWaiting         = "fuse_requests_waiting"
+	CacheDiskUtilizationPercent = "cache_disk_utilization_percent"
+)
+
+// Actions fired by alert rules
+const (
+	LogAction     = "log"
+	WebhookAction = "webhook"
+	ScriptAction  = "script"
 )
 
 var (
@@ -63,10 +90,26 @@ var (
 	NoMemProf      bool
 	NoNetProf      bool
 	NoFileCacheMon bool
+	NoDiskIOMon    bool
+	NoFuseQueueMon bool
+	NoFdMon        bool
 
 	TempCachePath string
 	MaxCacheSize  float64
 	OutputPath    string
+	MountPath     string
+
+	// Roots of procfs and sysfs, changed by tests to read from fake trees
+	ProcPath = "/proc"
+	SysPath  = "/sys"
+
+	Exporters          string
+	ExportJSON         bool
+	ExportOpenMetrics  bool
+	OpenMetricsAddress string
+
+	AlertRulesJSON string
+	AlertRules     []AlertRule
 
 	CheckVersion bool
 )
@@ -91,3 +134,45 @@ type CpuMemStat struct {
 	CpuUsage string
 	MemUsage string
 }
+
+// I/O on the disk holding the cache path, rates are over the last poll interval
+type DiskIOStat struct {
+	Device             string  `json:"device"`
+	ReadBytesPerSec    float64 `json:"readBytesPerSec"`
+	WriteBytesPerSec   float64 `json:"writeBytesPerSec"`
+	ReadsPerSec        float64 `json:"readsPerSec"`
+	WritesPerSec       float64 `json:"writesPerSec"`
+	UtilizationPercent float64 `json:"utilizationPercent"`
+	IOInProgress       uint64  `json:"ioInProgress"`
+}
+
+// Queue of the fuse connection of the mount
+type FuseQueueStat struct {
+	Connection          string `json:"connection"`
+	Waiting             uint64 `json:"waiting"`
+	MaxBackground       uint64 `json:"maxBackground"`
+	CongestionThreshold uint64 `json:"congestionThreshold"`
+}
+
+// Open file descriptors of the blobfuse2 process
+type FdStat struct {
+	Open         uint64  `json:"open"`
+	SoftLimit    uint64  `json:"softLimit"`
+	UsagePercent float64 `json:"usagePercent"`
+}
+
+// Rule firing its actions once the signal has crossed the threshold for the given duration, and again when it recovers
+type AlertRule struct {
+	Name      string        `config:"name" json:"name"`
+	Metric    string        `config:"metric" json:"metric"`
+	Op        string        `config:"op" json:"op"`
+	Threshold float64       `config:"threshold" json:"threshold"`
+	ForSec    int           `config:"for-sec" json:"forSec,omitempty"`
+	Actions   []AlertAction `config:"actions" json:"actions"`
+}
+
+type AlertAction struct {
+	Type   string `config:"type" json:"type"`
+	URL    string `config:"url" json:"url,omitempty"`
+	Script string `config:"script" json:"script,omitempty"`
+}
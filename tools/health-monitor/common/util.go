@@ -34,8 +34,12 @@
 package common
 
 import (
+	"encoding/json"
 	"fmt"
+	"net"
+	"net/url"
 	"os/exec"
+	"slices"
 	"strings"
 	"time"
 
@@ -76,3 +80,118 @@ func MonitorPid() {
 		}
 	}
 }
+
+// parse the comma separated list of exporters to enable
+func ParseExporters(list string) error {
+	ExportJSON, ExportOpenMetrics = false, false
+
+	for exp := range strings.SplitSeq(list, ",") {
+		switch strings.TrimSpace(exp) {
+		case JSONExporter:
+			ExportJSON = true
+		case OpenMetricsExporter:
+			ExportOpenMetrics = true
+		case "":
+		default:
+			return fmt.Errorf("invalid exporter %v, it shall be %v or %v", exp, JSONExporter, OpenMetricsExporter)
+		}
+	}
+
+	if !ExportJSON && !ExportOpenMetrics {
+		return fmt.Errorf("no exporter given")
+	}
+
+	return nil
+}
+
+// parse the json list of alert rules and validate them
+func ParseAlertRules(rules string) error {
+	AlertRules = nil
+	if strings.TrimSpace(rules) == "" {
+		return nil
+	}
+
+	err := json.Unmarshal([]byte(rules), &AlertRules)
+	if err != nil {
+		return fmt.Errorf("invalid alert rules [%v]", err)
+	}
+
+	names := make(map[string]bool)
+	for _, r := range AlertRules {
+		if r.Name == "" {
+			return fmt.Errorf("alert rule without a name")
+		}
+		if names[r.Name] {
+			return fmt.Errorf("duplicate alert rule %v", r.Name)
+		}
+		names[r.Name] = true
+
+		err = validateAlertRule(r)
+		if err != nil {
+			return fmt.Errorf("alert rule %v : %v", r.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func validateAlertRule(r AlertRule) error {
+	metrics := []string{CacheUsagePercent, CpuPercent, MemoryBytes, ErrorsPerMin, SecondsSinceStorageSuccess,
+		OpenFdsPercent, FuseRequestsWaiting, CacheDiskUtilizationPercent}
+	if !slices.Contains(metrics, r.Metric) {
+		return fmt.Errorf("invalid metric %v, it shall be one of %v", r.Metric, strings.Join(metrics, ", "))
+	}
+
+	if !slices.Contains([]string{">", ">=", "<", "<="}, r.Op) {
+		return fmt.Errorf("invalid op %v, it shall be one of >, >=, <, <=", r.Op)
+	}
+
+	if r.ForSec < 0 {
+		return fmt.Errorf("for-sec shall not be negative")
+	}
+
+	if len(r.Actions) == 0 {
+		return fmt.Errorf("no action given")
+	}
+
+	for _, a := range r.Actions {
+		switch a.Type {
+		case LogAction:
+		case WebhookAction:
+			err := validateWebhookURL(a.URL)
+			if err != nil {
+				return err
+			}
+		case ScriptAction:
+			if a.Script == "" {
+				return fmt.Errorf("script action without a script")
+			}
+		default:
+			return fmt.Errorf("invalid action %v, it shall be %v, %v or %v", a.Type, LogAction, WebhookAction, ScriptAction)
+		}
+	}
+
+	return nil
+}
+
+// webhooks are only posted to the local host, so that alerts do not leave the node unless a local agent forwards them
+func validateWebhookURL(u string) error {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return fmt.Errorf("invalid webhook url %v [%v]", u, err)
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("webhook url %v shall be http or https", u)
+	}
+
+	host := parsed.Hostname()
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+
+	return fmt.Errorf("webhook url %v shall be on the local host", u)
+}
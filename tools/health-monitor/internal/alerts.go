@@ -0,0 +1,381 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/Azure/azure-storage-fuse/v2/internal/stats_manager"
+	hmcommon "github.com/Azure/azure-storage-fuse/v2/tools/health-monitor/common"
+)
+
+const (
+	alertEvalInterval = 5 * time.Second
+	webhookTimeout    = 5 * time.Second
+	scriptTimeout     = 30 * time.Second
+
+	// window over which failed operations are counted
+	errorWindow = time.Minute
+
+	libfuseComponent   = "libfuse"
+	azstorageComponent = "azstorage"
+
+	alertFiring   = "firing"
+	alertResolved = "resolved"
+)
+
+// AlertEvaluator checks the alert rules against the latest data of the monitors and fires their actions
+type AlertEvaluator struct {
+	sync.Mutex
+
+	rules  []hmcommon.AlertRule
+	states []alertState
+
+	// latest value of each signal, a signal is absent until its monitor reported it
+	signals map[string]float64
+
+	// cumulative failed operations of libfuse with the time they were reported, oldest first
+	failures []failureSample
+
+	// storage stats as last reported, to find out when a call to storage last succeeded
+	storageStats   map[string]float64
+	storageSeen    bool
+	storageSuccess time.Time
+
+	now     func() time.Time
+	client  *http.Client
+	done    chan struct{}
+	wg      sync.WaitGroup
+	actions sync.WaitGroup
+}
+
+type alertState struct {
+	pendingSince time.Time
+	firing       bool
+}
+
+type failureSample struct {
+	at    time.Time
+	count float64
+}
+
+// AlertPayload is posted to webhooks and given to scripts on stdin
+type AlertPayload struct {
+	Rule      string  `json:"rule"`
+	Metric    string  `json:"metric"`
+	Value     float64 `json:"value"`
+	Op        string  `json:"op"`
+	Threshold float64 `json:"threshold"`
+	State     string  `json:"state"`
+	Pid       string  `json:"pid"`
+	MountPath string  `json:"mountPath,omitempty"`
+	Timestamp string  `json:"timestamp"`
+}
+
+func newAlertEvaluator(rules []hmcommon.AlertRule) *AlertEvaluator {
+	ae := &AlertEvaluator{
+		rules:        rules,
+		states:       make([]alertState, len(rules)),
+		signals:      make(map[string]float64),
+		storageStats: make(map[string]float64),
+		now:          time.Now,
+		client: &http.Client{
+			Timeout: webhookTimeout,
+			// Webhooks are validated to be on the local host, a redirect would take the alert off it
+			CheckRedirect: func(_ *http.Request, _ []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		done: make(chan struct{}),
+	}
+	ae.storageSuccess = ae.now()
+
+	return ae
+}
+
+// evaluate the rules periodically, so that rules on time since the last event fire without new data
+func (ae *AlertEvaluator) start() {
+	ae.wg.Add(1)
+	go func() {
+		defer ae.wg.Done()
+
+		ticker := time.NewTicker(alertEvalInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ae.done:
+				return
+			case <-ticker.C:
+				ae.evaluate()
+			}
+		}
+	}()
+}
+
+// stop evaluating and wait for the actions already fired
+func (ae *AlertEvaluator) stop() {
+	close(ae.done)
+	ae.wg.Wait()
+	ae.actions.Wait()
+}
+
+// update the signals fed by the stat of the given monitor
+func (ae *AlertEvaluator) addMonitorStats(monName string, st any) {
+	ae.Lock()
+	defer ae.Unlock()
+
+	switch monName {
+	case hmcommon.BlobfuseStats:
+		ae.addBlobfuseStats(st.(stats_manager.PipeMsg))
+	case hmcommon.FileCacheMon:
+		if pct, err := parsePercent(st.(*hmcommon.CacheEvent).CacheConsumed); err == nil {
+			ae.signals[hmcommon.CacheUsagePercent] = pct
+		}
+	case hmcommon.CpuProfiler:
+		if v, err := parsePercent(st.(string)); err == nil {
+			ae.signals[hmcommon.CpuPercent] = v
+		}
+	case hmcommon.MemoryProfiler:
+		if v, err := parseBytes(st.(string)); err == nil {
+			ae.signals[hmcommon.MemoryBytes] = v
+		}
+	case hmcommon.DiskIOMonitor:
+		ae.signals[hmcommon.CacheDiskUtilizationPercent] = st.(*hmcommon.DiskIOStat).UtilizationPercent
+	case hmcommon.FuseQueueMonitor:
+		ae.signals[hmcommon.FuseRequestsWaiting] = float64(st.(*hmcommon.FuseQueueStat).Waiting)
+	case hmcommon.FdMonitor:
+		fd := st.(*hmcommon.FdStat)
+		if fd.SoftLimit != 0 {
+			ae.signals[hmcommon.OpenFdsPercent] = fd.UsagePercent
+		}
+	}
+}
+
+func (ae *AlertEvaluator) addBlobfuseStats(msg stats_manager.PipeMsg) {
+	switch msg.ComponentName {
+	case libfuseComponent:
+		if msg.Operation != "" {
+			return
+		}
+		count, _ := msg.Value[stats_manager.FailedOperations].(float64)
+		ae.addFailures(count)
+
+	case azstorageComponent:
+		// events are only pushed by storage once the call succeeded
+		if msg.Operation != "" {
+			ae.storageSuccess = ae.now()
+			return
+		}
+
+		// stats of storage only grow on successful calls, the first snapshot is the baseline
+		for key, val := range msg.Value {
+			v, ok := val.(float64)
+			if !ok {
+				continue
+			}
+			if prev, found := ae.storageStats[key]; ae.storageSeen && (!found || v > prev) {
+				ae.storageSuccess = ae.now()
+			}
+			ae.storageStats[key] = v
+		}
+		ae.storageSeen = true
+	}
+}
+
+func (ae *AlertEvaluator) addFailures(count float64) {
+	now := ae.now()
+
+	// the count starts over if blobfuse2 restarted the component
+	if n := len(ae.failures); n > 0 && count < ae.failures[n-1].count {
+		ae.failures = ae.failures[:0]
+	}
+	ae.failures = append(ae.failures, failureSample{at: now, count: count})
+
+	// keep the newest sample older than the window as the base of the count
+	for len(ae.failures) > 1 && !ae.failures[1].at.After(now.Add(-errorWindow)) {
+		ae.failures = ae.failures[1:]
+	}
+
+	ae.signals[hmcommon.ErrorsPerMin] = count - ae.failures[0].count
+}
+
+// check each rule against its signal and fire the actions of the rules which changed state
+func (ae *AlertEvaluator) evaluate() {
+	ae.Lock()
+	defer ae.Unlock()
+
+	now := ae.now()
+	if ae.storageSeen {
+		ae.signals[hmcommon.SecondsSinceStorageSuccess] = now.Sub(ae.storageSuccess).Seconds()
+	}
+
+	for i, r := range ae.rules {
+		val, ok := ae.signals[r.Metric]
+		if !ok {
+			continue
+		}
+
+		state := &ae.states[i]
+		if !compare(val, r.Op, r.Threshold) {
+			state.pendingSince = time.Time{}
+			if state.firing {
+				state.firing = false
+				ae.fire(r, val, alertResolved, now)
+			}
+			continue
+		}
+
+		if state.firing {
+			continue
+		}
+		if state.pendingSince.IsZero() {
+			state.pendingSince = now
+		}
+		if now.Sub(state.pendingSince) >= time.Duration(r.ForSec)*time.Second {
+			state.firing = true
+			ae.fire(r, val, alertFiring, now)
+		}
+	}
+}
+
+func compare(val float64, op string, threshold float64) bool {
+	switch op {
+	case ">":
+		return val > threshold
+	case ">=":
+		return val >= threshold
+	case "<":
+		return val < threshold
+	case "<=":
+		return val <= threshold
+	}
+	return false
+}
+
+// run the actions of the rule, webhooks and scripts do not hold up the evaluation
+func (ae *AlertEvaluator) fire(r hmcommon.AlertRule, val float64, state string, now time.Time) {
+	p := AlertPayload{
+		Rule:      r.Name,
+		Metric:    r.Metric,
+		Value:     val,
+		Op:        r.Op,
+		Threshold: r.Threshold,
+		State:     state,
+		Pid:       hmcommon.Pid,
+		MountPath: hmcommon.MountPath,
+		Timestamp: now.Format(time.RFC3339),
+	}
+
+	for _, a := range r.Actions {
+		switch a.Type {
+		case hmcommon.LogAction:
+			if state == alertFiring {
+				log.Warn("alerts::fire : alert %v firing, %v = %v %v %v", p.Rule, p.Metric, p.Value, p.Op, p.Threshold)
+			} else {
+				log.Info("alerts::fire : alert %v resolved, %v = %v", p.Rule, p.Metric, p.Value)
+			}
+		case hmcommon.WebhookAction:
+			ae.runAction(func() { ae.postWebhook(a.URL, p) })
+		case hmcommon.ScriptAction:
+			ae.runAction(func() { runScript(a.Script, p) })
+		}
+	}
+}
+
+func (ae *AlertEvaluator) runAction(action func()) {
+	ae.actions.Add(1)
+	go func() {
+		defer ae.actions.Done()
+		action()
+	}()
+}
+
+func (ae *AlertEvaluator) postWebhook(url string, p AlertPayload) {
+	body, err := json.Marshal(p)
+	if err != nil {
+		log.Err("alerts::postWebhook : unable to marshal alert %v [%v]", p.Rule, err)
+		return
+	}
+
+	resp, err := ae.client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Err("alerts::postWebhook : unable to post alert %v to %v [%v]", p.Rule, url, err)
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		log.Err("alerts::postWebhook : webhook %v answered %v for alert %v", url, resp.Status, p.Rule)
+	}
+}
+
+// run the script with the alert in its environment and as json on stdin
+func runScript(script string, p AlertPayload) {
+	body, err := json.Marshal(p)
+	if err != nil {
+		log.Err("alerts::runScript : unable to marshal alert %v [%v]", p.Rule, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), scriptTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, script)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(),
+		"BLOBFUSE2_ALERT_RULE="+p.Rule,
+		"BLOBFUSE2_ALERT_METRIC="+p.Metric,
+		"BLOBFUSE2_ALERT_VALUE="+strconv.FormatFloat(p.Value, 'f', -1, 64),
+		"BLOBFUSE2_ALERT_OP="+p.Op,
+		"BLOBFUSE2_ALERT_THRESHOLD="+strconv.FormatFloat(p.Threshold, 'f', -1, 64),
+		"BLOBFUSE2_ALERT_STATE="+p.State,
+		"BLOBFUSE2_ALERT_PID="+p.Pid,
+		"BLOBFUSE2_ALERT_MOUNT_PATH="+p.MountPath,
+	)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Err("alerts::runScript : script %v failed for alert %v [%v] %v", script, p.Rule, err, string(out))
+		return
+	}
+	log.Debug("alerts::runScript : script %v ran for alert %v", script, p.Rule)
+}
@@ -0,0 +1,338 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/Azure/azure-storage-fuse/v2/internal/stats_manager"
+	hmcommon "github.com/Azure/azure-storage-fuse/v2/tools/health-monitor/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type alertsTestSuite struct {
+	suite.Suite
+	assert *assert.Assertions
+	now    time.Time
+}
+
+func (suite *alertsTestSuite) SetupTest() {
+	suite.assert = assert.New(suite.T())
+	err := log.SetDefaultLogger("silent", common.LogConfig{Level: common.ELogLevel.LOG_DEBUG()})
+	if err != nil {
+		panic("Unable to set silent logger as default.")
+	}
+	suite.now = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (suite *alertsTestSuite) newEvaluator(rules ...hmcommon.AlertRule) *AlertEvaluator {
+	ae := newAlertEvaluator(rules)
+	ae.now = func() time.Time { return suite.now }
+	ae.storageSuccess = suite.now
+	return ae
+}
+
+func (suite *alertsTestSuite) advance(d time.Duration) {
+	suite.now = suite.now.Add(d)
+}
+
+// webhook recording the states of the alerts posted to it
+func (suite *alertsTestSuite) webhook() (*httptest.Server, chan AlertPayload) {
+	posted := make(chan AlertPayload, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p AlertPayload
+		suite.assert.NoError(json.NewDecoder(r.Body).Decode(&p))
+		posted <- p
+	}))
+	return srv, posted
+}
+
+func (suite *alertsTestSuite) TestParseAlertRules() {
+	err := hmcommon.ParseAlertRules(`[{"name": "cache-full", "metric": "cache_usage_percent", "op": ">", "threshold": 95,
+		"forSec": 60, "actions": [{"type": "log"}, {"type": "webhook", "url": "http://localhost:8080/alert"}]}]`)
+	suite.assert.NoError(err)
+	suite.assert.Len(hmcommon.AlertRules, 1)
+	suite.assert.Equal(60, hmcommon.AlertRules[0].ForSec)
+	suite.assert.Len(hmcommon.AlertRules[0].Actions, 2)
+
+	suite.assert.NoError(hmcommon.ParseAlertRules(""))
+	suite.assert.Empty(hmcommon.AlertRules)
+
+	invalid := []string{
+		`not json`,
+		`[{"metric": "cpu_percent", "op": ">", "actions": [{"type": "log"}]}]`,
+		`[{"name": "a", "metric": "disk_full", "op": ">", "actions": [{"type": "log"}]}]`,
+		`[{"name": "a", "metric": "cpu_percent", "op": "==", "actions": [{"type": "log"}]}]`,
+		`[{"name": "a", "metric": "cpu_percent", "op": ">"}]`,
+		`[{"name": "a", "metric": "cpu_percent", "op": ">", "forSec": -1, "actions": [{"type": "log"}]}]`,
+		`[{"name": "a", "metric": "cpu_percent", "op": ">", "actions": [{"type": "email"}]}]`,
+		`[{"name": "a", "metric": "cpu_percent", "op": ">", "actions": [{"type": "script"}]}]`,
+		`[{"name": "a", "metric": "cpu_percent", "op": ">", "actions": [{"type": "webhook", "url": "http://example.com/alert"}]}]`,
+		`[{"name": "a", "metric": "cpu_percent", "op": ">", "actions": [{"type": "webhook", "url": "ftp://127.0.0.1/alert"}]}]`,
+		`[{"name": "a", "metric": "cpu_percent", "op": ">", "actions": [{"type": "log"}]},
+		  {"name": "a", "metric": "memory_bytes", "op": ">", "actions": [{"type": "log"}]}]`,
+	}
+	for _, rules := range invalid {
+		suite.assert.Error(hmcommon.ParseAlertRules(rules), rules)
+	}
+}
+
+func (suite *alertsTestSuite) TestFireAndResolve() {
+	srv, posted := suite.webhook()
+	defer srv.Close()
+
+	ae := suite.newEvaluator(hmcommon.AlertRule{
+		Name:      "cache-full",
+		Metric:    hmcommon.CacheUsagePercent,
+		Op:        ">",
+		Threshold: 95,
+		ForSec:    60,
+		Actions:   []hmcommon.AlertAction{{Type: hmcommon.LogAction}, {Type: hmcommon.WebhookAction, URL: srv.URL}},
+	})
+
+	// no data yet, nothing to evaluate
+	ae.evaluate()
+
+	ae.addMonitorStats(hmcommon.FileCacheMon, &hmcommon.CacheEvent{CacheConsumed: "97.5%"})
+	ae.evaluate()
+	suite.advance(30 * time.Second)
+	ae.evaluate()
+	ae.actions.Wait()
+	suite.assert.Empty(posted)
+
+	suite.advance(30 * time.Second)
+	ae.evaluate()
+	ae.actions.Wait()
+	suite.Require().Len(posted, 1)
+	p := <-posted
+	suite.assert.Equal("cache-full", p.Rule)
+	suite.assert.Equal(alertFiring, p.State)
+	suite.assert.InDelta(97.5, p.Value, 0.001)
+
+	// a firing alert does not fire again while the signal stays above the threshold
+	suite.advance(time.Minute)
+	ae.evaluate()
+	ae.actions.Wait()
+	suite.assert.Empty(posted)
+
+	ae.addMonitorStats(hmcommon.FileCacheMon, &hmcommon.CacheEvent{CacheConsumed: "50%"})
+	ae.evaluate()
+	ae.actions.Wait()
+	suite.Require().Len(posted, 1)
+	suite.assert.Equal(alertResolved, (<-posted).State)
+}
+
+func (suite *alertsTestSuite) TestPendingReset() {
+	srv, posted := suite.webhook()
+	defer srv.Close()
+
+	ae := suite.newEvaluator(hmcommon.AlertRule{
+		Name:      "high-cpu",
+		Metric:    hmcommon.CpuPercent,
+		Op:        ">=",
+		Threshold: 90,
+		ForSec:    60,
+		Actions:   []hmcommon.AlertAction{{Type: hmcommon.WebhookAction, URL: srv.URL}},
+	})
+
+	ae.addMonitorStats(hmcommon.CpuProfiler, "95.0%")
+	ae.evaluate()
+	suite.advance(45 * time.Second)
+	ae.addMonitorStats(hmcommon.CpuProfiler, "10.0%")
+	ae.evaluate()
+	suite.advance(15 * time.Second)
+	ae.addMonitorStats(hmcommon.CpuProfiler, "95.0%")
+	ae.evaluate()
+	ae.actions.Wait()
+	suite.assert.Empty(posted)
+
+	suite.advance(time.Minute)
+	ae.evaluate()
+	ae.actions.Wait()
+	suite.assert.Len(posted, 1)
+}
+
+func (suite *alertsTestSuite) TestWebhookRedirectNotFollowed() {
+	target, posted := suite.webhook()
+	defer target.Close()
+
+	srv := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer srv.Close()
+
+	ae := suite.newEvaluator(hmcommon.AlertRule{
+		Name:      "high-cpu",
+		Metric:    hmcommon.CpuPercent,
+		Op:        ">=",
+		Threshold: 90,
+		Actions:   []hmcommon.AlertAction{{Type: hmcommon.WebhookAction, URL: srv.URL}},
+	})
+
+	ae.addMonitorStats(hmcommon.CpuProfiler, "95.0%")
+	ae.evaluate()
+	ae.actions.Wait()
+	suite.assert.Empty(posted)
+}
+
+func (suite *alertsTestSuite) TestErrorsPerMin() {
+	ae := suite.newEvaluator()
+
+	failures := func(count float64) {
+		ae.addMonitorStats(hmcommon.BlobfuseStats, stats_manager.PipeMsg{
+			ComponentName: libfuseComponent,
+			Value:         map[string]any{stats_manager.FailedOperations: count},
+		})
+	}
+
+	failures(10)
+	suite.assert.InDelta(0, ae.signals[hmcommon.ErrorsPerMin], 0.001)
+
+	suite.advance(30 * time.Second)
+	failures(25)
+	suite.assert.InDelta(15, ae.signals[hmcommon.ErrorsPerMin], 0.001)
+
+	suite.advance(30 * time.Second)
+	failures(40)
+	suite.assert.InDelta(30, ae.signals[hmcommon.ErrorsPerMin], 0.001)
+
+	// failures older than a minute are not counted
+	suite.advance(30 * time.Second)
+	failures(41)
+	suite.assert.InDelta(16, ae.signals[hmcommon.ErrorsPerMin], 0.001)
+
+	// blobfuse2 restarted and the count started over
+	suite.advance(10 * time.Second)
+	failures(2)
+	suite.assert.InDelta(0, ae.signals[hmcommon.ErrorsPerMin], 0.001)
+}
+
+func (suite *alertsTestSuite) TestStorageSuccess() {
+	ae := suite.newEvaluator()
+
+	storage := func(downloaded float64) {
+		ae.addMonitorStats(hmcommon.BlobfuseStats, stats_manager.PipeMsg{
+			ComponentName: azstorageComponent,
+			Value:         map[string]any{"Bytes Downloaded": downloaded, "Cache Usage": "10 MB"},
+		})
+	}
+
+	// nothing is known of storage until its stats are reported
+	suite.advance(time.Minute)
+	ae.evaluate()
+	suite.assert.NotContains(ae.signals, hmcommon.SecondsSinceStorageSuccess)
+
+	storage(100)
+	suite.advance(time.Minute)
+	ae.evaluate()
+	suite.assert.InDelta(120, ae.signals[hmcommon.SecondsSinceStorageSuccess], 0.001)
+
+	// unchanged stats are no success
+	storage(100)
+	suite.advance(10 * time.Second)
+	ae.evaluate()
+	suite.assert.InDelta(130, ae.signals[hmcommon.SecondsSinceStorageSuccess], 0.001)
+
+	storage(200)
+	suite.advance(10 * time.Second)
+	ae.evaluate()
+	suite.assert.InDelta(10, ae.signals[hmcommon.SecondsSinceStorageSuccess], 0.001)
+
+	ae.addMonitorStats(hmcommon.BlobfuseStats, stats_manager.PipeMsg{
+		ComponentName: azstorageComponent,
+		Operation:     "Create File",
+		Path:          "a.txt",
+	})
+	ae.evaluate()
+	suite.assert.InDelta(0, ae.signals[hmcommon.SecondsSinceStorageSuccess], 0.001)
+}
+
+func (suite *alertsTestSuite) TestSystemSignals() {
+	ae := suite.newEvaluator()
+
+	ae.addMonitorStats(hmcommon.MemoryProfiler, "2g")
+	ae.addMonitorStats(hmcommon.DiskIOMonitor, &hmcommon.DiskIOStat{UtilizationPercent: 80})
+	ae.addMonitorStats(hmcommon.FuseQueueMonitor, &hmcommon.FuseQueueStat{Waiting: 12})
+	ae.addMonitorStats(hmcommon.FdMonitor, &hmcommon.FdStat{Open: 10, SoftLimit: 0})
+	suite.assert.InDelta(2*1024*1024*1024, ae.signals[hmcommon.MemoryBytes], 0.001)
+	suite.assert.InDelta(80, ae.signals[hmcommon.CacheDiskUtilizationPercent], 0.001)
+	suite.assert.InDelta(12, ae.signals[hmcommon.FuseRequestsWaiting], 0.001)
+
+	// usage of descriptors is unknown without a limit
+	suite.assert.NotContains(ae.signals, hmcommon.OpenFdsPercent)
+	ae.addMonitorStats(hmcommon.FdMonitor, &hmcommon.FdStat{Open: 900, SoftLimit: 1000, UsagePercent: 90})
+	suite.assert.InDelta(90, ae.signals[hmcommon.OpenFdsPercent], 0.001)
+}
+
+func (suite *alertsTestSuite) TestScript() {
+	dir := suite.T().TempDir()
+	out := filepath.Join(dir, "alert.out")
+	script := filepath.Join(dir, "alert.sh")
+	err := os.WriteFile(script, []byte("#!/bin/sh\necho \"$BLOBFUSE2_ALERT_RULE $BLOBFUSE2_ALERT_STATE\" > "+out+"\ncat >> "+out+"\n"), 0700)
+	suite.Require().NoError(err)
+
+	ae := suite.newEvaluator(hmcommon.AlertRule{
+		Name:      "queue",
+		Metric:    hmcommon.FuseRequestsWaiting,
+		Op:        ">",
+		Threshold: 10,
+		Actions:   []hmcommon.AlertAction{{Type: hmcommon.ScriptAction, Script: script}},
+	})
+
+	ae.addMonitorStats(hmcommon.FuseQueueMonitor, &hmcommon.FuseQueueStat{Waiting: 12})
+	ae.evaluate()
+	ae.actions.Wait()
+
+	data, err := os.ReadFile(out)
+	suite.Require().NoError(err)
+	suite.assert.Contains(string(data), "queue firing\n")
+	suite.assert.Contains(string(data), `"fuse_requests_waiting"`)
+}
+
+func (suite *alertsTestSuite) TestStop() {
+	ae := suite.newEvaluator()
+	ae.start()
+	ae.stop()
+}
+
+func TestAlerts(t *testing.T) {
+	suite.Run(t, new(alertsTestSuite))
+}
@@ -0,0 +1,249 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package internal
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/Azure/azure-storage-fuse/v2/internal/metrics"
+	"github.com/Azure/azure-storage-fuse/v2/internal/stats_manager"
+	hmcommon "github.com/Azure/azure-storage-fuse/v2/tools/health-monitor/common"
+)
+
+// OpenMetricsExporter serves the latest data of the monitors as OpenMetrics on a local port. Unlike the json exporter
+// it keeps no history, each stat only updates its series.
+type OpenMetricsExporter struct {
+	registry *metrics.Registry
+	server   *metrics.Server
+
+	componentStats  *metrics.GaugeVec
+	componentEvents *metrics.CounterVec
+	cpuUsage        *metrics.GaugeVec
+	memUsage        *metrics.GaugeVec
+	netUsage        *metrics.GaugeVec
+	cacheUsage      *metrics.GaugeVec
+	cacheUsageRatio *metrics.GaugeVec
+	cacheFiles      *metrics.GaugeVec
+	cacheEvicted    *metrics.GaugeVec
+	cacheEvents     *metrics.CounterVec
+
+	diskReadBytes   *metrics.GaugeVec
+	diskWriteBytes  *metrics.GaugeVec
+	diskReads       *metrics.GaugeVec
+	diskWrites      *metrics.GaugeVec
+	diskUtilization *metrics.GaugeVec
+	diskInProgress  *metrics.GaugeVec
+
+	fuseWaiting             *metrics.GaugeVec
+	fuseMaxBackground       *metrics.GaugeVec
+	fuseCongestionThreshold *metrics.GaugeVec
+
+	openFds *metrics.GaugeVec
+	maxFds  *metrics.GaugeVec
+}
+
+func newOpenMetricsExporter() *OpenMetricsExporter {
+	r := metrics.NewRegistry()
+
+	return &OpenMetricsExporter{
+		registry: r,
+
+		componentStats: r.NewGaugeVec("blobfuse2_component_stat",
+			"Numeric stats of blobfuse2 components", "component", "stat"),
+		componentEvents: r.NewCounterVec("blobfuse2_component_events_total",
+			"Events on files and directories reported by blobfuse2 components", "component", "operation"),
+		cpuUsage: r.NewGaugeVec("blobfuse2_process_cpu_percent",
+			"CPU usage of the blobfuse2 process in percent of one core"),
+		memUsage: r.NewGaugeVec("blobfuse2_process_virtual_memory_bytes",
+			"Virtual memory of the blobfuse2 process"),
+		netUsage: r.NewGaugeVec("blobfuse2_process_network_bytes",
+			"Network usage of the blobfuse2 process"),
+		cacheUsage: r.NewGaugeVec("blobfuse2_file_cache_usage_bytes",
+			"Bytes used in the file cache directory"),
+		cacheUsageRatio: r.NewGaugeVec("blobfuse2_file_cache_usage_ratio",
+			"Ratio of the maximum file cache size in use"),
+		cacheFiles: r.NewGaugeVec("blobfuse2_file_cache_files",
+			"Files created in the file cache directory"),
+		cacheEvicted: r.NewGaugeVec("blobfuse2_file_cache_evicted_files",
+			"Files removed from the file cache directory"),
+		cacheEvents: r.NewCounterVec("blobfuse2_file_cache_events_total",
+			"Events in the file cache directory", "event"),
+
+		diskReadBytes: r.NewGaugeVec("blobfuse2_cache_disk_read_bytes_per_second",
+			"Bytes read per second from the disk holding the cache path", "device"),
+		diskWriteBytes: r.NewGaugeVec("blobfuse2_cache_disk_write_bytes_per_second",
+			"Bytes written per second to the disk holding the cache path", "device"),
+		diskReads: r.NewGaugeVec("blobfuse2_cache_disk_reads_per_second",
+			"Reads completed per second by the disk holding the cache path", "device"),
+		diskWrites: r.NewGaugeVec("blobfuse2_cache_disk_writes_per_second",
+			"Writes completed per second by the disk holding the cache path", "device"),
+		diskUtilization: r.NewGaugeVec("blobfuse2_cache_disk_utilization_ratio",
+			"Ratio of time the disk holding the cache path was busy", "device"),
+		diskInProgress: r.NewGaugeVec("blobfuse2_cache_disk_io_in_progress",
+			"I/Os in progress on the disk holding the cache path", "device"),
+
+		fuseWaiting: r.NewGaugeVec("blobfuse2_fuse_requests_waiting",
+			"Requests of the fuse connection waiting to be served by blobfuse2", "connection"),
+		fuseMaxBackground: r.NewGaugeVec("blobfuse2_fuse_max_background",
+			"Maximum background requests of the fuse connection", "connection"),
+		fuseCongestionThreshold: r.NewGaugeVec("blobfuse2_fuse_congestion_threshold",
+			"Requests beyond which the fuse connection is congested", "connection"),
+
+		openFds: r.NewGaugeVec("blobfuse2_process_open_fds",
+			"Open file descriptors of the blobfuse2 process"),
+		maxFds: r.NewGaugeVec("blobfuse2_process_max_fds",
+			"Soft limit on open file descriptors of the blobfuse2 process, 0 when unlimited"),
+	}
+}
+
+// start serving the metrics on the given address
+func (om *OpenMetricsExporter) start(addr string) error {
+	var err error
+	om.server, err = om.registry.Start(addr)
+	if err != nil {
+		log.Err("openmetrics_exporter::start : unable to listen on %v [%v]", addr, err)
+		return err
+	}
+
+	return nil
+}
+
+func (om *OpenMetricsExporter) stop() {
+	if om.server != nil {
+		om.server.Stop()
+		om.server = nil
+	}
+}
+
+// update the series fed by the stat of the given monitor
+func (om *OpenMetricsExporter) addMonitorStats(monName string, st any) {
+	switch monName {
+	case hmcommon.BlobfuseStats:
+		om.addBlobfuseStats(st.(stats_manager.PipeMsg))
+	case hmcommon.FileCacheMon:
+		om.addCacheEvent(st.(*hmcommon.CacheEvent))
+	case hmcommon.CpuProfiler:
+		om.setParsed(om.cpuUsage, st.(string), parsePercent)
+	case hmcommon.MemoryProfiler:
+		om.setParsed(om.memUsage, st.(string), parseBytes)
+	case hmcommon.NetworkProfiler:
+		om.setParsed(om.netUsage, st.(string), parseBytes)
+	case hmcommon.DiskIOMonitor:
+		om.addDiskIOStat(st.(*hmcommon.DiskIOStat))
+	case hmcommon.FuseQueueMonitor:
+		om.addFuseQueueStat(st.(*hmcommon.FuseQueueStat))
+	case hmcommon.FdMonitor:
+		fd := st.(*hmcommon.FdStat)
+		om.openFds.Set(float64(fd.Open))
+		om.maxFds.Set(float64(fd.SoftLimit))
+	}
+}
+
+// Component stats are sent as a snapshot of all stats of the component, while events on a path carry an operation
+func (om *OpenMetricsExporter) addBlobfuseStats(msg stats_manager.PipeMsg) {
+	if msg.Operation != "" {
+		om.componentEvents.Add(1, msg.ComponentName, msg.Operation)
+		return
+	}
+
+	for key, val := range msg.Value {
+		// Stats like cache usage are formatted strings, only numbers are exported
+		if v, ok := val.(float64); ok {
+			om.componentStats.Set(v, msg.ComponentName, key)
+		}
+	}
+}
+
+func (om *OpenMetricsExporter) addCacheEvent(e *hmcommon.CacheEvent) {
+	om.cacheEvents.Add(1, e.CacheEvent)
+	om.cacheUsage.Set(float64(e.CacheSize))
+	om.cacheFiles.Set(float64(e.CacheFilesCnt))
+	om.cacheEvicted.Set(float64(e.EvictedFilesCnt))
+
+	if pct, err := parsePercent(e.CacheConsumed); err == nil {
+		om.cacheUsageRatio.Set(pct / 100)
+	}
+}
+
+func (om *OpenMetricsExporter) addDiskIOStat(st *hmcommon.DiskIOStat) {
+	om.diskReadBytes.Set(st.ReadBytesPerSec, st.Device)
+	om.diskWriteBytes.Set(st.WriteBytesPerSec, st.Device)
+	om.diskReads.Set(st.ReadsPerSec, st.Device)
+	om.diskWrites.Set(st.WritesPerSec, st.Device)
+	om.diskUtilization.Set(st.UtilizationPercent/100, st.Device)
+	om.diskInProgress.Set(float64(st.IOInProgress), st.Device)
+}
+
+func (om *OpenMetricsExporter) addFuseQueueStat(st *hmcommon.FuseQueueStat) {
+	om.fuseWaiting.Set(float64(st.Waiting), st.Connection)
+	om.fuseMaxBackground.Set(float64(st.MaxBackground), st.Connection)
+	om.fuseCongestionThreshold.Set(float64(st.CongestionThreshold), st.Connection)
+}
+
+func (om *OpenMetricsExporter) setParsed(g *metrics.GaugeVec, val string, parse func(string) (float64, error)) {
+	v, err := parse(val)
+	if err != nil {
+		log.Debug("openmetrics_exporter::setParsed : unable to parse %v [%v]", val, err)
+		return
+	}
+	g.Set(v)
+}
+
+// parse usage like 12.5%
+func parsePercent(val string) (float64, error) {
+	return strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(val), "%"), 64)
+}
+
+// parse sizes as reported by top, like 123456k or 1.2g, in bytes
+func parseBytes(val string) (float64, error) {
+	val = strings.ToLower(strings.TrimSpace(val))
+
+	multiplier := 1.0
+	if n := len(val); n > 0 {
+		if idx := strings.IndexByte("kmgtpe", val[n-1]); idx != -1 {
+			for range idx + 1 {
+				multiplier *= 1024
+			}
+			val = val[:n-1]
+		}
+	}
+
+	v, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return 0, err
+	}
+	return v * multiplier, nil
+}
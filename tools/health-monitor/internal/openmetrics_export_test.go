@@ -0,0 +1,159 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package internal
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	"github.com/Azure/azure-storage-fuse/v2/internal/metrics"
+	"github.com/Azure/azure-storage-fuse/v2/internal/stats_manager"
+	hmcommon "github.com/Azure/azure-storage-fuse/v2/tools/health-monitor/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type openMetricsExporterTestSuite struct {
+	suite.Suite
+	assert *assert.Assertions
+	om     *OpenMetricsExporter
+}
+
+func (suite *openMetricsExporterTestSuite) SetupTest() {
+	suite.assert = assert.New(suite.T())
+	err := log.SetDefaultLogger("silent", common.LogConfig{Level: common.ELogLevel.LOG_DEBUG()})
+	if err != nil {
+		panic("Unable to set silent logger as default.")
+	}
+
+	suite.om = newOpenMetricsExporter()
+	suite.Require().NoError(suite.om.start("127.0.0.1:0"))
+}
+
+func (suite *openMetricsExporterTestSuite) TearDownTest() {
+	suite.om.stop()
+}
+
+func (suite *openMetricsExporterTestSuite) scrape() string {
+	req, err := http.NewRequest(http.MethodGet, "http://"+suite.om.server.Addr()+"/metrics", nil)
+	suite.Require().NoError(err)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+
+	resp, err := http.DefaultClient.Do(req)
+	suite.Require().NoError(err)
+	defer resp.Body.Close()
+
+	suite.assert.Equal(metrics.OpenMetricsContentType, resp.Header.Get("Content-Type"))
+	body, err := io.ReadAll(resp.Body)
+	suite.Require().NoError(err)
+	return string(body)
+}
+
+func (suite *openMetricsExporterTestSuite) TestBlobfuseStats() {
+	// Values come out of the pipe as json, so numbers are float64
+	suite.om.addMonitorStats(hmcommon.BlobfuseStats, stats_manager.PipeMsg{
+		ComponentName: "azstorage",
+		Value:         map[string]any{"Bytes Downloaded": float64(4096), "Cache Usage": "1.00 MB"},
+	})
+	suite.om.addMonitorStats(hmcommon.BlobfuseStats, stats_manager.PipeMsg{
+		ComponentName: "azstorage",
+		Operation:     "CreateFile",
+		Path:          "a.txt",
+	})
+	suite.om.addMonitorStats(hmcommon.BlobfuseStats, stats_manager.PipeMsg{
+		ComponentName: "azstorage",
+		Operation:     "CreateFile",
+		Path:          "b.txt",
+	})
+
+	out := suite.scrape()
+	suite.assert.Contains(out, `blobfuse2_component_stat{component="azstorage",stat="Bytes Downloaded"} 4096`)
+	suite.assert.NotContains(out, "Cache Usage")
+	suite.assert.Contains(out, "# TYPE blobfuse2_component_events counter\n")
+	suite.assert.Contains(out, `blobfuse2_component_events_total{component="azstorage",operation="CreateFile"} 2`)
+	suite.assert.Contains(out, "# EOF\n")
+}
+
+func (suite *openMetricsExporterTestSuite) TestProcessAndCacheStats() {
+	suite.om.addMonitorStats(hmcommon.CpuProfiler, "12.5%")
+	suite.om.addMonitorStats(hmcommon.MemoryProfiler, "2.5g")
+	suite.om.addMonitorStats(hmcommon.FileCacheMon, &hmcommon.CacheEvent{
+		CacheEvent:      "CREATE",
+		CacheSize:       1048576,
+		CacheConsumed:   "25.00%",
+		CacheFilesCnt:   3,
+		EvictedFilesCnt: 1,
+	})
+
+	out := suite.scrape()
+	suite.assert.Contains(out, "blobfuse2_process_cpu_percent 12.5\n")
+	suite.assert.Contains(out, "blobfuse2_process_virtual_memory_bytes 2.68435456e+09\n")
+	suite.assert.Contains(out, "blobfuse2_file_cache_usage_bytes 1.048576e+06\n")
+	suite.assert.Contains(out, "blobfuse2_file_cache_usage_ratio 0.25\n")
+	suite.assert.Contains(out, "blobfuse2_file_cache_files 3\n")
+	suite.assert.Contains(out, "blobfuse2_file_cache_evicted_files 1\n")
+	suite.assert.Contains(out, `blobfuse2_file_cache_events_total{event="CREATE"} 1`)
+}
+
+func (suite *openMetricsExporterTestSuite) TestSystemStats() {
+	suite.om.addMonitorStats(hmcommon.DiskIOMonitor, &hmcommon.DiskIOStat{Device: "sda1", ReadBytesPerSec: 1024, UtilizationPercent: 40})
+	suite.om.addMonitorStats(hmcommon.FuseQueueMonitor, &hmcommon.FuseQueueStat{Connection: "52", Waiting: 9, CongestionThreshold: 9})
+	suite.om.addMonitorStats(hmcommon.FdMonitor, &hmcommon.FdStat{Open: 20, SoftLimit: 1024})
+
+	out := suite.scrape()
+	suite.assert.Contains(out, `blobfuse2_cache_disk_read_bytes_per_second{device="sda1"} 1024`)
+	suite.assert.Contains(out, `blobfuse2_cache_disk_utilization_ratio{device="sda1"} 0.4`)
+	suite.assert.Contains(out, `blobfuse2_fuse_requests_waiting{connection="52"} 9`)
+	suite.assert.Contains(out, `blobfuse2_fuse_congestion_threshold{connection="52"} 9`)
+	suite.assert.Contains(out, "blobfuse2_process_open_fds 20\n")
+	suite.assert.Contains(out, "blobfuse2_process_max_fds 1024\n")
+}
+
+func (suite *openMetricsExporterTestSuite) TestParseBytes() {
+	for val, expected := range map[string]float64{"512": 512, "100k": 102400, "1.5m": 1.5 * 1024 * 1024, "2G": 2 * 1024 * 1024 * 1024} {
+		v, err := parseBytes(val)
+		suite.assert.NoError(err)
+		suite.assert.Equal(expected, v, val)
+	}
+
+	_, err := parseBytes("abc")
+	suite.assert.Error(err)
+}
+
+func TestOpenMetricsExporter(t *testing.T) {
+	suite.Run(t, new(openMetricsExporterTestSuite))
+}
@@ -58,6 +58,8 @@ type StatsExporter struct {
 	wg         sync.WaitGroup
 	opFile     *os.File
 	outputList []*Output
+	om         *OpenMetricsExporter
+	alerts     *AlertEvaluator
 }
 
 type Output struct {
@@ -67,6 +69,9 @@ type Output struct {
 	Cpu       string                  `json:"CPUUsage,omitempty"`
 	Mem       string                  `json:"MemoryUsage,omitempty"`
 	Net       string                  `json:"NetworkUsage,omitempty"`
+	DiskIO    *hmcommon.DiskIOStat    `json:"DiskIO,omitempty"`
+	FuseQueue *hmcommon.FuseQueueStat `json:"FuseQueue,omitempty"`
+	Fd        *hmcommon.FdStat        `json:"FileDescriptors,omitempty"`
 }
 
 var expLock sync.Mutex
@@ -82,14 +87,32 @@ func NewStatsExporter() (*StatsExporter, error) {
 		defer expLock.Unlock()
 		if se == nil {
 			se = &StatsExporter{}
-			se.channel = make(chan ExportedStat, 10000)
-			se.wg.Add(1)
-			go se.StatsExporter()
 
-			err := se.getNewFile()
-			if err != nil {
-				log.Err("stats_exporter::NewStatsExporter : [%v]", err)
-				return nil, err
+			// failing to serve openmetrics does not stop the json exporter
+			if hmcommon.ExportOpenMetrics {
+				se.om = newOpenMetricsExporter()
+				err := se.om.start(hmcommon.OpenMetricsAddress)
+				if err != nil {
+					log.Err("stats_exporter::NewStatsExporter : [%v]", err)
+					se.om = nil
+				}
+			}
+
+			if len(hmcommon.AlertRules) > 0 {
+				se.alerts = newAlertEvaluator(hmcommon.AlertRules)
+				se.alerts.start()
+			}
+
+			if hmcommon.ExportJSON {
+				se.channel = make(chan ExportedStat, 10000)
+				se.wg.Add(1)
+				go se.StatsExporter()
+
+				err := se.getNewFile()
+				if err != nil {
+					log.Err("stats_exporter::NewStatsExporter : [%v]", err)
+					return nil, err
+				}
 			}
 		}
 	}
@@ -101,6 +124,18 @@ func (se *StatsExporter) Destroy() {
 	// add 1 to the atomic variable. This will prevent writing to it in AddMonitorStats() method
 	atomic.AddInt32(&pidStatus, 1)
 
+	if se.om != nil {
+		se.om.stop()
+	}
+
+	if se.alerts != nil {
+		se.alerts.stop()
+	}
+
+	if se.channel == nil {
+		return
+	}
+
 	// write remaining data to the output file
 	for i, op := range se.outputList {
 		jsonData, err := json.MarshalIndent(op, "", "\t")
@@ -132,6 +167,18 @@ func (se *StatsExporter) Destroy() {
 }
 
 func (se *StatsExporter) AddMonitorStats(monName string, timestamp string, st any) {
+	if se.om != nil && atomic.LoadInt32(&pidStatus) == 0 {
+		se.om.addMonitorStats(monName, st)
+	}
+
+	if se.alerts != nil && atomic.LoadInt32(&pidStatus) == 0 {
+		se.alerts.addMonitorStats(monName, st)
+	}
+
+	if se.channel == nil {
+		return
+	}
+
 	// check if the channel is full
 	if len(se.channel) == cap(se.channel) {
 		// remove the first element from the channel
@@ -185,6 +232,12 @@ func (se *StatsExporter) addToList(st *ExportedStat, idx int) {
 		se.outputList[idx].Mem = st.Stat.(string)
 	case hmcommon.NetworkProfiler:
 		se.outputList[idx].Net = st.Stat.(string)
+	case hmcommon.DiskIOMonitor:
+		se.outputList[idx].DiskIO = st.Stat.(*hmcommon.DiskIOStat)
+	case hmcommon.FuseQueueMonitor:
+		se.outputList[idx].FuseQueue = st.Stat.(*hmcommon.FuseQueueStat)
+	case hmcommon.FdMonitor:
+		se.outputList[idx].Fd = st.Stat.(*hmcommon.FdStat)
 	}
 }
 
@@ -53,6 +53,9 @@ func getMonitors() []hminternal.Monitor {
 		hmcommon.CpuMemoryProfiler: (hmcommon.NoCpuProf && hmcommon.NoMemProf),
 		hmcommon.NetworkProfiler:   hmcommon.NoNetProf,
 		hmcommon.FileCacheMon:      hmcommon.NoFileCacheMon,
+		hmcommon.DiskIOMonitor:     hmcommon.NoDiskIOMon,
+		hmcommon.FuseQueueMonitor:  hmcommon.NoFuseQueueMon,
+		hmcommon.FdMonitor:         hmcommon.NoFdMon,
 	}
 
 	comps := make([]hminternal.Monitor, 0)
@@ -100,6 +103,22 @@ func main() {
 		os.Exit(1)
 	}
 
+	err = hmcommon.ParseExporters(hmcommon.Exporters)
+	if err != nil {
+		fmt.Printf("health-monitor : %v\n", err)
+		log.Err("main::main : %v", err)
+		time.Sleep(1 * time.Second)
+		os.Exit(1)
+	}
+
+	err = hmcommon.ParseAlertRules(hmcommon.AlertRulesJSON)
+	if err != nil {
+		fmt.Printf("health-monitor : %v\n", err)
+		log.Err("main::main : %v", err)
+		time.Sleep(1 * time.Second)
+		os.Exit(1)
+	}
+
 	if hmcommon.OutputPath == "" {
 		currDir, err := os.Getwd()
 		if err != nil {
@@ -120,9 +139,14 @@ func main() {
 		"Health Stats poll interval: %v \n"+
 		"Cache Path: %v \n"+
 		"Max cache size in MB: %v \n"+
-		"Output path: %v",
+		"Output path: %v \n"+
+		"Mount path: %v \n"+
+		"Exporters: %v \n"+
+		"OpenMetrics address: %v \n"+
+		"Alert rules: %v",
 		hmcommon.Pid, common.TransferPipe, common.PollingPipe, hmcommon.BfsPollInterval,
-		hmcommon.ProcMonInterval, hmcommon.TempCachePath, hmcommon.MaxCacheSize, hmcommon.OutputPath)
+		hmcommon.ProcMonInterval, hmcommon.TempCachePath, hmcommon.MaxCacheSize, hmcommon.OutputPath, hmcommon.MountPath,
+		hmcommon.Exporters, hmcommon.OpenMetricsAddress, len(hmcommon.AlertRules))
 
 	comps := getMonitors()
 
@@ -148,14 +172,21 @@ func init() {
 	flag.IntVar(&hmcommon.BfsPollInterval, "stats-poll-interval-sec", 10, "Blobfuse2 stats polling interval in seconds")
 	flag.IntVar(&hmcommon.ProcMonInterval, "process-monitor-interval-sec", 30, "CPU, memory and network usage polling interval in seconds")
 	flag.StringVar(&hmcommon.OutputPath, "output-path", "", "Path where output files will be created")
+	flag.StringVar(&hmcommon.Exporters, "exporters", hmcommon.JSONExporter, "Comma separated list of exporters, json and openmetrics")
+	flag.StringVar(&hmcommon.OpenMetricsAddress, "openmetrics-address", hmcommon.DefaultOpenMetricsAddress, "Address to serve openmetrics on")
+	flag.StringVar(&hmcommon.AlertRulesJSON, "alert-rules", "", "JSON list of alert rules to evaluate")
 
 	flag.BoolVar(&hmcommon.NoBfsMon, "no-blobfuse2-stats", false, "Disable blobfuse2 stats polling")
 	flag.BoolVar(&hmcommon.NoCpuProf, "no-cpu-profiler", false, "Disable CPU monitoring on blobfuse2 process")
 	flag.BoolVar(&hmcommon.NoMemProf, "no-memory-profiler", false, "Disable memory monitoring on blobfuse2 process")
 	flag.BoolVar(&hmcommon.NoNetProf, "no-network-profiler", false, "Disable network monitoring on blobfuse2 process")
 	flag.BoolVar(&hmcommon.NoFileCacheMon, "no-file-cache-monitor", false, "Disable file cache directory monitor")
+	flag.BoolVar(&hmcommon.NoDiskIOMon, "no-disk-io-monitor", false, "Disable disk I/O monitoring on the cache path")
+	flag.BoolVar(&hmcommon.NoFuseQueueMon, "no-fuse-queue-monitor", false, "Disable fuse connection queue monitoring")
+	flag.BoolVar(&hmcommon.NoFdMon, "no-fd-monitor", false, "Disable open file descriptor monitoring on blobfuse2 process")
 
 	flag.StringVar(&hmcommon.TempCachePath, "cache-path", "", "path to local disk cache")
+	flag.StringVar(&hmcommon.MountPath, "mount-path", "", "path where blobfuse2 is mounted")
 	flag.Float64Var(&hmcommon.MaxCacheSize, "max-size-mb", 0, "maximum cache size allowed. Default - 0 (unlimited)")
 
 	flag.BoolVar(&hmcommon.CheckVersion, "version", false, "Print the current version of health-monitor")
@@ -0,0 +1,247 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package disk_io
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	hmcommon "github.com/Azure/azure-storage-fuse/v2/tools/health-monitor/common"
+	hminternal "github.com/Azure/azure-storage-fuse/v2/tools/health-monitor/internal"
+
+	"golang.org/x/sys/unix"
+)
+
+// Size of a sector in /proc/diskstats, irrespective of the sector size of the disk
+const sectorSize = 512
+
+type DiskIOMonitor struct {
+	name         string
+	cachePath    string
+	procPath     string
+	pollInterval int
+	major        uint32
+	minor        uint32
+	prev         *diskCounters
+	prevTime     time.Time
+}
+
+// counters of a disk as listed in /proc/diskstats
+type diskCounters struct {
+	device         string
+	reads          uint64
+	sectorsRead    uint64
+	writes         uint64
+	sectorsWritten uint64
+	ioInProgress   uint64
+	msDoingIO      uint64
+}
+
+func (dm *DiskIOMonitor) GetName() string {
+	return dm.name
+}
+
+func (dm *DiskIOMonitor) SetName(name string) {
+	dm.name = name
+}
+
+func (dm *DiskIOMonitor) Monitor() error {
+	err := dm.Validate()
+	if err != nil {
+		log.Err("disk_io_monitor::Monitor : [%v]", err)
+		return err
+	}
+
+	err = dm.resolveDevice()
+	if err != nil {
+		log.Err("disk_io_monitor::Monitor : [%v]", err)
+		return err
+	}
+	log.Debug("disk_io_monitor::Monitor : started for device %v:%v", dm.major, dm.minor)
+
+	ticker := time.NewTicker(time.Duration(dm.pollInterval) * time.Second)
+	defer ticker.Stop()
+
+	for t := range ticker.C {
+		st, err := dm.getDiskIOStat(t)
+		if err != nil {
+			log.Err("disk_io_monitor::Monitor : [%v]", err)
+			return err
+		}
+
+		// first poll only records the counters to compute rates from
+		if st != nil {
+			dm.ExportStats(t.Format(time.RFC3339), st)
+		}
+	}
+
+	return nil
+}
+
+func (dm *DiskIOMonitor) ExportStats(timestamp string, st any) {
+	se, err := hminternal.NewStatsExporter()
+	if err != nil || se == nil {
+		log.Err("disk_io_monitor::ExportStats : Error in creating stats exporter instance [%v]", err)
+		return
+	}
+
+	se.AddMonitorStats(dm.GetName(), timestamp, st)
+}
+
+func (dm *DiskIOMonitor) Validate() error {
+	if len(dm.cachePath) == 0 {
+		return fmt.Errorf("cache path is not given")
+	}
+
+	if dm.pollInterval == 0 {
+		return fmt.Errorf("process-monitor-interval-sec should be non-zero")
+	}
+
+	return nil
+}
+
+// find the device number of the disk holding the cache path
+func (dm *DiskIOMonitor) resolveDevice() error {
+	var st syscall.Stat_t
+	err := syscall.Stat(dm.cachePath, &st)
+	if err != nil {
+		return fmt.Errorf("unable to stat cache path %v [%v]", dm.cachePath, err)
+	}
+
+	dm.major = unix.Major(st.Dev)
+	dm.minor = unix.Minor(st.Dev)
+	return nil
+}
+
+// compute rates of the disk since the previous call, nil on the first call
+func (dm *DiskIOMonitor) getDiskIOStat(now time.Time) (*hmcommon.DiskIOStat, error) {
+	cur, err := dm.readCounters()
+	if err != nil {
+		return nil, err
+	}
+
+	prev, prevTime := dm.prev, dm.prevTime
+	dm.prev, dm.prevTime = cur, now
+
+	elapsed := now.Sub(prevTime).Seconds()
+	if prev == nil || elapsed <= 0 {
+		return nil, nil
+	}
+
+	return &hmcommon.DiskIOStat{
+		Device:             cur.device,
+		ReadBytesPerSec:    float64(delta(prev.sectorsRead, cur.sectorsRead)*sectorSize) / elapsed,
+		WriteBytesPerSec:   float64(delta(prev.sectorsWritten, cur.sectorsWritten)*sectorSize) / elapsed,
+		ReadsPerSec:        float64(delta(prev.reads, cur.reads)) / elapsed,
+		WritesPerSec:       float64(delta(prev.writes, cur.writes)) / elapsed,
+		UtilizationPercent: min(float64(delta(prev.msDoingIO, cur.msDoingIO))/(elapsed*10), 100),
+		IOInProgress:       cur.ioInProgress,
+	}, nil
+}
+
+// read the counters of the disk from /proc/diskstats
+func (dm *DiskIOMonitor) readCounters() (*diskCounters, error) {
+	f, err := os.Open(filepath.Join(dm.procPath, "diskstats"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	major := strconv.FormatUint(uint64(dm.major), 10)
+	minor := strconv.FormatUint(uint64(dm.minor), 10)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 14 || fields[0] != major || fields[1] != minor {
+			continue
+		}
+
+		var vals [11]uint64
+		for i := range vals {
+			vals[i], err = strconv.ParseUint(fields[i+3], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid diskstats of %v [%v]", fields[2], err)
+			}
+		}
+
+		return &diskCounters{
+			device:         fields[2],
+			reads:          vals[0],
+			sectorsRead:    vals[2],
+			writes:         vals[4],
+			sectorsWritten: vals[6],
+			ioInProgress:   vals[8],
+			msDoingIO:      vals[9],
+		}, nil
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, fmt.Errorf("no disk %v:%v in diskstats for cache path %v", major, minor, dm.cachePath)
+}
+
+// difference of counters, which may wrap around or be reset
+func delta(prev, cur uint64) uint64 {
+	if cur < prev {
+		return 0
+	}
+	return cur - prev
+}
+
+func NewDiskIOMonitor() hminternal.Monitor {
+	dm := &DiskIOMonitor{
+		cachePath:    common.ExpandPath(hmcommon.TempCachePath),
+		procPath:     hmcommon.ProcPath,
+		pollInterval: hmcommon.ProcMonInterval,
+	}
+
+	dm.SetName(hmcommon.DiskIOMonitor)
+
+	return dm
+}
+
+func init() {
+	hminternal.AddMonitor(hmcommon.DiskIOMonitor, NewDiskIOMonitor)
+}
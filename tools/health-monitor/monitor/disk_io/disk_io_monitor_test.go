@@ -0,0 +1,146 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package disk_io
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	hmcommon "github.com/Azure/azure-storage-fuse/v2/tools/health-monitor/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type diskIOMonitorTestSuite struct {
+	suite.Suite
+	assert *assert.Assertions
+	dm     *DiskIOMonitor
+}
+
+func (suite *diskIOMonitorTestSuite) SetupTest() {
+	suite.assert = assert.New(suite.T())
+	err := log.SetDefaultLogger("silent", common.LogConfig{Level: common.ELogLevel.LOG_DEBUG()})
+	if err != nil {
+		panic("Unable to set silent logger as default.")
+	}
+
+	suite.dm = &DiskIOMonitor{
+		name:         hmcommon.DiskIOMonitor,
+		cachePath:    "/tmp/cache",
+		procPath:     suite.T().TempDir(),
+		pollInterval: 5,
+		major:        259,
+		minor:        1,
+	}
+}
+
+func (suite *diskIOMonitorTestSuite) writeDiskstats(content string) {
+	err := os.WriteFile(filepath.Join(suite.dm.procPath, "diskstats"), []byte(content), 0644)
+	suite.Require().NoError(err)
+}
+
+func (suite *diskIOMonitorTestSuite) TestGetDiskIOStat() {
+	now := time.Now()
+	suite.writeDiskstats(`
+ 259       0 nvme0n1 900 0 9000 10 900 0 9000 10 0 100 20 0 0 0 0
+ 259       1 nvme0n1p1 100 5 2000 50 200 10 4000 80 1 1000 130 0 0 0 0
+`)
+	st, err := suite.dm.getDiskIOStat(now)
+	suite.assert.NoError(err)
+	suite.assert.Nil(st, "first poll only records counters")
+
+	suite.writeDiskstats(`
+ 259       0 nvme0n1 900 0 9000 10 900 0 9000 10 0 100 20 0 0 0 0
+ 259       1 nvme0n1p1 150 5 4000 60 300 10 8000 90 3 3500 150 0 0 0 0
+`)
+	st, err = suite.dm.getDiskIOStat(now.Add(5 * time.Second))
+	suite.assert.NoError(err)
+	suite.Require().NotNil(st)
+
+	suite.assert.Equal("nvme0n1p1", st.Device)
+	suite.assert.InDelta(2000*512/5.0, st.ReadBytesPerSec, 0.001)
+	suite.assert.InDelta(4000*512/5.0, st.WriteBytesPerSec, 0.001)
+	suite.assert.InDelta(10, st.ReadsPerSec, 0.001)
+	suite.assert.InDelta(20, st.WritesPerSec, 0.001)
+	suite.assert.InDelta(50, st.UtilizationPercent, 0.001)
+	suite.assert.EqualValues(3, st.IOInProgress)
+}
+
+func (suite *diskIOMonitorTestSuite) TestCounterReset() {
+	now := time.Now()
+	suite.writeDiskstats(" 259 1 nvme0n1p1 150 5 4000 60 300 10 8000 90 3 3500 150\n")
+	_, err := suite.dm.getDiskIOStat(now)
+	suite.assert.NoError(err)
+
+	suite.writeDiskstats(" 259 1 nvme0n1p1 10 0 100 1 10 0 100 1 0 20000 2\n")
+	st, err := suite.dm.getDiskIOStat(now.Add(time.Second))
+	suite.assert.NoError(err)
+	suite.Require().NotNil(st)
+	suite.assert.Zero(st.ReadBytesPerSec)
+	suite.assert.Zero(st.WritesPerSec)
+	suite.assert.InDelta(100, st.UtilizationPercent, 0.001, "utilization is capped")
+}
+
+func (suite *diskIOMonitorTestSuite) TestDiskNotFound() {
+	suite.writeDiskstats(" 8 0 sda 1 0 1 0 1 0 1 0 0 1 1\n")
+	_, err := suite.dm.getDiskIOStat(time.Now())
+	suite.assert.ErrorContains(err, "no disk 259:1")
+
+	suite.Require().NoError(os.Remove(filepath.Join(suite.dm.procPath, "diskstats")))
+	_, err = suite.dm.getDiskIOStat(time.Now())
+	suite.assert.Error(err)
+}
+
+func (suite *diskIOMonitorTestSuite) TestResolveDevice() {
+	suite.dm.cachePath = suite.T().TempDir()
+	suite.assert.NoError(suite.dm.resolveDevice())
+
+	suite.dm.cachePath = filepath.Join(suite.dm.cachePath, "missing")
+	suite.assert.Error(suite.dm.resolveDevice())
+}
+
+func (suite *diskIOMonitorTestSuite) TestValidate() {
+	suite.assert.NoError(suite.dm.Validate())
+
+	suite.dm.cachePath = ""
+	suite.assert.ErrorContains(suite.dm.Validate(), "cache path")
+}
+
+func TestDiskIOMonitor(t *testing.T) {
+	suite.Run(t, new(diskIOMonitorTestSuite))
+}
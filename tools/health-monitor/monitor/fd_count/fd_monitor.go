@@ -0,0 +1,175 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package fd_count
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	hmcommon "github.com/Azure/azure-storage-fuse/v2/tools/health-monitor/common"
+	hminternal "github.com/Azure/azure-storage-fuse/v2/tools/health-monitor/internal"
+)
+
+type FdMonitor struct {
+	name         string
+	pid          string
+	procPath     string
+	pollInterval int
+}
+
+func (fd *FdMonitor) GetName() string {
+	return fd.name
+}
+
+func (fd *FdMonitor) SetName(name string) {
+	fd.name = name
+}
+
+func (fd *FdMonitor) Monitor() error {
+	err := fd.Validate()
+	if err != nil {
+		log.Err("fd_monitor::Monitor : [%v]", err)
+		return err
+	}
+	log.Debug("fd_monitor::Monitor : started")
+
+	ticker := time.NewTicker(time.Duration(fd.pollInterval) * time.Second)
+	defer ticker.Stop()
+
+	for t := range ticker.C {
+		st, err := fd.getFdStat()
+		if err != nil {
+			log.Err("fd_monitor::Monitor : [%v]", err)
+			return err
+		}
+
+		fd.ExportStats(t.Format(time.RFC3339), st)
+	}
+
+	return nil
+}
+
+func (fd *FdMonitor) ExportStats(timestamp string, st any) {
+	se, err := hminternal.NewStatsExporter()
+	if err != nil || se == nil {
+		log.Err("fd_monitor::ExportStats : Error in creating stats exporter instance [%v]", err)
+		return
+	}
+
+	se.AddMonitorStats(fd.GetName(), timestamp, st)
+}
+
+func (fd *FdMonitor) Validate() error {
+	if len(fd.pid) == 0 {
+		return fmt.Errorf("pid of blobfuse2 is not given")
+	}
+
+	if fd.pollInterval == 0 {
+		return fmt.Errorf("process-monitor-interval-sec should be non-zero")
+	}
+
+	return nil
+}
+
+// count the open file descriptors of blobfuse2 against its soft limit
+func (fd *FdMonitor) getFdStat() (*hmcommon.FdStat, error) {
+	entries, err := os.ReadDir(filepath.Join(fd.procPath, fd.pid, "fd"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list file descriptors of pid %v [%v]", fd.pid, err)
+	}
+
+	st := &hmcommon.FdStat{Open: uint64(len(entries))}
+
+	st.SoftLimit, err = fd.getSoftLimit()
+	if err != nil {
+		return nil, err
+	}
+
+	if st.SoftLimit > 0 {
+		st.UsagePercent = float64(st.Open*100) / float64(st.SoftLimit)
+	}
+
+	return st, nil
+}
+
+// soft limit on open files from /proc/<pid>/limits, 0 when unlimited
+func (fd *FdMonitor) getSoftLimit() (uint64, error) {
+	f, err := os.Open(filepath.Join(fd.procPath, fd.pid, "limits"))
+	if err != nil {
+		return 0, fmt.Errorf("unable to read limits of pid %v [%v]", fd.pid, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// Max open files            1024                 1048576              files
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "Max open files") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			break
+		}
+		if fields[3] == "unlimited" {
+			return 0, nil
+		}
+		return strconv.ParseUint(fields[3], 10, 64)
+	}
+
+	return 0, fmt.Errorf("no open files limit for pid %v", fd.pid)
+}
+
+func NewFdMonitor() hminternal.Monitor {
+	fd := &FdMonitor{
+		pid:          hmcommon.Pid,
+		procPath:     hmcommon.ProcPath,
+		pollInterval: hmcommon.ProcMonInterval,
+	}
+
+	fd.SetName(hmcommon.FdMonitor)
+
+	return fd
+}
+
+func init() {
+	hminternal.AddMonitor(hmcommon.FdMonitor, NewFdMonitor)
+}
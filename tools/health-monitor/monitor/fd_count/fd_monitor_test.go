@@ -0,0 +1,127 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package fd_count
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	hmcommon "github.com/Azure/azure-storage-fuse/v2/tools/health-monitor/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+const limits = `Limit                     Soft Limit           Hard Limit           Units
+Max cpu time              unlimited            unlimited            seconds
+Max open files            %v                 1048576              files
+Max locked memory         8388608              8388608              bytes
+`
+
+type fdMonitorTestSuite struct {
+	suite.Suite
+	assert *assert.Assertions
+	fd     *FdMonitor
+}
+
+func (suite *fdMonitorTestSuite) SetupTest() {
+	suite.assert = assert.New(suite.T())
+	err := log.SetDefaultLogger("silent", common.LogConfig{Level: common.ELogLevel.LOG_DEBUG()})
+	if err != nil {
+		panic("Unable to set silent logger as default.")
+	}
+
+	suite.fd = &FdMonitor{
+		name:         hmcommon.FdMonitor,
+		pid:          "1234",
+		procPath:     suite.T().TempDir(),
+		pollInterval: 5,
+	}
+}
+
+// create a fake /proc/<pid> with the given number of descriptors and soft limit
+func (suite *fdMonitorTestSuite) createProc(fds int, softLimit string) {
+	dir := filepath.Join(suite.fd.procPath, suite.fd.pid)
+	suite.Require().NoError(os.MkdirAll(filepath.Join(dir, "fd"), 0755))
+	for i := range fds {
+		suite.Require().NoError(os.Symlink("/dev/null", filepath.Join(dir, "fd", fmt.Sprint(i))))
+	}
+	suite.Require().NoError(os.WriteFile(filepath.Join(dir, "limits"), []byte(fmt.Sprintf(limits, softLimit)), 0644))
+}
+
+func (suite *fdMonitorTestSuite) TestGetFdStat() {
+	suite.createProc(256, "1024")
+
+	st, err := suite.fd.getFdStat()
+	suite.assert.NoError(err)
+	suite.Require().NotNil(st)
+	suite.assert.EqualValues(256, st.Open)
+	suite.assert.EqualValues(1024, st.SoftLimit)
+	suite.assert.InDelta(25, st.UsagePercent, 0.001)
+}
+
+func (suite *fdMonitorTestSuite) TestUnlimited() {
+	suite.createProc(3, "unlimited")
+
+	st, err := suite.fd.getFdStat()
+	suite.assert.NoError(err)
+	suite.assert.EqualValues(3, st.Open)
+	suite.assert.Zero(st.SoftLimit)
+	suite.assert.Zero(st.UsagePercent)
+}
+
+func (suite *fdMonitorTestSuite) TestProcessNotRunning() {
+	_, err := suite.fd.getFdStat()
+	suite.assert.ErrorContains(err, "unable to list file descriptors of pid 1234")
+
+	suite.Require().NoError(os.MkdirAll(filepath.Join(suite.fd.procPath, suite.fd.pid, "fd"), 0755))
+	_, err = suite.fd.getFdStat()
+	suite.assert.ErrorContains(err, "unable to read limits of pid 1234")
+}
+
+func (suite *fdMonitorTestSuite) TestOwnProcess() {
+	suite.fd.procPath = hmcommon.ProcPath
+	suite.fd.pid = fmt.Sprint(os.Getpid())
+
+	st, err := suite.fd.getFdStat()
+	suite.assert.NoError(err)
+	suite.assert.NotZero(st.Open)
+}
+
+func TestFdMonitor(t *testing.T) {
+	suite.Run(t, new(fdMonitorTestSuite))
+}
@@ -0,0 +1,205 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package fuse_queue
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	hmcommon "github.com/Azure/azure-storage-fuse/v2/tools/health-monitor/common"
+	hminternal "github.com/Azure/azure-storage-fuse/v2/tools/health-monitor/internal"
+
+	"golang.org/x/sys/unix"
+)
+
+// Magic number of fuse file systems reported by statfs
+const fuseSuperMagic = 0x65735546
+
+type FuseQueueMonitor struct {
+	name         string
+	mountPath    string
+	sysPath      string
+	pollInterval int
+	connection   string
+}
+
+func (fq *FuseQueueMonitor) GetName() string {
+	return fq.name
+}
+
+func (fq *FuseQueueMonitor) SetName(name string) {
+	fq.name = name
+}
+
+func (fq *FuseQueueMonitor) Monitor() error {
+	err := fq.Validate()
+	if err != nil {
+		log.Err("fuse_queue_monitor::Monitor : [%v]", err)
+		return err
+	}
+	log.Debug("fuse_queue_monitor::Monitor : started")
+
+	ticker := time.NewTicker(time.Duration(fq.pollInterval) * time.Second)
+	defer ticker.Stop()
+
+	for t := range ticker.C {
+		st, err := fq.collect()
+		if err != nil {
+			continue
+		}
+
+		fq.ExportStats(t.Format(time.RFC3339), st)
+	}
+
+	return nil
+}
+
+// collect the queue of the fuse connection of the mount, looking the connection up first if it is not known.
+// A connection which can no longer be read, e.g. after a remount, is looked up again on the next poll.
+func (fq *FuseQueueMonitor) collect() (*hmcommon.FuseQueueStat, error) {
+	var err error
+
+	// health monitor is started before the mount is ready, so the connection is looked up until it shows up
+	if fq.connection == "" {
+		fq.connection, err = fq.findConnection()
+		if err != nil {
+			log.Debug("fuse_queue_monitor::collect : [%v]", err)
+			return nil, err
+		}
+		log.Debug("fuse_queue_monitor::collect : fuse connection of %v is %v", fq.mountPath, fq.connection)
+	}
+
+	st, err := fq.getFuseQueueStat()
+	if err != nil {
+		log.Err("fuse_queue_monitor::collect : [%v]", err)
+		fq.connection = ""
+		return nil, err
+	}
+
+	return st, nil
+}
+
+func (fq *FuseQueueMonitor) ExportStats(timestamp string, st any) {
+	se, err := hminternal.NewStatsExporter()
+	if err != nil || se == nil {
+		log.Err("fuse_queue_monitor::ExportStats : Error in creating stats exporter instance [%v]", err)
+		return
+	}
+
+	se.AddMonitorStats(fq.GetName(), timestamp, st)
+}
+
+func (fq *FuseQueueMonitor) Validate() error {
+	if len(fq.mountPath) == 0 {
+		return fmt.Errorf("mount path is not given")
+	}
+
+	if fq.pollInterval == 0 {
+		return fmt.Errorf("process-monitor-interval-sec should be non-zero")
+	}
+
+	return nil
+}
+
+// find the fuse connection of the mount, which fusectl names after the device number of the mount
+func (fq *FuseQueueMonitor) findConnection() (string, error) {
+	var fs syscall.Statfs_t
+	err := syscall.Statfs(fq.mountPath, &fs)
+	if err != nil {
+		return "", fmt.Errorf("unable to statfs mount path %v [%v]", fq.mountPath, err)
+	}
+
+	if fs.Type != fuseSuperMagic {
+		return "", fmt.Errorf("%v is not mounted yet", fq.mountPath)
+	}
+
+	var st syscall.Stat_t
+	err = syscall.Stat(fq.mountPath, &st)
+	if err != nil {
+		return "", fmt.Errorf("unable to stat mount path %v [%v]", fq.mountPath, err)
+	}
+
+	return connectionName(st.Dev), nil
+}
+
+// name of the fusectl directory of the connection using the device, the kernel encoding of the device number
+func connectionName(dev uint64) string {
+	return strconv.FormatUint(uint64(unix.Major(dev))<<20|uint64(unix.Minor(dev)), 10)
+}
+
+// read the queue of the connection from /sys/fs/fuse/connections/<connection>
+func (fq *FuseQueueMonitor) getFuseQueueStat() (*hmcommon.FuseQueueStat, error) {
+	dir := filepath.Join(fq.sysPath, "fs", "fuse", "connections", fq.connection)
+
+	st := &hmcommon.FuseQueueStat{Connection: fq.connection}
+	for file, val := range map[string]*uint64{
+		"waiting":              &st.Waiting,
+		"max_background":       &st.MaxBackground,
+		"congestion_threshold": &st.CongestionThreshold,
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read fuse connection %v [%v]", fq.connection, err)
+		}
+
+		*val, err = strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %v of fuse connection %v [%v]", file, fq.connection, err)
+		}
+	}
+
+	return st, nil
+}
+
+func NewFuseQueueMonitor() hminternal.Monitor {
+	fq := &FuseQueueMonitor{
+		mountPath:    hmcommon.MountPath,
+		sysPath:      hmcommon.SysPath,
+		pollInterval: hmcommon.ProcMonInterval,
+	}
+
+	fq.SetName(hmcommon.FuseQueueMonitor)
+
+	return fq
+}
+
+func init() {
+	hminternal.AddMonitor(hmcommon.FuseQueueMonitor, NewFuseQueueMonitor)
+}
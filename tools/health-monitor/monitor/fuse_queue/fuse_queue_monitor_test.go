@@ -0,0 +1,147 @@
+/*
+    _____           _____   _____   ____          ______  _____  ------
+   |     |  |      |     | |     | |     |     | |       |            |
+   |     |  |      |     | |     | |     |     | |       |            |
+   | --- |  |      |     | |-----| |---- |     | |-----| |-----  ------
+   |     |  |      |     | |     | |     |     |       | |       |
+   | ____|  |_____ | ____| | ____| |     |_____|  _____| |_____  |_____
+
+
+   Licensed under the MIT License <http://opensource.org/licenses/MIT>.
+
+   Copyright © 2020-2026 Microsoft Corporation. All rights reserved.
+   Author : <blobfusedev@microsoft.com>
+
+   Permission is hereby granted, free of charge, to any person obtaining a copy
+   of this software and associated documentation files (the "Software"), to deal
+   in the Software without restriction, including without limitation the rights
+   to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+   copies of the Software, and to permit persons to whom the Software is
+   furnished to do so, subject to the following conditions:
+
+   The above copyright notice and this permission notice shall be included in all
+   copies or substantial portions of the Software.
+
+   THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+   IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+   FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+   AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+   LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+   OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+   SOFTWARE
+*/
+
+package fuse_queue
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-storage-fuse/v2/common"
+	"github.com/Azure/azure-storage-fuse/v2/common/log"
+	hmcommon "github.com/Azure/azure-storage-fuse/v2/tools/health-monitor/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"golang.org/x/sys/unix"
+)
+
+type fuseQueueMonitorTestSuite struct {
+	suite.Suite
+	assert *assert.Assertions
+	fq     *FuseQueueMonitor
+}
+
+func (suite *fuseQueueMonitorTestSuite) SetupTest() {
+	suite.assert = assert.New(suite.T())
+	err := log.SetDefaultLogger("silent", common.LogConfig{Level: common.ELogLevel.LOG_DEBUG()})
+	if err != nil {
+		panic("Unable to set silent logger as default.")
+	}
+
+	suite.fq = &FuseQueueMonitor{
+		name:         hmcommon.FuseQueueMonitor,
+		mountPath:    "/mnt/blobfuse",
+		sysPath:      suite.T().TempDir(),
+		pollInterval: 5,
+		connection:   "52",
+	}
+}
+
+func (suite *fuseQueueMonitorTestSuite) writeConnection(waiting, maxBackground, threshold string) {
+	dir := filepath.Join(suite.fq.sysPath, "fs", "fuse", "connections", suite.fq.connection)
+	suite.Require().NoError(os.MkdirAll(dir, 0755))
+	for file, val := range map[string]string{"waiting": waiting, "max_background": maxBackground, "congestion_threshold": threshold} {
+		if val != "" {
+			suite.Require().NoError(os.WriteFile(filepath.Join(dir, file), []byte(val+"\n"), 0644))
+		}
+	}
+}
+
+func (suite *fuseQueueMonitorTestSuite) TestGetFuseQueueStat() {
+	suite.writeConnection("4", "12", "9")
+
+	st, err := suite.fq.getFuseQueueStat()
+	suite.assert.NoError(err)
+	suite.Require().NotNil(st)
+	suite.assert.Equal("52", st.Connection)
+	suite.assert.EqualValues(4, st.Waiting)
+	suite.assert.EqualValues(12, st.MaxBackground)
+	suite.assert.EqualValues(9, st.CongestionThreshold)
+}
+
+func (suite *fuseQueueMonitorTestSuite) TestCollectLooksUpConnectionAgain() {
+	suite.writeConnection("4", "12", "9")
+	st, err := suite.fq.collect()
+	suite.assert.NoError(err)
+	suite.assert.EqualValues(4, st.Waiting)
+
+	// connection going away, e.g. on remount, is forgotten rather than stopping the monitor
+	suite.Require().NoError(os.RemoveAll(filepath.Join(suite.fq.sysPath, "fs")))
+	_, err = suite.fq.collect()
+	suite.assert.ErrorContains(err, "unable to read fuse connection 52")
+	suite.assert.Empty(suite.fq.connection)
+
+	// mount path not being a fuse mount, the connection is not found until it is mounted again
+	suite.fq.mountPath = suite.T().TempDir()
+	_, err = suite.fq.collect()
+	suite.assert.ErrorContains(err, "is not mounted yet")
+	suite.assert.Empty(suite.fq.connection)
+}
+
+func (suite *fuseQueueMonitorTestSuite) TestGetFuseQueueStatError() {
+	_, err := suite.fq.getFuseQueueStat()
+	suite.assert.ErrorContains(err, "unable to read fuse connection 52")
+
+	suite.writeConnection("many", "12", "9")
+	_, err = suite.fq.getFuseQueueStat()
+	suite.assert.ErrorContains(err, "invalid waiting")
+}
+
+func (suite *fuseQueueMonitorTestSuite) TestConnectionName() {
+	suite.assert.Equal("52", connectionName(unix.Mkdev(0, 52)))
+	suite.assert.Equal("8388609", connectionName(unix.Mkdev(8, 1)))
+}
+
+func (suite *fuseQueueMonitorTestSuite) TestFindConnectionNotMounted() {
+	// a directory which is not a fuse mount has no connection yet
+	suite.fq.mountPath = suite.T().TempDir()
+	_, err := suite.fq.findConnection()
+	suite.assert.ErrorContains(err, "is not mounted yet")
+
+	suite.fq.mountPath = filepath.Join(suite.fq.mountPath, "missing")
+	_, err = suite.fq.findConnection()
+	suite.assert.Error(err)
+}
+
+func (suite *fuseQueueMonitorTestSuite) TestValidate() {
+	suite.assert.NoError(suite.fq.Validate())
+
+	suite.fq.mountPath = ""
+	suite.assert.ErrorContains(suite.fq.Validate(), "mount path")
+}
+
+func TestFuseQueueMonitor(t *testing.T) {
+	suite.Run(t, new(fuseQueueMonitorTestSuite))
+}
@@ -36,6 +36,9 @@ package monitor
 import (
 	_ "github.com/Azure/azure-storage-fuse/v2/tools/health-monitor/monitor/blobfuse_stats"
 	_ "github.com/Azure/azure-storage-fuse/v2/tools/health-monitor/monitor/cpu_mem_profiler"
+	_ "github.com/Azure/azure-storage-fuse/v2/tools/health-monitor/monitor/disk_io"
+	_ "github.com/Azure/azure-storage-fuse/v2/tools/health-monitor/monitor/fd_count"
 	_ "github.com/Azure/azure-storage-fuse/v2/tools/health-monitor/monitor/file_cache"
+	_ "github.com/Azure/azure-storage-fuse/v2/tools/health-monitor/monitor/fuse_queue"
 	_ "github.com/Azure/azure-storage-fuse/v2/tools/health-monitor/monitor/network_profiler"
 )